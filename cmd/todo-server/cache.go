@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+)
+
+// logCacheStatsEvery logs the hits and misses of the cache every interval
+// until ctx is done.
+func logCacheStatsEvery(ctx context.Context, cache *repository.TodoCache, interval time.Duration, logger common.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			logCacheStats(cache, logger)
+		}
+	}
+}
+
+func logCacheStats(cache *repository.TodoCache, logger common.Logger) {
+	stats := cache.Stats()
+	logger.Printf("todo cache: %d hits, %d misses\n", stats.Hits, stats.Misses)
+}
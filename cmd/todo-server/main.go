@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/config"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/handler"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/migrations"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/router"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"google.golang.org/api/option"
+)
+
+const shutdownTimeout time.Duration = 10 * time.Second
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrate(args[1:], os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	cfg, err := config.Load(args, os.LookupEnv)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run(cfg config.Config) error {
+	rules := repository.SubtaskRules{MaxDepth: cfg.Subtasks.MaxDepth, CompleteParents: cfg.Subtasks.CompleteParents}
+	todoRepository, unitOfWork, closeStorage, err := openStorage(cfg.Database, rules)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+	if cfg.Cache.TTL > 0 {
+		cache := repository.NewTodoCache(cfg.Cache.Users, cfg.Cache.EntriesPerUser, time.Duration(cfg.Cache.TTL))
+		defer logCacheStats(cache, log.Default())
+		if cfg.Cache.StatsInterval > 0 {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go logCacheStatsEvery(ctx, cache, time.Duration(cfg.Cache.StatsInterval), log.Default())
+		}
+		if todoRepository, err = repository.GetCachedTodoRepository(todoRepository, cache); err != nil {
+			return err
+		}
+		if unitOfWork, err = repository.GetCachedUnitOfWork(unitOfWork, cache); err != nil {
+			return err
+		}
+	}
+	authClient, err := newAuthClient(cfg.Auth)
+	if err != nil {
+		return err
+	}
+	if cfg.Trash.Retention > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go purgeTrash(ctx, todoRepository, cfg.Trash, log.Default())
+	}
+	errorHandler := handler.ErrorHandlerImpl{Logger: log.Default()}
+	engine := newEngine(cfg.LogLevel)
+	router.SetTodoRoutes(engine, todoRepository, unitOfWork, errorHandler, authClient)
+	return serve(cfg.ListenAddress, engine)
+}
+
+// openStorage opens the storage of the todos that the driver of dbConfig
+// chooses with the rules of the subtasks, and returns the function that
+// closes it. A SQLite database brings its schema up to date when it opens,
+// while a Postgres one must already have every migration applied.
+func openStorage(dbConfig config.DatabaseConfig, rules repository.SubtaskRules) (common.TodoRepository, common.UnitOfWork,
+	func() error, error) {
+	queryTimeout := time.Duration(dbConfig.QueryTimeout)
+	var todoRepository common.TodoRepository
+	var unitOfWork common.UnitOfWork
+	var closeStorage func() error
+	var err error
+	switch dbConfig.Driver {
+	case config.DatabaseDriverMemory:
+		var store *repository.MemoryStore
+		if store, err = repository.OpenMemoryStore(dbConfig.DSN, uuid.NewV7, time.Now); err != nil {
+			return nil, nil, nil, err
+		}
+		closeStorage = func() error { return nil }
+		if todoRepository, err = repository.GetMemoryTodoRepository(store, rules); err == nil {
+			unitOfWork, err = repository.GetMemoryUnitOfWork(store, rules)
+		}
+	case config.DatabaseDriverSQLite:
+		var db *repository.SQLiteDB
+		if db, err = repository.OpenSQLite(dbConfig.DSN); err != nil {
+			return nil, nil, nil, err
+		}
+		setPoolSizes(db.DB, dbConfig)
+		closeStorage = db.Close
+		if todoRepository, err = repository.GetSQLiteTodoRepository(db, queryTimeout, rules, uuid.NewV7, time.Now); err == nil {
+			unitOfWork, err = repository.GetSQLiteUnitOfWork(db, queryTimeout, rules, uuid.NewV7, time.Now)
+		}
+	default:
+		var db *sql.DB
+		if db, err = openDB(dbConfig); err != nil {
+			return nil, nil, nil, err
+		}
+		closeStorage = db.Close
+		if err = checkSchema(db); err != nil {
+			closeStorage()
+			return nil, nil, nil, err
+		}
+		if todoRepository, err = repository.GetTodoRepository(db, queryTimeout, rules, uuid.NewV7, time.Now); err == nil {
+			unitOfWork, err = repository.GetUnitOfWork(db, queryTimeout, rules, uuid.NewV7, time.Now)
+		}
+	}
+	if err != nil {
+		closeStorage()
+		return nil, nil, nil, err
+	}
+	return todoRepository, unitOfWork, closeStorage, nil
+}
+
+func openDB(dbConfig config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dbConfig.DSN)
+	if err != nil {
+		return nil, err
+	}
+	setPoolSizes(db, dbConfig)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// checkSchema refuses a Postgres database whose schema is behind the
+// migrations of this binary. The server doesn't migrate it itself, so that
+// starting several instances never races a migration that an operator runs.
+func checkSchema(db *sql.DB) error {
+	migrator, err := migrations.GetMigrator(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("%w, run todo-server migrate up first", err)
+	}
+	return nil
+}
+
+func setPoolSizes(db *sql.DB, dbConfig config.DatabaseConfig) {
+	db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(dbConfig.ConnMaxLifetime))
+}
+
+func newAuthClient(authConfig config.AuthConfig) (common.AuthClient, error) {
+	var options []option.ClientOption
+	if authConfig.CredentialsFile != "" {
+		options = append(options, option.WithCredentialsFile(authConfig.CredentialsFile))
+	}
+	var firebaseConfig *firebase.Config
+	if authConfig.ProjectID != "" {
+		firebaseConfig = &firebase.Config{ProjectID: authConfig.ProjectID}
+	}
+	app, err := firebase.NewApp(context.Background(), firebaseConfig, options...)
+	if err != nil {
+		return nil, err
+	}
+	return app.Auth(context.Background())
+}
+
+func newEngine(logLevel string) *gin.Engine {
+	if logLevel == config.LogLevelDebug {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	engine := gin.New()
+	if logLevel != config.LogLevelError {
+		engine.Use(gin.Logger())
+	}
+	engine.Use(gin.Recovery())
+	return engine
+}
+
+func serve(address string, engine *gin.Engine) error {
+	server := &http.Server{Addr: address, Handler: engine}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s\n", address)
+		serverErr <- server.ListenAndServe()
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		return err
+	case <-signals:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			return err
+		}
+		if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
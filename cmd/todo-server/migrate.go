@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/config"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/migrations"
+)
+
+var errMigrateUsage error = errors.New("usage: todo-server migrate up|down|status|redo [flags]")
+var errMigrateDriver error = errors.New("only the postgres driver has migrations, the sqlite schema is applied when the server starts")
+
+func migrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	cfg, err := config.Load(args[1:], os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	if cfg.Database.Driver != config.DatabaseDriverPostgres {
+		return errMigrateDriver
+	}
+	db, err := openDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := migrations.GetMigrator(db)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "redo":
+		return migrator.Redo()
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		return printStatus(out, statuses)
+	default:
+		return errMigrateUsage
+	}
+}
+
+func printStatus(out io.Writer, statuses []migrations.Status) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05Z07:00")
+		}
+		fmt.Fprintf(writer, "%06d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/config"
+)
+
+// purgeTrash removes the todos that have been in the trash for longer than the
+// retention, once at start and then every purge interval until ctx is done.
+func purgeTrash(ctx context.Context, todoRepository common.TodoRepository, trashConfig config.TrashConfig,
+	logger common.Logger) {
+	ticker := time.NewTicker(time.Duration(trashConfig.PurgeInterval))
+	defer ticker.Stop()
+	for {
+		purged, err := todoRepository.PurgeTrash(ctx, time.Now().UTC().Add(-time.Duration(trashConfig.Retention)))
+		if err != nil {
+			logger.Printf("purging the trash: %v\n", err)
+		} else if purged > 0 {
+			logger.Printf("purged %d todos from the trash\n", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
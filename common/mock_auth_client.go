@@ -35,6 +35,21 @@ func (m *MockAuthClient) EXPECT() *MockAuthClientMockRecorder {
 	return m.recorder
 }
 
+// GetUserByEmail mocks base method.
+func (m *MockAuthClient) GetUserByEmail(arg0 context.Context, arg1 string) (*auth.UserRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(*auth.UserRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockAuthClientMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockAuthClient)(nil).GetUserByEmail), arg0, arg1)
+}
+
 // VerifyIDToken mocks base method.
 func (m *MockAuthClient) VerifyIDToken(arg0 context.Context, arg1 string) (*auth.Token, error) {
 	m.ctrl.T.Helper()
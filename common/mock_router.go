@@ -72,6 +72,44 @@ func (mr *MockRouterMockRecorder) GET(arg0 interface{}, arg1 ...interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GET", reflect.TypeOf((*MockRouter)(nil).GET), varargs...)
 }
 
+// Group mocks base method.
+func (m *MockRouter) Group(arg0 string, arg1 ...gin.HandlerFunc) *gin.RouterGroup {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Group", varargs...)
+	ret0, _ := ret[0].(*gin.RouterGroup)
+	return ret0
+}
+
+// Group indicates an expected call of Group.
+func (mr *MockRouterMockRecorder) Group(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Group", reflect.TypeOf((*MockRouter)(nil).Group), varargs...)
+}
+
+// PATCH mocks base method.
+func (m *MockRouter) PATCH(arg0 string, arg1 ...gin.HandlerFunc) gin.IRoutes {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PATCH", varargs...)
+	ret0, _ := ret[0].(gin.IRoutes)
+	return ret0
+}
+
+// PATCH indicates an expected call of PATCH.
+func (mr *MockRouterMockRecorder) PATCH(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PATCH", reflect.TypeOf((*MockRouter)(nil).PATCH), varargs...)
+}
+
 // POST mocks base method.
 func (m *MockRouter) POST(arg0 string, arg1 ...gin.HandlerFunc) gin.IRoutes {
 	m.ctrl.T.Helper()
@@ -127,21 +165,3 @@ func (mr *MockRouterMockRecorder) Run(arg0 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRouter)(nil).Run), arg0...)
 }
-
-// Use mocks base method.
-func (m *MockRouter) Use(arg0 ...gin.HandlerFunc) gin.IRoutes {
-	m.ctrl.T.Helper()
-	varargs := []interface{}{}
-	for _, a := range arg0 {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Use", varargs...)
-	ret0, _ := ret[0].(gin.IRoutes)
-	return ret0
-}
-
-// Use indicates an expected call of Use.
-func (mr *MockRouterMockRecorder) Use(arg0 ...interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockRouter)(nil).Use), arg0...)
-}
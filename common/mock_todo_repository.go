@@ -5,7 +5,9 @@
 package common
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/ahmedsameha1/todo_backend_go_to_practice/model"
 	gomock "github.com/golang/mock/gomock"
@@ -35,73 +37,535 @@ func (m *MockTodoRepository) EXPECT() *MockTodoRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockTodoRepository) Create(arg0 *model.Todo, arg1 string) error {
+func (m *MockTodoRepository) Create(arg0 context.Context, arg1 *model.Todo, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTodoRepositoryMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTodoRepositoryMockRecorder) Create(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoRepository)(nil).Create), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTodoRepository)(nil).Create), arg0, arg1, arg2)
+}
+
+// CreateComment mocks base method.
+func (m *MockTodoRepository) CreateComment(arg0 context.Context, arg1 *model.Comment, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockTodoRepositoryMockRecorder) CreateComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockTodoRepository)(nil).CreateComment), arg0, arg1, arg2)
+}
+
+// CreateList mocks base method.
+func (m *MockTodoRepository) CreateList(arg0 context.Context, arg1 *model.List, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateList indicates an expected call of CreateList.
+func (mr *MockTodoRepositoryMockRecorder) CreateList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateList", reflect.TypeOf((*MockTodoRepository)(nil).CreateList), arg0, arg1, arg2)
+}
+
+// CreateMembership mocks base method.
+func (m *MockTodoRepository) CreateMembership(arg0 context.Context, arg1 *model.Membership, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMembership", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMembership indicates an expected call of CreateMembership.
+func (mr *MockTodoRepositoryMockRecorder) CreateMembership(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMembership", reflect.TypeOf((*MockTodoRepository)(nil).CreateMembership), arg0, arg1, arg2)
+}
+
+// CreateShareLink mocks base method.
+func (m *MockTodoRepository) CreateShareLink(arg0 context.Context, arg1 *model.ShareLink, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateShareLink indicates an expected call of CreateShareLink.
+func (mr *MockTodoRepositoryMockRecorder) CreateShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShareLink", reflect.TypeOf((*MockTodoRepository)(nil).CreateShareLink), arg0, arg1, arg2)
 }
 
 // Delete mocks base method.
-func (m *MockTodoRepository) Delete(arg0, arg1 string) error {
+func (m *MockTodoRepository) Delete(arg0 context.Context, arg1, arg2 string, arg3 int64, arg4 time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTodoRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTodoRepositoryMockRecorder) Delete(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoRepository)(nil).Delete), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoRepository)(nil).Delete), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteComment mocks base method.
+func (m *MockTodoRepository) DeleteComment(arg0 context.Context, arg1, arg2, arg3, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockTodoRepositoryMockRecorder) DeleteComment(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockTodoRepository)(nil).DeleteComment), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteList mocks base method.
+func (m *MockTodoRepository) DeleteList(arg0 context.Context, arg1, arg2 string, arg3 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteList indicates an expected call of DeleteList.
+func (mr *MockTodoRepositoryMockRecorder) DeleteList(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteList", reflect.TypeOf((*MockTodoRepository)(nil).DeleteList), arg0, arg1, arg2, arg3)
+}
+
+// DeleteMembership mocks base method.
+func (m *MockTodoRepository) DeleteMembership(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMembership", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMembership indicates an expected call of DeleteMembership.
+func (mr *MockTodoRepositoryMockRecorder) DeleteMembership(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMembership", reflect.TypeOf((*MockTodoRepository)(nil).DeleteMembership), arg0, arg1, arg2, arg3)
+}
+
+// DeleteShareLink mocks base method.
+func (m *MockTodoRepository) DeleteShareLink(arg0 context.Context, arg1 model.ShareTarget, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShareLink indicates an expected call of DeleteShareLink.
+func (mr *MockTodoRepositoryMockRecorder) DeleteShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShareLink", reflect.TypeOf((*MockTodoRepository)(nil).DeleteShareLink), arg0, arg1, arg2)
+}
+
+// DeleteTag mocks base method.
+func (m *MockTodoRepository) DeleteTag(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockTodoRepositoryMockRecorder) DeleteTag(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockTodoRepository)(nil).DeleteTag), arg0, arg1, arg2)
 }
 
 // GetAll mocks base method.
-func (m *MockTodoRepository) GetAll(arg0 string) ([]model.Todo, error) {
+func (m *MockTodoRepository) GetAll(arg0 context.Context, arg1 string) ([]model.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", arg0)
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
 	ret0, _ := ret[0].([]model.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTodoRepositoryMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+func (mr *MockTodoRepositoryMockRecorder) GetAll(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoRepository)(nil).GetAll), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoRepository)(nil).GetAll), arg0, arg1)
 }
 
 // GetById mocks base method.
-func (m *MockTodoRepository) GetById(arg0, arg1 string) (*model.Todo, error) {
+func (m *MockTodoRepository) GetById(arg0 context.Context, arg1, arg2 string) (*model.Todo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetById", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*model.Todo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockTodoRepositoryMockRecorder) GetById(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTodoRepositoryMockRecorder) GetById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoRepository)(nil).GetById), arg0, arg1, arg2)
+}
+
+// GetComments mocks base method.
+func (m *MockTodoRepository) GetComments(arg0 context.Context, arg1, arg2 string, arg3 model.PageRequest) (*model.CommentPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*model.CommentPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockTodoRepositoryMockRecorder) GetComments(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockTodoRepository)(nil).GetComments), arg0, arg1, arg2, arg3)
+}
+
+// GetList mocks base method.
+func (m *MockTodoRepository) GetList(arg0 context.Context, arg1, arg2 string) (*model.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetList indicates an expected call of GetList.
+func (mr *MockTodoRepositoryMockRecorder) GetList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockTodoRepository)(nil).GetList), arg0, arg1, arg2)
+}
+
+// GetListAccess mocks base method.
+func (m *MockTodoRepository) GetListAccess(arg0 context.Context, arg1, arg2 string) (*model.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListAccess", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListAccess indicates an expected call of GetListAccess.
+func (mr *MockTodoRepositoryMockRecorder) GetListAccess(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListAccess", reflect.TypeOf((*MockTodoRepository)(nil).GetListAccess), arg0, arg1, arg2)
+}
+
+// GetLists mocks base method.
+func (m *MockTodoRepository) GetLists(arg0 context.Context, arg1 string, arg2 bool) ([]model.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLists", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLists indicates an expected call of GetLists.
+func (mr *MockTodoRepositoryMockRecorder) GetLists(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLists", reflect.TypeOf((*MockTodoRepository)(nil).GetLists), arg0, arg1, arg2)
+}
+
+// GetMemberships mocks base method.
+func (m *MockTodoRepository) GetMemberships(arg0 context.Context, arg1, arg2 string) ([]model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberships", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberships indicates an expected call of GetMemberships.
+func (mr *MockTodoRepositoryMockRecorder) GetMemberships(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberships", reflect.TypeOf((*MockTodoRepository)(nil).GetMemberships), arg0, arg1, arg2)
+}
+
+// GetPage mocks base method.
+func (m *MockTodoRepository) GetPage(arg0 context.Context, arg1 string, arg2 model.TodoFilter, arg3 model.PageRequest) (*model.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPage", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*model.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPage indicates an expected call of GetPage.
+func (mr *MockTodoRepositoryMockRecorder) GetPage(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPage", reflect.TypeOf((*MockTodoRepository)(nil).GetPage), arg0, arg1, arg2, arg3)
+}
+
+// GetShareLink mocks base method.
+func (m *MockTodoRepository) GetShareLink(arg0 context.Context, arg1 model.ShareTarget, arg2 string) (*model.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShareLink indicates an expected call of GetShareLink.
+func (mr *MockTodoRepositoryMockRecorder) GetShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShareLink", reflect.TypeOf((*MockTodoRepository)(nil).GetShareLink), arg0, arg1, arg2)
+}
+
+// GetSubtasks mocks base method.
+func (m *MockTodoRepository) GetSubtasks(arg0 context.Context, arg1, arg2 string, arg3 bool) ([]model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubtasks", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubtasks indicates an expected call of GetSubtasks.
+func (mr *MockTodoRepositoryMockRecorder) GetSubtasks(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubtasks", reflect.TypeOf((*MockTodoRepository)(nil).GetSubtasks), arg0, arg1, arg2, arg3)
+}
+
+// GetTags mocks base method.
+func (m *MockTodoRepository) GetTags(arg0 context.Context, arg1 string) ([]model.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", arg0, arg1)
+	ret0, _ := ret[0].([]model.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockTodoRepositoryMockRecorder) GetTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockTodoRepository)(nil).GetTags), arg0, arg1)
+}
+
+// GetTodoAccess mocks base method.
+func (m *MockTodoRepository) GetTodoAccess(arg0 context.Context, arg1, arg2 string) (*model.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodoAccess", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodoAccess indicates an expected call of GetTodoAccess.
+func (mr *MockTodoRepositoryMockRecorder) GetTodoAccess(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoAccess", reflect.TypeOf((*MockTodoRepository)(nil).GetTodoAccess), arg0, arg1, arg2)
+}
+
+// GetTrash mocks base method.
+func (m *MockTodoRepository) GetTrash(arg0 context.Context, arg1 string) ([]model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", arg0, arg1)
+	ret0, _ := ret[0].([]model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockTodoRepositoryMockRecorder) GetTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockTodoRepository)(nil).GetTrash), arg0, arg1)
+}
+
+// MergeTag mocks base method.
+func (m *MockTodoRepository) MergeTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeTag indicates an expected call of MergeTag.
+func (mr *MockTodoRepositoryMockRecorder) MergeTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeTag", reflect.TypeOf((*MockTodoRepository)(nil).MergeTag), arg0, arg1, arg2, arg3)
+}
+
+// Move mocks base method.
+func (m *MockTodoRepository) Move(arg0 context.Context, arg1, arg2 string, arg3 model.MoveRequest, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockTodoRepositoryMockRecorder) Move(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockTodoRepository)(nil).Move), arg0, arg1, arg2, arg3, arg4)
+}
+
+// OpenShareLink mocks base method.
+func (m *MockTodoRepository) OpenShareLink(arg0 context.Context, arg1 string, arg2 time.Time) (*model.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenShareLink indicates an expected call of OpenShareLink.
+func (mr *MockTodoRepositoryMockRecorder) OpenShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenShareLink", reflect.TypeOf((*MockTodoRepository)(nil).OpenShareLink), arg0, arg1, arg2)
+}
+
+// Patch mocks base method.
+func (m *MockTodoRepository) Patch(arg0 context.Context, arg1, arg2 string, arg3 model.TodoChanges, arg4 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockTodoRepositoryMockRecorder) Patch(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoRepository)(nil).GetById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockTodoRepository)(nil).Patch), arg0, arg1, arg2, arg3, arg4)
+}
+
+// PatchList mocks base method.
+func (m *MockTodoRepository) PatchList(arg0 context.Context, arg1, arg2 string, arg3 model.ListChanges) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchList indicates an expected call of PatchList.
+func (mr *MockTodoRepositoryMockRecorder) PatchList(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchList", reflect.TypeOf((*MockTodoRepository)(nil).PatchList), arg0, arg1, arg2, arg3)
+}
+
+// Purge mocks base method.
+func (m *MockTodoRepository) Purge(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockTodoRepositoryMockRecorder) Purge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockTodoRepository)(nil).Purge), arg0, arg1, arg2)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockTodoRepository) PurgeTrash(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockTodoRepositoryMockRecorder) PurgeTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockTodoRepository)(nil).PurgeTrash), arg0, arg1)
+}
+
+// RenameTag mocks base method.
+func (m *MockTodoRepository) RenameTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockTodoRepositoryMockRecorder) RenameTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockTodoRepository)(nil).RenameTag), arg0, arg1, arg2, arg3)
+}
+
+// Restore mocks base method.
+func (m *MockTodoRepository) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockTodoRepositoryMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockTodoRepository)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Search mocks base method.
+func (m *MockTodoRepository) Search(arg0 context.Context, arg1, arg2 string, arg3 int) ([]model.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockTodoRepositoryMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockTodoRepository)(nil).Search), arg0, arg1, arg2, arg3)
 }
 
 // Update mocks base method.
-func (m *MockTodoRepository) Update(arg0 *model.Todo, arg1 string) error {
+func (m *MockTodoRepository) Update(arg0 context.Context, arg1 *model.Todo, arg2 string, arg3 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockTodoRepositoryMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTodoRepositoryMockRecorder) Update(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoRepository)(nil).Update), arg0, arg1, arg2, arg3)
+}
+
+// UpdateComment mocks base method.
+func (m *MockTodoRepository) UpdateComment(arg0 context.Context, arg1 *model.Comment, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockTodoRepositoryMockRecorder) UpdateComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockTodoRepository)(nil).UpdateComment), arg0, arg1, arg2)
+}
+
+// UpdateMembership mocks base method.
+func (m *MockTodoRepository) UpdateMembership(arg0 context.Context, arg1, arg2, arg3 string, arg4 model.MembershipChanges) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMembership", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMembership indicates an expected call of UpdateMembership.
+func (mr *MockTodoRepositoryMockRecorder) UpdateMembership(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoRepository)(nil).Update), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMembership", reflect.TypeOf((*MockTodoRepository)(nil).UpdateMembership), arg0, arg1, arg2, arg3, arg4)
 }
@@ -0,0 +1,622 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/ahmedsameha1/todo_backend_go_to_practice/common (interfaces: UnitOfWork,Transaction)
+
+// Package common is a generated GoMock package.
+package common
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUnitOfWork) Do(arg0 context.Context, arg1 func(Transaction) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUnitOfWorkMockRecorder) Do(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUnitOfWork)(nil).Do), arg0, arg1)
+}
+
+// MockTransaction is a mock of Transaction interface.
+type MockTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionMockRecorder
+}
+
+// MockTransactionMockRecorder is the mock recorder for MockTransaction.
+type MockTransactionMockRecorder struct {
+	mock *MockTransaction
+}
+
+// NewMockTransaction creates a new mock instance.
+func NewMockTransaction(ctrl *gomock.Controller) *MockTransaction {
+	mock := &MockTransaction{ctrl: ctrl}
+	mock.recorder = &MockTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTransaction) Create(arg0 context.Context, arg1 *model.Todo, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTransactionMockRecorder) Create(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransaction)(nil).Create), arg0, arg1, arg2)
+}
+
+// CreateComment mocks base method.
+func (m *MockTransaction) CreateComment(arg0 context.Context, arg1 *model.Comment, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockTransactionMockRecorder) CreateComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockTransaction)(nil).CreateComment), arg0, arg1, arg2)
+}
+
+// CreateList mocks base method.
+func (m *MockTransaction) CreateList(arg0 context.Context, arg1 *model.List, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateList indicates an expected call of CreateList.
+func (mr *MockTransactionMockRecorder) CreateList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateList", reflect.TypeOf((*MockTransaction)(nil).CreateList), arg0, arg1, arg2)
+}
+
+// CreateMembership mocks base method.
+func (m *MockTransaction) CreateMembership(arg0 context.Context, arg1 *model.Membership, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMembership", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMembership indicates an expected call of CreateMembership.
+func (mr *MockTransactionMockRecorder) CreateMembership(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMembership", reflect.TypeOf((*MockTransaction)(nil).CreateMembership), arg0, arg1, arg2)
+}
+
+// CreateShareLink mocks base method.
+func (m *MockTransaction) CreateShareLink(arg0 context.Context, arg1 *model.ShareLink, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateShareLink indicates an expected call of CreateShareLink.
+func (mr *MockTransactionMockRecorder) CreateShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShareLink", reflect.TypeOf((*MockTransaction)(nil).CreateShareLink), arg0, arg1, arg2)
+}
+
+// Delete mocks base method.
+func (m *MockTransaction) Delete(arg0 context.Context, arg1, arg2 string, arg3 int64, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTransactionMockRecorder) Delete(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTransaction)(nil).Delete), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteComment mocks base method.
+func (m *MockTransaction) DeleteComment(arg0 context.Context, arg1, arg2, arg3, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockTransactionMockRecorder) DeleteComment(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockTransaction)(nil).DeleteComment), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteList mocks base method.
+func (m *MockTransaction) DeleteList(arg0 context.Context, arg1, arg2 string, arg3 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteList indicates an expected call of DeleteList.
+func (mr *MockTransactionMockRecorder) DeleteList(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteList", reflect.TypeOf((*MockTransaction)(nil).DeleteList), arg0, arg1, arg2, arg3)
+}
+
+// DeleteMembership mocks base method.
+func (m *MockTransaction) DeleteMembership(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMembership", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMembership indicates an expected call of DeleteMembership.
+func (mr *MockTransactionMockRecorder) DeleteMembership(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMembership", reflect.TypeOf((*MockTransaction)(nil).DeleteMembership), arg0, arg1, arg2, arg3)
+}
+
+// DeleteShareLink mocks base method.
+func (m *MockTransaction) DeleteShareLink(arg0 context.Context, arg1 model.ShareTarget, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShareLink indicates an expected call of DeleteShareLink.
+func (mr *MockTransactionMockRecorder) DeleteShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShareLink", reflect.TypeOf((*MockTransaction)(nil).DeleteShareLink), arg0, arg1, arg2)
+}
+
+// DeleteTag mocks base method.
+func (m *MockTransaction) DeleteTag(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockTransactionMockRecorder) DeleteTag(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockTransaction)(nil).DeleteTag), arg0, arg1, arg2)
+}
+
+// GetAll mocks base method.
+func (m *MockTransaction) GetAll(arg0 context.Context, arg1 string) ([]model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
+	ret0, _ := ret[0].([]model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTransactionMockRecorder) GetAll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTransaction)(nil).GetAll), arg0, arg1)
+}
+
+// GetById mocks base method.
+func (m *MockTransaction) GetById(arg0 context.Context, arg1, arg2 string) (*model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockTransactionMockRecorder) GetById(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTransaction)(nil).GetById), arg0, arg1, arg2)
+}
+
+// GetComments mocks base method.
+func (m *MockTransaction) GetComments(arg0 context.Context, arg1, arg2 string, arg3 model.PageRequest) (*model.CommentPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*model.CommentPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockTransactionMockRecorder) GetComments(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockTransaction)(nil).GetComments), arg0, arg1, arg2, arg3)
+}
+
+// GetList mocks base method.
+func (m *MockTransaction) GetList(arg0 context.Context, arg1, arg2 string) (*model.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetList", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetList indicates an expected call of GetList.
+func (mr *MockTransactionMockRecorder) GetList(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockTransaction)(nil).GetList), arg0, arg1, arg2)
+}
+
+// GetListAccess mocks base method.
+func (m *MockTransaction) GetListAccess(arg0 context.Context, arg1, arg2 string) (*model.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetListAccess", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetListAccess indicates an expected call of GetListAccess.
+func (mr *MockTransactionMockRecorder) GetListAccess(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetListAccess", reflect.TypeOf((*MockTransaction)(nil).GetListAccess), arg0, arg1, arg2)
+}
+
+// GetLists mocks base method.
+func (m *MockTransaction) GetLists(arg0 context.Context, arg1 string, arg2 bool) ([]model.List, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLists", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.List)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLists indicates an expected call of GetLists.
+func (mr *MockTransactionMockRecorder) GetLists(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLists", reflect.TypeOf((*MockTransaction)(nil).GetLists), arg0, arg1, arg2)
+}
+
+// GetMemberships mocks base method.
+func (m *MockTransaction) GetMemberships(arg0 context.Context, arg1, arg2 string) ([]model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberships", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberships indicates an expected call of GetMemberships.
+func (mr *MockTransactionMockRecorder) GetMemberships(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberships", reflect.TypeOf((*MockTransaction)(nil).GetMemberships), arg0, arg1, arg2)
+}
+
+// GetPage mocks base method.
+func (m *MockTransaction) GetPage(arg0 context.Context, arg1 string, arg2 model.TodoFilter, arg3 model.PageRequest) (*model.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPage", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*model.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPage indicates an expected call of GetPage.
+func (mr *MockTransactionMockRecorder) GetPage(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPage", reflect.TypeOf((*MockTransaction)(nil).GetPage), arg0, arg1, arg2, arg3)
+}
+
+// GetShareLink mocks base method.
+func (m *MockTransaction) GetShareLink(arg0 context.Context, arg1 model.ShareTarget, arg2 string) (*model.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShareLink indicates an expected call of GetShareLink.
+func (mr *MockTransactionMockRecorder) GetShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShareLink", reflect.TypeOf((*MockTransaction)(nil).GetShareLink), arg0, arg1, arg2)
+}
+
+// GetSubtasks mocks base method.
+func (m *MockTransaction) GetSubtasks(arg0 context.Context, arg1, arg2 string, arg3 bool) ([]model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubtasks", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubtasks indicates an expected call of GetSubtasks.
+func (mr *MockTransactionMockRecorder) GetSubtasks(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubtasks", reflect.TypeOf((*MockTransaction)(nil).GetSubtasks), arg0, arg1, arg2, arg3)
+}
+
+// GetTags mocks base method.
+func (m *MockTransaction) GetTags(arg0 context.Context, arg1 string) ([]model.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", arg0, arg1)
+	ret0, _ := ret[0].([]model.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockTransactionMockRecorder) GetTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockTransaction)(nil).GetTags), arg0, arg1)
+}
+
+// GetTodoAccess mocks base method.
+func (m *MockTransaction) GetTodoAccess(arg0 context.Context, arg1, arg2 string) (*model.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodoAccess", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodoAccess indicates an expected call of GetTodoAccess.
+func (mr *MockTransactionMockRecorder) GetTodoAccess(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoAccess", reflect.TypeOf((*MockTransaction)(nil).GetTodoAccess), arg0, arg1, arg2)
+}
+
+// GetTrash mocks base method.
+func (m *MockTransaction) GetTrash(arg0 context.Context, arg1 string) ([]model.Todo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", arg0, arg1)
+	ret0, _ := ret[0].([]model.Todo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockTransactionMockRecorder) GetTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockTransaction)(nil).GetTrash), arg0, arg1)
+}
+
+// MergeTag mocks base method.
+func (m *MockTransaction) MergeTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeTag indicates an expected call of MergeTag.
+func (mr *MockTransactionMockRecorder) MergeTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeTag", reflect.TypeOf((*MockTransaction)(nil).MergeTag), arg0, arg1, arg2, arg3)
+}
+
+// Move mocks base method.
+func (m *MockTransaction) Move(arg0 context.Context, arg1, arg2 string, arg3 model.MoveRequest, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockTransactionMockRecorder) Move(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockTransaction)(nil).Move), arg0, arg1, arg2, arg3, arg4)
+}
+
+// OpenShareLink mocks base method.
+func (m *MockTransaction) OpenShareLink(arg0 context.Context, arg1 string, arg2 time.Time) (*model.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenShareLink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenShareLink indicates an expected call of OpenShareLink.
+func (mr *MockTransactionMockRecorder) OpenShareLink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenShareLink", reflect.TypeOf((*MockTransaction)(nil).OpenShareLink), arg0, arg1, arg2)
+}
+
+// Patch mocks base method.
+func (m *MockTransaction) Patch(arg0 context.Context, arg1, arg2 string, arg3 model.TodoChanges, arg4 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockTransactionMockRecorder) Patch(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockTransaction)(nil).Patch), arg0, arg1, arg2, arg3, arg4)
+}
+
+// PatchList mocks base method.
+func (m *MockTransaction) PatchList(arg0 context.Context, arg1, arg2 string, arg3 model.ListChanges) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchList indicates an expected call of PatchList.
+func (mr *MockTransactionMockRecorder) PatchList(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchList", reflect.TypeOf((*MockTransaction)(nil).PatchList), arg0, arg1, arg2, arg3)
+}
+
+// Purge mocks base method.
+func (m *MockTransaction) Purge(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockTransactionMockRecorder) Purge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockTransaction)(nil).Purge), arg0, arg1, arg2)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockTransaction) PurgeTrash(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockTransactionMockRecorder) PurgeTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockTransaction)(nil).PurgeTrash), arg0, arg1)
+}
+
+// RenameTag mocks base method.
+func (m *MockTransaction) RenameTag(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockTransactionMockRecorder) RenameTag(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockTransaction)(nil).RenameTag), arg0, arg1, arg2, arg3)
+}
+
+// Restore mocks base method.
+func (m *MockTransaction) Restore(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockTransactionMockRecorder) Restore(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockTransaction)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Savepoint mocks base method.
+func (m *MockTransaction) Savepoint(arg0 context.Context, arg1 func() error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Savepoint", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Savepoint indicates an expected call of Savepoint.
+func (mr *MockTransactionMockRecorder) Savepoint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Savepoint", reflect.TypeOf((*MockTransaction)(nil).Savepoint), arg0, arg1)
+}
+
+// Search mocks base method.
+func (m *MockTransaction) Search(arg0 context.Context, arg1, arg2 string, arg3 int) ([]model.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockTransactionMockRecorder) Search(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockTransaction)(nil).Search), arg0, arg1, arg2, arg3)
+}
+
+// Update mocks base method.
+func (m *MockTransaction) Update(arg0 context.Context, arg1 *model.Todo, arg2 string, arg3 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTransactionMockRecorder) Update(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTransaction)(nil).Update), arg0, arg1, arg2, arg3)
+}
+
+// UpdateComment mocks base method.
+func (m *MockTransaction) UpdateComment(arg0 context.Context, arg1 *model.Comment, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockTransactionMockRecorder) UpdateComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockTransaction)(nil).UpdateComment), arg0, arg1, arg2)
+}
+
+// UpdateMembership mocks base method.
+func (m *MockTransaction) UpdateMembership(arg0 context.Context, arg1, arg2, arg3 string, arg4 model.MembershipChanges) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMembership", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMembership indicates an expected call of UpdateMembership.
+func (mr *MockTransactionMockRecorder) UpdateMembership(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMembership", reflect.TypeOf((*MockTransaction)(nil).UpdateMembership), arg0, arg1, arg2, arg3, arg4)
+}
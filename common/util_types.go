@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"errors"
+	"time"
 
 	"firebase.google.com/go/v4/auth"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
@@ -12,11 +13,12 @@ import (
 var ErrError error = errors.New("an error")
 
 type Router interface {
-	Use(middleware ...gin.HandlerFunc) gin.IRoutes
 	POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
+	PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
+	Group(relativePath string, handlers ...gin.HandlerFunc) *gin.RouterGroup
 	Run(addr ...string) (err error)
 }
 
@@ -25,11 +27,57 @@ type Logger interface {
 }
 
 type TodoRepository interface {
-	Create(todo *model.Todo, userId string) error
-	GetAll(userId string) ([]model.Todo, error)
-	GetById(id string, userId string) (*model.Todo, error)
-	Update(todo *model.Todo, userId string) error
-	Delete(id string, userId string) error
+	Create(ctx context.Context, todo *model.Todo, userId string) error
+	GetAll(ctx context.Context, userId string) ([]model.Todo, error)
+	GetPage(ctx context.Context, userId string, filter model.TodoFilter, pageRequest model.PageRequest) (*model.Page, error)
+	GetById(ctx context.Context, id string, userId string) (*model.Todo, error)
+	GetSubtasks(ctx context.Context, id string, userId string, all bool) ([]model.Todo, error)
+	Search(ctx context.Context, userId string, query string, limit int) ([]model.SearchResult, error)
+	Update(ctx context.Context, todo *model.Todo, userId string, version int64) error
+	Patch(ctx context.Context, id string, userId string, changes model.TodoChanges, version int64) error
+	Move(ctx context.Context, id string, userId string, move model.MoveRequest, movedAt time.Time) error
+	Delete(ctx context.Context, id string, userId string, version int64, deletedAt time.Time) error
+	GetTrash(ctx context.Context, userId string) ([]model.Todo, error)
+	Restore(ctx context.Context, id string, userId string) error
+	Purge(ctx context.Context, id string, userId string) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetTags(ctx context.Context, userId string) ([]model.Tag, error)
+	RenameTag(ctx context.Context, userId string, name string, newName string) error
+	MergeTag(ctx context.Context, userId string, name string, into string) error
+	DeleteTag(ctx context.Context, userId string, name string) error
+	GetLists(ctx context.Context, userId string, includeArchived bool) ([]model.List, error)
+	GetList(ctx context.Context, id string, userId string) (*model.List, error)
+	CreateList(ctx context.Context, list *model.List, userId string) error
+	PatchList(ctx context.Context, id string, userId string, changes model.ListChanges) error
+	DeleteList(ctx context.Context, id string, userId string, cascade bool) error
+	GetTodoAccess(ctx context.Context, id string, userId string) (*model.Access, error)
+	GetListAccess(ctx context.Context, id string, userId string) (*model.Access, error)
+	GetMemberships(ctx context.Context, listId string, userId string) ([]model.Membership, error)
+	CreateMembership(ctx context.Context, membership *model.Membership, userId string) error
+	UpdateMembership(ctx context.Context, listId string, userId string, memberId string, changes model.MembershipChanges) error
+	DeleteMembership(ctx context.Context, listId string, userId string, memberId string) error
+	GetShareLink(ctx context.Context, target model.ShareTarget, userId string) (*model.ShareLink, error)
+	CreateShareLink(ctx context.Context, link *model.ShareLink, userId string) error
+	DeleteShareLink(ctx context.Context, target model.ShareTarget, userId string) error
+	OpenShareLink(ctx context.Context, token string, openedAt time.Time) (*model.ShareLink, error)
+	GetComments(ctx context.Context, todoId string, userId string, pageRequest model.PageRequest) (*model.CommentPage, error)
+	CreateComment(ctx context.Context, comment *model.Comment, userId string) error
+	UpdateComment(ctx context.Context, comment *model.Comment, userId string) error
+	DeleteComment(ctx context.Context, todoId string, id string, userId string, authorId string) error
+}
+
+// UnitOfWork runs work on the todos in one transaction, which is committed
+// when work returns nil and rolled back otherwise.
+type UnitOfWork interface {
+	Do(ctx context.Context, work func(tx Transaction) error) error
+}
+
+// Transaction is the TodoRepository of a transaction of a UnitOfWork.
+// Savepoint runs work and, when it fails, undoes only what work did so that
+// the transaction goes on.
+type Transaction interface {
+	TodoRepository
+	Savepoint(ctx context.Context, work func() error) error
 }
 
 type ErrorHandler interface {
@@ -38,4 +86,5 @@ type ErrorHandler interface {
 
 type AuthClient interface {
 	VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error)
+	GetUserByEmail(ctx context.Context, email string) (*auth.UserRecord, error)
 }
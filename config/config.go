@@ -0,0 +1,308 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const EnvPrefix string = "TODO_"
+const ConfigFileEnv string = EnvPrefix + "CONFIG_FILE"
+
+const (
+	AuthProviderFirebase string = "firebase"
+)
+
+const (
+	DatabaseDriverPostgres string = "postgres"
+	DatabaseDriverSQLite   string = "sqlite"
+	DatabaseDriverMemory   string = "memory"
+)
+
+const (
+	LogLevelDebug string = "debug"
+	LogLevelInfo  string = "info"
+	LogLevelError string = "error"
+)
+
+var ErrUnsupportedConfigFile error = errors.New("the config file must have a .yaml, .yml or .toml extension")
+var ErrNoDatabaseDSN error = errors.New("there is no database DSN in the configuration")
+var ErrUnknownDatabaseDriver error = errors.New("unknown database driver")
+var ErrUnknownAuthProvider error = errors.New("unknown auth provider")
+var ErrUnknownLogLevel error = errors.New("unknown log level")
+var ErrInvalidPoolSize error = errors.New("database pool sizes must not be negative")
+var ErrInvalidQueryTimeout error = errors.New("the database query timeout must not be negative")
+var ErrInvalidCacheConfig error = errors.New("the cache TTL and stats interval must not be negative and the cache sizes must be positive")
+var ErrInvalidTrashConfig error = errors.New("the trash retention must not be negative and the purge interval must be positive")
+var ErrInvalidSubtaskConfig error = errors.New("the maximum depth of the subtasks must not be negative")
+
+// Duration is a time.Duration that is read from its string form ("30s", "5m")
+// in config files, environment variables and flags.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// DatabaseConfig configures where the todos are stored and the pool. The DSN
+// is a Postgres data source name, the path of a SQLite database file, or the
+// path of the optional snapshot file of the memory driver. QueryTimeout
+// bounds every operation on the todos; a zero QueryTimeout never times out.
+type DatabaseConfig struct {
+	Driver          string   `yaml:"driver" toml:"driver"`
+	DSN             string   `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	QueryTimeout    Duration `yaml:"query_timeout" toml:"query_timeout"`
+}
+
+type AuthConfig struct {
+	Provider        string `yaml:"provider" toml:"provider"`
+	CredentialsFile string `yaml:"credentials_file" toml:"credentials_file"`
+	ProjectID       string `yaml:"project_id" toml:"project_id"`
+}
+
+// TrashConfig tells how long a deleted todo stays in the trash and how often
+// the expired ones are purged. A zero Retention keeps them forever.
+type TrashConfig struct {
+	Retention     Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// CacheConfig sizes the cache of the todos that are read and tells how often
+// its hits and misses are logged. A zero TTL turns the cache off, and a zero
+// StatsInterval only logs them when the server stops.
+type CacheConfig struct {
+	TTL            Duration `yaml:"ttl" toml:"ttl"`
+	Users          int      `yaml:"users" toml:"users"`
+	EntriesPerUser int      `yaml:"entries_per_user" toml:"entries_per_user"`
+	StatsInterval  Duration `yaml:"stats_interval" toml:"stats_interval"`
+}
+
+// SubtaskConfig tells how deep subtasks may nest below a todo without a
+// parent, and whether a todo is done once all its subtasks are. A zero
+// MaxDepth doesn't limit the depth.
+type SubtaskConfig struct {
+	MaxDepth        int  `yaml:"max_depth" toml:"max_depth"`
+	CompleteParents bool `yaml:"complete_parents" toml:"complete_parents"`
+}
+
+type Config struct {
+	ListenAddress string         `yaml:"listen_address" toml:"listen_address"`
+	LogLevel      string         `yaml:"log_level" toml:"log_level"`
+	Database      DatabaseConfig `yaml:"database" toml:"database"`
+	Auth          AuthConfig     `yaml:"auth" toml:"auth"`
+	Trash         TrashConfig    `yaml:"trash" toml:"trash"`
+	Cache         CacheConfig    `yaml:"cache" toml:"cache"`
+	Subtasks      SubtaskConfig  `yaml:"subtasks" toml:"subtasks"`
+}
+
+func Default() Config {
+	return Config{
+		ListenAddress: ":8080",
+		LogLevel:      LogLevelInfo,
+		Database: DatabaseConfig{
+			Driver:          DatabaseDriverPostgres,
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			QueryTimeout:    Duration(5 * time.Second),
+		},
+		Auth: AuthConfig{Provider: AuthProviderFirebase},
+		Trash: TrashConfig{
+			Retention:     Duration(30 * 24 * time.Hour),
+			PurgeInterval: Duration(time.Hour),
+		},
+		Cache: CacheConfig{
+			Users:          10000,
+			EntriesPerUser: 50,
+			StatsInterval:  Duration(time.Minute),
+		},
+		Subtasks: SubtaskConfig{MaxDepth: 3},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence, the
+// defaults, an optional YAML or TOML file, TODO_* environment variables and
+// command line flags. The file is named by the -config flag or by TODO_CONFIG_FILE.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	flagSet, flagValues, configFile := newFlagSet()
+	if err := flagSet.Parse(args); err != nil {
+		return cfg, err
+	}
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(ConfigFileEnv)
+	}
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, err
+		}
+	}
+	for _, setting := range settings(&cfg) {
+		if value, ok := lookupEnv(EnvPrefix + setting.env); ok {
+			if err := setting.set(value); err != nil {
+				return cfg, fmt.Errorf("%s%s: %w", EnvPrefix, setting.env, err)
+			}
+		}
+	}
+	var err error
+	flagSet.Visit(func(f *flag.Flag) {
+		for _, setting := range settings(&cfg) {
+			if err == nil && setting.flag == f.Name {
+				if setErr := setting.set(*flagValues[f.Name]); setErr != nil {
+					err = fmt.Errorf("-%s: %w", f.Name, setErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func (cfg Config) Validate() error {
+	switch cfg.Database.Driver {
+	case DatabaseDriverPostgres, DatabaseDriverSQLite:
+		if cfg.Database.DSN == "" {
+			return ErrNoDatabaseDSN
+		}
+	case DatabaseDriverMemory:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownDatabaseDriver, cfg.Database.Driver)
+	}
+	if cfg.Database.MaxOpenConns < 0 || cfg.Database.MaxIdleConns < 0 {
+		return ErrInvalidPoolSize
+	}
+	if cfg.Database.QueryTimeout < 0 {
+		return ErrInvalidQueryTimeout
+	}
+	if cfg.Trash.Retention < 0 || cfg.Trash.PurgeInterval <= 0 {
+		return ErrInvalidTrashConfig
+	}
+	if cfg.Cache.TTL < 0 || cfg.Cache.Users <= 0 || cfg.Cache.EntriesPerUser <= 0 || cfg.Cache.StatsInterval < 0 {
+		return ErrInvalidCacheConfig
+	}
+	if cfg.Subtasks.MaxDepth < 0 {
+		return ErrInvalidSubtaskConfig
+	}
+	if cfg.Auth.Provider != AuthProviderFirebase {
+		return fmt.Errorf("%w: %q", ErrUnknownAuthProvider, cfg.Auth.Provider)
+	}
+	switch cfg.LogLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelError:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownLogLevel, cfg.LogLevel)
+	}
+}
+
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(content, cfg)
+	case ".toml":
+		return toml.Unmarshal(content, cfg)
+	default:
+		return ErrUnsupportedConfigFile
+	}
+}
+
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(string) error
+}
+
+func settings(cfg *Config) []setting {
+	return []setting{
+		{"listen", "LISTEN_ADDRESS", "address the HTTP server listens on", setString(&cfg.ListenAddress)},
+		{"log-level", "LOG_LEVEL", "one of debug, info or error", setString(&cfg.LogLevel)},
+		{"db-driver", "DATABASE_DRIVER", "one of postgres, sqlite or memory", setString(&cfg.Database.Driver)},
+		{"db-dsn", "DATABASE_DSN", "Postgres data source name, SQLite database file or memory snapshot file", setString(&cfg.Database.DSN)},
+		{"db-max-open-conns", "DATABASE_MAX_OPEN_CONNS", "maximum number of open database connections", setInt(&cfg.Database.MaxOpenConns)},
+		{"db-max-idle-conns", "DATABASE_MAX_IDLE_CONNS", "maximum number of idle database connections", setInt(&cfg.Database.MaxIdleConns)},
+		{"db-conn-max-lifetime", "DATABASE_CONN_MAX_LIFETIME", "maximum lifetime of a database connection", setDuration(&cfg.Database.ConnMaxLifetime)},
+		{"db-query-timeout", "DATABASE_QUERY_TIMEOUT", "how long an operation on the todos may take, 0 never times out", setDuration(&cfg.Database.QueryTimeout)},
+		{"auth-provider", "AUTH_PROVIDER", "auth provider, only firebase is supported", setString(&cfg.Auth.Provider)},
+		{"auth-credentials-file", "AUTH_CREDENTIALS_FILE", "service account credentials file of the auth provider", setString(&cfg.Auth.CredentialsFile)},
+		{"auth-project-id", "AUTH_PROJECT_ID", "project id of the auth provider", setString(&cfg.Auth.ProjectID)},
+		{"trash-retention", "TRASH_RETENTION", "how long a deleted todo stays in the trash, 0 keeps it forever", setDuration(&cfg.Trash.Retention)},
+		{"trash-purge-interval", "TRASH_PURGE_INTERVAL", "how often the expired todos in the trash are purged", setDuration(&cfg.Trash.PurgeInterval)},
+		{"cache-ttl", "CACHE_TTL", "how long the todos that are read stay cached, 0 turns the cache off", setDuration(&cfg.Cache.TTL)},
+		{"cache-users", "CACHE_USERS", "how many users have todos in the cache", setInt(&cfg.Cache.Users)},
+		{"cache-entries-per-user", "CACHE_ENTRIES_PER_USER", "how many reads of a user the cache holds", setInt(&cfg.Cache.EntriesPerUser)},
+		{"cache-stats-interval", "CACHE_STATS_INTERVAL", "how often the hits and misses of the cache are logged, 0 logs them at shutdown only", setDuration(&cfg.Cache.StatsInterval)},
+		{"subtasks-max-depth", "SUBTASKS_MAX_DEPTH", "how deep subtasks may nest, 0 doesn't limit it", setInt(&cfg.Subtasks.MaxDepth)},
+		{"subtasks-complete-parents", "SUBTASKS_COMPLETE_PARENTS", "whether a todo is done once all its subtasks are", setBool(&cfg.Subtasks.CompleteParents)},
+	}
+}
+
+func newFlagSet() (*flag.FlagSet, map[string]*string, *string) {
+	flagSet := flag.NewFlagSet("todo-server", flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "path to a YAML or TOML config file")
+	flagValues := map[string]*string{}
+	for _, setting := range settings(&Config{}) {
+		flagValues[setting.flag] = flagSet.String(setting.flag, "", setting.usage)
+	}
+	return flagSet, flagValues, configFile
+}
+
+func setString(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+func setInt(target *int) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+		return nil
+	}
+}
+
+func setBool(target *bool) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+		return nil
+	}
+}
+
+func setDuration(target *Duration) func(string) error {
+	return func(value string) error {
+		return target.UnmarshalText([]byte(value))
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults are used when nothing else is set", func(t *testing.T) {
+		cfg, err := Load([]string{"-db-dsn", "host=localhost"}, env(nil))
+		assert.NoError(t, err)
+		expected := Default()
+		expected.Database.DSN = "host=localhost"
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("YAML file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+listen_address: ":9090"
+log_level: debug
+database:
+  driver: postgres
+  dsn: host=db
+  max_open_conns: 20
+  max_idle_conns: 4
+  conn_max_lifetime: 5m
+  query_timeout: 2s
+auth:
+  provider: firebase
+  credentials_file: /etc/todo/sa.json
+  project_id: todo-project
+trash:
+  retention: 168h
+  purge_interval: 10m
+cache:
+  ttl: 30s
+  users: 100
+  entries_per_user: 20
+  stats_interval: 5m
+subtasks:
+  max_depth: 5
+  complete_parents: true
+`)
+		cfg, err := Load([]string{"-config", path}, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, Config{
+			ListenAddress: ":9090",
+			LogLevel:      LogLevelDebug,
+			Database: DatabaseConfig{Driver: DatabaseDriverPostgres, DSN: "host=db", MaxOpenConns: 20, MaxIdleConns: 4,
+				ConnMaxLifetime: Duration(5 * time.Minute), QueryTimeout: Duration(2 * time.Second)},
+			Auth: AuthConfig{Provider: AuthProviderFirebase, CredentialsFile: "/etc/todo/sa.json",
+				ProjectID: "todo-project"},
+			Trash: TrashConfig{Retention: Duration(7 * 24 * time.Hour), PurgeInterval: Duration(10 * time.Minute)},
+			Cache: CacheConfig{TTL: Duration(30 * time.Second), Users: 100, EntriesPerUser: 20,
+				StatsInterval: Duration(5 * time.Minute)},
+			Subtasks: SubtaskConfig{MaxDepth: 5, CompleteParents: true},
+		}, cfg)
+	})
+
+	t.Run("TOML file named by the environment", func(t *testing.T) {
+		path := writeFile(t, "config.toml", `
+listen_address = ":7070"
+
+[database]
+dsn = "host=db"
+conn_max_lifetime = "1h"
+`)
+		cfg, err := Load(nil, env(map[string]string{ConfigFileEnv: path}))
+		assert.NoError(t, err)
+		assert.Equal(t, ":7070", cfg.ListenAddress)
+		assert.Equal(t, "host=db", cfg.Database.DSN)
+		assert.Equal(t, Duration(time.Hour), cfg.Database.ConnMaxLifetime)
+		assert.Equal(t, Default().Database.MaxOpenConns, cfg.Database.MaxOpenConns)
+	})
+
+	t.Run("Environment overrides the file and flags override the environment", func(t *testing.T) {
+		path := writeFile(t, "config.yml", "listen_address: \":1\"\ndatabase:\n  dsn: file\n  max_open_conns: 1\n")
+		cfg, err := Load([]string{"-config", path, "-listen", ":3"},
+			env(map[string]string{"TODO_LISTEN_ADDRESS": ":2", "TODO_DATABASE_DSN": "env",
+				"TODO_DATABASE_MAX_IDLE_CONNS": "7"}))
+		assert.NoError(t, err)
+		assert.Equal(t, ":3", cfg.ListenAddress)
+		assert.Equal(t, "env", cfg.Database.DSN)
+		assert.Equal(t, 1, cfg.Database.MaxOpenConns)
+		assert.Equal(t, 7, cfg.Database.MaxIdleConns)
+	})
+
+	t.Run("When the environment has an invalid number", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn"}, env(map[string]string{"TODO_DATABASE_MAX_OPEN_CONNS": "many"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("When the environment has an invalid boolean", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn"}, env(map[string]string{"TODO_SUBTASKS_COMPLETE_PARENTS": "sometimes"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("When a flag has an invalid duration", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn", "-db-conn-max-lifetime", "forever"}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("When the config file has an unsupported extension", func(t *testing.T) {
+		path := writeFile(t, "config.json", "{}")
+		_, err := Load([]string{"-config", path}, env(nil))
+		assert.Equal(t, ErrUnsupportedConfigFile, err)
+	})
+
+	t.Run("When the config file doesn't exist", func(t *testing.T) {
+		_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("When there is an unknown flag", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn", "-unknown"}, env(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("When there is no database DSN", func(t *testing.T) {
+		assert.Equal(t, ErrNoDatabaseDSN, Default().Validate())
+	})
+
+	t.Run("When the memory driver has no DSN", func(t *testing.T) {
+		cfg, err := Load([]string{"-db-driver", "memory"}, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, DatabaseDriverMemory, cfg.Database.Driver)
+	})
+
+	t.Run("When the SQLite driver has no DSN", func(t *testing.T) {
+		_, err := Load(nil, env(map[string]string{"TODO_DATABASE_DRIVER": "sqlite"}))
+		assert.Equal(t, ErrNoDatabaseDSN, err)
+	})
+
+	t.Run("When the database driver is unknown", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.Driver = "mysql"
+		assert.ErrorIs(t, cfg.Validate(), ErrUnknownDatabaseDriver)
+	})
+
+	t.Run("When a pool size is negative", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.DSN = "dsn"
+		cfg.Database.MaxIdleConns = -1
+		assert.Equal(t, ErrInvalidPoolSize, cfg.Validate())
+	})
+
+	t.Run("When the query timeout is negative", func(t *testing.T) {
+		cfg, err := Load([]string{"-db-dsn", "dsn", "-db-query-timeout", "-1s"}, env(nil))
+		assert.Equal(t, ErrInvalidQueryTimeout, err)
+		assert.Equal(t, Duration(-time.Second), cfg.Database.QueryTimeout)
+	})
+
+	t.Run("When the auth provider is unknown", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.DSN = "dsn"
+		cfg.Auth.Provider = "auth0"
+		assert.ErrorIs(t, cfg.Validate(), ErrUnknownAuthProvider)
+	})
+
+	t.Run("When the trash retention or purge interval is invalid", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.DSN = "dsn"
+		cfg.Trash.Retention = Duration(-time.Hour)
+		assert.Equal(t, ErrInvalidTrashConfig, cfg.Validate())
+		cfg = Default()
+		cfg.Database.DSN = "dsn"
+		cfg.Trash.PurgeInterval = 0
+		assert.Equal(t, ErrInvalidTrashConfig, cfg.Validate())
+	})
+
+	t.Run("When the trash retention is zero", func(t *testing.T) {
+		cfg, err := Load([]string{"-db-dsn", "dsn", "-trash-retention", "0s"}, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, Duration(0), cfg.Trash.Retention)
+	})
+
+	t.Run("When the cache config is invalid", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn", "-cache-ttl", "-1s"}, env(nil))
+		assert.Equal(t, ErrInvalidCacheConfig, err)
+		_, err = Load([]string{"-db-dsn", "dsn", "-cache-ttl", "1m"}, env(map[string]string{"TODO_CACHE_USERS": "0"}))
+		assert.Equal(t, ErrInvalidCacheConfig, err)
+		_, err = Load([]string{"-db-dsn", "dsn", "-cache-stats-interval", "-1s"}, env(nil))
+		assert.Equal(t, ErrInvalidCacheConfig, err)
+	})
+
+	t.Run("When the subtask config is invalid", func(t *testing.T) {
+		_, err := Load([]string{"-db-dsn", "dsn", "-subtasks-max-depth", "-1"}, env(nil))
+		assert.Equal(t, ErrInvalidSubtaskConfig, err)
+		cfg, err := Load([]string{"-db-dsn", "dsn", "-subtasks-max-depth", "0", "-subtasks-complete-parents", "true"}, env(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, SubtaskConfig{MaxDepth: 0, CompleteParents: true}, cfg.Subtasks)
+	})
+
+	t.Run("When the log level is unknown", func(t *testing.T) {
+		cfg := Default()
+		cfg.Database.DSN = "dsn"
+		cfg.LogLevel = "verbose"
+		assert.ErrorIs(t, cfg.Validate(), ErrUnknownLogLevel)
+	})
+}
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -42,9 +42,17 @@ func TestIt(t *testing.T) {
 		log.Fatalln(err)
 	}
 	errorHandler := handler.ErrorHandlerImpl{Logger: log.Default()}
-	container, todoRepository := repository.SetupPostgres(t)
+	container, db := repository.SetupPostgresDB(t)
 	defer container.Terminate(context.Background())
-	router := router.SetTodoRoutes(gin.Default(), todoRepository, errorHandler, authClient)
+	todoRepository, err := repository.GetTodoRepository(db, 0, repository.SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	unitOfWork, err := repository.GetUnitOfWork(db, 0, repository.SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	router := router.SetTodoRoutes(gin.Default(), todoRepository, unitOfWork, errorHandler, authClient)
 	go router.Run()
 	toGetIdTokenRequestBody := `{"email":"test1@test.com","password":"password","returnSecureToken":true}`
 	toGetIdTokenRequestUrl := fmt.Sprintf("https://identitytoolkit.googleapis.com/v1/accounts:signInWithPassword?key=%s", apiKey)
@@ -259,7 +267,14 @@ func TestIt(t *testing.T) {
 		if err != nil {
 			log.Fatalln(err)
 		}
-		assert.Equal(t, expectedTodo, todo)
+		assert.NotEqual(t, todoId, todo.Id)
+		assert.WithinDuration(t, time.Now(), todo.CreatedAt, time.Minute)
+		assert.Equal(t, model.Todo{Id: todo.Id, Title: expectedTodo.Title, Description: expectedTodo.Description,
+			Done: expectedTodo.Done, CreatedAt: todo.CreatedAt, UpdatedAt: todo.CreatedAt, CompletedAt: &todo.CreatedAt,
+			Tags: []string{}, ListId: todo.ListId}, todo)
+		assert.NotEmpty(t, todo.ListId)
+		expectedTodo = todo
+		todoId = todo.Id
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
@@ -357,6 +372,7 @@ func TestIt(t *testing.T) {
 		}
 		request, err := http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(toBeUpdatedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, etagOf(expectedTodo.Version))
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -366,6 +382,7 @@ func TestIt(t *testing.T) {
 		}
 		defer res.Body.Close()
 		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, etagOf(expectedTodo.Version+1), res.Header.Get(handler.ETagHeader))
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Fatalln(err)
@@ -392,6 +409,13 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		returnedTodo := todos[0]
+		assert.False(t, returnedTodo.UpdatedAt.Before(expectedTodo.UpdatedAt))
+		toBeUpdatedTodo.CreatedAt = expectedTodo.CreatedAt
+		toBeUpdatedTodo.UpdatedAt = returnedTodo.UpdatedAt
+		toBeUpdatedTodo.CompletedAt = expectedTodo.CompletedAt
+		toBeUpdatedTodo.Version = expectedTodo.Version + 1
+		toBeUpdatedTodo.Tags = expectedTodo.Tags
+		toBeUpdatedTodo.ListId = expectedTodo.ListId
 		assert.Equal(t, toBeUpdatedTodo, returnedTodo)
 		expectedTodoJson, err := json.Marshal(expectedTodo)
 		if err != nil {
@@ -399,6 +423,7 @@ func TestIt(t *testing.T) {
 		}
 		request, err = http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(expectedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, etagOf(returnedTodo.Version))
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -406,6 +431,27 @@ func TestIt(t *testing.T) {
 		if err != nil {
 			log.Fatalln(err)
 		}
+		expectedTodo = getTodo(idToken, todoId)
+	})
+
+	t.Run("PUT method - /todos: stale ETag", func(t *testing.T) {
+		expectedTodoJson, err := json.Marshal(expectedTodo)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		request, err := http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(expectedTodoJson))
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, etagOf(expectedTodo.Version-1))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		res, err := http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+		assert.Equal(t, expectedTodo, getTodo(idToken, todoId))
 	})
 
 	t.Run("PUT method - /todos: invalid todo", func(t *testing.T) {
@@ -423,6 +469,7 @@ func TestIt(t *testing.T) {
 		}
 		request, err := http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(toBeUpdatedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, "*")
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -483,6 +530,7 @@ func TestIt(t *testing.T) {
 		}
 		request, err := http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(toBeUpdatedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, "*")
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -491,12 +539,12 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		defer res.Body.Close()
-		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		assert.Empty(t, body)
+		assert.True(t, strings.Contains(string(body), repository.ErrNotFound.Error()))
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
@@ -537,6 +585,7 @@ func TestIt(t *testing.T) {
 		}
 		request, err := http.NewRequest("PUT", "http://localhost:8080/todos", bytes.NewBuffer(toBeUpdatedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
+		request.Header.Set(handler.IfMatchHeader, "*")
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -545,12 +594,12 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		defer res.Body.Close()
-		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		assert.Empty(t, body)
+		assert.True(t, strings.Contains(string(body), repository.ErrNotFound.Error()))
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
@@ -576,17 +625,9 @@ func TestIt(t *testing.T) {
 	})
 
 	t.Run("DELETE method - /todos/:id: Good case", func(t *testing.T) {
-		toBeDeletedTodoJson, err := json.Marshal(toBeDeletedTodo)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(toBeDeletedTodoJson))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
+		toBeDeletedTodo = postTodo(idToken, toBeDeletedTodo)
+		toBeDeletedTodoId = toBeDeletedTodo.Id
+		request, err := http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
 			log.Fatalln(err)
@@ -607,10 +648,11 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		assert.Len(t, todos, 2)
-		returnedTodo := todos[1]
+		returnedTodo := todos[0]
 		assert.Equal(t, toBeDeletedTodo, returnedTodo)
 		request, err = http.NewRequest("DELETE", "http://localhost:8080/todos/"+toBeDeletedTodoId, nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, etagOf(toBeDeletedTodo.Version))
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -650,18 +692,83 @@ func TestIt(t *testing.T) {
 		assert.Equal(t, expectedTodo, returnedTodo)
 	})
 
-	t.Run("DELETE method - /todos/:id: invalid todo id", func(t *testing.T) {
-		toBeDeletedTodoJson, err := json.Marshal(toBeDeletedTodo)
+	t.Run("GET method - /trash, POST method - /todos/:id/restore and DELETE method - /trash/:id", func(t *testing.T) {
+		request, err := http.NewRequest("GET", "http://localhost:8080/trash", nil)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(toBeDeletedTodoJson))
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		res, err := http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		var trash []model.Todo
+		err = json.Unmarshal(body, &trash)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		assert.Len(t, trash, 1)
+		assert.Equal(t, toBeDeletedTodoId, trash[0].Id)
+		assert.NotNil(t, trash[0].DeletedAt)
+		request, err = http.NewRequest("POST", "http://localhost:8080/todos/"+toBeDeletedTodoId+"/restore", nil)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		http.DefaultClient.Do(request)
-		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		res, err = http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		restoredTodo := getTodo(idToken, toBeDeletedTodoId)
+		assert.Nil(t, restoredTodo.DeletedAt)
+		request, err = http.NewRequest("DELETE", "http://localhost:8080/todos/"+toBeDeletedTodoId, nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, etagOf(restoredTodo.Version))
+		res, err = http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		request, err = http.NewRequest("DELETE", "http://localhost:8080/trash/"+toBeDeletedTodoId, nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		res, err = http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		request, err = http.NewRequest("POST", "http://localhost:8080/todos/"+toBeDeletedTodoId+"/restore", nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		res, err = http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("DELETE method - /todos/:id: invalid todo id", func(t *testing.T) {
+		toBeDeletedTodo = postTodo(idToken, toBeDeletedTodo)
+		toBeDeletedTodoId = toBeDeletedTodo.Id
+		request, err := http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
 			log.Fatalln(err)
@@ -682,7 +789,7 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		assert.Len(t, todos, 2)
-		returnedTodo := todos[1]
+		returnedTodo := todos[0]
 		assert.Equal(t, toBeDeletedTodo, returnedTodo)
 		request, err = http.NewRequest("DELETE", "http://localhost:8080/todos/"+"turw", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
@@ -709,18 +816,9 @@ func TestIt(t *testing.T) {
 	})
 
 	t.Run("DELETE method - /todos/:id: todo id is diferent", func(t *testing.T) {
-		toBeDeletedTodoJson, err := json.Marshal(toBeDeletedTodo)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(toBeDeletedTodoJson))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		request, err = http.NewRequest("DELETE", "http://localhost:8080/todos/"+uuid.New().String(), nil)
+		request, err := http.NewRequest("DELETE", "http://localhost:8080/todos/"+uuid.New().String(), nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfMatchHeader, "*")
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -729,12 +827,12 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		defer res.Body.Close()
-		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		assert.Empty(t, body)
+		assert.True(t, strings.Contains(string(body), repository.ErrNotFound.Error()))
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
@@ -759,18 +857,9 @@ func TestIt(t *testing.T) {
 	})
 
 	t.Run("DELETE method - /todos/:id: user id is diferent", func(t *testing.T) {
-		toBeDeletedTodoJson, err := json.Marshal(toBeDeletedTodo)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(toBeDeletedTodoJson))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		request, err = http.NewRequest("DELETE", "http://localhost:8080/todos/"+toBeDeletedTodoId, nil)
+		request, err := http.NewRequest("DELETE", "http://localhost:8080/todos/"+toBeDeletedTodoId, nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
+		request.Header.Set(handler.IfMatchHeader, "*")
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -779,12 +868,12 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		defer res.Body.Close()
-		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		assert.Empty(t, body)
+		assert.True(t, strings.Contains(string(body), repository.ErrNotFound.Error()))
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
@@ -830,6 +919,19 @@ func TestIt(t *testing.T) {
 			log.Fatalln(err)
 		}
 		assert.Equal(t, expectedTodo, todo)
+		assert.Equal(t, etagOf(expectedTodo.Version), res.Header.Get(handler.ETagHeader))
+		request, err = http.NewRequest("GET", "http://localhost:8080/todos/"+expectedTodo.Id, nil)
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+		request.Header.Set(handler.IfNoneMatchHeader, etagOf(expectedTodo.Version))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		res, err = http.DefaultClient.Do(request)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
 	})
 
 	t.Run("GET method - /todos/:id: invalid todo id", func(t *testing.T) {
@@ -936,37 +1038,10 @@ func TestIt(t *testing.T) {
 			Done:        &todoDone5,
 			CreatedAt:   ti5,
 		}
-		expectedTodoJson3, err := json.Marshal(expectedTodo3)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(expectedTodoJson3))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		expectedTodoJson4, err := json.Marshal(expectedTodo4)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err = http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(expectedTodoJson4))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		expectedTodoJson5, err := json.Marshal(expectedTodo5)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		request, err = http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(expectedTodoJson5))
-		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		http.DefaultClient.Do(request)
-		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
+		expectedTodo3 = postTodo(idToken2, expectedTodo3)
+		expectedTodo4 = postTodo(idToken2, expectedTodo4)
+		expectedTodo5 = postTodo(idToken2, expectedTodo5)
+		request, err := http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
 		if err != nil {
 			log.Fatalln(err)
@@ -988,9 +1063,9 @@ func TestIt(t *testing.T) {
 		}
 		assert.Len(t, todos, 2)
 		returnedTodo := todos[0]
-		assert.Equal(t, expectedTodo, returnedTodo)
-		returnedTodo = todos[1]
 		assert.Equal(t, toBeDeletedTodo, returnedTodo)
+		returnedTodo = todos[1]
+		assert.Equal(t, expectedTodo, returnedTodo)
 		request, err = http.NewRequest("GET", "http://localhost:8080/todos", nil)
 		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken2)
 		if err != nil {
@@ -1019,4 +1094,93 @@ func TestIt(t *testing.T) {
 		assert.Equal(t, expectedTodo3, returnedTodo)
 	})
 
+	t.Run("POST method - /todos:batch", func(t *testing.T) {
+		batchBody := `{"operations": [
+			{"op": "create", "todo": {"title": "title6", "description": "description6", "done": false}},
+			{"op": "delete", "ifMatch": "*", "id": "` + uuid.New().String() + `"}]`
+		results, statusCode := postBatch(idToken2, batchBody+`}`)
+		assert.Equal(t, http.StatusNotFound, statusCode)
+		assert.Len(t, results, 2)
+		assert.Equal(t, http.StatusFailedDependency, results[0].Status)
+		assert.Equal(t, http.StatusNotFound, results[1].Status)
+		results, statusCode = postBatch(idToken2, batchBody+`, "allowPartial": true}`)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Len(t, results, 2)
+		assert.Equal(t, http.StatusOK, results[0].Status)
+		assert.Equal(t, "title6", results[0].Todo.Title)
+		assert.Equal(t, etagOf(model.FirstVersion), results[0].ETag)
+		assert.Equal(t, http.StatusNotFound, results[1].Status)
+		assert.Equal(t, *results[0].Todo, getTodo(idToken2, results[0].Todo.Id))
+	})
+}
+
+// postBatch posts body to /todos:batch as the user of idToken and returns the
+// results and the status code.
+func postBatch(idToken string, body string) ([]model.BatchResult, int) {
+	request, err := http.NewRequest("POST", "http://localhost:8080/todos:batch", strings.NewReader(body))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer res.Body.Close()
+	responseBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var results []model.BatchResult
+	err = json.Unmarshal(responseBody, &results)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return results, res.StatusCode
+}
+
+// postTodo creates todo as the user of idToken and returns the todo that the
+// server stored, with the id and the timestamps that it set.
+func postTodo(idToken string, todo model.Todo) model.Todo {
+	todoJson, err := json.Marshal(todo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	request, err := http.NewRequest("POST", "http://localhost:8080/todos", bytes.NewBuffer(todoJson))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+	return doForTodo(request)
+}
+
+func getTodo(idToken string, id string) model.Todo {
+	request, err := http.NewRequest("GET", "http://localhost:8080/todos/"+id, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+idToken)
+	return doForTodo(request)
+}
+
+func doForTodo(request *http.Request) model.Todo {
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var todo model.Todo
+	err = json.Unmarshal(body, &todo)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return todo
+}
+
+func etagOf(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
 }
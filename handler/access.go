@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrForbidden error = errors.New("the role of the user in the list doesn't allow this")
+
+// permission is what a request does with a list or a todo.
+type permission int
+
+const (
+	readPermission permission = iota
+	writePermission
+	ownPermission
+)
+
+func allows(access model.Access, needed permission) bool {
+	switch needed {
+	case ownPermission:
+		return access.IsOwner()
+	case writePermission:
+		return access.CanWrite()
+	default:
+		return true
+	}
+}
+
+// todoAccessOf returns the access of the user to the todo of id once it
+// allows needed, or the error and the status code that tell why it doesn't.
+// A todo that the user can't see at all is not found, so that requests never
+// learn about the todos of other users.
+func todoAccessOf(ctx *gin.Context, todoRepository common.TodoRepository, id string, userId string,
+	needed permission) (*model.Access, int, error) {
+	access, err := todoRepository.GetTodoAccess(ctx.Request.Context(), id, userId)
+	return checkAccess(access, err, needed)
+}
+
+// listAccessOf is todoAccessOf for a list.
+func listAccessOf(ctx *gin.Context, todoRepository common.TodoRepository, id string, userId string,
+	needed permission) (*model.Access, int, error) {
+	access, err := todoRepository.GetListAccess(ctx.Request.Context(), id, userId)
+	return checkAccess(access, err, needed)
+}
+
+func checkAccess(access *model.Access, err error, needed permission) (*model.Access, int, error) {
+	if err == repository.ErrNotFound {
+		return nil, http.StatusNotFound, err
+	} else if err != nil {
+		return nil, serverErrorStatusOf(err), err
+	} else if !allows(*access, needed) {
+		return nil, http.StatusForbidden, ErrForbidden
+	}
+	return access, http.StatusOK, nil
+}
+
+// staysInList tells whether a todo that the user reaches through access stays
+// in the list of access once its list becomes listId. Only the owner moves
+// todos between lists.
+func staysInList(access model.Access, listId string) bool {
+	return access.IsOwner() || listId == "" || listId == access.ListId
+}
+
+// ownerOfNewTodo returns the user that a new todo of the user belongs to,
+// who is the owner of the list of its parent or of its list, once the user
+// may write to that list.
+func ownerOfNewTodo(ctx *gin.Context, todoRepository common.TodoRepository, todo model.Todo,
+	userId string) (string, int, error) {
+	var access *model.Access
+	var code int
+	var err error
+	if todo.ParentId != "" {
+		access, code, err = todoAccessOf(ctx, todoRepository, todo.ParentId, userId, writePermission)
+		if err == repository.ErrNotFound {
+			return "", http.StatusBadRequest, repository.ErrParentNotFound
+		}
+	} else if todo.ListId != "" {
+		access, code, err = listAccessOf(ctx, todoRepository, todo.ListId, userId, writePermission)
+		if err == repository.ErrNotFound {
+			return "", http.StatusBadRequest, repository.ErrListNotFound
+		}
+	} else {
+		return userId, http.StatusOK, nil
+	}
+	if err != nil {
+		return "", code, err
+	} else if !staysInList(*access, todo.ListId) {
+		return "", http.StatusForbidden, ErrForbidden
+	}
+	return access.OwnerId, http.StatusOK, nil
+}
+
+// visibleSubtasks leaves out the subtasks that a member doesn't see because
+// the owner keeps them in another list.
+func visibleSubtasks(access model.Access, subtasks []model.Todo) []model.Todo {
+	if access.IsOwner() {
+		return subtasks
+	}
+	visible := []model.Todo{}
+	for _, subtask := range subtasks {
+		if subtask.ListId == access.ListId {
+			visible = append(visible, subtask)
+		}
+	}
+	return visible
+}
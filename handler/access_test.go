@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedTodos(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, todoId, listId := "wehgowe", uuid.New().String(), uuid.New().String()
+	accessAs := func(role model.Role) *model.Access {
+		return &model.Access{OwnerId: ownerId, ListId: listId, Role: role}
+	}
+	done := false
+	storedTodo := func() *model.Todo {
+		return &model.Todo{Id: todoId, Title: "title1", Description: "description1", Done: &done, CreatedAt: now,
+			UpdatedAt: now, Version: 2, Tags: []string{}, ListId: listId}
+	}
+
+	t.Run("Good case: a viewer reads a todo of the list as its owner", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+todoId, todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleViewer), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId, ownerId).Return(storedTodo(), nil)
+		getById := GetById(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getById(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, *storedTodo(), got)
+	})
+
+	t.Run("Good case: a member only sees the subtasks in the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/subtasks", todoId, "", token)
+		subtasks := []model.Todo{
+			{Id: uuid.New().String(), Title: "shared", Done: &done, CreatedAt: now, UpdatedAt: now, Tags: []string{},
+				ListId: listId, ParentId: todoId},
+			{Id: uuid.New().String(), Title: "private", Done: &done, CreatedAt: now, UpdatedAt: now, Tags: []string{},
+				ListId: uuid.New().String(), ParentId: todoId},
+		}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleEditor), nil)
+		todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), todoId, ownerId, false).Return(subtasks, nil)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, subtasks[:1], got)
+	})
+
+	t.Run("Good case: an editor changes a todo as its owner", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPatch, "/todos/"+todoId, todoId, `{"done": true}`, token)
+		gin_context.Request.Header.Set("Content-Type", MergePatchContentType)
+		gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleEditor), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId, ownerId).Return(storedTodo(), nil)
+		completed := true
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId, ownerId, model.TodoChanges{Done: &completed, UpdatedAt: now},
+			int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When a viewer changes a todo", func(t *testing.T) {
+		updateBody := `{"id": "` + todoId + `", "title": "title1", "description": "description1", "done": true}`
+		for name, handlerOf := range map[string]func(*common.MockTodoRepository, *common.MockErrorHandler) gin.HandlerFunc{
+			"Update": func(todoRepository *common.MockTodoRepository, errorHandler *common.MockErrorHandler) gin.HandlerFunc {
+				return Update(todoRepository, errorHandler, nowMock)
+			},
+			"UpdateById": func(todoRepository *common.MockTodoRepository, errorHandler *common.MockErrorHandler) gin.HandlerFunc {
+				return UpdateById(todoRepository, errorHandler, uuid.Parse, nowMock)
+			},
+			"Delete": func(todoRepository *common.MockTodoRepository, errorHandler *common.MockErrorHandler) gin.HandlerFunc {
+				return Delete(todoRepository, errorHandler, uuid.Parse, nowMock)
+			},
+			"Move": func(todoRepository *common.MockTodoRepository, errorHandler *common.MockErrorHandler) gin.HandlerFunc {
+				return Move(todoRepository, errorHandler, uuid.Parse, nowMock)
+			},
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			body := updateBody
+			if name == "Move" {
+				body = `{"before": "` + uuid.New().String() + `"}`
+			}
+			setListRequest(gin_context, http.MethodPut, "/todos/"+todoId, todoId, body, token)
+			gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleViewer), nil)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+			handlerOf(todoRepositoryMock, errorHandlerMock)(gin_context)
+		}
+	})
+
+	t.Run("When a viewer patches a todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPatch, "/todos/"+todoId, todoId, `{"done": true}`, token)
+		gin_context.Request.Header.Set("Content-Type", MergePatchContentType)
+		gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleViewer), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When an editor moves a todo out of the list", func(t *testing.T) {
+		for _, otherListId := range []string{uuid.New().String(), ""} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPatch, "/todos/"+todoId, todoId, `{"listId": "`+otherListId+`"}`, token)
+			gin_context.Request.Header.Set("Content-Type", MergePatchContentType)
+			gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleEditor), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId, ownerId).Return(storedTodo(), nil)
+			todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			patch(gin_context)
+		}
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPut, "/todos/"+todoId, todoId, `{"id": "`+todoId+
+			`", "title": "title1", "description": "description1", "done": true, "listId": "`+uuid.New().String()+`"}`, token)
+		gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleEditor), nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When an editor moves a todo next to a todo of another user", func(t *testing.T) {
+		anchorId := uuid.New().String()
+		for _, anchorErr := range []error{nil, repository.ErrNotFound} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, `{"after": "`+anchorId+`"}`, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(accessAs(model.RoleEditor), nil)
+			if anchorErr == nil {
+				todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), anchorId, token.UID).Return(ownerAccess(token.UID), nil)
+			} else {
+				todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), anchorId, token.UID).Return(nil, anchorErr)
+			}
+			todoRepositoryMock.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrAnchorNotFound, http.StatusBadRequest)
+			moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			moveTodo(gin_context)
+		}
+	})
+
+	t.Run("When the user isn't a member of the list of the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+todoId, todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		getById := GetById(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getById(gin_context)
+	})
+}
+
+func TestCreateInSharedList(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, listId, parentId := "wehgowe", uuid.New().String(), uuid.New().String()
+	todoId := uuid.Must(uuid.NewV7())
+	newIdMock := func() (uuid.UUID, error) {
+		return todoId, nil
+	}
+	done := false
+	requestOf := func(listId string, parentId string) string {
+		body, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &done,
+			ListId: listId, ParentId: parentId})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	t.Run("Good case: an editor creates a todo as the owner of the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos", "", requestOf(listId, ""), token)
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, CreatedAt: now,
+			UpdatedAt: now, Tags: []string{}, ListId: listId}
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, ListId: listId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, ownerId).Return(nil)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("Good case: an editor creates a subtask in the list of its parent", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos", "", requestOf("", parentId), token)
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, CreatedAt: now,
+			UpdatedAt: now, Tags: []string{}, ParentId: parentId}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), parentId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, ListId: listId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, ownerId).Return(nil)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When the user may not create the todo there", func(t *testing.T) {
+		for body, access := range map[string]*model.Access{
+			requestOf(listId, ""):                    {OwnerId: ownerId, ListId: listId, Role: model.RoleViewer},
+			requestOf(uuid.New().String(), parentId): {OwnerId: ownerId, ListId: listId, Role: model.RoleEditor},
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos", "", body, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(access, nil).MaxTimes(1)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), parentId, token.UID).Return(access, nil).MaxTimes(1)
+			todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+			createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+			createTodo(gin_context)
+		}
+	})
+
+	t.Run("When the list or the parent isn't found", func(t *testing.T) {
+		for body, expected := range map[string]error{
+			requestOf(listId, ""):   repository.ErrListNotFound,
+			requestOf("", parentId): repository.ErrParentNotFound,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos", "", body, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(nil, repository.ErrNotFound).
+				MaxTimes(1)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), parentId, token.UID).Return(nil, repository.ErrNotFound).
+				MaxTimes(1)
+			todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, expected, http.StatusBadRequest)
+			createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+			createTodo(gin_context)
+		}
+	})
+}
+
+func TestSharedLists(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, listId := "wehgowe", uuid.New().String()
+	accessAs := func(role model.Role) *model.Access {
+		return &model.Access{OwnerId: ownerId, ListId: listId, Role: role}
+	}
+
+	t.Run("Good case: a member gets the list with its owner and their role", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/"+listId, listId, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(accessAs(model.RoleViewer), nil)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), listId, ownerId).
+			Return(&model.List{Id: listId, Name: "work", CreatedAt: now, UpdatedAt: now}, nil)
+		getList := GetList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getList(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.List
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, model.List{Id: listId, Name: "work", CreatedAt: now, UpdatedAt: now, OwnerId: ownerId,
+			Role: model.RoleViewer}, got)
+	})
+
+	t.Run("Good case: a member pages through the todos of the owner in the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/"+listId+"/todos", listId, "", token)
+		filter := defaultFilter
+		filter.ListId = listId
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(accessAs(model.RoleViewer), nil)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), ownerId, filter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getListTodos := GetListTodos(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		getListTodos(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When a member changes or deletes the list", func(t *testing.T) {
+		for _, role := range []model.Role{model.RoleEditor, model.RoleViewer} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPatch, "/lists/"+listId, listId, `{"name": "office"}`, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(accessAs(role), nil)
+			todoRepositoryMock.EXPECT().PatchList(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+			patchList := PatchList(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			patchList(gin_context)
+
+			todoRepositoryMock, gin_context, _, errorHandlerMock = createMocks(t)
+			setListRequest(gin_context, http.MethodDelete, "/lists/"+listId, listId, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(accessAs(role), nil)
+			todoRepositoryMock.EXPECT().DeleteList(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+			deleteList := DeleteList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteList(gin_context)
+		}
+	})
+}
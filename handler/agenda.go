@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAgenda lists the days from ?from= to ?to= with the todos that are due on
+// each of them in the time zone of ?timezone=. The agenda starts today when
+// from is left out. Like GET /todos, it only covers the todos that the user
+// owns.
+func GetAgenda(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		var request model.AgendaRequest
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if err := ctx.ShouldBindQuery(&request); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if agenda, err := request.Agenda(now().UTC()); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			token := token.(*auth.Token)
+			dueAfter, dueBefore := agenda.DueWindow()
+			filter := model.TodoFilter{DueAfter: &dueAfter, DueBefore: &dueBefore,
+				Sort: model.SortByDueAt, Order: model.OrderAsc}
+			if page, err := todoRepository.GetPage(ctx.Request.Context(), token.UID, filter, model.PageRequest{}); err != nil {
+				if err == repository.ErrInvalidFilter {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
+			} else {
+				ctx.JSON(http.StatusOK, agenda.Days(page.Todos))
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAgenda(t *testing.T) {
+	token := &auth.Token{UID: "wbfewh"}
+	todoDone := false
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/agenda?from=2022-09-21&to=2022-09-22&timezone=Asia/Tokyo", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		// 2022-09-21T20:00:00Z is already the 22nd in Tokyo.
+		evening := model.Todo{Id: uuid.New().String(), Title: "title1", Done: &todoDone, CreatedAt: now, UpdatedAt: now,
+			Version: 1, Tags: []string{}, DueAt: &model.Due{Time: time.Date(2022, 9, 21, 20, 0, 0, 0, time.UTC)}}
+		allDay := model.Todo{Id: uuid.New().String(), Title: "title2", Done: &todoDone, CreatedAt: now, UpdatedAt: now,
+			Version: 1, Tags: []string{}, DueAt: &model.Due{Time: time.Date(2022, 9, 22, 0, 0, 0, 0, time.UTC), DateOnly: true}}
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{}).
+			DoAndReturn(func(ctx context.Context, userId string, filter model.TodoFilter, pageRequest model.PageRequest) (*model.Page, error) {
+				assert.Equal(t, time.Date(2022, 9, 20, 0, 0, 0, 0, time.UTC), *filter.DueAfter)
+				assert.Equal(t, time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC), *filter.DueBefore)
+				assert.Equal(t, model.SortByDueAt, filter.Sort)
+				assert.Equal(t, model.OrderAsc, filter.Order)
+				return &model.Page{Todos: []model.Todo{evening, allDay}}, nil
+			})
+		getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAgenda(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"dueAt":"2022-09-22"`)
+		var got []model.AgendaDay
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, got, 2) {
+			assert.Equal(t, model.AgendaDay{Date: "2022-09-21", Todos: []model.Todo{}}, got[0])
+			assert.Equal(t, "2022-09-22", got[1].Date)
+			assert.Equal(t, []string{allDay.Id, evening.Id}, []string{got[1].Todos[0].Id, got[1].Todos[1].Id})
+		}
+	})
+
+	t.Run("Good case: from and to are times", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		gin_context.Request = httptest.NewRequest(http.MethodGet,
+			"/agenda?from=2022-09-21T20:00:00Z&to=2022-09-23T08:00:00%2B09:00&timezone=Asia/Tokyo", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAgenda(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.AgendaDay
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []model.AgendaDay{{Date: "2022-09-22", Todos: []model.Todo{}},
+			{Date: "2022-09-23", Todos: []model.Todo{}}}, got)
+	})
+
+	t.Run("Good case: the week from today", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/agenda", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAgenda(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.AgendaDay
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, got, model.DefaultAgendaDays) {
+			assert.Equal(t, "2022-09-21", got[0].Date)
+			assert.Equal(t, "2022-09-27", got[len(got)-1].Date)
+		}
+	})
+
+	t.Run("When the query is invalid", func(t *testing.T) {
+		for _, query := range []string{"from=tomorrow", "to=2022-13-01", "timezone=Mars/Olympus"} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/agenda?"+query, nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAgenda(gin_context)
+		}
+	})
+
+	t.Run("When the range is invalid", func(t *testing.T) {
+		for _, query := range []string{"from=2022-09-21&to=2022-09-20", "from=2022-09-01&to=2022-10-02"} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/agenda?"+query, nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidAgendaRange, http.StatusBadRequest)
+			getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAgenda(gin_context)
+		}
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrInvalidFilter: http.StatusBadRequest,
+			repository.ErrQueryTimeout:  http.StatusGatewayTimeout,
+			common.ErrError:             http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/agenda", nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{}).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAgenda(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getAgenda := GetAgenda(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAgenda(gin_context)
+	})
+}
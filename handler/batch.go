@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// batchParam is the path parameter that gin makes of the ":batch" in
+// /todos:batch, which then also matches /todos followed by anything else.
+const batchParam string = ":batch"
+
+var ErrBatchFailed error = errors.New("another operation of the batch failed, so nothing was applied")
+
+// Batch runs the operations of a model.BatchRequest in one transaction and
+// answers their results in order. When an operation fails and the batch
+// isn't partial, the status code is the one of that operation and every
+// other operation is answered 424 Failed Dependency. An operation that fails
+// with a server error aborts the batch even when it is partial.
+func Batch(unitOfWork common.UnitOfWork, errorHandler common.ErrorHandler, parse func(string) (uuid.UUID, error),
+	newId func() (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if ctx.Param("batch") != batchParam {
+			ctx.AbortWithStatus(http.StatusNotFound)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else {
+			var request model.BatchRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			token := token.(*auth.Token)
+			results := make([]model.BatchResult, len(request.Operations))
+			failed := -1
+			err := unitOfWork.Do(ctx.Request.Context(), func(tx common.Transaction) error {
+				for i, operation := range request.Operations {
+					var operationErr error
+					run := func() error {
+						results[i], operationErr = runOperation(ctx, tx, operation, token.UID, parse, newId, now)
+						return operationErr
+					}
+					var err error
+					if request.AllowPartial {
+						err = tx.Savepoint(ctx.Request.Context(), run)
+					} else {
+						err = run()
+					}
+					if err == nil {
+						continue
+					}
+					if operationErr == nil || results[i].Status >= http.StatusInternalServerError {
+						return err
+					}
+					if !request.AllowPartial {
+						failed = i
+						return err
+					}
+				}
+				return nil
+			})
+			if failed >= 0 {
+				for i := range results {
+					if i != failed {
+						results[i] = model.BatchResult{Status: http.StatusFailedDependency, Error: ErrBatchFailed.Error()}
+					}
+				}
+				ctx.JSON(results[failed].Status, results)
+			} else if err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, results)
+			}
+		}
+	}
+}
+
+// runOperation runs one operation of a batch as the matching single request
+// would, with the same checks of the role of the user in a shared list. The
+// result of a failed operation holds its status code and error.
+func runOperation(ctx *gin.Context, todoRepository common.TodoRepository, operation model.BatchOperation, userId string,
+	parse func(string) (uuid.UUID, error), newId func() (uuid.UUID, error), now func() time.Time) (model.BatchResult, error) {
+	switch operation.Op {
+	case model.CreateOperation:
+		var request model.CreateTodoRequest
+		if err := bindTodo(operation.Todo, &request); err != nil {
+			return failedOperation(err, http.StatusBadRequest)
+		}
+		id, err := newId()
+		if err != nil {
+			return failedOperation(err, http.StatusInternalServerError)
+		}
+		todo := request.Todo(id.String(), now().UTC())
+		ownerId, code, err := ownerOfNewTodo(ctx, todoRepository, todo, userId)
+		if err != nil {
+			return failedOperation(err, code)
+		}
+		if err := todoRepository.Create(ctx.Request.Context(), &todo, ownerId); err != nil {
+			return failedOperation(err, writeStatusOf(err))
+		}
+		return model.BatchResult{Status: http.StatusOK, ETag: etagOf(todo.Version), Todo: &todo}, nil
+	case model.UpdateOperation:
+		version, code, err := expectedVersion(operation.IfMatch)
+		if err != nil {
+			return failedOperation(err, code)
+		}
+		var request model.UpdateTodoRequest
+		if err := bindTodo(operation.Todo, &request); err != nil {
+			return failedOperation(err, http.StatusBadRequest)
+		}
+		access, code, err := todoAccessOf(ctx, todoRepository, request.Id, userId, writePermission)
+		if err != nil {
+			return failedOperation(err, code)
+		}
+		if !staysInList(*access, request.ListId) {
+			return failedOperation(ErrForbidden, http.StatusForbidden)
+		}
+		if code, err := keepsCommentCount(ctx, todoRepository, request, access.OwnerId); err != nil {
+			return failedOperation(err, code)
+		}
+		todo := request.Todo(now().UTC())
+		if err := todoRepository.Update(ctx.Request.Context(), &todo, access.OwnerId, version); err != nil {
+			return failedOperation(err, writeStatusOf(err))
+		}
+		result := model.BatchResult{Status: http.StatusNoContent}
+		if version != repository.AnyVersion {
+			result.ETag = etagOf(version + 1)
+		}
+		return result, nil
+	default: // model.DeleteOperation, the only other op that binds
+		if _, err := parse(operation.Id); err != nil {
+			return failedOperation(err, http.StatusBadRequest)
+		}
+		version, code, err := expectedVersion(operation.IfMatch)
+		if err != nil {
+			return failedOperation(err, code)
+		}
+		access, code, err := todoAccessOf(ctx, todoRepository, operation.Id, userId, writePermission)
+		if err != nil {
+			return failedOperation(err, code)
+		}
+		if err := todoRepository.Delete(ctx.Request.Context(), operation.Id, access.OwnerId, version,
+			now().UTC()); err != nil {
+			return failedOperation(err, writeStatusOf(err))
+		}
+		return model.BatchResult{Status: http.StatusNoContent}, nil
+	}
+}
+
+// bindTodo decodes and validates the todo of an operation like
+// ShouldBindJSON does for the body of a single request.
+func bindTodo(todo json.RawMessage, request any) error {
+	if err := json.Unmarshal(todo, request); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(request)
+}
+
+func failedOperation(err error, code int) (model.BatchResult, error) {
+	return model.BatchResult{Status: code, Error: err.Error()}, err
+}
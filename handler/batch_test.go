@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	token := &auth.Token{UID: "oiwhbegfwh"}
+	createdId := uuid.New()
+	newIdMock := func() (uuid.UUID, error) {
+		return createdId, nil
+	}
+	updatedId := uuid.New().String()
+	deletedId := uuid.New().String()
+	todoDone := true
+	createdTodo := model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &todoDone}.Todo(createdId.String(), now)
+	createdTodo.Version = model.FirstVersion
+	updatedTodo := model.UpdateTodoRequest{Id: updatedId, Title: "title2", Description: "description2", Done: &todoDone}.Todo(now)
+	body := `{"operations": [
+		{"op": "create", "todo": {"title": "title1", "description": "description1", "done": true}},
+		{"op": "update", "ifMatch": "\"3\"", "todo": {"id": "` + updatedId + `", "title": "title2", "description": "description2", "done": true}},
+		{"op": "delete", "ifMatch": "\"7\"", "id": "` + deletedId + `"}]}`
+
+	t.Run("Good case", func(t *testing.T) {
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, body)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), token.UID).DoAndReturn(func(ctx context.Context, todo *model.Todo, userId string) error {
+			todo.Version = model.FirstVersion
+			return nil
+		})
+		txMock.EXPECT().Update(gomock.Any(), &updatedTodo, token.UID, int64(3)).Return(nil)
+		txMock.EXPECT().Delete(gomock.Any(), deletedId, token.UID, int64(7), now).Return(nil)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).Times(0)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, []model.BatchResult{
+			{Status: http.StatusOK, ETag: `"1"`, Todo: &createdTodo},
+			{Status: http.StatusNoContent, ETag: `"4"`},
+			{Status: http.StatusNoContent}}, resultsOf(t, http_recorder))
+	})
+
+	t.Run("When an operation fails", func(t *testing.T) {
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, body)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), token.UID).Return(nil)
+		txMock.EXPECT().Update(gomock.Any(), &updatedTodo, token.UID, int64(3)).Return(repository.ErrVersionMismatch)
+		txMock.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusPreconditionFailed, http_recorder.Code)
+		assert.Equal(t, []model.BatchResult{
+			{Status: http.StatusFailedDependency, Error: ErrBatchFailed.Error()},
+			{Status: http.StatusPreconditionFailed, Error: repository.ErrVersionMismatch.Error()},
+			{Status: http.StatusFailedDependency, Error: ErrBatchFailed.Error()}}, resultsOf(t, http_recorder))
+	})
+
+	t.Run("When an operation of a partial batch fails", func(t *testing.T) {
+		partialBody := strings.Replace(body, "{", `{"allowPartial": true, `, 1)
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, partialBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func() error) error {
+			return work()
+		}).Times(3)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), token.UID).DoAndReturn(func(ctx context.Context, todo *model.Todo, userId string) error {
+			todo.Version = model.FirstVersion
+			return nil
+		})
+		txMock.EXPECT().Update(gomock.Any(), &updatedTodo, token.UID, int64(3)).Return(repository.ErrNotFound)
+		txMock.EXPECT().Delete(gomock.Any(), deletedId, token.UID, int64(7), now).Return(nil)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, []model.BatchResult{
+			{Status: http.StatusOK, ETag: `"1"`, Todo: &createdTodo},
+			{Status: http.StatusNotFound, Error: repository.ErrNotFound.Error()},
+			{Status: http.StatusNoContent}}, resultsOf(t, http_recorder))
+	})
+
+	t.Run("When an operation of a partial batch fails with an internal error", func(t *testing.T) {
+		partialBody := strings.Replace(body, "{", `{"allowPartial": true, `, 1)
+		unitOfWorkMock, txMock, gin_context, _, errorHandlerMock := createBatchMocks(t, partialBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func() error) error {
+			return work()
+		}).Times(2)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), token.UID).Return(nil)
+		txMock.EXPECT().Update(gomock.Any(), &updatedTodo, token.UID, int64(3)).Return(common.ErrError)
+		txMock.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+	})
+
+	t.Run("When an operation of a partial batch times out", func(t *testing.T) {
+		partialBody := strings.Replace(body, "{", `{"allowPartial": true, `, 1)
+		unitOfWorkMock, txMock, gin_context, _, errorHandlerMock := createBatchMocks(t, partialBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func() error) error {
+			return work()
+		})
+		txMock.EXPECT().Create(gin_context.Request.Context(), gomock.Any(), token.UID).Return(repository.ErrQueryTimeout)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrQueryTimeout, http.StatusGatewayTimeout)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+	})
+
+	t.Run("When a savepoint fails", func(t *testing.T) {
+		partialBody := strings.Replace(body, "{", `{"allowPartial": true, `, 1)
+		unitOfWorkMock, txMock, gin_context, _, errorHandlerMock := createBatchMocks(t, partialBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+	})
+
+	t.Run("When an editor of a shared list runs the batch", func(t *testing.T) {
+		ownerId, listId := "wehgowe", uuid.New().String()
+		listBody := `{"operations": [
+			{"op": "create", "todo": {"title": "title1", "description": "description1", "done": true, "listId": "` + listId + `"}},
+			{"op": "update", "ifMatch": "\"3\"", "todo": {"id": "` + updatedId + `", "title": "title2", "description": "description2", "done": true}},
+			{"op": "delete", "ifMatch": "\"7\"", "id": "` + deletedId + `"}]}`
+		editor := &model.Access{OwnerId: ownerId, ListId: listId, Role: model.RoleEditor}
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, listBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		txMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(editor, nil)
+		txMock.EXPECT().GetTodoAccess(gomock.Any(), gomock.Any(), token.UID).Return(editor, nil).Times(2)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), ownerId).Return(nil)
+		txMock.EXPECT().Update(gomock.Any(), &updatedTodo, ownerId, int64(3)).Return(nil)
+		txMock.EXPECT().Delete(gomock.Any(), deletedId, ownerId, int64(7), now).Return(nil)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When a viewer of a shared list runs the batch", func(t *testing.T) {
+		partialBody := strings.Replace(body, "{", `{"allowPartial": true, `, 1)
+		viewer := &model.Access{OwnerId: "wehgowe", ListId: uuid.New().String(), Role: model.RoleViewer}
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, partialBody)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func() error) error {
+			return work()
+		}).Times(3)
+		txMock.EXPECT().Create(gomock.Any(), gomock.Any(), token.UID).Return(nil)
+		txMock.EXPECT().GetTodoAccess(gomock.Any(), gomock.Any(), token.UID).Return(viewer, nil).Times(2)
+		txMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		txMock.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		results := resultsOf(t, http_recorder)
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, http.StatusOK, results[0].Status)
+			assert.Equal(t, model.BatchResult{Status: http.StatusForbidden, Error: ErrForbidden.Error()}, results[1])
+			assert.Equal(t, model.BatchResult{Status: http.StatusForbidden, Error: ErrForbidden.Error()}, results[2])
+		}
+	})
+
+	t.Run("When an update changes the comment count", func(t *testing.T) {
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, `{"operations": [
+			{"op": "update", "ifMatch": "\"3\"", "todo": {"id": "`+updatedId+`", "title": "title2", "description": "description2", "done": true, "commentCount": 5}}]}`)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().GetById(gomock.Any(), updatedId, token.UID).Return(&model.Todo{Id: updatedId, CommentCount: 2}, nil)
+		txMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusBadRequest, http_recorder.Code)
+		assert.Equal(t, []model.BatchResult{{Status: http.StatusBadRequest, Error: ErrReadOnlyField.Error()}},
+			resultsOf(t, http_recorder))
+	})
+
+	t.Run("When the operations are invalid", func(t *testing.T) {
+		unitOfWorkMock, txMock, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, `{"operations": [
+			{"op": "create", "todo": {"description": "description1", "done": true}},
+			{"op": "update", "todo": {"id": "`+updatedId+`", "title": "title2", "description": "description2", "done": true}},
+			{"op": "delete", "ifMatch": "\"7\"", "id": "oehwegiuf"}],
+			"allowPartial": true}`)
+		gin_context.Set(middleware.AuthToken, token)
+		expectWork(unitOfWorkMock, txMock)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Savepoint(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func() error) error {
+			return work()
+		}).Times(3)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		results := resultsOf(t, http_recorder)
+		assert.Len(t, results, 3)
+		assert.Equal(t, http.StatusBadRequest, results[0].Status)
+		assert.Equal(t, model.BatchResult{Status: http.StatusPreconditionRequired, Error: ErrIfMatchRequired.Error()}, results[1])
+		assert.Equal(t, http.StatusBadRequest, results[2].Status)
+	})
+
+	t.Run("When Commit returns an error", func(t *testing.T) {
+		unitOfWorkMock, txMock, gin_context, _, errorHandlerMock := createBatchMocks(t,
+			`{"operations": [{"op": "delete", "ifMatch": "*", "id": "`+deletedId+`"}]}`)
+		unitOfWorkMock.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func(common.Transaction) error) error {
+			if err := work(txMock); err != nil {
+				return err
+			}
+			return common.ErrError
+		})
+		gin_context.Set(middleware.AuthToken, token)
+		expectOwnerAccess(txMock, token.UID)
+		txMock.EXPECT().Delete(gomock.Any(), deletedId, token.UID, repository.AnyVersion, now).Return(nil)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+	})
+
+	t.Run("When the body is invalid", func(t *testing.T) {
+		for _, body := range []string{`{"operations": []}`, `{"operations": [{"op": "move"}]}`,
+			`{"operations": [` + strings.Repeat(`{"op": "create"},`, model.MaxBatchOperations) + `{"op": "create"}]}`} {
+			unitOfWorkMock, _, gin_context, _, errorHandlerMock := createBatchMocks(t, body)
+			gin_context.Set(middleware.AuthToken, token)
+			unitOfWorkMock.EXPECT().Do(gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+			batch(gin_context)
+		}
+	})
+
+	t.Run("When the path is not /todos:batch", func(t *testing.T) {
+		unitOfWorkMock, _, gin_context, http_recorder, errorHandlerMock := createBatchMocks(t, body)
+		gin_context.Params = gin.Params{{Key: "batch", Value: "x"}}
+		gin_context.Set(middleware.AuthToken, token)
+		unitOfWorkMock.EXPECT().Do(gomock.Any(), gomock.Any()).Times(0)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+		assert.Equal(t, http.StatusNotFound, http_recorder.Code)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		unitOfWorkMock, _, gin_context, _, errorHandlerMock := createBatchMocks(t, body)
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, nil, newIdMock, nowMock)
+		batch(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		unitOfWorkMock, _, gin_context, _, errorHandlerMock := createBatchMocks(t, body)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		batch := Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		batch(gin_context)
+	})
+}
+
+// createBatchMocks returns mocks for a POST /todos:batch with body.
+func createBatchMocks(t *testing.T, body string) (*common.MockUnitOfWork, *common.MockTransaction, *gin.Context,
+	*httptest.ResponseRecorder, *common.MockErrorHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	mockCtrl := gomock.NewController(t)
+	unitOfWorkMock := common.NewMockUnitOfWork(mockCtrl)
+	txMock := common.NewMockTransaction(mockCtrl)
+	http_recorder := httptest.NewRecorder()
+	gin_context, _ := gin.CreateTestContext(http_recorder)
+	gin_context.Request = httptest.NewRequest(http.MethodPost, "/todos:batch", strings.NewReader(body))
+	gin_context.Request.Header.Set("Content-Type", "application/json")
+	gin_context.Params = gin.Params{{Key: "batch", Value: batchParam}}
+	return unitOfWorkMock, txMock, gin_context, http_recorder, common.NewMockErrorHandler(mockCtrl)
+}
+
+// expectWork expects the unit of work to run its work on txMock.
+func expectWork(unitOfWorkMock *common.MockUnitOfWork, txMock *common.MockTransaction) {
+	unitOfWorkMock.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, work func(common.Transaction) error) error {
+		return work(txMock)
+	})
+}
+
+// expectOwnerAccess lets the user of userId own every todo of txMock.
+func expectOwnerAccess(txMock *common.MockTransaction, userId string) {
+	txMock.EXPECT().GetTodoAccess(gomock.Any(), gomock.Any(), userId).Return(ownerAccess(userId), nil).AnyTimes()
+}
+
+func resultsOf(t *testing.T, http_recorder *httptest.ResponseRecorder) []model.BatchResult {
+	t.Helper()
+	var results []model.BatchResult
+	if err := json.Unmarshal(http_recorder.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetComments lists the comments on a todo from the oldest to the newest,
+// paged with ?limit and ?cursor, for anyone who reads the todo.
+func GetComments(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if pageRequest, err := pageRequestOf(ctx); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else if page, err := todoRepository.GetComments(ctx.Request.Context(), ctx.Param("id"), access.OwnerId,
+			pageRequest); err != nil {
+			errorHandler.HandleAppError(ctx, err, commentStatusOf(err))
+		} else {
+			setLinkHeader(ctx, page.Next, page.Prev, pageRequest.Limit)
+			ctx.JSON(http.StatusOK, page.Comments)
+		}
+	}
+}
+
+// CreateComment adds a comment of the user with an id from newId on a todo
+// that they may write to, at the time from now. A viewer of a shared list
+// reads the comments but doesn't write any.
+func CreateComment(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), newId func() (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			var request model.CreateCommentRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			token := token.(*auth.Token)
+			access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.UID, writePermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			id, err := newId()
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				return
+			}
+			comment := request.Comment(id.String(), ctx.Param("id"), token.UID, now().UTC())
+			if err := todoRepository.CreateComment(ctx.Request.Context(), &comment, access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, commentStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, comment)
+			}
+		}
+	}
+}
+
+// UpdateComment replaces the body of a comment that the user wrote at the
+// time from now, and answers with the comment after the change. Like
+// CreateComment, it needs a role that writes to the todo.
+func UpdateComment(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if _, err := parse(ctx.Param("commentId")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			var request model.UpdateCommentRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			token := token.(*auth.Token)
+			access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.UID, writePermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			comment := request.Comment(ctx.Param("commentId"), ctx.Param("id"), token.UID, now().UTC())
+			if err := todoRepository.UpdateComment(ctx.Request.Context(), &comment, access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, commentStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, comment)
+			}
+		}
+	}
+}
+
+// DeleteComment deletes a comment that the user wrote on a todo that they may
+// still write to.
+func DeleteComment(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if _, err := parse(ctx.Param("commentId")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			token := token.(*auth.Token)
+			access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.UID, writePermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			if err := todoRepository.DeleteComment(ctx.Request.Context(), ctx.Param("id"), ctx.Param("commentId"),
+				access.OwnerId, token.UID); err != nil {
+				errorHandler.HandleAppError(ctx, err, commentStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusNoContent, gin.H{})
+			}
+		}
+	}
+}
+
+func commentStatusOf(err error) int {
+	if err == repository.ErrInvalidComment {
+		return http.StatusBadRequest
+	} else if err == repository.ErrNotCommentAuthor {
+		return http.StatusForbidden
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
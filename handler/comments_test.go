@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setCommentRequest(gin_context *gin.Context, method string, target string, todoId string, commentId string,
+	body string, token *auth.Token) {
+	setListRequest(gin_context, method, target, todoId, body, token)
+	if commentId != "" {
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "commentId", Value: commentId})
+	}
+}
+
+func TestGetComments(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, todoId := "wehgowe", uuid.New().String()
+	comments := []model.Comment{{Id: uuid.New().String(), TodoId: todoId, AuthorId: token.UID, Body: "body1",
+		CreatedAt: now, UpdatedAt: now}}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/comments", todoId, "", "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().GetComments(gomock.Any(), todoId, ownerId, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.CommentPage{Comments: comments}, nil)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, "</todos/"+todoId+`/comments?limit=50>; rel="first"`, http_recorder.Header().Get(LinkHeader))
+		var got []model.Comment
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, comments, got)
+	})
+
+	t.Run("Good case: paged", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		cursor := model.CommentCursorOf(comments[0], false)
+		target := "/todos/" + todoId + "/comments"
+		setCommentRequest(gin_context, http.MethodGet, target+"?limit=1&cursor="+cursor.Encode(), todoId, "", "", token)
+		page := &model.CommentPage{Comments: comments, Next: model.CommentCursorOf(comments[0], false),
+			Prev: model.CommentCursorOf(comments[0], true)}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetComments(gomock.Any(), todoId, token.UID, model.PageRequest{Limit: 1, Cursor: cursor}).
+			Return(page, nil)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `<`+target+`?cursor=`+page.Next.Encode()+`&limit=1>; rel="next", <`+target+`?cursor=`+
+			page.Prev.Encode()+`&limit=1>; rel="prev", </todos/`+todoId+`/comments?limit=1>; rel="first"`,
+			http_recorder.Header().Get(LinkHeader))
+	})
+
+	t.Run("When the limit is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/comments?limit=0", todoId, "", "", token)
+		todoRepositoryMock.EXPECT().GetComments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidLimit, http.StatusBadRequest)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+	})
+
+	t.Run("When the user has no access to the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/comments", todoId, "", "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().GetComments(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound: http.StatusNotFound,
+			common.ErrError:        http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/comments", todoId, "", "", token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetComments(gomock.Any(), todoId, token.UID, model.PageRequest{Limit: model.DefaultPageLimit}).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getComments(gin_context)
+		}
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodGet, "/todos/wrong/comments", "wrong", "", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/comments", todoId, "", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, nil)
+		getComments(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getComments := GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getComments(gin_context)
+	})
+}
+
+func TestCreateComment(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, todoId, commentId := "wehgowe", uuid.New().String(), uuid.New()
+	newIdMock := func() (uuid.UUID, error) { return commentId, nil }
+	target := "/todos/" + todoId + "/comments"
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPost, target, todoId, "", `{"body": "body1"}`, token)
+		comment := model.Comment{Id: commentId.String(), TodoId: todoId, AuthorId: token.UID, Body: "body1",
+			CreatedAt: now, UpdatedAt: now}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), &comment, ownerId).Return(nil)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		createComment(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.Comment
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, comment, got)
+	})
+
+	t.Run("When the body is not valid", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"body": ""}`, `{"body": 1}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodPost, target, todoId, "", body, token)
+			todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+			createComment(gin_context)
+		}
+	})
+
+	t.Run("When the user has no access to the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPost, target, todoId, "", `{"body": "body1"}`, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		createComment(gin_context)
+	})
+
+	t.Run("When the user views the todo in a shared list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPost, target, todoId, "", `{"body": "body1"}`, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		createComment(gin_context)
+	})
+
+	t.Run("When newId returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPost, target, todoId, "", `{"body": "body1"}`, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse,
+			func() (uuid.UUID, error) { return uuid.Nil, common.ErrError }, nowMock)
+		createComment(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrInvalidComment: http.StatusBadRequest,
+			repository.ErrNotFound:       http.StatusNotFound,
+			repository.ErrQueryTimeout:   http.StatusGatewayTimeout,
+			common.ErrError:              http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodPost, target, todoId, "", `{"body": "body1"}`, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().CreateComment(gomock.Any(), gomock.Any(), token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+			createComment(gin_context)
+		}
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPost, "/todos/wrong/comments", "wrong", "", `{"body": "body1"}`, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		createComment(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		createComment := CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, newIdMock, nowMock)
+		createComment(gin_context)
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, todoId, commentId := "wehgowe", uuid.New().String(), uuid.New().String()
+	target := "/todos/" + todoId + "/comments/" + commentId
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPut, target, todoId, commentId, `{"body": "body2"}`, token)
+		comment := model.Comment{Id: commentId, TodoId: todoId, AuthorId: token.UID, Body: "body2", UpdatedAt: now}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().UpdateComment(gomock.Any(), &comment, ownerId).
+			Do(func(_ any, comment *model.Comment, _ string) { comment.CreatedAt = now }).Return(nil)
+		updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		updateComment(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.Comment
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		comment.CreatedAt = now
+		assert.Equal(t, comment, got)
+	})
+
+	t.Run("When the body is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPut, target, todoId, commentId, `{"body": ""}`, token)
+		todoRepositoryMock.EXPECT().UpdateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		updateComment(gin_context)
+	})
+
+	t.Run("When the user views the todo in a shared list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodPut, target, todoId, commentId, `{"body": "body2"}`, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().UpdateComment(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		updateComment(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrInvalidComment:   http.StatusBadRequest,
+			repository.ErrNotCommentAuthor: http.StatusForbidden,
+			repository.ErrNotFound:         http.StatusNotFound,
+			common.ErrError:                http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodPut, target, todoId, commentId, `{"body": "body2"}`, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().UpdateComment(gomock.Any(), gomock.Any(), token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			updateComment(gin_context)
+		}
+	})
+
+	t.Run("When an id is not valid", func(t *testing.T) {
+		for _, ids := range [][2]string{{"wrong", commentId}, {todoId, "wrong"}} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodPut, "/todos/"+ids[0]+"/comments/"+ids[1], ids[0], ids[1],
+				`{"body": "body2"}`, token)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			updateComment(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		updateComment := UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		updateComment(gin_context)
+	})
+}
+
+func TestDeleteComment(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, todoId, commentId := "wehgowe", uuid.New().String(), uuid.New().String()
+	target := "/todos/" + todoId + "/comments/" + commentId
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodDelete, target, todoId, commentId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().DeleteComment(gomock.Any(), todoId, commentId, ownerId, token.UID).Return(nil)
+		deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteComment(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+	})
+
+	t.Run("When the user has no access to the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodDelete, target, todoId, commentId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().DeleteComment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteComment(gin_context)
+	})
+
+	t.Run("When the user views the todo in a shared list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setCommentRequest(gin_context, http.MethodDelete, target, todoId, commentId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().DeleteComment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteComment(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotCommentAuthor: http.StatusForbidden,
+			repository.ErrNotFound:         http.StatusNotFound,
+			repository.ErrCanceled:         http.StatusServiceUnavailable,
+			common.ErrError:                http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodDelete, target, todoId, commentId, "", token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().DeleteComment(gomock.Any(), todoId, commentId, token.UID, token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteComment(gin_context)
+		}
+	})
+
+	t.Run("When an id is not valid", func(t *testing.T) {
+		for _, ids := range [][2]string{{"wrong", commentId}, {todoId, "wrong"}} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setCommentRequest(gin_context, http.MethodDelete, "/todos/"+ids[0]+"/comments/"+ids[1], ids[0], ids[1], "",
+				token)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteComment(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		deleteComment := DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteComment(gin_context)
+	})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+)
+
+const ETagHeader string = "ETag"
+const IfMatchHeader string = "If-Match"
+const IfNoneMatchHeader string = "If-None-Match"
+
+var ErrIfMatchRequired error = errors.New("the If-Match header with the ETag of the todo is required")
+var ErrInvalidIfMatch error = errors.New("the If-Match header must be * or one ETag of the todo")
+
+// etagOf is the strong ETag of a version of a todo.
+func etagOf(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// expectedVersionOf reads the version of the todo that the If-Match header of
+// a write expects, or the error and the status code that tell why it can't.
+func expectedVersionOf(ctx *gin.Context) (int64, int, error) {
+	return expectedVersion(ctx.GetHeader(IfMatchHeader))
+}
+
+// expectedVersion is expectedVersionOf for the value of an If-Match header.
+// A weak ETag never matches, as If-Match compares strongly.
+func expectedVersion(ifMatch string) (int64, int, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" {
+		return 0, http.StatusPreconditionRequired, ErrIfMatchRequired
+	}
+	if ifMatch == "*" {
+		return repository.AnyVersion, http.StatusOK, nil
+	}
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, http.StatusPreconditionFailed, repository.ErrVersionMismatch
+	}
+	version, ok := versionOf(ifMatch)
+	if !ok {
+		return 0, http.StatusBadRequest, ErrInvalidIfMatch
+	}
+	return version, http.StatusOK, nil
+}
+
+// noneMatch tells whether the If-None-Match header of a read names none of
+// the ETags of version, comparing weakly.
+func noneMatch(ctx *gin.Context, version int64) bool {
+	ifNoneMatch := strings.TrimSpace(ctx.GetHeader(IfNoneMatchHeader))
+	if ifNoneMatch == "*" {
+		return false
+	}
+	for _, etag := range strings.Split(ifNoneMatch, ",") {
+		if other, ok := versionOf(strings.TrimPrefix(strings.TrimSpace(etag), "W/")); ok && other == version {
+			return false
+		}
+	}
+	return true
+}
+
+func versionOf(etag string) (int64, bool) {
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	return version, err == nil && version >= model.FirstVersion
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrUnknownQueryParameter error = errors.New("unknown query parameter")
+
+var todoListParameters = map[string]bool{
+	limitParam: true, cursorParam: true, "done": true, "created_after": true,
+	"created_before": true, "title": true, "sort": true, "order": true, "tag": true, "tag_match": true,
+	"list_id": true, "due_after": true, "due_before": true, "timezone": true, "overdue": true,
+}
+
+// todoFilterOf binds and validates the filtering and sorting query
+// parameters of GET /todos. Parameters it doesn't know are rejected. A todo
+// is overdue or not at the time now returns.
+func todoFilterOf(ctx *gin.Context, now func() time.Time) (model.TodoFilter, error) {
+	var filter model.TodoFilter
+	for parameter := range ctx.Request.URL.Query() {
+		if !todoListParameters[parameter] {
+			return filter, fmt.Errorf("%w: %s", ErrUnknownQueryParameter, parameter)
+		}
+	}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		return filter, err
+	}
+	for parameter, bound := range map[string]**time.Time{"due_after": &filter.DueAfter, "due_before": &filter.DueBefore} {
+		if value, ok := ctx.GetQuery(parameter); ok {
+			at, err := model.DueBoundOf(value, filter.Timezone)
+			if err != nil {
+				return filter, err
+			}
+			*bound = &at
+		}
+	}
+	filter = filter.WithDefaults()
+	if filter.Overdue != nil {
+		filter.Now = now().UTC()
+	}
+	if len(filter.Tags) > 0 {
+		filter.Tags = model.NormalizeTags(filter.Tags)
+	}
+	return filter, filter.Validate()
+}
@@ -3,6 +3,9 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"firebase.google.com/go/v4/auth"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
@@ -14,6 +17,7 @@ import (
 )
 
 var ErrParseIsNil error = errors.New("parse is nil")
+var ErrIdMismatch error = errors.New("the id in the url doesn't match the id of the todo")
 
 type ErrorHandlerImpl struct {
 	Logger common.Logger
@@ -24,46 +28,105 @@ func (eh ErrorHandlerImpl) HandleAppError(webContext *gin.Context, someError err
 	webContext.AbortWithStatusJSON(code, gin.H{"error": someError.Error()})
 }
 
-func Create(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+// serverErrorStatusOf is the status code of an error that isn't the fault of
+// the client: 504 when the database didn't answer in time, 503 when the
+// request was canceled before it did and 500 otherwise.
+func serverErrorStatusOf(err error) int {
+	if err == repository.ErrQueryTimeout {
+		return http.StatusGatewayTimeout
+	} else if err == repository.ErrCanceled {
+		return http.StatusServiceUnavailable
+	} else {
+		return http.StatusInternalServerError
+	}
+}
+
+// writeStatusOf is the status code of the error of a write of a todo, for
+// every handler that writes todos and for the operations of a batch.
+func writeStatusOf(err error) int {
+	if err == repository.ErrInvalidTodo || err == repository.ErrListNotFound ||
+		err == repository.ErrParentNotFound || err == repository.ErrSubtaskTooDeep {
+		return http.StatusBadRequest
+	} else if err == repository.ErrListArchived {
+		return http.StatusConflict
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else if err == repository.ErrVersionMismatch {
+		return http.StatusPreconditionFailed
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
+
+// Create stores a new todo with an id from newId and the time from now.
+func Create(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	newId func() (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token, ok := ctx.Get(middleware.AuthToken)
 		if !ok {
 			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
 		} else {
-			var json model.Todo
+			var json model.CreateTodoRequest
 			if err := ctx.ShouldBindJSON(&json); err != nil {
 				errorHandler.HandleAppError(ctx, err,
 					http.StatusBadRequest)
 				return
 			}
+			id, err := newId()
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				return
+			}
+			todo := json.Todo(id.String(), now().UTC())
 			token := token.(*auth.Token)
-			err := todoRepository.Create(&json, token.UID)
+			ownerId, code, err := ownerOfNewTodo(ctx, todoRepository, todo, token.UID)
 			if err != nil {
-				errorHandler.HandleAppError(ctx, err,
-					http.StatusInternalServerError)
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			err = todoRepository.Create(ctx.Request.Context(), &todo, ownerId)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, writeStatusOf(err))
 			} else {
-				ctx.JSON(http.StatusOK, json)
+				ctx.Header(ETagHeader, etagOf(todo.Version))
+				ctx.JSON(http.StatusOK, todo)
 			}
 		}
 	}
 }
 
-func GetAll(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+// GetAll lists the todos that the user owns, filtered, sorted and paged with
+// the query parameters, model.DefaultPageLimit at a time when there is no
+// ?limit. The todos of a list that is shared with the user are listed by
+// GetListTodos.
+func GetAll(todoRepository common.TodoRepository, errorHandler common.ErrorHandler, now func() time.Time) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokeN, ok := ctx.Get(middleware.AuthToken)
 		if !ok {
 			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
 		} else {
 			token := tokeN.(*auth.Token)
-			if todos, err := todoRepository.GetAll(token.UID); err != nil {
-				errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+			if filter, err := todoFilterOf(ctx, now); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else if pageRequest, err := pageRequestOf(ctx); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else if page, err := todoRepository.GetPage(ctx.Request.Context(), token.UID, filter, pageRequest); err != nil {
+				if err == repository.ErrInvalidFilter {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
 			} else {
-				ctx.JSON(http.StatusOK, todos)
+				setLinkHeader(ctx, page.Next, page.Prev, pageRequest.Limit)
+				ctx.JSON(http.StatusOK, page.Todos)
 			}
 		}
 	}
 }
 
+// GetById answers 304 Not Modified when the If-None-Match header names the
+// ETag of the todo. With ?expand=subtasks the todo comes with the tree of its
+// subtasks, which its ETag doesn't cover, so it is always answered in full.
 func GetById(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
 	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -76,17 +139,35 @@ func GetById(todoRepository common.TodoRepository, errorHandler common.ErrorHand
 				_, err := parse(id)
 				if err != nil {
 					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else if expand, err := expandSubtasksOf(ctx); err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else if access, code, err := todoAccessOf(ctx, todoRepository, id, token.(*auth.Token).UID,
+					readPermission); err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
 				} else {
-					token := token.(*auth.Token)
-					todo, err := todoRepository.GetById(id, token.UID)
+					todo, err := todoRepository.GetById(ctx.Request.Context(), id, access.OwnerId)
+					if err == nil && expand {
+						var subtasks []model.Todo
+						if subtasks, err = todoRepository.GetSubtasks(ctx.Request.Context(), id, access.OwnerId, true); err == nil {
+							todo.Subtasks = model.NestSubtasks(todo.Id, visibleSubtasks(*access, subtasks))
+						}
+					}
 					if err != nil {
 						if err == repository.ErrNotFound {
 							errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
 						} else {
-							errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+							errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
 						}
-					} else {
+					} else if expand {
+						ctx.Header(ETagHeader, etagOf(todo.Version))
 						ctx.JSON(http.StatusOK, todo)
+					} else {
+						ctx.Header(ETagHeader, etagOf(todo.Version))
+						if noneMatch(ctx, todo.Version) {
+							ctx.JSON(http.StatusOK, todo)
+						} else {
+							ctx.AbortWithStatus(http.StatusNotModified)
+						}
 					}
 				}
 			} else {
@@ -96,31 +177,151 @@ func GetById(todoRepository common.TodoRepository, errorHandler common.ErrorHand
 	}
 }
 
-func Update(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+// Search looks for ?q= in the todos that the user owns, which leaves out the
+// lists that are shared with them.
+func Search(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			query := strings.TrimSpace(ctx.Query("q"))
+			if query == "" || utf8.RuneCountInString(query) > model.MaxSearchQueryLength {
+				errorHandler.HandleAppError(ctx, model.ErrInvalidSearchQuery, http.StatusBadRequest)
+			} else if limit, err := limitOf(ctx, model.DefaultSearchLimit); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else {
+				token := token.(*auth.Token)
+				if results, err := todoRepository.Search(ctx.Request.Context(), token.UID, query, limit); err != nil {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				} else {
+					ctx.JSON(http.StatusOK, results)
+				}
+			}
+		}
+	}
+}
+
+// Update stores the todo when the If-Match header names its ETag or is *.
+func Update(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	now func() time.Time) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token, ok := ctx.Get(middleware.AuthToken)
 		if !ok {
 			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if version, code, err := expectedVersionOf(ctx); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
 		} else {
-			var todo model.Todo
-			err := ctx.ShouldBindJSON(&todo)
+			var request model.UpdateTodoRequest
+			err := ctx.ShouldBindJSON(&request)
 			if err != nil {
 				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else if access, code, err := todoAccessOf(ctx, todoRepository, request.Id, token.(*auth.Token).UID,
+				writePermission); err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+			} else if !staysInList(*access, request.ListId) {
+				errorHandler.HandleAppError(ctx, ErrForbidden, http.StatusForbidden)
+			} else if code, err := keepsCommentCount(ctx, todoRepository, request,
+				access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
 			} else {
+				todo := request.Todo(now().UTC())
+				err := todoRepository.Update(ctx.Request.Context(), &todo, access.OwnerId, version)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, writeStatusOf(err))
+				} else {
+					setNextETag(ctx, version)
+					ctx.JSON(http.StatusNoContent, gin.H{})
+				}
+			}
+		}
+	}
+}
+
+// keepsCommentCount returns the error and the status code of an update that
+// sends a commentCount other than the one of the stored todo.
+func keepsCommentCount(ctx *gin.Context, todoRepository common.TodoRepository, request model.UpdateTodoRequest,
+	ownerId string) (int, error) {
+	if request.CommentCount == nil {
+		return http.StatusOK, nil
+	}
+	todo, err := todoRepository.GetById(ctx.Request.Context(), request.Id, ownerId)
+	if err != nil {
+		return writeStatusOf(err), err
+	}
+	if todo.CommentCount != *request.CommentCount {
+		return http.StatusBadRequest, ErrReadOnlyField
+	}
+	return http.StatusOK, nil
+}
+
+// setNextETag sets the ETag of the todo after a write at version, which is
+// only known when If-Match named one.
+func setNextETag(ctx *gin.Context, version int64) {
+	if version != repository.AnyVersion {
+		ctx.Header(ETagHeader, etagOf(version+1))
+	}
+}
+
+func UpdateById(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			if parse != nil {
+				id := ctx.Param("id")
+				_, err := parse(id)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+					return
+				}
+				version, code, err := expectedVersionOf(ctx)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				var request model.UpdateTodoRequest
+				if err := ctx.ShouldBindJSON(&request); err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+					return
+				}
+				if request.Id != id {
+					errorHandler.HandleAppError(ctx, ErrIdMismatch, http.StatusBadRequest)
+					return
+				}
 				token := token.(*auth.Token)
-				err := todoRepository.Update(&todo, token.UID)
+				access, code, err := todoAccessOf(ctx, todoRepository, id, token.UID, writePermission)
 				if err != nil {
-					errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				if !staysInList(*access, request.ListId) {
+					errorHandler.HandleAppError(ctx, ErrForbidden, http.StatusForbidden)
+					return
+				}
+				if code, err := keepsCommentCount(ctx, todoRepository, request, access.OwnerId); err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				todo := request.Todo(now().UTC())
+				if err := todoRepository.Update(ctx.Request.Context(), &todo, access.OwnerId, version); err != nil {
+					errorHandler.HandleAppError(ctx, err, writeStatusOf(err))
 				} else {
+					setNextETag(ctx, version)
 					ctx.JSON(http.StatusNoContent, gin.H{})
 				}
+			} else {
+				errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
 			}
 		}
 	}
 }
 
+// Delete moves a todo to the trash at the time from now.
 func Delete(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
-	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		token, ok := ctx.Get(middleware.AuthToken)
 		if !ok {
@@ -131,11 +332,15 @@ func Delete(todoRepository common.TodoRepository, errorHandler common.ErrorHandl
 				_, err := parse(id)
 				if err != nil {
 					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else if version, code, err := expectedVersionOf(ctx); err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+				} else if access, code, err := todoAccessOf(ctx, todoRepository, id, token.(*auth.Token).UID,
+					writePermission); err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
 				} else {
-					token := token.(*auth.Token)
-					err := todoRepository.Delete(id, token.UID)
+					err := todoRepository.Delete(ctx.Request.Context(), id, access.OwnerId, version, now().UTC())
 					if err != nil {
-						errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+						errorHandler.HandleAppError(ctx, err, writeStatusOf(err))
 					} else {
 						ctx.JSON(http.StatusNoContent, gin.H{})
 					}
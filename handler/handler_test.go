@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -47,17 +48,24 @@ func TestHandleError(t *testing.T) {
 }
 
 func TestCreate(t *testing.T) {
+	todoId := uuid.Must(uuid.NewV7())
+	newIdMock := func() (uuid.UUID, error) {
+		return todoId, nil
+	}
+
 	t.Run("Good case", func(t *testing.T) {
 		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
-		createTodo := Create(todoRepositoryMock, errorHandlerMock)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
 		done := false
 		token := &auth.Token{UID: "sfweo"}
-		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
-		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
+		todo := model.Todo{Id: todoId.String(), Title: "title1",
 			Description: "description1",
-			Done:        &done, CreatedAt: ti}
-		todoRepositoryMock.EXPECT().Create(&todo, token.UID).Return(nil)
-		json_bytes, err := json.Marshal(todo)
+			Done:        &done, CreatedAt: now, UpdatedAt: now, Tags: []string{}}
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).DoAndReturn(func(ctx context.Context, todo *model.Todo, userId string) error {
+			todo.Version = model.FirstVersion
+			return nil
+		})
+		json_bytes, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &done})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -68,24 +76,147 @@ func TestCreate(t *testing.T) {
 		gin_context.Set(middleware.AuthToken, token)
 		createTodo(gin_context)
 		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"1"`, http_recorder.Header().Get(ETagHeader))
 		var got model.Todo
 		err = json.Unmarshal(http_recorder.Body.Bytes(), &got)
 		if err != nil {
 			t.Fatal(err)
 		}
+		todo.Version = model.FirstVersion
 		assert.Equal(t, todo, got)
 	})
 
+	t.Run("Good case: the id and the timestamps that the client sends are ignored", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		done := true
+		token := &auth.Token{UID: "sfweo"}
+		backdated, _ := time.Parse(time.RFC3339, "2000-01-01T00:00:00Z")
+		sent := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &done, CreatedAt: backdated, UpdatedAt: backdated, CompletedAt: &backdated,
+			Tags: []string{"Work ", "home", "work"}}
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1",
+			Done: &done, CreatedAt: now, UpdatedAt: now, CompletedAt: &now, Tags: []string{"home", "work"}}
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(nil)
+		json_bytes, err := json.Marshal(sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		web_request := &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Request = web_request
+		gin_context.Set(middleware.AuthToken, token)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.Todo
+		err = json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, todo, got)
+	})
+
+	t.Run("Good case: due on a day in a time zone", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "sfweo"}
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done,
+			CreatedAt: now, UpdatedAt: now, Tags: []string{}, DueAt: &model.Due{Time: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), DateOnly: true},
+			Timezone: "Europe/Berlin"}
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(nil)
+		web_request := &http.Request{
+			Body: io.NopCloser(bytes.NewBufferString(
+				`{"title": "title1", "description": "description1", "done": false, "dueAt": "2022-10-01", ` +
+					`"timezone": "Europe/Berlin"}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Request = web_request
+		gin_context.Set(middleware.AuthToken, token)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"dueAt":"2022-10-01","timezone":"Europe/Berlin"`)
+	})
+
+	t.Run("Good case: a priority", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "sfweo"}
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done,
+			CreatedAt: now, UpdatedAt: now, Tags: []string{}, Priority: model.PriorityMedium}
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(nil)
+		gin_context.Request = &http.Request{
+			Body: io.NopCloser(bytes.NewBufferString(
+				`{"title": "title1", "description": "description1", "done": false, "priority": "medium"}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Set(middleware.AuthToken, token)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"priority":"medium"`)
+	})
+
+	t.Run("Good case: a recurrence", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "sfweo"}
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done,
+			CreatedAt: now, UpdatedAt: now, Tags: []string{},
+			DueAt:      &model.Due{Time: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), DateOnly: true},
+			Recurrence: "FREQ=MONTHLY;BYDAY=1SA;COUNT=3"}
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(nil)
+		gin_context.Request = &http.Request{
+			Body: io.NopCloser(bytes.NewBufferString(`{"title": "title1", "description": "description1", "done": false, ` +
+				`"dueAt": "2022-10-01", "recurrence": "RRULE:FREQ=MONTHLY;BYDAY=+1SA;COUNT=3"}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Set(middleware.AuthToken, token)
+		createTodo(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"recurrence":"FREQ=MONTHLY;BYDAY=1SA;COUNT=3"`)
+	})
+
+	t.Run("When the recurrence is invalid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, todo *model.Todo, _ string) {
+				assert.Equal(t, "FREQ=HOURLY", todo.Recurrence)
+			}).Return(repository.ErrInvalidTodo)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrInvalidTodo, http.StatusBadRequest)
+		gin_context.Request = &http.Request{
+			Body: io.NopCloser(bytes.NewBufferString(`{"title": "title1", "description": "description1", "done": false, ` +
+				`"dueAt": "2022-10-01", "recurrence": "FREQ=HOURLY"}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Set(middleware.AuthToken, &auth.Token{UID: "sfweo"})
+		createTodo(gin_context)
+	})
+
+	t.Run("When the due date, the time zone or the priority is invalid", func(t *testing.T) {
+		for _, body := range []string{`{"title": "title1", "description": "description1", "done": false, "dueAt": "next week"}`,
+			`{"title": "title1", "description": "description1", "done": false, "timezone": "Mars/Olympus"}`,
+			`{"title": "title1", "description": "description1", "done": false, "priority": "urgent"}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+			todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			gin_context.Request = &http.Request{
+				Body:   io.NopCloser(bytes.NewBufferString(body)),
+				Header: map[string][]string{"Content-Type": {"application/json"}}}
+			gin_context.Set(middleware.AuthToken, &auth.Token{UID: "sfweo"})
+			createTodo(gin_context)
+		}
+	})
+
 	t.Run("When TodoRepository returns an error", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
-		createTodo := Create(todoRepositoryMock, errorHandlerMock)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
 		done := false
 		token := &auth.Token{UID: "sfweo"}
-		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
-		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
+		todo := model.Todo{Id: todoId.String(), Title: "title1",
 			Description: "description1",
-			Done:        &done, CreatedAt: ti}
-		json_bytes, err := json.Marshal(todo)
+			Done:        &done, CreatedAt: now, UpdatedAt: now, Tags: []string{}}
+		json_bytes, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &done})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -94,20 +225,69 @@ func TestCreate(t *testing.T) {
 			Header: map[string][]string{"Content-Type": {"application/json"}}}
 		gin_context.Request = web_request
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Create(&todo, token.UID).Return(common.ErrError)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createTodo(gin_context)
+	})
+
+	t.Run("When the list or the parent can't take the todo", func(t *testing.T) {
+		for err, status := range map[error]int{
+			repository.ErrListNotFound:   http.StatusBadRequest,
+			repository.ErrListArchived:   http.StatusConflict,
+			repository.ErrParentNotFound: http.StatusBadRequest,
+			repository.ErrSubtaskTooDeep: http.StatusBadRequest,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+			done := false
+			token := &auth.Token{UID: "sfweo"}
+			listId := uuid.New().String()
+			todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1",
+				Done: &done, CreatedAt: now, UpdatedAt: now, Tags: []string{}, ListId: listId}
+			json_bytes, jsonErr := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1",
+				Done: &done, ListId: listId})
+			if jsonErr != nil {
+				t.Fatal(jsonErr)
+			}
+			gin_context.Request = &http.Request{
+				Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+				Header: map[string][]string{"Content-Type": {"application/json"}}}
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().Create(gomock.Any(), &todo, token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			createTodo(gin_context)
+		}
+	})
+
+	t.Run("When newId returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		newIdMock := func() (uuid.UUID, error) {
+			return uuid.Nil, common.ErrError
+		}
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "sfweo"}
+		json_bytes, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &done})
+		if err != nil {
+			t.Fatal(err)
+		}
+		web_request := &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}}}
+		gin_context.Request = web_request
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
 		createTodo(gin_context)
 	})
 
 	t.Run("When required fields are not present in the web request body", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
-		createTodo := Create(todoRepositoryMock, errorHandlerMock)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
 		done := false
 		token := &auth.Token{UID: "sfweo"}
-		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
-		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
-			Done: &done, CreatedAt: ti}
-		json_bytes, err := json.Marshal(todo)
+		json_bytes, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Done: &done})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -116,7 +296,7 @@ func TestCreate(t *testing.T) {
 			Header: map[string][]string{"Content-Type": {"application/json"}}}
 		gin_context.Request = web_request
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest).
 			DoAndReturn(func(ctx *gin.Context, err error, code int) {
 				if !strings.Contains(err.Error(), "Description") {
@@ -129,14 +309,10 @@ func TestCreate(t *testing.T) {
 	t.Run("When there is no auth token in the web context", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
-		createTodo := Create(todoRepositoryMock, errorHandlerMock)
+		createTodo := Create(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
 		done := false
-		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
-		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
-			Description: "description1",
-			Done:        &done, CreatedAt: ti}
-		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
-		json_bytes, err := json.Marshal(todo)
+		todoRepositoryMock.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		json_bytes, err := json.Marshal(model.CreateTodoRequest{Title: "title1", Description: "description1", Done: &done})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -152,9 +328,11 @@ func TestGetAll(t *testing.T) {
 	t.Run("Good case: there are no todos", func(t *testing.T) {
 		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
 		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos", nil)
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetAll(token.UID).Return([]model.Todo{}, nil)
-		getAll := GetAll(todoRepositoryMock, errorHandlerMock)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
 		getAll(gin_context)
 		assert.Equal(t, http.StatusOK, http_recorder.Code)
 		var got []model.Todo
@@ -177,9 +355,11 @@ func TestGetAll(t *testing.T) {
 		todos := []model.Todo{{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todo1done, CreatedAt: ti1},
 			{Id: uuid.New().String(), Title: "title2", Description: "description2", Done: &todo2done, CreatedAt: ti2},
 			{Id: uuid.New().String(), Title: "title3", Description: "description3", Done: &todo3done, CreatedAt: ti3}}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos", nil)
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetAll(token.UID).Return(todos, nil)
-		getAll := GetAll(todoRepositoryMock, errorHandlerMock)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: todos}, nil)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
 		getAll(gin_context)
 		assert.Equal(t, http.StatusOK, http_recorder.Code)
 		var got []model.Todo
@@ -188,24 +368,237 @@ func TestGetAll(t *testing.T) {
 			t.Fatal(err)
 		}
 		assert.Equal(t, todos, got)
+		assert.Equal(t, `</todos?limit=50>; rel="first"`, http_recorder.Header().Get(LinkHeader))
 	})
 
 	t.Run("When TodoRepository returns an error", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit}).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+	})
+
+	t.Run("Good case: paged", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05.768Z")
+		cursor := model.Cursor{CreatedAt: ti, Id: uuid.New().String()}
+		todos := []model.Todo{{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone, CreatedAt: ti}}
+		page := &model.Page{Todos: todos, Next: model.CursorOf(todos[0], false), Prev: model.CursorOf(todos[0], true)}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?limit=1&cursor="+cursor.Encode(), nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: 1, Cursor: &cursor}).Return(page, nil)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `</todos?cursor=`+page.Next.Encode()+`&limit=1>; rel="next", </todos?cursor=`+
+			page.Prev.Encode()+`&limit=1>; rel="prev", </todos?limit=1>; rel="first"`, http_recorder.Header().Get(LinkHeader))
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, todos, got)
+	})
+
+	t.Run("Good case: paged with the default limit and no more pages", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		cursor := model.Cursor{CreatedAt: time.Now().UTC(), Id: uuid.New().String()}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?cursor="+cursor.Encode(), nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit, Cursor: &cursor}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `</todos?limit=50>; rel="first"`, http_recorder.Header().Get(LinkHeader))
+	})
+
+	t.Run("When the limit is invalid", func(t *testing.T) {
+		for _, limit := range []string{"0", "101", "ten"} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			token := &auth.Token{UID: "wbfewh"}
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?limit="+limit, nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidLimit, http.StatusBadRequest)
+			getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAll(gin_context)
+		}
+	})
+
+	t.Run("When the cursor is invalid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?cursor=oehwegiuf", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidCursor, http.StatusBadRequest)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+	})
+
+	t.Run("Good case: filtered and sorted", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		done := true
+		after, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05Z")
+		before, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05+02:00")
+		filter := model.TodoFilter{Done: &done, CreatedAfter: &after, CreatedBefore: &before,
+			Title: "groceries", Sort: model.SortByTitle, Order: model.OrderAsc,
+			Tags: []string{"home", "work"}, TagMatch: model.TagMatchAll}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?done=true&created_after=2022-07-21T14:07:05Z"+
+			"&created_before=2022-09-21T14:07:05%2B02:00&title=groceries&sort=title&order=asc"+
+			"&tag=Work&tag=home&tag=work&tag_match=all", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{Limit: model.DefaultPageLimit}).
+			DoAndReturn(func(ctx context.Context, userId string, got model.TodoFilter, pageRequest model.PageRequest) (*model.Page, error) {
+				assert.True(t, after.Equal(*got.CreatedAfter))
+				assert.True(t, before.Equal(*got.CreatedBefore))
+				got.CreatedAfter, got.CreatedBefore = filter.CreatedAfter, filter.CreatedBefore
+				assert.Equal(t, filter, got)
+				return &model.Page{Todos: []model.Todo{}}, nil
+			})
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Header().Get(LinkHeader), `&limit=50&`)
+	})
+
+	t.Run("Good case: due and overdue", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		overdue := true
+		after, _ := time.Parse(time.RFC3339, "2022-07-21T00:00:00Z")
+		before, _ := time.Parse(time.RFC3339, "2022-10-01T00:00:00Z")
+		filter := model.TodoFilter{DueAfter: &after, DueBefore: &before, Overdue: &overdue, Now: now,
+			Sort: model.SortByDueAt, Order: model.OrderAsc, TagMatch: model.TagMatchAny}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?due_after=2022-07-21T00:00:00Z"+
+			"&due_before=2022-10-01T00:00:00Z&overdue=true&sort=due_at&order=asc", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{Limit: model.DefaultPageLimit}).
+			DoAndReturn(func(ctx context.Context, userId string, got model.TodoFilter, pageRequest model.PageRequest) (*model.Page, error) {
+				assert.True(t, after.Equal(*got.DueAfter))
+				assert.True(t, before.Equal(*got.DueBefore))
+				got.DueAfter, got.DueBefore = filter.DueAfter, filter.DueBefore
+				assert.Equal(t, filter, got)
+				return &model.Page{Todos: []model.Todo{}}, nil
+			})
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("Good case: due days in a time zone", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet,
+			"/todos?due_after=2022-07-21&due_before=2022-10-01T00:00:00Z&timezone=Asia/Tokyo", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, gomock.Any(), model.PageRequest{Limit: model.DefaultPageLimit}).
+			DoAndReturn(func(ctx context.Context, userId string, got model.TodoFilter, pageRequest model.PageRequest) (*model.Page, error) {
+				assert.Equal(t, time.Date(2022, 7, 20, 15, 0, 0, 0, time.UTC), *got.DueAfter)
+				assert.Equal(t, time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), *got.DueBefore)
+				return &model.Page{Todos: []model.Todo{}}, nil
+			})
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When a query parameter is unknown", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?done=true&color=red", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest).
+			DoAndReturn(func(ctx *gin.Context, err error, code int) {
+				assert.ErrorIs(t, err, ErrUnknownQueryParameter)
+				assert.Contains(t, err.Error(), "color")
+			})
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+	})
+
+	t.Run("When a query parameter is invalid", func(t *testing.T) {
+		for _, query := range []string{"done=maybe", "created_after=yesterday", "sort=color", "order=up",
+			"created_after=2022-09-21T14:07:05Z&created_before=2022-07-21T14:07:05Z", "overdue=soon",
+			"due_before=tomorrow", "due_after=2022-09-21T14:07:05Z&due_before=2022-07-21T14:07:05Z",
+			"due_after=2022-09-21&due_before=2022-09-21", "timezone=Mars/Olympus"} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			token := &auth.Token{UID: "wbfewh"}
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?"+query, nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAll(gin_context)
+		}
+	})
+
+	t.Run("When TodoRepository rejects the filter", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?sort=done", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, repository.ErrInvalidFilter)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrInvalidFilter, http.StatusBadRequest)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error for a page", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos?limit=5", nil)
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetAll(token.UID).Return(nil, common.ErrError)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: 5}).Return(nil, common.ErrError)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
-		getAll := GetAll(todoRepositoryMock, errorHandlerMock)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
 		getAll(gin_context)
 	})
 
 	t.Run("When there is no auth token in the web context", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
-		getAll := GetAll(todoRepositoryMock, errorHandlerMock)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
 		getAll(gin_context)
 	})
+
+	t.Run("Passes the context of the request to the repository", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		requestCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos", nil).WithContext(requestCtx)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetPage(requestCtx, token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+		getAll(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When the query times out or the request is canceled", func(t *testing.T) {
+		for err, code := range map[error]int{repository.ErrQueryTimeout: http.StatusGatewayTimeout,
+			repository.ErrCanceled: http.StatusServiceUnavailable} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			token := &auth.Token{UID: "wbfewh"}
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos", nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, defaultFilter, model.PageRequest{Limit: model.DefaultPageLimit}).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getAll := GetAll(todoRepositoryMock, errorHandlerMock, nowMock)
+			getAll(gin_context)
+		}
+	})
 }
 
 func TestGetById(t *testing.T) {
@@ -219,13 +612,17 @@ func TestGetById(t *testing.T) {
 		done := false
 		ti, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05.768Z")
 		todo := model.Todo{Id: todoId.String(), Title: "title1",
-			Description: "description1", Done: &done, CreatedAt: ti}
+			Description: "description1", Done: &done, CreatedAt: ti, Version: 4}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/"+todoId.String(), nil)
+		gin_context.Request.Header.Set(IfNoneMatchHeader, `"3"`)
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetById(todoId.String(), token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(&todo, nil)
 		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
 		getById(gin_context)
 		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"4"`, http_recorder.Header().Get(ETagHeader))
 		var got model.Todo
 		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
 		if err != nil {
@@ -234,6 +631,97 @@ func TestGetById(t *testing.T) {
 		assert.Equal(t, todo, got)
 	})
 
+	t.Run("Good case: not modified", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{`"4"`, `W/"4"`, `"3", W/"4"`, "*"} {
+			todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+			todoId := uuid.New()
+			token := &auth.Token{UID: "heowh"}
+			uUidParseMock := func(id string) (uuid.UUID, error) {
+				return todoId, nil
+			}
+			done := false
+			todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, Version: 4}
+			gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/"+todoId.String(), nil)
+			gin_context.Request.Header.Set(IfNoneMatchHeader, ifNoneMatch)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(&todo, nil)
+			getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+			getById(gin_context)
+			assert.Equal(t, http.StatusNotModified, http_recorder.Code)
+			assert.Equal(t, `"4"`, http_recorder.Header().Get(ETagHeader))
+			assert.Empty(t, http_recorder.Body.Bytes())
+		}
+	})
+
+	t.Run("Good case: ?expand=subtasks", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		todoId := uuid.New()
+		token := &auth.Token{UID: "heowh"}
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		done := false
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, Version: 4,
+			Progress: &model.Progress{Total: 1}}
+		subtask := model.Todo{Id: uuid.New().String(), Title: "title2", Done: &done, Version: 1, ParentId: todo.Id}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/"+todoId.String()+"?expand=subtasks", nil)
+		gin_context.Request.Header.Set(IfNoneMatchHeader, `"4"`)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), todoId.String(), token.UID, true).
+			Return([]model.Todo{subtask}, nil)
+		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		getById(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"4"`, http_recorder.Header().Get(ETagHeader))
+		var got model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		todo.Subtasks = []model.Todo{subtask}
+		assert.Equal(t, todo, got)
+	})
+
+	t.Run("When expand is not subtasks", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		todoId := uuid.New()
+		token := &auth.Token{UID: "heowh"}
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/"+todoId.String()+"?expand=lists", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrInvalidExpand, http.StatusBadRequest)
+		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		getById(gin_context)
+	})
+
+	t.Run("When GetSubtasks returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		todoId := uuid.New()
+		token := &auth.Token{UID: "heowh"}
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		done := false
+		todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, Version: 4}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/"+todoId.String()+"?expand=subtasks", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), todoId.String(), token.UID, true).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		getById(gin_context)
+	})
+
 	t.Run("Todo not found", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		todoId := uuid.New()
@@ -243,7 +731,8 @@ func TestGetById(t *testing.T) {
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetById(todoId.String(), token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(nil, repository.ErrNotFound)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
 		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
 		getById(gin_context)
@@ -257,7 +746,7 @@ func TestGetById(t *testing.T) {
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: "oehwegiuf"})
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetById(gomock.Any(), token.UID).Times(0)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), token.UID).Times(0)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
 		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
 		getById(gin_context)
@@ -272,7 +761,8 @@ func TestGetById(t *testing.T) {
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: "oehwegiuf"})
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().GetById(gomock.Any(), token.UID).Return(nil, common.ErrError)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), gomock.Any(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), token.UID).Return(nil, common.ErrError)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
 		getById := GetById(todoRepositoryMock, errorHandlerMock, uUidParseMock)
 		getById(gin_context)
@@ -295,10 +785,88 @@ func TestGetById(t *testing.T) {
 	})
 }
 
+func TestSearch(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		done := false
+		ti, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05.768Z")
+		results := []model.SearchResult{{Todo: model.Todo{Id: uuid.New().String(), Title: "buy milk",
+			Description: "from the shop", Done: &done, CreatedAt: ti}, Rank: 0.5,
+			HighlightedTitle: "buy <mark>milk</mark>", Snippet: "from the shop"}}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/search?q=+milk+&limit=5", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Search(gomock.Any(), token.UID, "milk", 5).Return(results, nil)
+		search := Search(todoRepositoryMock, errorHandlerMock)
+		search(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.SearchResult
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, results, got)
+	})
+
+	t.Run("Good case: default limit", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/search?q=milk", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Search(gomock.Any(), token.UID, "milk", model.DefaultSearchLimit).Return([]model.SearchResult{}, nil)
+		search := Search(todoRepositoryMock, errorHandlerMock)
+		search(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, "[]", http_recorder.Body.String())
+	})
+
+	t.Run("When q is empty or too long", func(t *testing.T) {
+		for _, query := range []string{"", "q=", "q=++", "q=" + strings.Repeat("a", model.MaxSearchQueryLength+1)} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			token := &auth.Token{UID: "wbfewh"}
+			gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/search?"+query, nil)
+			gin_context.Set(middleware.AuthToken, token)
+			todoRepositoryMock.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidSearchQuery, http.StatusBadRequest)
+			search := Search(todoRepositoryMock, errorHandlerMock)
+			search(gin_context)
+		}
+	})
+
+	t.Run("When the limit is invalid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/search?q=milk&limit=0", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidLimit, http.StatusBadRequest)
+		search := Search(todoRepositoryMock, errorHandlerMock)
+		search(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Request = httptest.NewRequest(http.MethodGet, "/todos/search?q=milk", nil)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Search(gomock.Any(), token.UID, "milk", model.DefaultSearchLimit).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		search := Search(todoRepositoryMock, errorHandlerMock)
+		search(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		search := Search(todoRepositoryMock, errorHandlerMock)
+		search(gin_context)
+	})
+}
+
 func TestUpdate(t *testing.T) {
 	t.Run("Good case", func(t *testing.T) {
 		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
-		update := Update(todoRepositoryMock, errorHandlerMock)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
 		done := false
 		token := &auth.Token{UID: "nfwseo"}
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
@@ -311,15 +879,74 @@ func TestUpdate(t *testing.T) {
 		}
 		web_request := &http.Request{
 			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
-			Header: map[string][]string{"Content-Type": {"application/json"}}}
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
 		gin_context.Request = web_request
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Update(&todo, token.UID).Return(nil)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(nil)
 		update(gin_context)
 		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Equal(t, `"4"`, http_recorder.Header().Get(ETagHeader))
 		assert.Empty(t, http_recorder.Body.Bytes())
 	})
 
+	t.Run("Good case: If-Match is *", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "nfwseo"}
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done}
+		json_bytes, err := json.Marshal(todo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gin_context.Request = &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {"*"}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, repository.AnyVersion).Return(nil)
+		update(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Header().Get(ETagHeader))
+	})
+
+	t.Run("When If-Match is missing or invalid", func(t *testing.T) {
+		for _, header := range []map[string][]string{{}, {IfMatchHeader: {"3"}}, {IfMatchHeader: {`W/"3"`}}} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+			gin_context.Request = &http.Request{Header: header}
+			gin_context.Set(middleware.AuthToken, &auth.Token{UID: "nfwseo"})
+			todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			_, code, err := expectedVersionOf(gin_context)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			update(gin_context)
+		}
+	})
+
+	t.Run("When the todo has another version", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "nfwseo"}
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done}
+		json_bytes, err := json.Marshal(todo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gin_context.Request = &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(repository.ErrVersionMismatch)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+		update(gin_context)
+	})
+
 	t.Run("When required fields are not present in the web request body", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		done := false
@@ -333,11 +960,11 @@ func TestUpdate(t *testing.T) {
 		}
 		web_request := &http.Request{
 			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
-			Header: map[string][]string{"Content-Type": {"application/json"}}}
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
 		gin_context.Request = web_request
 		gin_context.Set(middleware.AuthToken, token)
-		update := Update(todoRepositoryMock, errorHandlerMock)
-		todoRepositoryMock.EXPECT().Update(gomock.Any(), token.UID).Times(0)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), token.UID, gomock.Any()).Times(0)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest).
 			DoAndReturn(func(ctx *gin.Context, err error, code int) {
 				if !strings.Contains(err.Error(), "Description") {
@@ -349,7 +976,7 @@ func TestUpdate(t *testing.T) {
 
 	t.Run("When TodoRepository returns an error", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
-		update := Update(todoRepositoryMock, errorHandlerMock)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
 		done := false
 		token := &auth.Token{UID: "nfwseo"}
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
@@ -362,17 +989,81 @@ func TestUpdate(t *testing.T) {
 		}
 		web_request := &http.Request{
 			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
-			Header: map[string][]string{"Content-Type": {"application/json"}}}
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
 		gin_context.Request = web_request
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Update(&todo, token.UID).Return(common.ErrError)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(common.ErrError)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
 		update(gin_context)
 	})
 
+	t.Run("Todo not found", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "nfwseo"}
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
+			Description: "description1",
+			Done:        &done, CreatedAt: ti}
+		json_bytes, err := json.Marshal(todo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		web_request := &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
+		gin_context.Request = web_request
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		update(gin_context)
+	})
+
+	t.Run("When the body changes the comment count", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "nfwseo"}
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done,
+			CommentCount: 2}
+		gin_context.Request = &http.Request{
+			Body: io.NopCloser(strings.NewReader(`{"id": "` + todo.Id +
+				`", "title": "title1", "description": "description1", "done": false, "commentCount": 5}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrReadOnlyField, http.StatusBadRequest)
+		update(gin_context)
+	})
+
+	t.Run("Good case: the body leaves out the comment count", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
+		done := false
+		token := &auth.Token{UID: "nfwseo"}
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done}
+		gin_context.Request = &http.Request{
+			Body: io.NopCloser(strings.NewReader(`{"id": "` + todo.Id +
+				`", "title": "title1", "description": "description1", "done": false}`)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(nil)
+		update(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+	})
+
 	t.Run("When there is no auth token in the web context", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
-		update := Update(todoRepositoryMock, errorHandlerMock)
+		update := Update(todoRepositoryMock, errorHandlerMock, nowMock)
 		done := false
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
 		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
@@ -384,13 +1075,153 @@ func TestUpdate(t *testing.T) {
 		}
 		web_request := &http.Request{
 			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
-			Header: map[string][]string{"Content-Type": {"application/json"}}}
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
 		gin_context.Request = web_request
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
 		update(gin_context)
 	})
 }
 
+func TestUpdateById(t *testing.T) {
+	todoId := uuid.New()
+	token := &auth.Token{UID: "nfwseo"}
+	uUidParseMock := func(id string) (uuid.UUID, error) {
+		return todoId, nil
+	}
+	setRequest := func(t *testing.T, gin_context *gin.Context, todo model.Todo) {
+		json_bytes, err := json.Marshal(todo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gin_context.Request = &http.Request{
+			Body:   io.NopCloser(bytes.NewBuffer(json_bytes)),
+			Header: map[string][]string{"Content-Type": {"application/json"}, IfMatchHeader: {`"3"`}}}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+	}
+	done := false
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	todo := model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done, CreatedAt: ti}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(nil)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Equal(t, `"4"`, http_recorder.Header().Get(ETagHeader))
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When If-Match is missing", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		gin_context.Request.Header.Del(IfMatchHeader)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrIfMatchRequired, http.StatusPreconditionRequired)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When the todo has another version", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(repository.ErrVersionMismatch)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When the id in the url doesn't match the id of the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		otherTodo := todo
+		otherTodo.Id = uuid.New().String()
+		setRequest(t, gin_context, otherTodo)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrIdMismatch, http.StatusBadRequest)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When required fields are not present in the web request body", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		invalidTodo := todo
+		invalidTodo.Description = ""
+		setRequest(t, gin_context, invalidTodo)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("Todo not found", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), updateOf(todo), token.UID, int64(3)).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When the body changes the comment count", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		counted := todo
+		counted.CommentCount = 5
+		setRequest(t, gin_context, counted)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todo.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todo.Id, token.UID).Return(&todo, nil)
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrReadOnlyField, http.StatusBadRequest)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When invalid id is sent as a path parameter in the url", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(t, gin_context, todo)
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return uuid.Nil, common.ErrError
+		}
+		todoRepositoryMock.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, nil, nowMock)
+		updateById(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		updateById := UpdateById(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		updateById(gin_context)
+	})
+}
+
 func TestDelete(t *testing.T) {
 	t.Run("Good case", func(t *testing.T) {
 		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
@@ -400,9 +1231,11 @@ func TestDelete(t *testing.T) {
 			return todoId, nil
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = &http.Request{Header: map[string][]string{IfMatchHeader: {`"5"`}}}
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Delete(todoId.String(), token.UID).Return(nil)
-		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), todoId.String(), token.UID, int64(5), now).Return(nil)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
 		delete(gin_context)
 		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
 		assert.Empty(t, http_recorder.Body.Bytes())
@@ -415,10 +1248,11 @@ func TestDelete(t *testing.T) {
 			return uuid.Nil, common.ErrError
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: "oehwegiuf"})
+		gin_context.Request = &http.Request{Header: map[string][]string{IfMatchHeader: {`"5"`}}}
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Delete(gomock.Any(), token.UID).Times(0)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), gomock.Any(), token.UID, gomock.Any(), gomock.Any()).Times(0)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
-		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
 		delete(gin_context)
 	})
 
@@ -430,10 +1264,62 @@ func TestDelete(t *testing.T) {
 			return todoId, nil
 		}
 		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = &http.Request{Header: map[string][]string{IfMatchHeader: {`"5"`}}}
 		gin_context.Set(middleware.AuthToken, token)
-		todoRepositoryMock.EXPECT().Delete(todoId.String(), token.UID).Return(common.ErrError)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), todoId.String(), token.UID, int64(5), now).Return(common.ErrError)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
-		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		delete(gin_context)
+	})
+
+	t.Run("Todo not found", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = &http.Request{Header: map[string][]string{IfMatchHeader: {`"5"`}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), todoId.String(), token.UID, int64(5), now).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		delete(gin_context)
+	})
+
+	t.Run("When If-Match is missing", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = &http.Request{Header: map[string][]string{}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrIfMatchRequired, http.StatusPreconditionRequired)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		delete(gin_context)
+	})
+
+	t.Run("When the todo has another version", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = &http.Request{Header: map[string][]string{IfMatchHeader: {`"5"`}}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().Delete(gomock.Any(), todoId.String(), token.UID, int64(5), now).Return(repository.ErrVersionMismatch)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
 		delete(gin_context)
 	})
 
@@ -442,23 +1328,62 @@ func TestDelete(t *testing.T) {
 		token := &auth.Token{UID: "oiwhbegfwh"}
 		gin_context.Set(middleware.AuthToken, token)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
-		delete := Delete(todoRepositoryMock, errorHandlerMock, nil)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, nil, nowMock)
 		delete(gin_context)
 	})
 
 	t.Run("When there is no auth token in the web context", func(t *testing.T) {
 		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
 		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
-		delete := Delete(todoRepositoryMock, errorHandlerMock, nil)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, nil, nowMock)
+		delete(gin_context)
+	})
+
+	t.Run("When the query times out", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Request = httptest.NewRequest(http.MethodDelete, "/todos/"+todoId.String(), nil)
+		gin_context.Request.Header.Set(IfMatchHeader, `"5"`)
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().Delete(gin_context.Request.Context(), todoId.String(), token.UID, int64(5), now).
+			Return(repository.ErrQueryTimeout)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrQueryTimeout, http.StatusGatewayTimeout)
+		delete := Delete(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
 		delete(gin_context)
 	})
 }
 
+var defaultFilter = model.TodoFilter{Sort: model.SortByCreatedAt, Order: model.OrderDesc, TagMatch: model.TagMatchAny}
+
+var now, _ = time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+
+func nowMock() time.Time {
+	return now
+}
+
+// updateOf is the todo that is stored by an update whose body is todo.
+func updateOf(todo model.Todo) *model.Todo {
+	return &model.Todo{Id: todo.Id, Title: todo.Title, Description: todo.Description, Done: todo.Done, UpdatedAt: now}
+}
+
 func createMocks(t *testing.T) (*common.MockTodoRepository, *gin.Context, *httptest.ResponseRecorder, *common.MockErrorHandler) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
 	mockCtrl := gomock.NewController(t)
 	http_recorder := httptest.NewRecorder()
 	gin_context, _ := gin.CreateTestContext(http_recorder)
+	gin_context.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 	return common.NewMockTodoRepository(mockCtrl), gin_context, http_recorder, common.NewMockErrorHandler(mockCtrl)
 }
+
+// ownerAccess is the access of the user of userId to a todo or a list of
+// their own.
+func ownerAccess(userId string) *model.Access {
+	return &model.Access{OwnerId: userId, Role: model.RoleOwner}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetLists lists the lists of the user with their inbox first, followed by
+// the lists that other users share with them. Archived lists are only listed
+// with ?archived=true.
+func GetLists(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			token := token.(*auth.Token)
+			lists, err := todoRepository.GetLists(ctx.Request.Context(), token.UID, ctx.Query("archived") == "true")
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, lists)
+			}
+		}
+	}
+}
+
+// GetList answers with a list of the user or a list that is shared with them,
+// which then comes with its owner and the role of the user.
+func GetList(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := listAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			if list, err := todoRepository.GetList(ctx.Request.Context(), ctx.Param("id"), access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, listStatusOf(err))
+			} else {
+				if !access.IsOwner() {
+					list.OwnerId, list.Role = access.OwnerId, access.Role
+				}
+				ctx.JSON(http.StatusOK, list)
+			}
+		}
+	}
+}
+
+// CreateList stores a new list with an id from newId and the time from now.
+func CreateList(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	newId func() (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			var request model.CreateListRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			id, err := newId()
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				return
+			}
+			list := request.List(id.String(), now().UTC())
+			token := token.(*auth.Token)
+			if err := todoRepository.CreateList(ctx.Request.Context(), &list, token.UID); err != nil {
+				errorHandler.HandleAppError(ctx, err, listStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, list)
+			}
+		}
+	}
+}
+
+// PatchList renames, archives or unarchives a list at the time from now, and
+// answers with the list after the change. Only the owner changes a list.
+func PatchList(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			var changes model.ListChanges
+			if err := ctx.ShouldBindJSON(&changes); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			token := token.(*auth.Token)
+			id := ctx.Param("id")
+			if _, code, err := listAccessOf(ctx, todoRepository, id, token.UID, ownPermission); err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			changes.UpdatedAt = now().UTC()
+			if err := todoRepository.PatchList(ctx.Request.Context(), id, token.UID, changes); err != nil {
+				errorHandler.HandleAppError(ctx, err, listStatusOf(err))
+			} else if list, err := todoRepository.GetList(ctx.Request.Context(), id, token.UID); err != nil {
+				errorHandler.HandleAppError(ctx, err, listStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, list)
+			}
+		}
+	}
+}
+
+// DeleteList deletes a list, which only its owner does. Its todos move to the
+// inbox, or are deleted with it with ?cascade=true.
+func DeleteList(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if _, code, err := listAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			ownPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			token := token.(*auth.Token)
+			err := todoRepository.DeleteList(ctx.Request.Context(), ctx.Param("id"), token.UID, ctx.Query("cascade") == "true")
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, listStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusNoContent, gin.H{})
+			}
+		}
+	}
+}
+
+// GetListTodos pages through the todos of a list of the user or of a list
+// that is shared with them, with the query parameters of GET /todos.
+func GetListTodos(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if filter, err := todoFilterOf(ctx, now); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if pageRequest, err := pageRequestOf(ctx); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := listAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			filter.ListId = ctx.Param("id")
+			if page, err := todoRepository.GetPage(ctx.Request.Context(), access.OwnerId, filter, pageRequest); err != nil {
+				if err == repository.ErrInvalidFilter {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
+			} else {
+				setLinkHeader(ctx, page.Next, page.Prev, pageRequest.Limit)
+				ctx.JSON(http.StatusOK, page.Todos)
+			}
+		}
+	}
+}
+
+func listStatusOf(err error) int {
+	if err == repository.ErrInvalidList {
+		return http.StatusBadRequest
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else if err == repository.ErrInboxList {
+		return http.StatusConflict
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setListRequest(gin_context *gin.Context, method string, target string, id string, body string, token *auth.Token) {
+	gin_context.Request = httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	gin_context.Request.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: id})
+	}
+	gin_context.Set(middleware.AuthToken, token)
+}
+
+func TestGetLists(t *testing.T) {
+	token := &auth.Token{UID: "oewhgwe"}
+
+	t.Run("Good case", func(t *testing.T) {
+		for target, includeArchived := range map[string]bool{"/lists": false, "/lists?archived=true": true} {
+			todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodGet, target, "", "", token)
+			lists := []model.List{{Id: uuid.New().String(), Name: model.InboxName, Inbox: true, CreatedAt: now, UpdatedAt: now}}
+			todoRepositoryMock.EXPECT().GetLists(gomock.Any(), token.UID, includeArchived).Return(lists, nil)
+			getLists := GetLists(todoRepositoryMock, errorHandlerMock)
+			getLists(gin_context)
+			assert.Equal(t, http.StatusOK, http_recorder.Code)
+			var got []model.List
+			err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, lists, got)
+		}
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists", "", "", token)
+		todoRepositoryMock.EXPECT().GetLists(gomock.Any(), token.UID, false).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getLists := GetLists(todoRepositoryMock, errorHandlerMock)
+		getLists(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getLists := GetLists(todoRepositoryMock, errorHandlerMock)
+		getLists(gin_context)
+	})
+}
+
+func TestGetList(t *testing.T) {
+	token := &auth.Token{UID: "wegwhwe"}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		list := model.List{Id: uuid.New().String(), Name: "work", CreatedAt: now, UpdatedAt: now}
+		setListRequest(gin_context, http.MethodGet, "/lists/"+list.Id, list.Id, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), list.Id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), list.Id, token.UID).Return(&list, nil)
+		getList := GetList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getList(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.List
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, list, got)
+	})
+
+	t.Run("When the id is not a uuid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/abc", "abc", "", token)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getList := GetList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getList(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, status := range map[error]int{
+			repository.ErrNotFound: http.StatusNotFound,
+			common.ErrError:        http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			id := uuid.New().String()
+			setListRequest(gin_context, http.MethodGet, "/lists/"+id, id, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetList(gomock.Any(), id, token.UID).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			getList := GetList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getList(gin_context)
+		}
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/abc", "abc", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		getList := GetList(todoRepositoryMock, errorHandlerMock, nil)
+		getList(gin_context)
+	})
+}
+
+func TestCreateList(t *testing.T) {
+	token := &auth.Token{UID: "bweohgwe"}
+	listId := uuid.New()
+	newIdMock := func() (uuid.UUID, error) { return listId, nil }
+	nowMock := func() time.Time { return now }
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/lists", "", `{"name": "work"}`, token)
+		list := model.List{Id: listId.String(), Name: "work", CreatedAt: now, UpdatedAt: now}
+		todoRepositoryMock.EXPECT().CreateList(gomock.Any(), &list, token.UID).Return(nil)
+		createList := CreateList(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createList(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.List
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, list, got)
+	})
+
+	t.Run("When the body has no name", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/lists", "", `{}`, token)
+		todoRepositoryMock.EXPECT().CreateList(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		createList := CreateList(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createList(gin_context)
+	})
+
+	t.Run("When newId returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/lists", "", `{"name": "work"}`, token)
+		todoRepositoryMock.EXPECT().CreateList(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createList := CreateList(todoRepositoryMock, errorHandlerMock,
+			func() (uuid.UUID, error) { return uuid.Nil, common.ErrError }, nowMock)
+		createList(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/lists", "", `{"name": "work"}`, token)
+		todoRepositoryMock.EXPECT().CreateList(gomock.Any(), gomock.Any(), token.UID).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createList := CreateList(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createList(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		createList := CreateList(todoRepositoryMock, errorHandlerMock, newIdMock, nowMock)
+		createList(gin_context)
+	})
+}
+
+func TestPatchList(t *testing.T) {
+	token := &auth.Token{UID: "hwoeigwe"}
+	nowMock := func() time.Time { return now }
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodPatch, "/lists/"+id, id, `{"name": "office", "archived": true}`, token)
+		name, archived := "office", true
+		list := model.List{Id: id, Name: name, CreatedAt: now, UpdatedAt: now, ArchivedAt: &now}
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+		gomock.InOrder(
+			todoRepositoryMock.EXPECT().PatchList(gomock.Any(), id, token.UID,
+				model.ListChanges{Name: &name, Archived: &archived, UpdatedAt: now}).Return(nil),
+			todoRepositoryMock.EXPECT().GetList(gomock.Any(), id, token.UID).Return(&list, nil),
+		)
+		patchList := PatchList(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patchList(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.List
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, list.Name, got.Name)
+		assert.True(t, got.ArchivedAt.Equal(now))
+	})
+
+	t.Run("When the name is empty", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodPatch, "/lists/"+id, id, `{"name": ""}`, token)
+		todoRepositoryMock.EXPECT().PatchList(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		patchList := PatchList(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patchList(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, status := range map[error]int{
+			repository.ErrInvalidList: http.StatusBadRequest,
+			repository.ErrNotFound:    http.StatusNotFound,
+			repository.ErrInboxList:   http.StatusConflict,
+			common.ErrError:           http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			id := uuid.New().String()
+			setListRequest(gin_context, http.MethodPatch, "/lists/"+id, id, `{"archived": false}`, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().PatchList(gomock.Any(), id, token.UID, gomock.Any()).Return(err)
+			todoRepositoryMock.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			patchList := PatchList(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			patchList(gin_context)
+		}
+	})
+}
+
+func TestDeleteList(t *testing.T) {
+	token := &auth.Token{UID: "ewhgoweh"}
+
+	t.Run("Good case", func(t *testing.T) {
+		for query, cascade := range map[string]bool{"": false, "?cascade=true": true} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			id := uuid.New().String()
+			setListRequest(gin_context, http.MethodDelete, "/lists/"+id+query, id, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().DeleteList(gomock.Any(), id, token.UID, cascade).Return(nil)
+			deleteList := DeleteList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteList(gin_context)
+			assert.Equal(t, http.StatusNoContent, gin_context.Writer.Status())
+		}
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, status := range map[error]int{
+			repository.ErrNotFound:  http.StatusNotFound,
+			repository.ErrInboxList: http.StatusConflict,
+			common.ErrError:         http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			id := uuid.New().String()
+			setListRequest(gin_context, http.MethodDelete, "/lists/"+id, id, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().DeleteList(gomock.Any(), id, token.UID, false).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			deleteList := DeleteList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteList(gin_context)
+		}
+	})
+
+	t.Run("When the id is not a uuid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodDelete, "/lists/abc", "abc", "", token)
+		todoRepositoryMock.EXPECT().DeleteList(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		deleteList := DeleteList(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteList(gin_context)
+	})
+}
+
+func TestGetListTodos(t *testing.T) {
+	token := &auth.Token{UID: "gwehoweg"}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/lists/"+id+"/todos", id, "", token)
+		done := false
+		todos := []model.Todo{{Id: uuid.New().String(), Title: "title1", Done: &done, CreatedAt: now, UpdatedAt: now,
+			Tags: []string{}, ListId: id}}
+		filter := defaultFilter
+		filter.ListId = id
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), token.UID, filter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: todos}, nil)
+		getListTodos := GetListTodos(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		getListTodos(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, todos, got)
+	})
+
+	t.Run("When the list doesn't exist", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/lists/"+id+"/todos", id, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), id, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		getListTodos := GetListTodos(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		getListTodos(gin_context)
+	})
+
+	t.Run("When there is an unknown query parameter", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/lists/"+id+"/todos?colour=red", id, "", token)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getListTodos := GetListTodos(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		getListTodos(gin_context)
+	})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrUnknownEmail error = errors.New("there is no user with that email")
+
+// GetMemberships lists the users that a list is shared with by the time they
+// were invited, for its owner and for its members alike.
+func GetMemberships(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := listAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			memberships, err := todoRepository.GetMemberships(ctx.Request.Context(), ctx.Param("id"), access.OwnerId)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, membershipStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, memberships)
+			}
+		}
+	}
+}
+
+// CreateMembership shares a list of the user with another user, whom the
+// request names by their id or by the email that authClient knows them by, at
+// the time from now.
+func CreateMembership(todoRepository common.TodoRepository, authClient common.AuthClient,
+	errorHandler common.ErrorHandler, parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if authClient == nil {
+			errorHandler.HandleAppError(ctx, middleware.ErrAuthClientIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			var request model.CreateMembershipRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			listId := ctx.Param("id")
+			access, code, err := listAccessOf(ctx, todoRepository, listId, token.(*auth.Token).UID, ownPermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			memberId := request.UserId
+			if request.Email != "" {
+				user, err := authClient.GetUserByEmail(ctx.Request.Context(), request.Email)
+				if auth.IsUserNotFound(err) {
+					errorHandler.HandleAppError(ctx, ErrUnknownEmail, http.StatusBadRequest)
+					return
+				} else if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+					return
+				}
+				memberId = user.UID
+			}
+			membership := request.Membership(listId, memberId, now().UTC())
+			if err := todoRepository.CreateMembership(ctx.Request.Context(), &membership, access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, membershipStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, membership)
+			}
+		}
+	}
+}
+
+// PatchMembership changes the role of a member of a list of the user at the
+// time from now, and answers with the membership after the change.
+func PatchMembership(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			var changes model.MembershipChanges
+			if err := ctx.ShouldBindJSON(&changes); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			listId, memberId := ctx.Param("id"), ctx.Param("userId")
+			access, code, err := listAccessOf(ctx, todoRepository, listId, token.(*auth.Token).UID, ownPermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			changes.UpdatedAt = now().UTC()
+			if err := todoRepository.UpdateMembership(ctx.Request.Context(), listId, access.OwnerId, memberId,
+				changes); err != nil {
+				errorHandler.HandleAppError(ctx, err, membershipStatusOf(err))
+			} else if memberships, err := todoRepository.GetMemberships(ctx.Request.Context(), listId,
+				access.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, membershipStatusOf(err))
+			} else if membership, ok := membershipOf(memberships, memberId); !ok {
+				errorHandler.HandleAppError(ctx, repository.ErrNotFound, http.StatusNotFound)
+			} else {
+				ctx.JSON(http.StatusOK, membership)
+			}
+		}
+	}
+}
+
+// DeleteMembership stops sharing a list with a member. The owner removes any
+// member and a member only removes themselves.
+func DeleteMembership(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			token := token.(*auth.Token)
+			listId, memberId := ctx.Param("id"), ctx.Param("userId")
+			needed := ownPermission
+			if memberId == token.UID {
+				needed = readPermission
+			}
+			access, code, err := listAccessOf(ctx, todoRepository, listId, token.UID, needed)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			if err := todoRepository.DeleteMembership(ctx.Request.Context(), listId, access.OwnerId, memberId); err != nil {
+				errorHandler.HandleAppError(ctx, err, membershipStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusNoContent, gin.H{})
+			}
+		}
+	}
+}
+
+func membershipOf(memberships []model.Membership, memberId string) (model.Membership, bool) {
+	for _, membership := range memberships {
+		if membership.UserId == memberId {
+			return membership, true
+		}
+	}
+	return model.Membership{}, false
+}
+
+func membershipStatusOf(err error) int {
+	if err == repository.ErrInvalidMembership {
+		return http.StatusBadRequest
+	} else if err == repository.ErrDuplicateMembership || err == repository.ErrInboxList {
+		return http.StatusConflict
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
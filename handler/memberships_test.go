@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setMembershipRequest(gin_context *gin.Context, method string, listId string, memberId string, body string,
+	token *auth.Token) {
+	target := "/lists/" + listId + "/members"
+	if memberId != "" {
+		target += "/" + memberId
+	}
+	setListRequest(gin_context, method, target, listId, body, token)
+	if memberId != "" {
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "userId", Value: memberId})
+	}
+}
+
+func TestGetMemberships(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	ownerId, listId := "wehgowe", uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodGet, listId, "", "", token)
+		memberships := []model.Membership{{ListId: listId, UserId: token.UID, Role: model.RoleViewer, CreatedAt: now,
+			UpdatedAt: now}}
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: ownerId, ListId: listId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().GetMemberships(gomock.Any(), listId, ownerId).Return(memberships, nil)
+		getMemberships := GetMemberships(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getMemberships(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Membership
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, memberships, got)
+	})
+
+	t.Run("When the user has no access to the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodGet, listId, "", "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().GetMemberships(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		getMemberships := GetMemberships(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getMemberships(gin_context)
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodGet, "wrong", "", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getMemberships := GetMemberships(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getMemberships(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getMemberships := GetMemberships(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getMemberships(gin_context)
+	})
+}
+
+func TestCreateMembership(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	listId := uuid.New().String()
+	createMembershipMocks := func(t *testing.T) (*common.MockTodoRepository, *common.MockAuthClient, *gin.Context,
+		*common.MockErrorHandler) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		return todoRepositoryMock, common.NewMockAuthClient(gomock.NewController(t)), gin_context, errorHandlerMock
+	}
+
+	t.Run("Good case", func(t *testing.T) {
+		for body, expectEmail := range map[string]bool{
+			`{"userId": "wehgowe", "role": "editor"}`:           false,
+			`{"email": "member@example.com", "role": "editor"}`: true,
+		} {
+			todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+			authClientMock := common.NewMockAuthClient(gomock.NewController(t))
+			setMembershipRequest(gin_context, http.MethodPost, listId, "", body, token)
+			membership := model.Membership{ListId: listId, UserId: "wehgowe", Role: model.RoleEditor, CreatedAt: now,
+				UpdatedAt: now}
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			if expectEmail {
+				authClientMock.EXPECT().GetUserByEmail(gomock.Any(), "member@example.com").
+					Return(&auth.UserRecord{UserInfo: &auth.UserInfo{UID: "wehgowe"}}, nil)
+			}
+			todoRepositoryMock.EXPECT().CreateMembership(gomock.Any(), &membership, token.UID).Return(nil)
+			createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+			createMembership(gin_context)
+			assert.Equal(t, http.StatusOK, http_recorder.Code)
+			var got model.Membership
+			err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, membership, got)
+		}
+	})
+
+	t.Run("When the body is not valid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"role": "editor"}`,
+			`{"userId": "wehgowe", "email": "member@example.com", "role": "editor"}`,
+			`{"userId": "wehgowe", "role": "owner"}`,
+			`{"email": "member", "role": "viewer"}`,
+			`{"userId": "wehgowe"}`,
+		} {
+			todoRepositoryMock, authClientMock, gin_context, errorHandlerMock := createMembershipMocks(t)
+			setMembershipRequest(gin_context, http.MethodPost, listId, "", body, token)
+			todoRepositoryMock.EXPECT().CreateMembership(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+			createMembership(gin_context)
+		}
+	})
+
+	t.Run("When a member shares the list", func(t *testing.T) {
+		todoRepositoryMock, authClientMock, gin_context, errorHandlerMock := createMembershipMocks(t)
+		setMembershipRequest(gin_context, http.MethodPost, listId, "", `{"userId": "oewhgwe", "role": "viewer"}`, token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().CreateMembership(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+		createMembership(gin_context)
+	})
+
+	t.Run("When the email can't be looked up", func(t *testing.T) {
+		todoRepositoryMock, authClientMock, gin_context, errorHandlerMock := createMembershipMocks(t)
+		setMembershipRequest(gin_context, http.MethodPost, listId, "", `{"email": "member@example.com", "role": "viewer"}`,
+			token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+		authClientMock.EXPECT().GetUserByEmail(gomock.Any(), "member@example.com").Return(nil, common.ErrError)
+		todoRepositoryMock.EXPECT().CreateMembership(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+		createMembership(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrInvalidMembership:   http.StatusBadRequest,
+			repository.ErrDuplicateMembership: http.StatusConflict,
+			repository.ErrInboxList:           http.StatusConflict,
+			repository.ErrNotFound:            http.StatusNotFound,
+			common.ErrError:                   http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, authClientMock, gin_context, errorHandlerMock := createMembershipMocks(t)
+			setMembershipRequest(gin_context, http.MethodPost, listId, "", `{"userId": "wehgowe", "role": "viewer"}`, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().CreateMembership(gomock.Any(), gomock.Any(), token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+			createMembership(gin_context)
+		}
+	})
+
+	t.Run("When authClient is nil", func(t *testing.T) {
+		todoRepositoryMock, _, gin_context, errorHandlerMock := createMembershipMocks(t)
+		setMembershipRequest(gin_context, http.MethodPost, listId, "", `{"userId": "wehgowe", "role": "viewer"}`, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrAuthClientIsNil, http.StatusInternalServerError)
+		createMembership := CreateMembership(todoRepositoryMock, nil, errorHandlerMock, uuid.Parse, nowMock)
+		createMembership(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, authClientMock, gin_context, errorHandlerMock := createMembershipMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		createMembership := CreateMembership(todoRepositoryMock, authClientMock, errorHandlerMock, uuid.Parse, nowMock)
+		createMembership(gin_context)
+	})
+}
+
+func TestPatchMembership(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	listId := uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodPatch, listId, "wehgowe", `{"role": "viewer"}`, token)
+		membership := model.Membership{ListId: listId, UserId: "wehgowe", Role: model.RoleViewer, CreatedAt: now,
+			UpdatedAt: now}
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+		gomock.InOrder(
+			todoRepositoryMock.EXPECT().UpdateMembership(gomock.Any(), listId, token.UID, "wehgowe",
+				model.MembershipChanges{Role: model.RoleViewer, UpdatedAt: now}).Return(nil),
+			todoRepositoryMock.EXPECT().GetMemberships(gomock.Any(), listId, token.UID).Return([]model.Membership{membership}, nil),
+		)
+		patchMembership := PatchMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patchMembership(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.Membership
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, membership, got)
+	})
+
+	t.Run("When the role is not valid", func(t *testing.T) {
+		for _, body := range []string{`{"role": "owner"}`, `{}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setMembershipRequest(gin_context, http.MethodPatch, listId, "wehgowe", body, token)
+			todoRepositoryMock.EXPECT().UpdateMembership(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+				gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			patchMembership := PatchMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			patchMembership(gin_context)
+		}
+	})
+
+	t.Run("When a member changes a role", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodPatch, listId, token.UID, `{"role": "editor"}`, token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().UpdateMembership(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		patchMembership := PatchMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		patchMembership(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound:     http.StatusNotFound,
+			repository.ErrQueryTimeout: http.StatusGatewayTimeout,
+			common.ErrError:            http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setMembershipRequest(gin_context, http.MethodPatch, listId, "wehgowe", `{"role": "viewer"}`, token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().UpdateMembership(gomock.Any(), listId, token.UID, "wehgowe", gomock.Any()).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			patchMembership := PatchMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			patchMembership(gin_context)
+		}
+	})
+}
+
+func TestDeleteMembership(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	listId := uuid.New().String()
+
+	t.Run("Good case: the owner removes a member", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodDelete, listId, "wehgowe", "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().DeleteMembership(gomock.Any(), listId, token.UID, "wehgowe").Return(nil)
+		deleteMembership := DeleteMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteMembership(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+	})
+
+	t.Run("Good case: a member leaves the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodDelete, listId, token.UID, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().DeleteMembership(gomock.Any(), listId, "wehgowe", token.UID).Return(nil)
+		deleteMembership := DeleteMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteMembership(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+	})
+
+	t.Run("When a member removes another member", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodDelete, listId, "oewhgwe", "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().DeleteMembership(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		deleteMembership := DeleteMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteMembership(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound: http.StatusNotFound,
+			common.ErrError:        http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setMembershipRequest(gin_context, http.MethodDelete, listId, "wehgowe", "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().DeleteMembership(gomock.Any(), listId, token.UID, "wehgowe").Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			deleteMembership := DeleteMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteMembership(gin_context)
+		}
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setMembershipRequest(gin_context, http.MethodDelete, listId, "wehgowe", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		deleteMembership := DeleteMembership(todoRepositoryMock, errorHandlerMock, nil)
+		deleteMembership(gin_context)
+	})
+}
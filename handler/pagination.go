@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/gin-gonic/gin"
+)
+
+const LinkHeader string = "Link"
+const limitParam string = "limit"
+const cursorParam string = "cursor"
+
+// pageRequestOf reads the limit and cursor query parameters. Without a
+// limit, a page holds model.DefaultPageLimit items.
+func pageRequestOf(ctx *gin.Context) (model.PageRequest, error) {
+	limitValue, hasLimit := ctx.GetQuery(limitParam)
+	cursorValue, hasCursor := ctx.GetQuery(cursorParam)
+	pageRequest := model.PageRequest{Limit: model.DefaultPageLimit}
+	if hasLimit {
+		limit, err := parseLimit(limitValue)
+		if err != nil {
+			return pageRequest, err
+		}
+		pageRequest.Limit = limit
+	}
+	if hasCursor {
+		cursor, err := model.DecodeCursor(cursorValue)
+		if err != nil {
+			return pageRequest, err
+		}
+		pageRequest.Cursor = cursor
+	}
+	return pageRequest, nil
+}
+
+// limitOf reads the limit query parameter, or returns defaultLimit when it
+// isn't there.
+func limitOf(ctx *gin.Context, defaultLimit int) (int, error) {
+	if limitValue, ok := ctx.GetQuery(limitParam); ok {
+		return parseLimit(limitValue)
+	}
+	return defaultLimit, nil
+}
+
+func parseLimit(limitValue string) (int, error) {
+	limit, err := strconv.Atoi(limitValue)
+	if err != nil || limit < 1 || limit > model.MaxPageLimit {
+		return 0, model.ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+// setLinkHeader points the client at the next and previous pages, when
+// there are any, and at the first page, with the same query parameters as
+// the current request.
+func setLinkHeader(ctx *gin.Context, next *model.Cursor, prev *model.Cursor, limit int) {
+	links := []string{}
+	if next != nil {
+		links = append(links, "<"+pageURL(ctx.Request.URL, next, limit)+`>; rel="next"`)
+	}
+	if prev != nil {
+		links = append(links, "<"+pageURL(ctx.Request.URL, prev, limit)+`>; rel="prev"`)
+	}
+	links = append(links, "<"+pageURL(ctx.Request.URL, nil, limit)+`>; rel="first"`)
+	ctx.Header(LinkHeader, strings.Join(links, ", "))
+}
+
+// pageURL is the URL of the page that starts at cursor, or of the first page
+// when cursor is nil.
+func pageURL(requestURL *url.URL, cursor *model.Cursor, limit int) string {
+	query := requestURL.Query()
+	query.Set(limitParam, strconv.Itoa(limit))
+	query.Del(cursorParam)
+	if cursor != nil {
+		query.Set(cursorParam, cursor.Encode())
+	}
+	pageURL := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return pageURL.String()
+}
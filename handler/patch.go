@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const MergePatchContentType string = "application/merge-patch+json"
+const JSONPatchContentType string = "application/json-patch+json"
+
+var ErrUnsupportedPatchType error = errors.New(`the Content-Type of a PATCH must be "application/merge-patch+json" or "application/json-patch+json"`)
+var ErrReadOnlyField error = errors.New("only the title, description, done, tags, list, due date, time zone, priority and recurrence of a todo can be changed")
+
+// Patch applies an RFC 7396 merge patch or an RFC 6902 JSON patch, chosen by
+// the Content-Type, on top of the stored todo and saves the changed fields.
+// The If-Match header must name the ETag of the stored todo or be *.
+func Patch(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			if parse != nil {
+				id := ctx.Param("id")
+				_, err := parse(id)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+					return
+				}
+				contentType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+				if contentType != MergePatchContentType && contentType != JSONPatchContentType {
+					errorHandler.HandleAppError(ctx, ErrUnsupportedPatchType, http.StatusUnsupportedMediaType)
+					return
+				}
+				version, code, err := expectedVersionOf(ctx)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				patchDocument, err := io.ReadAll(ctx.Request.Body)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+					return
+				}
+				token := token.(*auth.Token)
+				access, code, err := todoAccessOf(ctx, todoRepository, id, token.UID, writePermission)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				todo, err := todoRepository.GetById(ctx.Request.Context(), id, access.OwnerId)
+				if err != nil {
+					if err == repository.ErrNotFound {
+						errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+					} else {
+						errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+					}
+					return
+				}
+				if version != repository.AnyVersion && version != todo.Version {
+					errorHandler.HandleAppError(ctx, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+					return
+				}
+				patched, code, err := applyPatch(*todo, contentType, patchDocument)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, code)
+					return
+				}
+				changes := model.Diff(*todo, *patched)
+				if changes.ListId != nil && !access.IsOwner() {
+					errorHandler.HandleAppError(ctx, ErrForbidden, http.StatusForbidden)
+					return
+				}
+				if !changes.IsEmpty() {
+					changes.UpdatedAt = now().UTC()
+					patched.Touch(changes.UpdatedAt)
+					patched.Version++
+				}
+				if err := todoRepository.Patch(ctx.Request.Context(), id, access.OwnerId, changes, todo.Version); err != nil {
+					errorHandler.HandleAppError(ctx, err, writeStatusOf(err))
+				} else {
+					ctx.Header(ETagHeader, etagOf(patched.Version))
+					ctx.JSON(http.StatusOK, patched)
+				}
+			} else {
+				errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+			}
+		}
+	}
+}
+
+// applyPatch returns the todo after the patch, or the error and the status
+// code that tells why the patch can't be applied.
+func applyPatch(todo model.Todo, contentType string, patchDocument []byte) (*model.Todo, int, error) {
+	original, err := json.Marshal(todo)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	var patchedDocument []byte
+	if contentType == MergePatchContentType {
+		if !json.Valid(patchDocument) {
+			return nil, http.StatusBadRequest, jsonpatch.ErrBadJSONPatch
+		}
+		patchedDocument, err = jsonpatch.MergePatch(original, patchDocument)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	} else {
+		patch, err := jsonpatch.DecodePatch(patchDocument)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		patchedDocument, err = patch.Apply(original)
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+	}
+	var patched model.Todo
+	if err := json.Unmarshal(patchedDocument, &patched); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	patched.Tags = model.NormalizeTags(patched.Tags)
+	patched.Recurrence = model.NormalizeRecurrence(patched.Recurrence)
+	if patched.Id != todo.Id || !patched.CreatedAt.Equal(todo.CreatedAt) || !patched.UpdatedAt.Equal(todo.UpdatedAt) ||
+		!sameTime(patched.CompletedAt, todo.CompletedAt) || patched.Version != todo.Version ||
+		!sameTime(patched.DeletedAt, todo.DeletedAt) || patched.ParentId != todo.ParentId ||
+		patched.Position != todo.Position || !sameProgress(patched.Progress, todo.Progress) || len(patched.Subtasks) != 0 ||
+		patched.CommentCount != todo.CommentCount {
+		return nil, http.StatusBadRequest, ErrReadOnlyField
+	}
+	if !model.IsValid(patched) {
+		return nil, http.StatusBadRequest, repository.ErrInvalidTodo
+	}
+	return &patched, http.StatusOK, nil
+}
+
+func sameProgress(a *model.Progress, b *model.Progress) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
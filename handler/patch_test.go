@@ -0,0 +1,333 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatch(t *testing.T) {
+	todoId := uuid.New()
+	token := &auth.Token{UID: "pwehgw"}
+	uUidParseMock := func(id string) (uuid.UUID, error) {
+		return todoId, nil
+	}
+	storedTodo := func() *model.Todo {
+		done := false
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		return &model.Todo{Id: todoId.String(), Title: "title1", Description: "description1", Done: &done,
+			CreatedAt: ti, UpdatedAt: ti, Version: 2, Tags: []string{}}
+	}
+	setRequest := func(gin_context *gin.Context, contentType string, body string) {
+		gin_context.Request = httptest.NewRequest(http.MethodPatch, "/todos/"+todoId.String(), bytes.NewBufferString(body))
+		gin_context.Request.Header.Set("Content-Type", contentType)
+		gin_context.Request.Header.Set(IfMatchHeader, `"2"`)
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+	}
+
+	t.Run("Good case: merge patch", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true, "description": "description1"}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		done := true
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, model.TodoChanges{Done: &done, UpdatedAt: now}, int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"3"`, http_recorder.Header().Get(ETagHeader))
+		var got model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := storedTodo()
+		expected.Done = &done
+		expected.UpdatedAt = now
+		expected.CompletedAt = &now
+		expected.Version = 3
+		assert.Equal(t, *expected, got)
+	})
+
+	t.Run("Good case: JSON patch", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, JSONPatchContentType+"; charset=utf-8",
+			`[{"op": "test", "path": "/title", "value": "title1"}, {"op": "replace", "path": "/title", "value": "title2"}]`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		title := "title2"
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, model.TodoChanges{Title: &title, UpdatedAt: now}, int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("Good case: the due date and the time zone", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"dueAt": "2022-10-01", "timezone": "Europe/Berlin"}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		dueAt := &model.Due{Time: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), DateOnly: true}
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID,
+			model.TodoChanges{Due: &model.DueChange{DueAt: dueAt, Timezone: "Europe/Berlin"}, UpdatedAt: now}, int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"dueAt":"2022-10-01","timezone":"Europe/Berlin"`)
+	})
+
+	t.Run("Good case: the priority", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"priority": "high"}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		priority := model.PriorityHigh
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID,
+			model.TodoChanges{Priority: &priority, UpdatedAt: now}, int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"priority":"high"`)
+	})
+
+	t.Run("Good case: the recurrence", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"dueAt": "2022-10-01", "recurrence": "freq=weekly;byday=sa"}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		dueAt := &model.Due{Time: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC), DateOnly: true}
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID,
+			model.TodoChanges{Due: &model.DueChange{DueAt: dueAt, Recurrence: "FREQ=WEEKLY;BYDAY=SA"}, UpdatedAt: now},
+			int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"recurrence":"FREQ=WEEKLY;BYDAY=SA"`)
+	})
+
+	t.Run("When the Content-Type is not a patch type", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, "application/json", `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrUnsupportedPatchType, http.StatusUnsupportedMediaType)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When the patch is malformed", func(t *testing.T) {
+		for contentType, body := range map[string]string{MergePatchContentType: `{"done": `,
+			JSONPatchContentType: `{"op": "replace"}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, contentType, body)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+			todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+			patch(gin_context)
+		}
+	})
+
+	t.Run("When the JSON patch can't be applied", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, JSONPatchContentType, `[{"op": "test", "path": "/title", "value": "other"}]`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusUnprocessableEntity)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When the patch changes a read only field", func(t *testing.T) {
+		for _, patchRequest := range []struct{ contentType, body string }{
+			{MergePatchContentType, `{"id": "` + uuid.New().String() + `"}`},
+			{MergePatchContentType, `{"updatedAt": "2000-01-01T00:00:00Z"}`},
+			{JSONPatchContentType, `[{"op": "replace", "path": "/createdAt", "value": "2000-01-01T00:00:00Z"}]`},
+			{JSONPatchContentType, `[{"op": "add", "path": "/completedAt", "value": "2000-01-01T00:00:00Z"}]`},
+			{MergePatchContentType, `{"version": 9}`},
+			{MergePatchContentType, `{"parentId": "` + uuid.New().String() + `"}`},
+			{MergePatchContentType, `{"progress": "1/1 done"}`},
+			{MergePatchContentType, `{"position": "0"}`},
+			{JSONPatchContentType, `[{"op": "add", "path": "/subtasks", "value": [{"title": "title2"}]}]`},
+			{MergePatchContentType, `{"commentCount": 5}`},
+			{JSONPatchContentType, `[{"op": "replace", "path": "/commentCount", "value": 5}]`},
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, patchRequest.contentType, patchRequest.body)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+			todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrReadOnlyField, http.StatusBadRequest)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+			patch(gin_context)
+		}
+	})
+
+	t.Run("Good case: nothing changed", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"title": "title1"}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, model.TodoChanges{}, int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"2"`, http_recorder.Header().Get(ETagHeader))
+		var got model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, *storedTodo(), got)
+	})
+
+	t.Run("When the patched todo is invalid", func(t *testing.T) {
+		for _, body := range []string{`{"title": ""}`, `{"done": null}`, `{"done": "yes"}`,
+			`{"dueAt": "tomorrow"}`, `{"timezone": "Mars/Olympus"}`, `{"priority": "urgent"}`, `{"priority": 2}`,
+			`{"recurrence": "FREQ=DAILY"}`, `{"dueAt": "2022-10-01", "recurrence": "every day"}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, MergePatchContentType, body)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+			todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+			patch(gin_context)
+		}
+	})
+
+	t.Run("Todo not found", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(nil, repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When TodoRepository.GetById returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When the todo is gone before it is patched", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, gomock.Any(), int64(2)).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("Good case: If-Match is *", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		gin_context.Request.Header.Set(IfMatchHeader, "*")
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, gomock.Any(), int64(2)).Return(nil)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `"3"`, http_recorder.Header().Get(ETagHeader))
+	})
+
+	t.Run("When If-Match is missing or invalid", func(t *testing.T) {
+		for ifMatch, code := range map[string]int{"": http.StatusPreconditionRequired, "2": http.StatusBadRequest,
+			`"2", "3"`: http.StatusBadRequest} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+			gin_context.Request.Header.Set(IfMatchHeader, ifMatch)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), code)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+			patch(gin_context)
+		}
+	})
+
+	t.Run("When If-Match names another version", func(t *testing.T) {
+		for _, ifMatch := range []string{`"1"`, `W/"2"`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+			gin_context.Request.Header.Set(IfMatchHeader, ifMatch)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil).MaxTimes(1)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil).MaxTimes(1)
+			todoRepositoryMock.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+			patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+			patch(gin_context)
+		}
+	})
+
+	t.Run("When the todo is changed before it is patched", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, gomock.Any(), int64(2)).Return(repository.ErrVersionMismatch)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrVersionMismatch, http.StatusPreconditionFailed)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When TodoRepository.Patch returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId.String(), token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId.String(), token.UID).Return(storedTodo(), nil)
+		todoRepositoryMock.EXPECT().Patch(gomock.Any(), todoId.String(), token.UID, gomock.Any(), int64(2)).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When invalid id is sent as a path parameter in the url", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, MergePatchContentType, `{"done": true}`)
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return uuid.Nil, common.ErrError
+		}
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, nil, nowMock)
+		patch(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		patch := Patch(todoRepositoryMock, errorHandlerMock, uUidParseMock, nowMock)
+		patch(gin_context)
+	})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Move puts a todo right before or right after another todo of the same
+// user, in the order that ?sort=position lists.
+func Move(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else {
+			id := ctx.Param("id")
+			var request model.MoveRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			if err := request.Validate(id); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+			token := token.(*auth.Token)
+			access, code, err := todoAccessOf(ctx, todoRepository, id, token.UID, writePermission)
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, code)
+				return
+			}
+			if !access.IsOwner() {
+				// A member only moves a todo next to another todo that they see.
+				anchor, _, err := todoAccessOf(ctx, todoRepository, request.Anchor(), token.UID, readPermission)
+				if err == repository.ErrNotFound || (err == nil && anchor.OwnerId != access.OwnerId) {
+					errorHandler.HandleAppError(ctx, repository.ErrAnchorNotFound, http.StatusBadRequest)
+					return
+				} else if err != nil {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+					return
+				}
+			}
+			err = todoRepository.Move(ctx.Request.Context(), id, access.OwnerId, request, now().UTC())
+			if err != nil {
+				if err == repository.ErrNotFound {
+					errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+				} else if err == repository.ErrAnchorNotFound || err == model.ErrInvalidMove {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
+			} else {
+				ctx.JSON(http.StatusNoContent, gin.H{})
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMove(t *testing.T) {
+	token := &auth.Token{UID: "oewhgwe"}
+	todoId, anchorId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		for body, move := range map[string]model.MoveRequest{
+			`{"before":"` + anchorId + `"}`: {Before: anchorId},
+			`{"after":"` + anchorId + `"}`:  {After: anchorId},
+		} {
+			todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, body, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().Move(gomock.Any(), todoId, token.UID, move, nowMock().UTC()).Return(nil)
+			moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			moveTodo(gin_context)
+			assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+			assert.Empty(t, http_recorder.Body.Bytes())
+		}
+	})
+
+	t.Run("When invalid todo id is sent as a path parameter in the url", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/oehwegiuf/move", "oehwegiuf", `{"before":"`+anchorId+`"}`, token)
+		todoRepositoryMock.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		moveTodo(gin_context)
+	})
+
+	t.Run("When the body is invalid", func(t *testing.T) {
+		for _, body := range []string{`{"before":"oehwegiuf"}`, `{"before":`, `[]`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, body, token)
+			todoRepositoryMock.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			moveTodo(gin_context)
+		}
+	})
+
+	t.Run("When the move is invalid", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"before":"` + anchorId + `","after":"` + anchorId + `"}`, `{"after":"` + todoId + `"}`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, body, token)
+			todoRepositoryMock.EXPECT().Move(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidMove, http.StatusBadRequest)
+			moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			moveTodo(gin_context)
+		}
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound:       http.StatusNotFound,
+			repository.ErrAnchorNotFound: http.StatusBadRequest,
+			repository.ErrQueryTimeout:   http.StatusGatewayTimeout,
+			common.ErrError:              http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, `{"before":"`+anchorId+`"}`, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().Move(gomock.Any(), todoId, token.UID, model.MoveRequest{Before: anchorId}, gomock.Any()).
+				Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+			moveTodo(gin_context)
+		}
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/move", todoId, `{"before":"`+anchorId+`"}`, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		moveTodo := Move(todoRepositoryMock, errorHandlerMock, nil, nowMock)
+		moveTodo(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		moveTodo := Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, nowMock)
+		moveTodo(gin_context)
+	})
+}
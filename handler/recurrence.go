@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const countParam string = "count"
+
+// GetOccurrences previews the due dates of the next ?count occurrences of a
+// todo after the one it is due at, which are none when it doesn't recur.
+func GetOccurrences(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if count, err := occurrenceCountOf(ctx); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			if todo, err := todoRepository.GetById(ctx.Request.Context(), ctx.Param("id"), access.OwnerId); err != nil {
+				if err == repository.ErrNotFound {
+					errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
+			} else {
+				ctx.JSON(http.StatusOK, todo.Occurrences(count))
+			}
+		}
+	}
+}
+
+// occurrenceCountOf reads the count query parameter, or returns
+// model.DefaultOccurrences when it isn't there.
+func occurrenceCountOf(ctx *gin.Context) (int, error) {
+	countValue, ok := ctx.GetQuery(countParam)
+	if !ok {
+		return model.DefaultOccurrences, nil
+	}
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count < 1 || count > model.MaxOccurrences {
+		return 0, model.ErrInvalidOccurrenceCount
+	}
+	return count, nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOccurrences(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	todoDone := false
+	id := uuid.New().String()
+	todo := model.Todo{Id: id, Title: "title1", Done: &todoDone, CreatedAt: now, UpdatedAt: now, Version: 1,
+		Tags: []string{}, DueAt: &model.Due{Time: time.Date(2022, 9, 21, 0, 0, 0, 0, time.UTC), DateOnly: true},
+		Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE"}
+
+	t.Run("Good case", func(t *testing.T) {
+		for url, expected := range map[string][]string{
+			"/todos/" + id + "/occurrences":         {"2022-09-26", "2022-09-28", "2022-10-03", "2022-10-05", "2022-10-10"},
+			"/todos/" + id + "/occurrences?count=2": {"2022-09-26", "2022-09-28"},
+		} {
+			todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodGet, url, id, "", token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), id, token.UID).Return(&todo, nil)
+			getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getOccurrences(gin_context)
+			assert.Equal(t, http.StatusOK, http_recorder.Code)
+			var got []string
+			err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, expected, got, url)
+		}
+	})
+
+	t.Run("Good case: a todo that doesn't recur has no occurrences", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/occurrences", id, "", token)
+		single := todo
+		single.Recurrence = ""
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), id, token.UID).Return(&single, nil)
+		getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getOccurrences(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, "[]", http_recorder.Body.String())
+	})
+
+	t.Run("When count is not valid", func(t *testing.T) {
+		for _, count := range []string{"0", "101", "two", ""} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/occurrences?count="+count, id, "", token)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidOccurrenceCount, http.StatusBadRequest)
+			getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getOccurrences(gin_context)
+		}
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/wrong/occurrences", "wrong", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getOccurrences(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound:     http.StatusNotFound,
+			repository.ErrQueryTimeout: http.StatusGatewayTimeout,
+			common.ErrError:            http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/occurrences", id, "", token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), id, token.UID).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getOccurrences(gin_context)
+		}
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/occurrences", id, "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, nil)
+		getOccurrences(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getOccurrences := GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getOccurrences(gin_context)
+	})
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// accessOf is todoAccessOf or listAccessOf, for the handlers of the share
+// links of todos and of lists alike.
+type accessOf func(ctx *gin.Context, todoRepository common.TodoRepository, id string, userId string,
+	needed permission) (*model.Access, int, error)
+
+// CreateTodoShareLink shares a todo of the user with anyone through a new
+// share link with a token from newToken, created at the time from now. The
+// link replaces the one the todo had.
+func CreateTodoShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), newToken func() (string, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		createShareLink(ctx, todoRepository, errorHandler, parse, newToken, now, todoAccessOf,
+			model.ShareTarget{TodoId: ctx.Param("id")})
+	}
+}
+
+// CreateListShareLink is CreateTodoShareLink for a list of the user.
+func CreateListShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), newToken func() (string, error), now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		createShareLink(ctx, todoRepository, errorHandler, parse, newToken, now, listAccessOf,
+			model.ShareTarget{ListId: ctx.Param("id")})
+	}
+}
+
+// GetTodoShareLink answers with the share link of a todo of the user, with
+// how many times it was opened.
+func GetTodoShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		getShareLink(ctx, todoRepository, errorHandler, parse, todoAccessOf, model.ShareTarget{TodoId: ctx.Param("id")})
+	}
+}
+
+// GetListShareLink is GetTodoShareLink for a list of the user.
+func GetListShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		getShareLink(ctx, todoRepository, errorHandler, parse, listAccessOf, model.ShareTarget{ListId: ctx.Param("id")})
+	}
+}
+
+// DeleteTodoShareLink revokes the share link of a todo of the user, whose
+// token then shows nothing.
+func DeleteTodoShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		deleteShareLink(ctx, todoRepository, errorHandler, parse, todoAccessOf, model.ShareTarget{TodoId: ctx.Param("id")})
+	}
+}
+
+// DeleteListShareLink is DeleteTodoShareLink for a list of the user.
+func DeleteListShareLink(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		deleteShareLink(ctx, todoRepository, errorHandler, parse, listAccessOf, model.ShareTarget{ListId: ctx.Param("id")})
+	}
+}
+
+// GetShared shows what the share link of :token shares to anyone, without
+// an auth token, and counts that the link was opened at the time from now: a
+// todo with the tree of its subtasks, or a list with the todos in it by
+// position, paged with ?limit and ?cursor.
+func GetShared(todoRepository common.TodoRepository, errorHandler common.ErrorHandler, now func() time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		pageRequest, err := pageRequestOf(ctx)
+		if err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			return
+		}
+		link, err := todoRepository.OpenShareLink(ctx.Request.Context(), ctx.Param("token"), now().UTC())
+		if err != nil {
+			errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+		} else if link.TodoId != "" {
+			todo, err := todoRepository.GetById(ctx.Request.Context(), link.TodoId, link.OwnerId)
+			if err == nil {
+				var subtasks []model.Todo
+				if subtasks, err = todoRepository.GetSubtasks(ctx.Request.Context(), link.TodoId, link.OwnerId, true); err == nil {
+					todo.Subtasks = model.NestSubtasks(todo.Id, subtasks)
+				}
+			}
+			if err != nil {
+				errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, model.SharedView{Todo: todo})
+			}
+		} else {
+			filter := model.TodoFilter{ListId: link.ListId, Sort: model.SortByPosition}.WithDefaults()
+			if list, err := todoRepository.GetList(ctx.Request.Context(), link.ListId, link.OwnerId); err != nil {
+				errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+			} else if page, err := todoRepository.GetPage(ctx.Request.Context(), link.OwnerId, filter, pageRequest); err != nil {
+				errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+			} else {
+				setLinkHeader(ctx, page.Next, page.Prev, pageRequest.Limit)
+				ctx.JSON(http.StatusOK, model.SharedView{List: list, Todos: page.Todos})
+			}
+		}
+	}
+}
+
+func createShareLink(ctx *gin.Context, todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), newToken func() (string, error), now func() time.Time, accessOf accessOf,
+	target model.ShareTarget) {
+	token, ok := ctx.Get(middleware.AuthToken)
+	if !ok {
+		errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+	} else if parse == nil {
+		errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+	} else if _, err := parse(ctx.Param("id")); err != nil {
+		errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+	} else {
+		var request model.CreateShareLinkRequest
+		if ctx.Request.ContentLength != 0 {
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				return
+			}
+		}
+		token := token.(*auth.Token)
+		if _, code, err := accessOf(ctx, todoRepository, ctx.Param("id"), token.UID, ownPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+			return
+		}
+		shareToken, err := newToken()
+		if err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusInternalServerError)
+			return
+		}
+		link, err := request.ShareLink(shareToken, target, now().UTC())
+		if err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if err := todoRepository.CreateShareLink(ctx.Request.Context(), &link, token.UID); err != nil {
+			errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+		} else {
+			ctx.JSON(http.StatusOK, link)
+		}
+	}
+}
+
+func getShareLink(ctx *gin.Context, todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), accessOf accessOf, target model.ShareTarget) {
+	token, ok := ctx.Get(middleware.AuthToken)
+	if !ok {
+		errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+	} else if parse == nil {
+		errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+	} else if _, err := parse(ctx.Param("id")); err != nil {
+		errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+	} else if _, code, err := accessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+		ownPermission); err != nil {
+		errorHandler.HandleAppError(ctx, err, code)
+	} else {
+		if link, err := todoRepository.GetShareLink(ctx.Request.Context(), target, token.(*auth.Token).UID); err != nil {
+			errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+		} else {
+			ctx.JSON(http.StatusOK, link)
+		}
+	}
+}
+
+func deleteShareLink(ctx *gin.Context, todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error), accessOf accessOf, target model.ShareTarget) {
+	token, ok := ctx.Get(middleware.AuthToken)
+	if !ok {
+		errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+	} else if parse == nil {
+		errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+	} else if _, err := parse(ctx.Param("id")); err != nil {
+		errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+	} else if _, code, err := accessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+		ownPermission); err != nil {
+		errorHandler.HandleAppError(ctx, err, code)
+	} else {
+		if err := todoRepository.DeleteShareLink(ctx.Request.Context(), target, token.(*auth.Token).UID); err != nil {
+			errorHandler.HandleAppError(ctx, err, shareLinkStatusOf(err))
+		} else {
+			ctx.JSON(http.StatusNoContent, gin.H{})
+		}
+	}
+}
+
+func shareLinkStatusOf(err error) int {
+	if err == repository.ErrInvalidShareLink {
+		return http.StatusBadRequest
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else if err == repository.ErrInboxList {
+		return http.StatusConflict
+	} else if err == repository.ErrShareLinkExpired {
+		return http.StatusGone
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTokenMock() (string, error) {
+	return "hweogwe", nil
+}
+
+func TestCreateShareLink(t *testing.T) {
+	token := &auth.Token{UID: "oewhgwe"}
+	todoId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case: a todo without an expiry", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/share", todoId, "", token)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{TodoId: todoId}, CreatedAt: now}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().CreateShareLink(gomock.Any(), &link, token.UID).Return(nil)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+		createTodoShareLink(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.ShareLink
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, link, got)
+	})
+
+	t.Run("Good case: a list with an expiry", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/lists/"+listId+"/share", listId,
+			`{"expiresAt": "2022-09-22T16:07:05.768+02:00"}`, token)
+		expiresAt := now.Add(24 * time.Hour)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{ListId: listId}, ExpiresAt: &expiresAt,
+			CreatedAt: now}
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().CreateShareLink(gomock.Any(), gomock.Any(), token.UID).
+			Do(func(ctx any, created *model.ShareLink, userId string) {
+				assert.Equal(t, link, *created)
+			}).Return(nil)
+		createListShareLink := CreateListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+		createListShareLink(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+	})
+
+	t.Run("When the body is not valid", func(t *testing.T) {
+		for _, body := range []string{`{"expiresAt": "tomorrow"}`, `{"expiresAt": "2022-09-21T14:07:05.768Z"}`, `[`} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/share", todoId, body, token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil).
+				MaxTimes(1)
+			todoRepositoryMock.EXPECT().CreateShareLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+			createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+			createTodoShareLink(gin_context)
+		}
+	})
+
+	t.Run("When a member shares the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/share", todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleEditor}, nil)
+		todoRepositoryMock.EXPECT().CreateShareLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+		createTodoShareLink(gin_context)
+	})
+
+	t.Run("When no token can be made", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/share", todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse,
+			func() (string, error) { return "", common.ErrError }, nowMock)
+		createTodoShareLink(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrInvalidShareLink: http.StatusBadRequest,
+			repository.ErrInboxList:        http.StatusConflict,
+			repository.ErrNotFound:         http.StatusNotFound,
+			repository.ErrQueryTimeout:     http.StatusGatewayTimeout,
+			common.ErrError:                http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodPost, "/lists/"+listId+"/share", listId, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().CreateShareLink(gomock.Any(), gomock.Any(), token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			createListShareLink := CreateListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+			createListShareLink(gin_context)
+		}
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/wrong/share", "wrong", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+		createTodoShareLink(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodPost, "/todos/"+todoId+"/share", todoId, "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, nil, newTokenMock, nowMock)
+		createTodoShareLink(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		createTodoShareLink := CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse, newTokenMock, nowMock)
+		createTodoShareLink(gin_context)
+	})
+}
+
+func TestGetShareLink(t *testing.T) {
+	token := &auth.Token{UID: "oewhgwe"}
+	todoId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/"+todoId+"/share", todoId, "", token)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{TodoId: todoId}, AccessCount: 3,
+			LastAccessedAt: &now, CreatedAt: now}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetShareLink(gomock.Any(), model.ShareTarget{TodoId: todoId}, token.UID).Return(&link, nil)
+		getTodoShareLink := GetTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getTodoShareLink(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.ShareLink
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, link, got)
+	})
+
+	t.Run("When the list has no share link", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/"+listId+"/share", listId, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetShareLink(gomock.Any(), model.ShareTarget{ListId: listId}, token.UID).
+			Return(nil, repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		getListShareLink := GetListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getListShareLink(gin_context)
+	})
+
+	t.Run("When a member asks for the share link of the list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/lists/"+listId+"/share", listId, "", token)
+		todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).
+			Return(&model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleViewer}, nil)
+		todoRepositoryMock.EXPECT().GetShareLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrForbidden, http.StatusForbidden)
+		getListShareLink := GetListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getListShareLink(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getTodoShareLink := GetTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getTodoShareLink(gin_context)
+	})
+}
+
+func TestDeleteShareLink(t *testing.T) {
+	token := &auth.Token{UID: "oewhgwe"}
+	todoId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodDelete, "/todos/"+todoId+"/share", todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().DeleteShareLink(gomock.Any(), model.ShareTarget{TodoId: todoId}, token.UID).Return(nil)
+		deleteTodoShareLink := DeleteTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteTodoShareLink(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound: http.StatusNotFound,
+			common.ErrError:        http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setListRequest(gin_context, http.MethodDelete, "/lists/"+listId+"/share", listId, "", token)
+			todoRepositoryMock.EXPECT().GetListAccess(gomock.Any(), listId, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().DeleteShareLink(gomock.Any(), model.ShareTarget{ListId: listId}, token.UID).Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			deleteListShareLink := DeleteListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			deleteListShareLink(gin_context)
+		}
+	})
+
+	t.Run("When the user has no access to the todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodDelete, "/todos/"+todoId+"/share", todoId, "", token)
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), todoId, token.UID).Return(nil, repository.ErrNotFound)
+		todoRepositoryMock.EXPECT().DeleteShareLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		deleteTodoShareLink := DeleteTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		deleteTodoShareLink(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodDelete, "/todos/"+todoId+"/share", todoId, "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		deleteTodoShareLink := DeleteTodoShareLink(todoRepositoryMock, errorHandlerMock, nil)
+		deleteTodoShareLink(gin_context)
+	})
+}
+
+func TestGetShared(t *testing.T) {
+	ownerId, todoId, listId := "wehgowe", uuid.New().String(), uuid.New().String()
+	setSharedRequest := func(gin_context *gin.Context, target string) {
+		setListRequest(gin_context, http.MethodGet, target, "", "", nil)
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "token", Value: "hweogwe"})
+	}
+
+	t.Run("Good case: a todo", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setSharedRequest(gin_context, "/shared/hweogwe")
+		done := false
+		todo := model.Todo{Id: todoId, Title: "todo", Description: "todo", Done: &done, CreatedAt: now, UpdatedAt: now,
+			Version: model.FirstVersion, Tags: []string{}, ListId: listId}
+		subtask := model.Todo{Id: uuid.New().String(), Title: "subtask", Description: "subtask", Done: &done, CreatedAt: now,
+			UpdatedAt: now, Version: model.FirstVersion, Tags: []string{}, ListId: listId, ParentId: todoId}
+		gomock.InOrder(
+			todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", now).Return(&model.ShareLink{Token: "hweogwe",
+				ShareTarget: model.ShareTarget{TodoId: todoId}, AccessCount: 1, CreatedAt: now, OwnerId: ownerId}, nil),
+			todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId, ownerId).Return(&todo, nil),
+			todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), todoId, ownerId, true).Return([]model.Todo{subtask}, nil),
+		)
+		getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+		getShared(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got model.SharedView
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subtask.Subtasks = nil
+		todo.Subtasks = []model.Todo{subtask}
+		assert.Equal(t, model.SharedView{Todo: &todo}, got)
+		assert.NotContains(t, http_recorder.Body.String(), ownerId)
+	})
+
+	t.Run("Good case: a list", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setSharedRequest(gin_context, "/shared/hweogwe?limit=1")
+		done := false
+		list := model.List{Id: listId, Name: "work", CreatedAt: now, UpdatedAt: now}
+		todo := model.Todo{Id: todoId, Title: "todo", Description: "todo", Done: &done, CreatedAt: now, UpdatedAt: now,
+			Version: model.FirstVersion, Tags: []string{}, ListId: listId}
+		filter := model.TodoFilter{ListId: listId, Sort: model.SortByPosition, Order: model.OrderAsc,
+			TagMatch: model.TagMatchAny}
+		todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", now).Return(&model.ShareLink{Token: "hweogwe",
+			ShareTarget: model.ShareTarget{ListId: listId}, AccessCount: 1, CreatedAt: now, OwnerId: ownerId}, nil)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), listId, ownerId).Return(&list, nil)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), ownerId, filter, model.PageRequest{Limit: 1}).
+			Return(&model.Page{Todos: []model.Todo{todo}, Next: model.CursorOf(todo, false)}, nil)
+		getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+		getShared(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Header().Get(LinkHeader), `/shared/hweogwe?cursor=`)
+		var got model.SharedView
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, model.SharedView{List: &list, Todos: []model.Todo{todo}}, got)
+	})
+
+	t.Run("Good case: a list without a limit is paged with the default one", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setSharedRequest(gin_context, "/shared/hweogwe")
+		list := model.List{Id: listId, Name: "work", CreatedAt: now, UpdatedAt: now}
+		filter := model.TodoFilter{ListId: listId, Sort: model.SortByPosition, Order: model.OrderAsc,
+			TagMatch: model.TagMatchAny}
+		todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", now).Return(&model.ShareLink{Token: "hweogwe",
+			ShareTarget: model.ShareTarget{ListId: listId}, AccessCount: 1, CreatedAt: now, OwnerId: ownerId}, nil)
+		todoRepositoryMock.EXPECT().GetList(gomock.Any(), listId, ownerId).Return(&list, nil)
+		todoRepositoryMock.EXPECT().GetPage(gomock.Any(), ownerId, filter, model.PageRequest{Limit: model.DefaultPageLimit}).
+			Return(&model.Page{Todos: []model.Todo{}}, nil)
+		getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+		getShared(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Equal(t, `</shared/hweogwe?limit=50>; rel="first"`, http_recorder.Header().Get(LinkHeader))
+	})
+
+	t.Run("When the share link can't be opened", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound:         http.StatusNotFound,
+			repository.ErrShareLinkExpired: http.StatusGone,
+			repository.ErrQueryTimeout:     http.StatusGatewayTimeout,
+			common.ErrError:                http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setSharedRequest(gin_context, "/shared/hweogwe")
+			todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", now).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+			getShared(gin_context)
+		}
+	})
+
+	t.Run("When the todo can't be read", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setSharedRequest(gin_context, "/shared/hweogwe")
+		todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", now).Return(&model.ShareLink{Token: "hweogwe",
+			ShareTarget: model.ShareTarget{TodoId: todoId}, CreatedAt: now, OwnerId: ownerId}, nil)
+		todoRepositoryMock.EXPECT().GetById(gomock.Any(), todoId, ownerId).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+		getShared(gin_context)
+	})
+
+	t.Run("When the limit is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setSharedRequest(gin_context, "/shared/hweogwe?limit=0")
+		todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, model.ErrInvalidLimit, http.StatusBadRequest)
+		getShared := GetShared(todoRepositoryMock, errorHandlerMock, nowMock)
+		getShared(gin_context)
+	})
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidExpand error = errors.New(`expand can only be "subtasks"`)
+
+// GetSubtasks lists the subtasks right below a todo by creation. With
+// ?expand=subtasks each of them comes with the tree of its own subtasks.
+func GetSubtasks(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else if parse == nil {
+			errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+		} else if _, err := parse(ctx.Param("id")); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if expand, err := expandSubtasksOf(ctx); err != nil {
+			errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+		} else if access, code, err := todoAccessOf(ctx, todoRepository, ctx.Param("id"), token.(*auth.Token).UID,
+			readPermission); err != nil {
+			errorHandler.HandleAppError(ctx, err, code)
+		} else {
+			id := ctx.Param("id")
+			if subtasks, err := todoRepository.GetSubtasks(ctx.Request.Context(), id, access.OwnerId, expand); err != nil {
+				if err == repository.ErrNotFound {
+					errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+				} else {
+					errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+				}
+			} else if expand {
+				ctx.JSON(http.StatusOK, model.NestSubtasks(id, visibleSubtasks(*access, subtasks)))
+			} else {
+				ctx.JSON(http.StatusOK, visibleSubtasks(*access, subtasks))
+			}
+		}
+	}
+}
+
+// expandSubtasksOf tells whether the expand query parameter asks for the
+// subtasks.
+func expandSubtasksOf(ctx *gin.Context) (bool, error) {
+	expand, ok := ctx.GetQuery("expand")
+	if !ok {
+		return false, nil
+	} else if expand != model.ExpandSubtasks {
+		return false, ErrInvalidExpand
+	}
+	return true, nil
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSubtasks(t *testing.T) {
+	token := &auth.Token{UID: "hwoegwe"}
+	todoDone := false
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/subtasks", id, "", token)
+		subtasks := []model.Todo{{Id: uuid.New().String(), Title: "title1", Done: &todoDone, CreatedAt: now,
+			UpdatedAt: now, Version: 1, Tags: []string{}, ParentId: id, Progress: &model.Progress{Done: 1, Total: 2}}}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), id, token.UID, false).Return(subtasks, nil)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		assert.Contains(t, http_recorder.Body.String(), `"progress":"1/2 done"`)
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, subtasks, got)
+	})
+
+	t.Run("Good case: ?expand=subtasks nests the subtasks", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/subtasks?expand=subtasks", id, "", token)
+		child := model.Todo{Id: uuid.New().String(), Title: "title1", Done: &todoDone, CreatedAt: now,
+			UpdatedAt: now, Version: 1, Tags: []string{}, ParentId: id, Progress: &model.Progress{Total: 1}}
+		grandchild := model.Todo{Id: uuid.New().String(), Title: "title2", Done: &todoDone, CreatedAt: now,
+			UpdatedAt: now, Version: 1, Tags: []string{}, ParentId: child.Id}
+		todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+		todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), id, token.UID, true).
+			Return([]model.Todo{child, grandchild}, nil)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child.Subtasks = []model.Todo{grandchild}
+		assert.Equal(t, []model.Todo{child}, got)
+	})
+
+	t.Run("When expand is not subtasks", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		id := uuid.New().String()
+		setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/subtasks?expand=tags", id, "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrInvalidExpand, http.StatusBadRequest)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+	})
+
+	t.Run("When the id is not valid", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/wrong/subtasks", "wrong", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, code := range map[error]int{
+			repository.ErrNotFound:     http.StatusNotFound,
+			repository.ErrQueryTimeout: http.StatusGatewayTimeout,
+			common.ErrError:            http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			id := uuid.New().String()
+			setListRequest(gin_context, http.MethodGet, "/todos/"+id+"/subtasks", id, "", token)
+			todoRepositoryMock.EXPECT().GetTodoAccess(gomock.Any(), id, token.UID).Return(ownerAccess(token.UID), nil)
+			todoRepositoryMock.EXPECT().GetSubtasks(gomock.Any(), id, token.UID, false).Return(nil, err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, code)
+			getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+			getSubtasks(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse)
+		getSubtasks(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setListRequest(gin_context, http.MethodGet, "/todos/x/subtasks", "x", "", token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		getSubtasks := GetSubtasks(todoRepositoryMock, errorHandlerMock, nil)
+		getSubtasks(gin_context)
+	})
+}
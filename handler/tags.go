@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTags lists the tags of the user by name, with how many of their todos
+// outside the trash have each. Tags are kept per owner, so the tags on the
+// todos of a list shared with the user are neither listed nor changed by the
+// tag handlers.
+func GetTags(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			token := token.(*auth.Token)
+			if tags, err := todoRepository.GetTags(ctx.Request.Context(), token.UID); err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, tags)
+			}
+		}
+	}
+}
+
+// RenameTag renames the tag in the path on every todo that has it.
+func RenameTag(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			var request model.RenameTagRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else {
+				token := token.(*auth.Token)
+				err := todoRepository.RenameTag(ctx.Request.Context(), token.UID, model.NormalizeTag(ctx.Param("name")),
+					model.NormalizeTag(request.Name))
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, tagStatusOf(err))
+				} else {
+					ctx.JSON(http.StatusNoContent, gin.H{})
+				}
+			}
+		}
+	}
+}
+
+// MergeTag moves the todos of the tag in the path to the tag that the body
+// names, and deletes the tag in the path.
+func MergeTag(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			var request model.MergeTagRequest
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+			} else {
+				token := token.(*auth.Token)
+				err := todoRepository.MergeTag(ctx.Request.Context(), token.UID, model.NormalizeTag(ctx.Param("name")),
+					model.NormalizeTag(request.Into))
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, tagStatusOf(err))
+				} else {
+					ctx.JSON(http.StatusNoContent, gin.H{})
+				}
+			}
+		}
+	}
+}
+
+// DeleteTag takes the tag in the path off every todo that has it.
+func DeleteTag(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			token := token.(*auth.Token)
+			if err := todoRepository.DeleteTag(ctx.Request.Context(), token.UID, model.NormalizeTag(ctx.Param("name"))); err != nil {
+				errorHandler.HandleAppError(ctx, err, tagStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusNoContent, gin.H{})
+			}
+		}
+	}
+}
+
+func tagStatusOf(err error) int {
+	if err == model.ErrInvalidTag || err == repository.ErrSameTag {
+		return http.StatusBadRequest
+	} else if err == repository.ErrNotFound {
+		return http.StatusNotFound
+	} else if err == repository.ErrTagExists {
+		return http.StatusConflict
+	} else {
+		return serverErrorStatusOf(err)
+	}
+}
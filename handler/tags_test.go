@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTags(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		tags := []model.Tag{{Name: "home", Count: 2}, {Name: "work", Count: 0}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTags(gomock.Any(), token.UID).Return(tags, nil)
+		getTags := GetTags(todoRepositoryMock, errorHandlerMock)
+		getTags(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Tag
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tags, got)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTags(gomock.Any(), token.UID).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getTags := GetTags(todoRepositoryMock, errorHandlerMock)
+		getTags(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getTags := GetTags(todoRepositoryMock, errorHandlerMock)
+		getTags(gin_context)
+	})
+}
+
+func TestRenameTag(t *testing.T) {
+	token := &auth.Token{UID: "oiwhbegfwh"}
+	setRequest := func(gin_context *gin.Context, name string, body string) {
+		gin_context.Request = httptest.NewRequest(http.MethodPatch, "/tags/"+name, bytes.NewBufferString(body))
+		gin_context.Request.Header.Set("Content-Type", "application/json")
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "name", Value: name})
+		gin_context.Set(middleware.AuthToken, token)
+	}
+
+	t.Run("Good case: the names are normalized", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, "Work", `{"name": " Office "}`)
+		todoRepositoryMock.EXPECT().RenameTag(gomock.Any(), token.UID, "work", "office").Return(nil)
+		renameTag := RenameTag(todoRepositoryMock, errorHandlerMock)
+		renameTag(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When the body has no name", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, "work", `{}`)
+		todoRepositoryMock.EXPECT().RenameTag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		renameTag := RenameTag(todoRepositoryMock, errorHandlerMock)
+		renameTag(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, status := range map[error]int{
+			model.ErrInvalidTag:     http.StatusBadRequest,
+			repository.ErrNotFound:  http.StatusNotFound,
+			repository.ErrTagExists: http.StatusConflict,
+			common.ErrError:         http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, "work", `{"name": "office"}`)
+			todoRepositoryMock.EXPECT().RenameTag(gomock.Any(), token.UID, "work", "office").Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			renameTag := RenameTag(todoRepositoryMock, errorHandlerMock)
+			renameTag(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		renameTag := RenameTag(todoRepositoryMock, errorHandlerMock)
+		renameTag(gin_context)
+	})
+}
+
+func TestMergeTag(t *testing.T) {
+	token := &auth.Token{UID: "oiwhbegfwh"}
+	setRequest := func(gin_context *gin.Context, name string, body string) {
+		gin_context.Request = httptest.NewRequest(http.MethodPost, "/tags/"+name+"/merge", bytes.NewBufferString(body))
+		gin_context.Request.Header.Set("Content-Type", "application/json")
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "name", Value: name})
+		gin_context.Set(middleware.AuthToken, token)
+	}
+
+	t.Run("Good case: the names are normalized", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, "Job", `{"into": "Work"}`)
+		todoRepositoryMock.EXPECT().MergeTag(gomock.Any(), token.UID, "job", "work").Return(nil)
+		mergeTag := MergeTag(todoRepositoryMock, errorHandlerMock)
+		mergeTag(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When the body has no into", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		setRequest(gin_context, "job", `{"name": "work"}`)
+		todoRepositoryMock.EXPECT().MergeTag(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, gomock.Any(), http.StatusBadRequest)
+		mergeTag := MergeTag(todoRepositoryMock, errorHandlerMock)
+		mergeTag(gin_context)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		for err, status := range map[error]int{
+			repository.ErrSameTag:  http.StatusBadRequest,
+			repository.ErrNotFound: http.StatusNotFound,
+			common.ErrError:        http.StatusInternalServerError,
+		} {
+			todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+			setRequest(gin_context, "job", `{"into": "work"}`)
+			todoRepositoryMock.EXPECT().MergeTag(gomock.Any(), token.UID, "job", "work").Return(err)
+			errorHandlerMock.EXPECT().HandleAppError(gin_context, err, status)
+			mergeTag := MergeTag(todoRepositoryMock, errorHandlerMock)
+			mergeTag(gin_context)
+		}
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		mergeTag := MergeTag(todoRepositoryMock, errorHandlerMock)
+		mergeTag(gin_context)
+	})
+}
+
+func TestDeleteTag(t *testing.T) {
+	token := &auth.Token{UID: "oiwhbegfwh"}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "name", Value: "Work"})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().DeleteTag(gomock.Any(), token.UID, "work").Return(nil)
+		deleteTag := DeleteTag(todoRepositoryMock, errorHandlerMock)
+		deleteTag(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When the tag doesn't exist", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "name", Value: "work"})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().DeleteTag(gomock.Any(), token.UID, "work").Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		deleteTag := DeleteTag(todoRepositoryMock, errorHandlerMock)
+		deleteTag(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		deleteTag := DeleteTag(todoRepositoryMock, errorHandlerMock)
+		deleteTag(gin_context)
+	})
+}
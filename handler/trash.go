@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTrash lists the todos in the trash, the most recently deleted first.
+// Every user has a trash of their own: a todo that a member deletes from a
+// shared list goes to the trash of its owner.
+func GetTrash(todoRepository common.TodoRepository, errorHandler common.ErrorHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			token := token.(*auth.Token)
+			if todos, err := todoRepository.GetTrash(ctx.Request.Context(), token.UID); err != nil {
+				errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+			} else {
+				ctx.JSON(http.StatusOK, todos)
+			}
+		}
+	}
+}
+
+// Restore takes a todo out of the trash of the user.
+func Restore(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			if parse != nil {
+				id := ctx.Param("id")
+				_, err := parse(id)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					token := token.(*auth.Token)
+					err := todoRepository.Restore(ctx.Request.Context(), id, token.UID)
+					if err != nil {
+						if err == repository.ErrNotFound {
+							errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+						} else if err == repository.ErrParentInTrash {
+							errorHandler.HandleAppError(ctx, err, http.StatusConflict)
+						} else {
+							errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+						}
+					} else {
+						ctx.JSON(http.StatusNoContent, gin.H{})
+					}
+				}
+			} else {
+				errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+			}
+		}
+	}
+}
+
+// Purge removes a todo in the trash of the user for good.
+func Purge(todoRepository common.TodoRepository, errorHandler common.ErrorHandler,
+	parse func(string) (uuid.UUID, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := ctx.Get(middleware.AuthToken)
+		if !ok {
+			errorHandler.HandleAppError(ctx, middleware.ErrNoUID, http.StatusUnauthorized)
+		} else {
+			if parse != nil {
+				id := ctx.Param("id")
+				_, err := parse(id)
+				if err != nil {
+					errorHandler.HandleAppError(ctx, err, http.StatusBadRequest)
+				} else {
+					token := token.(*auth.Token)
+					err := todoRepository.Purge(ctx.Request.Context(), id, token.UID)
+					if err != nil {
+						if err == repository.ErrNotFound {
+							errorHandler.HandleAppError(ctx, err, http.StatusNotFound)
+						} else {
+							errorHandler.HandleAppError(ctx, err, serverErrorStatusOf(err))
+						}
+					} else {
+						ctx.JSON(http.StatusNoContent, gin.H{})
+					}
+				}
+			} else {
+				errorHandler.HandleAppError(ctx, ErrParseIsNil, http.StatusInternalServerError)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrash(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05.768Z")
+		todos := []model.Todo{{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti, Version: 2, DeletedAt: &now}}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTrash(gomock.Any(), token.UID).Return(todos, nil)
+		getTrash := GetTrash(todoRepositoryMock, errorHandlerMock)
+		getTrash(gin_context)
+		assert.Equal(t, http.StatusOK, http_recorder.Code)
+		var got []model.Todo
+		err := json.Unmarshal(http_recorder.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, todos, got)
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "wbfewh"}
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().GetTrash(gomock.Any(), token.UID).Return(nil, common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		getTrash := GetTrash(todoRepositoryMock, errorHandlerMock)
+		getTrash(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		getTrash := GetTrash(todoRepositoryMock, errorHandlerMock)
+		getTrash(gin_context)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Restore(gomock.Any(), todoId.String(), token.UID).Return(nil)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		restore(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When invalid todo id is sent as a path parameter in the url", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return uuid.Nil, common.ErrError
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: "oehwegiuf"})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Restore(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		restore(gin_context)
+	})
+
+	t.Run("When the todo is not in the trash", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Restore(gomock.Any(), todoId.String(), token.UID).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		restore(gin_context)
+	})
+
+	t.Run("When the parent of the todo is in the trash", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Restore(gomock.Any(), todoId.String(), token.UID).Return(repository.ErrParentInTrash)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrParentInTrash, http.StatusConflict)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		restore(gin_context)
+	})
+
+	t.Run("When TodoRepository return an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Restore(gomock.Any(), todoId.String(), token.UID).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		restore(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, nil)
+		restore(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		restore := Restore(todoRepositoryMock, errorHandlerMock, nil)
+		restore(gin_context)
+	})
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepositoryMock, gin_context, http_recorder, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Purge(gomock.Any(), todoId.String(), token.UID).Return(nil)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		purge(gin_context)
+		assert.Equal(t, http.StatusNoContent, http_recorder.Code)
+		assert.Empty(t, http_recorder.Body.Bytes())
+	})
+
+	t.Run("When invalid todo id is sent as a path parameter in the url", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return uuid.Nil, common.ErrError
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: "oehwegiuf"})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Purge(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusBadRequest)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		purge(gin_context)
+	})
+
+	t.Run("When the todo is not in the trash", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Purge(gomock.Any(), todoId.String(), token.UID).Return(repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, repository.ErrNotFound, http.StatusNotFound)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		purge(gin_context)
+	})
+
+	t.Run("When TodoRepository return an error", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		todoId := uuid.New()
+		uUidParseMock := func(id string) (uuid.UUID, error) {
+			return todoId, nil
+		}
+		gin_context.Params = append(gin_context.Params, gin.Param{Key: "id", Value: todoId.String()})
+		gin_context.Set(middleware.AuthToken, token)
+		todoRepositoryMock.EXPECT().Purge(gomock.Any(), todoId.String(), token.UID).Return(common.ErrError)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, common.ErrError, http.StatusInternalServerError)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, uUidParseMock)
+		purge(gin_context)
+	})
+
+	t.Run("When parse is nil", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		token := &auth.Token{UID: "oiwhbegfwh"}
+		gin_context.Set(middleware.AuthToken, token)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrParseIsNil, http.StatusInternalServerError)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, nil)
+		purge(gin_context)
+	})
+
+	t.Run("When there is no auth token in the web context", func(t *testing.T) {
+		todoRepositoryMock, gin_context, _, errorHandlerMock := createMocks(t)
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, middleware.ErrNoUID, http.StatusUnauthorized)
+		purge := Purge(todoRepositoryMock, errorHandlerMock, nil)
+		purge(gin_context)
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository/repositorytest"
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/stretchr/testify/assert"
@@ -24,11 +26,12 @@ func TestTodoRepositoryImplOnPostgres1(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone,
 			CreatedAt:   ti,
+			UpdatedAt:   ti,
 		}
 		userId := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo, userId)
+		err := todoRepository.Create(context.Background(), &expectedTodo, userId)
 		assert.NoError(t, err)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.NoError(t, err)
 		assert.Equal(t, len(todos), 1)
 		returnedTodo := todos[0]
@@ -49,9 +52,10 @@ func TestTodoRepositoryImplOnPostgres2(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone1,
 			CreatedAt:   ti1,
+			UpdatedAt:   ti1,
 		}
 		userId1 := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo1, userId1)
+		err := todoRepository.Create(context.Background(), &expectedTodo1, userId1)
 		assert.NoError(t, err)
 		todoDone2 := false
 		ti2, _ := time.Parse(time.RFC3339, "2021-09-21T14:07:05.768Z")
@@ -61,11 +65,12 @@ func TestTodoRepositoryImplOnPostgres2(t *testing.T) {
 			Description: "description1updated",
 			Done:        &todoDone2,
 			CreatedAt:   ti2,
+			UpdatedAt:   ti2,
 		}
 		userId2 := uuid.New().String()
-		err = todoRepository.Update(&expectedTodo2, userId2)
-		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId, userId1)
+		err = todoRepository.Update(context.Background(), &expectedTodo2, userId2, repository.AnyVersion)
+		assert.Equal(t, repository.ErrNotFound, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId, userId1)
 		assert.NoError(t, err)
 		assert.Equal(t, &expectedTodo1, returnedTodo)
 	})
@@ -84,9 +89,10 @@ func TestTodoRepositoryImplOnPostgres3(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone1,
 			CreatedAt:   ti1,
+			UpdatedAt:   ti1,
 		}
 		userId := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo1, userId)
+		err := todoRepository.Create(context.Background(), &expectedTodo1, userId)
 		assert.NoError(t, err)
 		todoDone2 := false
 		ti2, _ := time.Parse(time.RFC3339, "2021-09-21T14:07:05.768Z")
@@ -97,10 +103,11 @@ func TestTodoRepositoryImplOnPostgres3(t *testing.T) {
 			Description: "description1updated",
 			Done:        &todoDone2,
 			CreatedAt:   ti2,
+			UpdatedAt:   ti2,
 		}
-		err = todoRepository.Update(&expectedTodo2, userId)
-		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId1, userId)
+		err = todoRepository.Update(context.Background(), &expectedTodo2, userId, repository.AnyVersion)
+		assert.Equal(t, repository.ErrNotFound, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId1, userId)
 		assert.NoError(t, err)
 		assert.Equal(t, &expectedTodo1, returnedTodo)
 	})
@@ -119,22 +126,26 @@ func TestTodoRepositoryImplOnPostgres4(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone1,
 			CreatedAt:   ti1,
+			UpdatedAt:   ti1,
 		}
 		userId := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo1, userId)
+		err := todoRepository.Create(context.Background(), &expectedTodo1, userId)
 		assert.NoError(t, err)
 		todoDone2 := false
-		ti2, _ := time.Parse(time.RFC3339, "2021-09-21T14:07:05.768Z")
+		ti2, _ := time.Parse(time.RFC3339, "2023-09-21T14:07:05.768Z")
 		expectedTodo2 := model.Todo{
 			Id:          todoId,
 			Title:       "title1updated",
 			Description: "description1updated",
 			Done:        &todoDone2,
-			CreatedAt:   ti2,
+			CreatedAt:   ti1,
+			UpdatedAt:   ti2,
+			Version:     2,
 		}
-		err = todoRepository.Update(&expectedTodo2, userId)
+		err = todoRepository.Update(context.Background(), &model.Todo{Id: todoId, Title: "title1updated", Description: "description1updated",
+			Done: &todoDone2, UpdatedAt: ti2}, userId, model.FirstVersion)
 		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId, userId)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId, userId)
 		assert.NoError(t, err)
 		assert.Equal(t, &expectedTodo2, returnedTodo)
 	})
@@ -153,14 +164,15 @@ func TestTodoRepositoryImplOnPostgres5(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone,
 			CreatedAt:   ti,
+			UpdatedAt:   ti,
 		}
 		userId1 := uuid.New().String()
 		userId2 := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo, userId1)
+		err := todoRepository.Create(context.Background(), &expectedTodo, userId1)
 		assert.NoError(t, err)
-		err = todoRepository.Delete(todoId, userId2)
-		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId, userId1)
+		err = todoRepository.Delete(context.Background(), todoId, userId2, repository.AnyVersion, ti)
+		assert.Equal(t, repository.ErrNotFound, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId, userId1)
 		assert.NoError(t, err)
 		assert.NotNil(t, returnedTodo)
 	})
@@ -179,14 +191,15 @@ func TestTodoRepositoryImplOnPostgres6(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone,
 			CreatedAt:   ti,
+			UpdatedAt:   ti,
 		}
 		userId := uuid.New().String()
 		todoId2 := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo, userId)
+		err := todoRepository.Create(context.Background(), &expectedTodo, userId)
 		assert.NoError(t, err)
-		err = todoRepository.Delete(todoId2, userId)
-		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId1, userId)
+		err = todoRepository.Delete(context.Background(), todoId2, userId, repository.AnyVersion, ti)
+		assert.Equal(t, repository.ErrNotFound, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId1, userId)
 		assert.NoError(t, err)
 		assert.NotNil(t, returnedTodo)
 	})
@@ -205,13 +218,14 @@ func TestTodoRepositoryImplOnPostgres7(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone,
 			CreatedAt:   ti,
+			UpdatedAt:   ti,
 		}
 		userId := uuid.New().String()
-		err := todoRepository.Create(&expectedTodo, userId)
+		err := todoRepository.Create(context.Background(), &expectedTodo, userId)
 		assert.NoError(t, err)
-		err = todoRepository.Delete(todoId, userId)
+		err = todoRepository.Delete(context.Background(), todoId, userId, model.FirstVersion, ti)
 		assert.NoError(t, err)
-		returnedTodo, err := todoRepository.GetById(todoId, userId)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todoId, userId)
 		assert.Equal(t, repository.ErrNotFound, err)
 		assert.Nil(t, returnedTodo)
 	})
@@ -230,6 +244,7 @@ func TestTodoRepositoryImplOnPostgres8(t *testing.T) {
 			Description: "description1",
 			Done:        &todoDone1,
 			CreatedAt:   ti1,
+			UpdatedAt:   ti1,
 		}
 		todoDone2 := false
 		ti2, _ := time.Parse(time.RFC3339, "2021-09-21T14:07:05.768Z")
@@ -240,6 +255,7 @@ func TestTodoRepositoryImplOnPostgres8(t *testing.T) {
 			Description: "description2",
 			Done:        &todoDone2,
 			CreatedAt:   ti2,
+			UpdatedAt:   ti2,
 		}
 		todoDone3 := true
 		ti3, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
@@ -250,6 +266,7 @@ func TestTodoRepositoryImplOnPostgres8(t *testing.T) {
 			Description: "description3",
 			Done:        &todoDone3,
 			CreatedAt:   ti3,
+			UpdatedAt:   ti3,
 		}
 		todoDone4 := false
 		ti4, _ := time.Parse(time.RFC3339, "2022-07-21T14:07:05.768Z")
@@ -260,20 +277,329 @@ func TestTodoRepositoryImplOnPostgres8(t *testing.T) {
 			Description: "description4",
 			Done:        &todoDone4,
 			CreatedAt:   ti4,
+			UpdatedAt:   ti4,
 		}
 		userId1 := uuid.New().String()
 		userId2 := uuid.New().String()
-		todoRepository.Create(&expectedTodo2, userId1)
-		todoRepository.Create(&expectedTodo1, userId1)
-		todoRepository.Create(&expectedTodo4, userId2)
-		todoRepository.Create(&expectedTodo3, userId1)
-		returnedTodos, err := todoRepository.GetAll(userId1)
+		todoRepository.Create(context.Background(), &expectedTodo2, userId1)
+		todoRepository.Create(context.Background(), &expectedTodo1, userId1)
+		todoRepository.Create(context.Background(), &expectedTodo4, userId2)
+		todoRepository.Create(context.Background(), &expectedTodo3, userId1)
+		returnedTodos, err := todoRepository.GetAll(context.Background(), userId1)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTodo3, returnedTodos[0])
 		assert.Equal(t, expectedTodo2, returnedTodos[1])
 		assert.Equal(t, expectedTodo1, returnedTodos[2])
-		returnedTodos, err = todoRepository.GetAll(userId2)
+		returnedTodos, err = todoRepository.GetAll(context.Background(), userId2)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTodo4, returnedTodos[0])
 	})
 }
+
+func TestTodoRepositoryImplOnPostgres9(t *testing.T) {
+	t.Run("Test GetPage", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		expectedTodos := []model.Todo{}
+		for i := 0; i < 5; i++ {
+			todoDone := i%2 == 0
+			todo := model.Todo{
+				Id:          uuid.New().String(),
+				Title:       "title",
+				Description: "description",
+				Done:        &todoDone,
+				CreatedAt:   ti.Add(-time.Duration(i) * time.Hour),
+				UpdatedAt:   ti.Add(-time.Duration(i) * time.Hour),
+			}
+			assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+			expectedTodos = append(expectedTodos, todo)
+		}
+		assert.NoError(t, todoRepository.Create(context.Background(), &model.Todo{Id: uuid.New().String(), Title: "title",
+			Description: "description", Done: expectedTodos[0].Done, CreatedAt: ti, UpdatedAt: ti}, uuid.New().String()))
+		page1, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodos[0:2], page1.Todos)
+		assert.Nil(t, page1.Prev)
+		page2, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2, Cursor: page1.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodos[2:4], page2.Todos)
+		page3, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2, Cursor: page2.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodos[4:], page3.Todos)
+		assert.Nil(t, page3.Next)
+		backToPage2, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2, Cursor: page3.Prev})
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodos[2:4], backToPage2.Todos)
+		backToPage1, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2, Cursor: backToPage2.Prev})
+		assert.NoError(t, err)
+		assert.Equal(t, expectedTodos[0:2], backToPage1.Todos)
+		assert.Nil(t, backToPage1.Prev)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres10(t *testing.T) {
+	t.Run("Test GetPage with a filter", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		titles := []string{"a Buy milk", "b buy 100% juice", "c Call mom", "d buy_bread"}
+		expectedTodos := []model.Todo{}
+		for i, title := range titles {
+			todoDone := i%2 == 1
+			todo := model.Todo{
+				Id:          uuid.New().String(),
+				Title:       title,
+				Description: "description",
+				Done:        &todoDone,
+				CreatedAt:   ti.Add(-time.Duration(i) * time.Hour),
+				UpdatedAt:   ti.Add(-time.Duration(i) * time.Hour),
+			}
+			assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+			expectedTodos = append(expectedTodos, todo)
+		}
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "buy", Sort: model.SortByTitle,
+			Order: model.OrderAsc}, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{expectedTodos[0], expectedTodos[1], expectedTodos[3]}, page.Todos)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "100%"}, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{expectedTodos[1]}, page.Todos)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "buy_"}, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{expectedTodos[3]}, page.Todos)
+		done := true
+		createdAfter := ti.Add(-150 * time.Minute)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Done: &done, CreatedAfter: &createdAfter},
+			model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{expectedTodos[1]}, page.Todos)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: model.SortByDone}, model.PageRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, page.Todos, 2)
+		assert.True(t, *page.Todos[0].Done)
+		assert.True(t, *page.Todos[1].Done)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: model.SortByDone}, model.PageRequest{Limit: 2, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Len(t, page.Todos, 2)
+		assert.False(t, *page.Todos[0].Done)
+		assert.Nil(t, page.Next)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres11(t *testing.T) {
+	t.Run("Test Search", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todoDone := false
+		todos := []model.Todo{
+			{Id: uuid.New().String(), Title: "Groceries", Description: "buy apples and bananas", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti},
+			{Id: uuid.New().String(), Title: "Apples for the pie", Description: "the green ones", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti},
+			{Id: uuid.New().String(), Title: "Call the plumber", Description: "kitchen sink", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti},
+		}
+		for i := range todos {
+			assert.NoError(t, todoRepository.Create(context.Background(), &todos[i], userId))
+		}
+		assert.NoError(t, todoRepository.Create(context.Background(), &model.Todo{Id: uuid.New().String(), Title: "apples",
+			Description: "apples", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}, uuid.New().String()))
+		results, err := todoRepository.Search(context.Background(), userId, "apple", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, todos[1], results[0].Todo)
+		assert.Equal(t, "<mark>Apples</mark> for the pie", results[0].HighlightedTitle)
+		assert.Equal(t, todos[0], results[1].Todo)
+		assert.Contains(t, results[1].Snippet, "<mark>apples</mark>")
+		results, err = todoRepository.Search(context.Background(), userId, "plumbr", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, todos[2], results[0].Todo)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres12(t *testing.T) {
+	t.Run("Test updated_at and completed_at", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti1, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		ti2 := ti1.Add(time.Hour)
+		ti3 := ti2.Add(time.Hour)
+		ti4 := ti3.Add(time.Hour)
+		todoDone := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone, CreatedAt: ti1, UpdatedAt: ti1}
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		done := true
+		err := todoRepository.Update(context.Background(), &model.Todo{Id: todo.Id, Title: "title1", Description: "description1",
+			Done: &done, UpdatedAt: ti2}, userId, repository.AnyVersion)
+		assert.NoError(t, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, ti1, returnedTodo.CreatedAt)
+		assert.Equal(t, ti2, returnedTodo.UpdatedAt)
+		assert.Equal(t, &ti2, returnedTodo.CompletedAt)
+		title := "title2"
+		err = todoRepository.Update(context.Background(), &model.Todo{Id: todo.Id, Title: title, Description: "description1",
+			Done: &done, UpdatedAt: ti3}, userId, repository.AnyVersion)
+		assert.NoError(t, err)
+		returnedTodo, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, ti3, returnedTodo.UpdatedAt)
+		assert.Equal(t, &ti2, returnedTodo.CompletedAt)
+		err = todoRepository.Patch(context.Background(), todo.Id, userId, model.TodoChanges{Done: &todoDone, UpdatedAt: ti4}, repository.AnyVersion)
+		assert.NoError(t, err)
+		returnedTodo, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, ti4, returnedTodo.UpdatedAt)
+		assert.Nil(t, returnedTodo.CompletedAt)
+		assert.Equal(t, title, returnedTodo.Title)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres13(t *testing.T) {
+	t.Run("Test versions", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todoDone := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		assert.Equal(t, model.FirstVersion, todo.Version)
+		err := todoRepository.Update(context.Background(), &model.Todo{Id: todo.Id, Title: "title2", Description: "description1",
+			Done: &todoDone, UpdatedAt: ti}, userId, 1)
+		assert.NoError(t, err)
+		err = todoRepository.Update(context.Background(), &model.Todo{Id: todo.Id, Title: "title3", Description: "description1",
+			Done: &todoDone, UpdatedAt: ti}, userId, 1)
+		assert.Equal(t, repository.ErrVersionMismatch, err)
+		title := "title3"
+		err = todoRepository.Patch(context.Background(), todo.Id, userId, model.TodoChanges{Title: &title, UpdatedAt: ti}, 1)
+		assert.Equal(t, repository.ErrVersionMismatch, err)
+		err = todoRepository.Patch(context.Background(), todo.Id, userId, model.TodoChanges{Title: &title, UpdatedAt: ti}, 2)
+		assert.NoError(t, err)
+		err = todoRepository.Delete(context.Background(), todo.Id, userId, 2, ti)
+		assert.Equal(t, repository.ErrVersionMismatch, err)
+		returnedTodo, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), returnedTodo.Version)
+		assert.Equal(t, title, returnedTodo.Title)
+		err = todoRepository.Delete(context.Background(), todo.Id, userId, 3, ti)
+		assert.NoError(t, err)
+		err = todoRepository.Delete(context.Background(), todo.Id, userId, 3, ti)
+		assert.Equal(t, repository.ErrNotFound, err)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres14(t *testing.T) {
+	t.Run("Test trash", func(t *testing.T) {
+		container, todoRepository := repository.SetupPostgres(t)
+		defer container.Terminate(context.Background())
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todoDone := false
+		todo1 := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		todo2 := model.Todo{Id: uuid.New().String(), Title: "title2", Description: "description2",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo1, userId))
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo2, userId))
+		assert.NoError(t, todoRepository.Delete(context.Background(), todo1.Id, userId, repository.AnyVersion, ti))
+		assert.NoError(t, todoRepository.Delete(context.Background(), todo2.Id, userId, repository.AnyVersion, ti.Add(time.Hour)))
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Empty(t, todos)
+		trash, err := todoRepository.GetTrash(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Len(t, trash, 2)
+		assert.Equal(t, todo2.Id, trash[0].Id)
+		assert.Equal(t, ti.Add(time.Hour), *trash[0].DeletedAt)
+		assert.Equal(t, int64(2), trash[0].Version)
+		assert.Equal(t, repository.ErrNotFound, todoRepository.Delete(context.Background(), todo1.Id, userId, repository.AnyVersion, ti))
+		assert.NoError(t, todoRepository.Restore(context.Background(), todo1.Id, userId))
+		assert.Equal(t, repository.ErrNotFound, todoRepository.Restore(context.Background(), todo1.Id, userId))
+		returnedTodo, err := todoRepository.GetById(context.Background(), todo1.Id, userId)
+		assert.NoError(t, err)
+		assert.Nil(t, returnedTodo.DeletedAt)
+		assert.Equal(t, int64(3), returnedTodo.Version)
+		assert.Equal(t, repository.ErrNotFound, todoRepository.Purge(context.Background(), todo1.Id, userId))
+		purged, err := todoRepository.PurgeTrash(context.Background(), ti.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+		purged, err = todoRepository.PurgeTrash(context.Background(), ti.Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.NoError(t, todoRepository.Delete(context.Background(), todo1.Id, userId, repository.AnyVersion, ti))
+		assert.NoError(t, todoRepository.Purge(context.Background(), todo1.Id, userId))
+		trash, err = todoRepository.GetTrash(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Empty(t, trash)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgres15(t *testing.T) {
+	t.Run("Test unit of work", func(t *testing.T) {
+		container, db := repository.SetupPostgresDB(t)
+		defer container.Terminate(context.Background())
+		todoRepository, err := repository.GetTodoRepository(db, 0, repository.SubtaskRules{}, uuid.NewV7, time.Now)
+		assert.NoError(t, err)
+		unitOfWork, err := repository.GetUnitOfWork(db, 0, repository.SubtaskRules{}, uuid.NewV7, time.Now)
+		assert.NoError(t, err)
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todoDone := false
+		todo1 := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		todo2 := model.Todo{Id: uuid.New().String(), Title: "title2", Description: "description2",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		todo3 := model.Todo{Id: uuid.New().String(), Title: "title3", Description: "description3",
+			Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		err = unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			if err := tx.Create(context.Background(), &todo1, userId); err != nil {
+				return err
+			}
+			return tx.Delete(context.Background(), todo2.Id, userId, repository.AnyVersion, ti)
+		})
+		assert.Equal(t, repository.ErrNotFound, err)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Empty(t, todos)
+		err = unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			assert.NoError(t, tx.Savepoint(context.Background(), func() error {
+				return tx.Create(context.Background(), &todo1, userId)
+			}))
+			assert.Equal(t, repository.ErrVersionMismatch, tx.Savepoint(context.Background(), func() error {
+				if err := tx.Create(context.Background(), &todo2, userId); err != nil {
+					return err
+				}
+				return tx.Delete(context.Background(), todo1.Id, userId, 2, ti)
+			}))
+			assert.Error(t, tx.Savepoint(context.Background(), func() error {
+				return tx.Create(context.Background(), &todo1, userId)
+			}))
+			return tx.Create(context.Background(), &todo3, userId)
+		})
+		assert.NoError(t, err)
+		todos, err = todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Len(t, todos, 2)
+		_, err = todoRepository.GetById(context.Background(), todo2.Id, userId)
+		assert.Equal(t, repository.ErrNotFound, err)
+	})
+}
+
+func TestTodoRepositoryImplOnPostgresConformance(t *testing.T) {
+	container, db := repository.SetupPostgresDB(t)
+	defer container.Terminate(context.Background())
+	repositorytest.Run(t, func(t *testing.T, rules repository.SubtaskRules) common.TodoRepository {
+		todoRepository, err := repository.GetTodoRepository(db, 0, rules, uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todoRepository
+	})
+}
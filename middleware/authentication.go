@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -19,6 +18,7 @@ var ErrAuthorizationHeaderDoesntStartWithBearer error = errors.New(`the Authoriz
 var ErrAuthClientIsNil error = errors.New("auth client is nil")
 var ErrIdTokenVerificationFailed error = errors.New("id token verification faild")
 var ErrNoUID error = errors.New("there is no UID in the token")
+var ErrVerificationCanceled error = errors.New("the request was canceled before its id token was verified")
 
 func GetAuthMiddleware(authClient common.AuthClient, errorHandler common.ErrorHandler) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -32,9 +32,13 @@ func GetAuthMiddleware(authClient common.AuthClient, errorHandler common.ErrorHa
 				errorHandler.HandleAppError(ctx, ErrAuthorizationHeaderDoesntStartWithBearer, http.StatusUnauthorized)
 			} else {
 				token := strings.Replace(authorizationHeader, BEARER, "", 1)
-				authToken, err := authClient.VerifyIDToken(context.Background(), token)
+				authToken, err := authClient.VerifyIDToken(ctx.Request.Context(), token)
 				if err != nil {
-					errorHandler.HandleAppError(ctx, err, http.StatusUnauthorized)
+					if ctx.Request.Context().Err() != nil {
+						errorHandler.HandleAppError(ctx, ErrVerificationCanceled, http.StatusServiceUnavailable)
+					} else {
+						errorHandler.HandleAppError(ctx, err, http.StatusUnauthorized)
+					}
 				} else {
 					if authToken.UID == "" {
 						errorHandler.HandleAppError(ctx, ErrNoUID, http.StatusUnauthorized)
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -61,6 +62,24 @@ func TestGetAuthMiddleware(t *testing.T) {
 		authMiddleware(gin_context)
 	})
 
+	t.Run(`The request is canceled while Client.VerifyIDToken() runs`, func(t *testing.T) {
+		firebaseAuthClientMock, gin_context, errorHandlerMock := CreateMocks(t)
+		ha := "eyJhbGciOiJ"
+		requestCtx, cancel := context.WithCancel(context.Background())
+		errorHandlerMock.EXPECT().HandleAppError(gin_context, ErrVerificationCanceled,
+			http.StatusServiceUnavailable)
+		firebaseAuthClientMock.EXPECT().VerifyIDToken(requestCtx, ha).
+			DoAndReturn(func(ctx context.Context, idToken string) (*auth.Token, error) {
+				cancel()
+				return nil, ctx.Err()
+			})
+		web_request := (&http.Request{
+			Header: map[string][]string{AUTHORIZATION: {BEARER + ha}}}).WithContext(requestCtx)
+		gin_context.Request = web_request
+		authMiddleware := GetAuthMiddleware(firebaseAuthClientMock, errorHandlerMock)
+		authMiddleware(gin_context)
+	})
+
 	t.Run(`token doesn't have uid`, func(t *testing.T) {
 		firebaseAuthClientMock, gin_context, errorHandlerMock := CreateMocks(t)
 		ha := "eyJhbGciOiJ"
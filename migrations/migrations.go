@@ -0,0 +1,323 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var embeddedFiles embed.FS
+
+var ErrDBPoolIsNil = errors.New("DBPool is nil")
+var ErrInvalidFileName = errors.New("migration file name must look like 000001_name.up.sql or 000001_name.down.sql")
+var ErrMissingDownMigration = errors.New("migration has no down file")
+var ErrChecksumMismatch = errors.New("an applied migration was changed after it was applied")
+var ErrUnknownAppliedVersion = errors.New("the database has an applied migration that is not known")
+var ErrNothingToRollBack = errors.New("there is no applied migration to roll back")
+var ErrPendingMigrations = errors.New("the database has migrations that are not applied yet")
+
+// lockId is the key of the Postgres advisory lock that keeps two instances
+// from migrating the same database at the same time.
+const lockId int64 = 4_682_011_733_415_870_001
+
+const (
+	createMigrationsTableQuery string = "create table if not exists schema_migrations (version bigint primary key, name varchar(200) not null, checksum char(64) not null, applied_at timestamptz not null)"
+	lockQuery                  string = "select pg_advisory_lock($1)"
+	unlockQuery                string = "select pg_advisory_unlock($1)"
+	appliedMigrationsQuery     string = "select version, checksum, applied_at from schema_migrations order by version"
+	insertMigrationQuery       string = "insert into schema_migrations (version, name, checksum, applied_at) values ($1, $2, $3, $4)"
+	deleteMigrationQuery       string = "delete from schema_migrations where version = $1"
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+type Migrator struct {
+	DBPool     *sql.DB
+	Migrations []Migration
+	Now        func() time.Time
+}
+
+// GetMigrator returns a Migrator for the SQL files embedded in this package.
+func GetMigrator(dbPool *sql.DB) (*Migrator, error) {
+	migrations, err := Load(embeddedFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(dbPool, migrations)
+}
+
+func NewMigrator(dbPool *sql.DB, migrations []Migration) (*Migrator, error) {
+	if dbPool == nil {
+		return nil, ErrDBPoolIsNil
+	}
+	return &Migrator{DBPool: dbPool, Migrations: migrations, Now: time.Now}, nil
+}
+
+// Load reads every *.up.sql and *.down.sql file under the sql directory of
+// fsys and returns the migrations ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	fileNames, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, fileName := range fileNames {
+		version, name, direction, err := parseFileName(path.Base(fileName))
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+			checksum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrMissingDownMigration, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parseFileName(fileName string) (version int64, name string, direction string, err error) {
+	withoutExtension := strings.TrimSuffix(fileName, ".sql")
+	switch {
+	case strings.HasSuffix(withoutExtension, ".up"):
+		direction = "up"
+	case strings.HasSuffix(withoutExtension, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
+	}
+	versionAndName := strings.SplitN(strings.TrimSuffix(withoutExtension, "."+direction), "_", 2)
+	if len(versionAndName) != 2 || versionAndName[1] == "" {
+		return 0, "", "", fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
+	}
+	version, err = strconv.ParseInt(versionAndName[0], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
+	}
+	return version, versionAndName[1], direction, nil
+}
+
+// Up applies every pending migration, each one in its own transaction.
+func (m *Migrator) Up() error {
+	return m.withLock(func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		for _, migration := range m.Migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down() error {
+	return m.withLock(func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		migration, ok := m.latestApplied(applied)
+		if !ok {
+			return ErrNothingToRollBack
+		}
+		return m.rollBack(conn, migration)
+	})
+}
+
+// Redo rolls back the latest applied migration and applies it again.
+func (m *Migrator) Redo() error {
+	return m.withLock(func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		migration, ok := m.latestApplied(applied)
+		if !ok {
+			return ErrNothingToRollBack
+		}
+		if err := m.rollBack(conn, migration); err != nil {
+			return err
+		}
+		return m.apply(conn, migration)
+	})
+}
+
+// Status reports every known migration and when it was applied, if it was.
+func (m *Migrator) Status() ([]Status, error) {
+	statuses := []Status{}
+	err := m.withLock(func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		for _, migration := range m.Migrations {
+			status := Status{Migration: migration}
+			if appliedMigration, ok := applied[migration.Version]; ok {
+				appliedAt := appliedMigration.appliedAt.UTC()
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// Check fails with ErrPendingMigrations, naming the pending migrations, when
+// the schema of the database is behind the known migrations.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	pending := []string{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%06d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func (m *Migrator) withLock(do func(*sql.Conn, map[int64]appliedMigration) error) (err error) {
+	ctx := context.Background()
+	conn, err := m.DBPool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, lockQuery, lockId); err != nil {
+		return err
+	}
+	defer func() {
+		if _, unlockErr := conn.ExecContext(ctx, unlockQuery, lockId); err == nil {
+			err = unlockErr
+		}
+	}()
+	if _, err := conn.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return err
+	}
+	applied, err := m.applied(conn)
+	if err != nil {
+		return err
+	}
+	return do(conn, applied)
+}
+
+func (m *Migrator) applied(conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(context.Background(), appliedMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var migration appliedMigration
+		if err := rows.Scan(&version, &migration.checksum, &migration.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = migration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	known := map[int64]Migration{}
+	for _, migration := range m.Migrations {
+		known[migration.Version] = migration
+	}
+	for version, appliedMigration := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownAppliedVersion, version)
+		}
+		if migration.Checksum != appliedMigration.checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) latestApplied(applied map[int64]appliedMigration) (Migration, bool) {
+	for i := len(m.Migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.Migrations[i].Version]; ok {
+			return m.Migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) apply(conn *sql.Conn, migration Migration) error {
+	return inTransaction(conn, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(migration.Up); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(insertMigrationQuery, migration.Version, migration.Name,
+			migration.Checksum, m.Now().UTC())
+		return err
+	})
+}
+
+func (m *Migrator) rollBack(conn *sql.Conn, migration Migration) error {
+	return inTransaction(conn, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(migration.Down); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(deleteMigrationQuery, migration.Version)
+		return err
+	})
+}
+
+func inTransaction(conn *sql.Conn, do func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := do(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
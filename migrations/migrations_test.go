@@ -0,0 +1,238 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFiles = fstest.MapFS{
+	"sql/000002_add_index.up.sql":     {Data: []byte("create index i on todo (user_id);")},
+	"sql/000002_add_index.down.sql":   {Data: []byte("drop index i;")},
+	"sql/000001_create_todo.up.sql":   {Data: []byte("create table todo (id uuid);")},
+	"sql/000001_create_todo.down.sql": {Data: []byte("drop table todo;")},
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		migrations, err := Load(testFiles)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "create_todo", migrations[0].Name)
+		assert.Equal(t, "create table todo (id uuid);", migrations[0].Up)
+		assert.Equal(t, "drop table todo;", migrations[0].Down)
+		assert.Len(t, migrations[0].Checksum, 64)
+		assert.Equal(t, int64(2), migrations[1].Version)
+		assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+	})
+
+	t.Run("The embedded migrations", func(t *testing.T) {
+		migrations, err := Load(embeddedFiles)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+		assert.Equal(t, int64(1), migrations[0].Version)
+	})
+
+	t.Run("When a file name is invalid", func(t *testing.T) {
+		for _, fileName := range []string{"sql/create_todo.up.sql", "sql/000001_create_todo.sql",
+			"sql/000001.up.sql", "sql/000000_zero.up.sql"} {
+			_, err := Load(fstest.MapFS{fileName: {Data: []byte("select 1;")}})
+			assert.ErrorIs(t, err, ErrInvalidFileName, fileName)
+		}
+	})
+
+	t.Run("When a down file is missing", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"sql/000001_create_todo.up.sql": {Data: []byte("select 1;")}})
+		assert.ErrorIs(t, err, ErrMissingDownMigration)
+	})
+}
+
+func TestNewMigrator(t *testing.T) {
+	t.Run("DBPool is nil", func(t *testing.T) {
+		migrator, err := GetMigrator(nil)
+		assert.Equal(t, ErrDBPoolIsNil, err)
+		assert.Nil(t, migrator)
+	})
+}
+
+func TestUp(t *testing.T) {
+	t.Run("Only pending migrations are applied", func(t *testing.T) {
+		migrator, mock, now := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(migrator.Migrations[0]))
+		mock.ExpectBegin()
+		mock.ExpectExec(migrator.Migrations[1].Up).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).WithArgs(migrator.Migrations[1].Version,
+			migrator.Migrations[1].Name, migrator.Migrations[1].Checksum, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock(mock)
+		assert.NoError(t, migrator.Up())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When a migration fails its transaction is rolled back", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows())
+		mock.ExpectBegin()
+		mock.ExpectExec(migrator.Migrations[0].Up).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		expectUnlock(mock)
+		assert.ErrorIs(t, migrator.Up(), common.ErrError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When an applied migration was changed", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		changed := migrator.Migrations[0]
+		changed.Checksum = "changed"
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(changed))
+		expectUnlock(mock)
+		assert.ErrorIs(t, migrator.Up(), ErrChecksumMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When the database has an unknown migration", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(Migration{Version: 3}))
+		expectUnlock(mock)
+		assert.ErrorIs(t, migrator.Up(), ErrUnknownAppliedVersion)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When the advisory lock can't be taken", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		mock.ExpectExec(lockQuery).WithArgs(lockId).WillReturnError(common.ErrError)
+		assert.Equal(t, common.ErrError, migrator.Up())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDown(t *testing.T) {
+	t.Run("The latest applied migration is rolled back", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(migrator.Migrations...))
+		mock.ExpectBegin()
+		mock.ExpectExec(migrator.Migrations[1].Down).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteMigrationQuery).WithArgs(migrator.Migrations[1].Version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock(mock)
+		assert.NoError(t, migrator.Down())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When there is no applied migration", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows())
+		expectUnlock(mock)
+		assert.Equal(t, ErrNothingToRollBack, migrator.Down())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRedo(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		migrator, mock, now := create(t)
+		latest := migrator.Migrations[0]
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(latest))
+		mock.ExpectBegin()
+		mock.ExpectExec(latest.Down).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteMigrationQuery).WithArgs(latest.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(latest.Up).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).WithArgs(latest.Version, latest.Name, latest.Checksum, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock(mock)
+		assert.NoError(t, migrator.Redo())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Run("Applied and pending migrations", func(t *testing.T) {
+		migrator, mock, now := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(migrator.Migrations[0]))
+		expectUnlock(mock)
+		statuses, err := migrator.Status()
+		assert.NoError(t, err)
+		assert.Equal(t, []Status{
+			{Migration: migrator.Migrations[0], AppliedAt: &now},
+			{Migration: migrator.Migrations[1]},
+		}, statuses)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("Every migration is applied", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows(migrator.Migrations...))
+		expectUnlock(mock)
+		assert.NoError(t, migrator.Check())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("When migrations are pending", func(t *testing.T) {
+		migrator, mock, _ := create(t)
+		expectLock(mock)
+		mock.ExpectQuery(appliedMigrationsQuery).WillReturnRows(appliedRows())
+		expectUnlock(mock)
+		err := migrator.Check()
+		assert.ErrorIs(t, err, ErrPendingMigrations)
+		assert.EqualError(t, err, ErrPendingMigrations.Error()+": 000001_create_todo, 000002_add_index")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func create(t *testing.T) (*Migrator, sqlmock.Sqlmock, time.Time) {
+	t.Helper()
+	dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := Load(testFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := NewMigrator(dbPool, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	migrator.Now = func() time.Time { return now }
+	return migrator, mock, now
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(lockQuery).WithArgs(lockId).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createMigrationsTableQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(unlockQuery).WithArgs(lockId).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func appliedRows(migrations ...Migration) *sqlmock.Rows {
+	now, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	rows := sqlmock.NewRows([]string{"version", "checksum", "applied_at"})
+	for _, migration := range migrations {
+		rows.AddRow(migration.Version, migration.Checksum, now)
+	}
+	return rows
+}
@@ -1,4 +1,4 @@
-create table todo (
+create table if not exists todo (
     id uuid primary key,
     title varchar(500) not null,
     description varchar(10000) not null,
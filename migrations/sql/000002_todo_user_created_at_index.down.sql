@@ -0,0 +1 @@
+drop index if exists todo_user_id_created_at_id_idx;
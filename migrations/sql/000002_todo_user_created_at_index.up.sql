@@ -0,0 +1 @@
+create index if not exists todo_user_id_created_at_id_idx on todo (user_id, created_at, id);
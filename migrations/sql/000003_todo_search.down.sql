@@ -0,0 +1,5 @@
+drop index if exists todo_title_trgm_idx;
+
+drop index if exists todo_search_idx;
+
+alter table todo drop column if exists search;
@@ -0,0 +1,10 @@
+create extension if not exists pg_trgm;
+
+alter table todo add column if not exists search tsvector generated always as (
+    setweight(to_tsvector('english'::regconfig, title), 'A') ||
+    setweight(to_tsvector('english'::regconfig, description), 'B')
+) stored;
+
+create index if not exists todo_search_idx on todo using gin (search);
+
+create index if not exists todo_title_trgm_idx on todo using gin (title gin_trgm_ops);
@@ -0,0 +1,3 @@
+alter table todo drop column if exists completed_at;
+
+alter table todo drop column if exists updated_at;
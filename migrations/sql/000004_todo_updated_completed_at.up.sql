@@ -0,0 +1,9 @@
+alter table todo add column if not exists updated_at timestamptz;
+
+update todo set updated_at = created_at where updated_at is null;
+
+alter table todo alter column updated_at set not null;
+
+alter table todo add column if not exists completed_at timestamptz;
+
+update todo set completed_at = updated_at where done and completed_at is null;
@@ -0,0 +1,3 @@
+drop index if exists todo_deleted_at_idx;
+
+alter table todo drop column if exists deleted_at;
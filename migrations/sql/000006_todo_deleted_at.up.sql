@@ -0,0 +1,3 @@
+alter table todo add column if not exists deleted_at timestamptz;
+
+create index if not exists todo_deleted_at_idx on todo (deleted_at) where deleted_at is not null;
@@ -0,0 +1,5 @@
+drop table if exists todo_tag;
+
+drop function if exists delete_unused_tag;
+
+drop table if exists tag;
@@ -0,0 +1,25 @@
+create table if not exists tag (
+    id bigint generated always as identity primary key,
+    user_id varchar(40) not null,
+    name varchar(50) not null,
+    unique (user_id, name)
+);
+
+create table if not exists todo_tag (
+    todo_id uuid not null references todo (id) on delete cascade,
+    tag_id bigint not null references tag (id) on delete cascade,
+    primary key (todo_id, tag_id)
+);
+
+create index if not exists todo_tag_tag_id_idx on todo_tag (tag_id);
+
+create or replace function delete_unused_tag() returns trigger as $$
+begin
+    delete from tag where id = old.tag_id and not exists (select 1 from todo_tag where tag_id = old.tag_id);
+    return null;
+end;
+$$ language plpgsql;
+
+drop trigger if exists todo_tag_delete_unused_tag on todo_tag;
+create trigger todo_tag_delete_unused_tag after delete on todo_tag
+    for each row execute function delete_unused_tag();
@@ -0,0 +1,3 @@
+alter table todo drop column if exists list_id;
+
+drop table if exists list;
@@ -0,0 +1,25 @@
+create table if not exists list (
+    id uuid primary key,
+    user_id varchar(40) not null,
+    name varchar(100) not null,
+    inbox bool not null default false,
+    created_at timestamptz not null,
+    updated_at timestamptz not null,
+    archived_at timestamptz
+);
+
+create unique index if not exists list_user_id_inbox_idx on list (user_id) where inbox;
+
+create index if not exists list_user_id_created_at_id_idx on list (user_id, created_at, id);
+
+insert into list (id, user_id, name, inbox, created_at, updated_at)
+select gen_random_uuid(), user_id, 'Inbox', true, now(), now() from todo group by user_id
+on conflict do nothing;
+
+alter table todo add column if not exists list_id uuid references list (id) on delete cascade;
+
+update todo set list_id = list.id from list where list.user_id = todo.user_id and list.inbox and todo.list_id is null;
+
+alter table todo alter column list_id set not null;
+
+create index if not exists todo_list_id_idx on todo (list_id);
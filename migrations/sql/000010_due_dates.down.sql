@@ -0,0 +1,8 @@
+drop index if exists todo_user_id_overdue_at_idx;
+
+drop index if exists todo_user_id_due_at_id_idx;
+
+alter table todo drop column if exists overdue_at;
+alter table todo drop column if exists timezone;
+alter table todo drop column if exists due_all_day;
+alter table todo drop column if exists due_at;
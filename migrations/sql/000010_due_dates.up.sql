@@ -0,0 +1,11 @@
+alter table todo add column if not exists due_at timestamptz;
+alter table todo add column if not exists due_all_day bool not null default false;
+alter table todo add column if not exists timezone varchar(64) not null default '';
+
+-- overdue_at is when the todo becomes overdue, which for a todo that is due
+-- on a day is the end of that day in its timezone.
+alter table todo add column if not exists overdue_at timestamptz;
+
+create index if not exists todo_user_id_due_at_id_idx on todo (user_id, due_at, id) where deleted_at is null;
+
+create index if not exists todo_user_id_overdue_at_idx on todo (user_id, overdue_at) where deleted_at is null and not done;
@@ -0,0 +1,6 @@
+drop index if exists todo_user_id_priority_id_idx;
+
+drop index if exists todo_user_id_position_idx;
+
+alter table todo drop column if exists position;
+alter table todo drop column if exists priority;
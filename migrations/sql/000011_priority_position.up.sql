@@ -0,0 +1,18 @@
+alter table todo add column if not exists priority smallint not null default 0;
+
+-- position compares byte by byte, as the positions are fractional keys that
+-- sort as plain byte strings. The todos that exist keep the order in which
+-- they were created.
+alter table todo add column if not exists position text collate "C";
+
+update todo set position = numbered.position from (
+    select id, 'V' || lpad(row_number() over (partition by user_id order by created_at, id)::text, 10, '0') ||
+        replace(id::text, '-', '') as position
+    from todo
+) as numbered where todo.id = numbered.id and todo.position is null;
+
+alter table todo alter column position set not null;
+
+create unique index if not exists todo_user_id_position_idx on todo (user_id, position);
+
+create index if not exists todo_user_id_priority_id_idx on todo (user_id, priority, id) where deleted_at is null;
@@ -0,0 +1 @@
+alter table todo drop column if exists recurrence;
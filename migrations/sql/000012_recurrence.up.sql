@@ -0,0 +1,2 @@
+-- The RRULE of a todo that recurs, empty for one that doesn't.
+alter table todo add column if not exists recurrence text not null default '';
@@ -0,0 +1 @@
+drop table if exists list_member;
@@ -0,0 +1,12 @@
+-- The users that a list is shared with, and whether they edit or only view
+-- its todos. The owner of a list is list.user_id.
+create table if not exists list_member (
+    list_id uuid not null references list (id) on delete cascade,
+    user_id varchar(40) not null,
+    role varchar(16) not null check (role in ('editor', 'viewer')),
+    created_at timestamptz not null,
+    updated_at timestamptz not null,
+    primary key (list_id, user_id)
+);
+
+create index if not exists list_member_user_id_idx on list_member (user_id);
@@ -0,0 +1,13 @@
+-- The public links that show a todo or a list to anyone who has their token,
+-- without an account. A todo or a list has at most one.
+create table if not exists share_link (
+    token varchar(64) primary key,
+    user_id varchar(40) not null,
+    todo_id uuid unique references todo (id) on delete cascade,
+    list_id uuid unique references list (id) on delete cascade,
+    expires_at timestamptz,
+    access_count bigint not null default 0,
+    last_accessed_at timestamptz,
+    created_at timestamptz not null,
+    check ((todo_id is null) <> (list_id is null))
+);
@@ -0,0 +1,12 @@
+-- The comments that users write on todos, which go with their todo.
+-- todo_comment.user_id is the author of a comment.
+create table if not exists todo_comment (
+    id uuid primary key,
+    todo_id uuid not null references todo (id) on delete cascade,
+    user_id varchar(40) not null,
+    body varchar(10000) not null,
+    created_at timestamptz not null,
+    updated_at timestamptz not null
+);
+
+create index if not exists todo_comment_todo_id_created_at_id_idx on todo_comment (todo_id, created_at, id);
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+const DefaultAgendaDays int = 7
+const MaxAgendaDays int = 31
+
+var ErrInvalidAgendaRange error = errors.New("to must be from the day of from to 30 days after it")
+
+// AgendaRequest is the query of a GET /agenda: the days from From to To,
+// both included, in the time zone of Timezone. From and To are days, or times
+// that are on their day in Timezone. The agenda starts today when From is
+// left out, runs for DefaultAgendaDays when To is, and is in UTC when
+// Timezone is.
+type AgendaRequest struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Timezone string `form:"timezone" binding:"omitempty,timezone"`
+}
+
+// AgendaDay is a day of an agenda with the todos that are due on it: the
+// ones due on the whole day first, and then the others by their due time.
+type AgendaDay struct {
+	Date  string `json:"date"`
+	Todos []Todo `json:"todos"`
+}
+
+// Agenda is the days from First to Last in Location. The days are kept as
+// their midnight in UTC, as the days of a Due are.
+type Agenda struct {
+	First    time.Time
+	Last     time.Time
+	Location *time.Location
+}
+
+// Agenda returns the agenda that the request asks for at now.
+func (request AgendaRequest) Agenda(now time.Time) (Agenda, error) {
+	agenda := Agenda{Location: locationOf(request.Timezone)}
+	var err error
+	if request.From == "" {
+		agenda.First, _ = time.Parse(DateLayout, now.In(agenda.Location).Format(DateLayout))
+	} else if agenda.First, err = dayOf(request.From, agenda.Location); err != nil {
+		return Agenda{}, err
+	}
+	if request.To == "" {
+		agenda.Last = agenda.First.AddDate(0, 0, DefaultAgendaDays-1)
+	} else if agenda.Last, err = dayOf(request.To, agenda.Location); err != nil {
+		return Agenda{}, err
+	}
+	if agenda.Last.Before(agenda.First) || !agenda.Last.Before(agenda.First.AddDate(0, 0, MaxAgendaDays)) {
+		return Agenda{}, ErrInvalidAgendaRange
+	}
+	return agenda, nil
+}
+
+// dayOf reads value as a Due and returns the day it is on in location, as its
+// midnight in UTC.
+func dayOf(value string, location *time.Location) (time.Time, error) {
+	var due Due
+	if err := due.UnmarshalText([]byte(value)); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(DateLayout, due.Day(location))
+}
+
+// DueWindow returns the due times between which every todo that is due on a
+// day of the agenda is, with some that are due a day around it. A day of the
+// agenda starts up to 14 hours apart from the same day in UTC.
+func (agenda Agenda) DueWindow() (after time.Time, before time.Time) {
+	return agenda.First.AddDate(0, 0, -1), agenda.Last.AddDate(0, 0, 2)
+}
+
+// Days returns every day of the agenda with the todos that are due on it, in
+// the order of todos within each group.
+func (agenda Agenda) Days(todos []Todo) []AgendaDay {
+	days := []AgendaDay{}
+	index := map[string]int{}
+	for day := agenda.First; !day.After(agenda.Last); day = day.AddDate(0, 0, 1) {
+		index[day.Format(DateLayout)] = len(days)
+		days = append(days, AgendaDay{Date: day.Format(DateLayout), Todos: []Todo{}})
+	}
+	for _, todo := range todos {
+		if todo.DueAt == nil {
+			continue
+		}
+		if i, ok := index[todo.DueAt.Day(agenda.Location)]; ok {
+			days[i].Todos = append(days[i].Todos, todo)
+		}
+	}
+	for _, day := range days {
+		sort.SliceStable(day.Todos, func(i, j int) bool {
+			a, b := day.Todos[i].DueAt, day.Todos[j].DueAt
+			return a.DateOnly && !b.DateOnly || (a.DateOnly == b.DateOnly && a.Time.Before(b.Time))
+		})
+	}
+	return days
+}
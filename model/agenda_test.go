@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgendaRequestAgenda(t *testing.T) {
+	now := time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC)
+
+	t.Run("Defaults", func(t *testing.T) {
+		agenda, err := AgendaRequest{}.Agenda(now)
+		assert.NoError(t, err)
+		assert.Equal(t, Agenda{First: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC),
+			Last: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Location: time.UTC}, agenda)
+	})
+
+	t.Run("Today is the day in the time zone", func(t *testing.T) {
+		agenda, err := AgendaRequest{Timezone: "Asia/Tokyo"}.Agenda(now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), agenda.First)
+		assert.Equal(t, "Asia/Tokyo", agenda.Location.String())
+	})
+
+	t.Run("Good case", func(t *testing.T) {
+		agenda, err := AgendaRequest{From: "2024-02-28", To: "2024-03-01"}.Agenda(now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), agenda.First)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), agenda.Last)
+		_, err = AgendaRequest{From: "2024-03-01", To: "2024-03-31"}.Agenda(now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Good case: times are read as their day in the time zone", func(t *testing.T) {
+		agenda, err := AgendaRequest{From: "2024-02-28T20:00:00Z", To: "2024-03-01T12:00:00+09:00",
+			Timezone: "Asia/Tokyo"}.Agenda(now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), agenda.First)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), agenda.Last)
+	})
+
+	t.Run("Invalid day", func(t *testing.T) {
+		for _, request := range []AgendaRequest{{From: "2024-13-01"}, {To: "tomorrow"}} {
+			_, err := request.Agenda(now)
+			assert.Equal(t, ErrInvalidDue, err, request)
+		}
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		for _, request := range []AgendaRequest{{From: "2024-03-02", To: "2024-03-01"}, {From: "2024-03-01", To: "2024-04-01"},
+			{To: "2024-03-08"}} {
+			_, err := request.Agenda(now)
+			assert.Equal(t, ErrInvalidAgendaRange, err, request)
+		}
+	})
+}
+
+func TestAgendaDays(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	agenda := Agenda{First: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), Last: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		Location: tokyo}
+	after, before := agenda.DueWindow()
+	assert.Equal(t, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), after)
+	assert.Equal(t, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), before)
+	// 16:00 UTC on the 9th is already the 10th in Tokyo.
+	evening := Todo{Id: uuid.New().String(), DueAt: &Due{Time: time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC)}}
+	morning := Todo{Id: uuid.New().String(), DueAt: &Due{Time: time.Date(2024, 3, 9, 1, 0, 0, 0, time.UTC)}}
+	allDay := Todo{Id: uuid.New().String(), DueAt: &Due{Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), DateOnly: true}}
+	tooLate := Todo{Id: uuid.New().String(), DueAt: &Due{Time: time.Date(2024, 3, 10, 16, 0, 0, 0, time.UTC)}}
+	notDue := Todo{Id: uuid.New().String()}
+	days := agenda.Days([]Todo{morning, evening, allDay, tooLate, notDue})
+	assert.Equal(t, []AgendaDay{{Date: "2024-03-09", Todos: []Todo{morning}}, {Date: "2024-03-10", Todos: []Todo{allDay, evening}}},
+		days)
+	assert.Equal(t, []AgendaDay{{Date: "2024-03-09", Todos: []Todo{}}, {Date: "2024-03-10", Todos: []Todo{}}}, agenda.Days(nil))
+}
@@ -0,0 +1,40 @@
+package model
+
+import "encoding/json"
+
+const MaxBatchOperations int = 100
+
+const (
+	CreateOperation string = "create"
+	UpdateOperation string = "update"
+	DeleteOperation string = "delete"
+)
+
+// BatchRequest is the body of a POST /todos:batch. Its operations run in
+// order in one transaction, which is rolled back as a whole when one of them
+// fails unless AllowPartial is set, in which case only the failed operations
+// are undone.
+type BatchRequest struct {
+	AllowPartial bool             `json:"allowPartial"`
+	Operations   []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchOperation is one operation of a batch. Todo is the body of the
+// matching POST /todos or PUT /todos, Id is the todo to delete and IfMatch is
+// the If-Match header of an update or a delete.
+type BatchOperation struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	Id      string          `json:"id"`
+	IfMatch string          `json:"ifMatch"`
+	Todo    json.RawMessage `json:"todo"`
+}
+
+// BatchResult is the outcome of one operation of a batch: the status code,
+// ETag and todo that the matching single request would have answered, or the
+// error that it failed with.
+type BatchResult struct {
+	Status int    `json:"status"`
+	ETag   string `json:"etag,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// TodoChanges holds the new values of the fields of a todo that changed.
+// Fields that didn't change are nil. An empty ListId moves the todo to the
+// inbox. UpdatedAt is the time of the change.
+type TodoChanges struct {
+	Title       *string
+	Description *string
+	Done        *bool
+	Tags        *[]string
+	ListId      *string
+	Due         *DueChange
+	Priority    *Priority
+	UpdatedAt   time.Time
+}
+
+// DueChange is the new due date, timezone and recurrence of a todo, which
+// change together as the timezone tells when a day is over and the
+// recurrence starts from the due date. A nil DueAt leaves the todo without a
+// due date, and an empty Recurrence makes it stop recurring.
+type DueChange struct {
+	DueAt      *Due
+	Timezone   string
+	Recurrence string
+}
+
+// IsValid tells whether a todo can have the due date, the timezone and the
+// recurrence of the change, as only a todo that is due can recur.
+func (change DueChange) IsValid() bool {
+	return IsValidTimezone(change.Timezone) && IsValidRecurrence(change.Recurrence) &&
+		(change.Recurrence == "" || change.DueAt != nil)
+}
+
+// Diff returns the fields of after that are different from before. The id
+// and the timestamps are not compared as clients can't change them.
+func Diff(before Todo, after Todo) TodoChanges {
+	var changes TodoChanges
+	if before.Title != after.Title {
+		changes.Title = &after.Title
+	}
+	if before.Description != after.Description {
+		changes.Description = &after.Description
+	}
+	if (before.Done == nil) != (after.Done == nil) || (after.Done != nil && *before.Done != *after.Done) {
+		changes.Done = after.Done
+	}
+	if tags := NormalizeTags(after.Tags); !sameTags(NormalizeTags(before.Tags), tags) {
+		changes.Tags = &tags
+	}
+	if before.ListId != after.ListId {
+		changes.ListId = &after.ListId
+	}
+	if !sameDue(before.DueAt, after.DueAt) || before.Timezone != after.Timezone || before.Recurrence != after.Recurrence {
+		changes.Due = &DueChange{DueAt: after.DueAt, Timezone: after.Timezone, Recurrence: after.Recurrence}
+	}
+	if before.Priority != after.Priority {
+		changes.Priority = &after.Priority
+	}
+	return changes
+}
+
+func (changes TodoChanges) IsEmpty() bool {
+	return changes.Title == nil && changes.Description == nil && changes.Done == nil && changes.Tags == nil &&
+		changes.ListId == nil && changes.Due == nil && changes.Priority == nil
+}
+
+func sameTags(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
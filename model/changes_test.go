@@ -0,0 +1,105 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	done := false
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	before := Todo{Id: uuid.New().String(), Title: "title", Description: "description", Done: &done, CreatedAt: ti}
+
+	t.Run("Nothing changed", func(t *testing.T) {
+		after := before
+		sameDone := false
+		after.Done = &sameDone
+		after.UpdatedAt = ti.Add(time.Hour)
+		changes := Diff(before, after)
+		assert.True(t, changes.IsEmpty())
+	})
+
+	t.Run("Some fields changed", func(t *testing.T) {
+		after := before
+		newDone := true
+		after.Done = &newDone
+		after.Title = "new title"
+		changes := Diff(before, after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, TodoChanges{Title: &after.Title, Done: &newDone}, changes)
+	})
+
+	t.Run("Every field changed", func(t *testing.T) {
+		newDone := true
+		after := Todo{Id: before.Id, Title: "t", Description: "d", Done: &newDone, CreatedAt: ti.Add(time.Hour)}
+		changes := Diff(before, after)
+		assert.Equal(t, TodoChanges{Title: &after.Title, Description: &after.Description, Done: &newDone}, changes)
+	})
+
+	t.Run("The tags changed", func(t *testing.T) {
+		tagged := before
+		tagged.Tags = []string{"home", "work"}
+		after := tagged
+		after.Tags = []string{"Work", "home"}
+		assert.True(t, Diff(tagged, after).IsEmpty())
+		after.Tags = []string{"work"}
+		changes := Diff(tagged, after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, &[]string{"work"}, changes.Tags)
+		after.Tags = nil
+		assert.Equal(t, &[]string{}, Diff(tagged, after).Tags)
+		assert.True(t, Diff(before, Todo{Id: before.Id, Title: before.Title, Description: before.Description,
+			Done: &done, Tags: []string{}}).IsEmpty())
+	})
+
+	t.Run("The list changed", func(t *testing.T) {
+		listed := before
+		listed.ListId = uuid.New().String()
+		after := listed
+		after.ListId = uuid.New().String()
+		changes := Diff(listed, after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, TodoChanges{ListId: &after.ListId}, changes)
+		after.ListId = ""
+		assert.Equal(t, "", *Diff(listed, after).ListId)
+	})
+
+	t.Run("The due date or the timezone changed", func(t *testing.T) {
+		due := before
+		due.DueAt = &Due{Time: ti}
+		after := due
+		after.DueAt = &Due{Time: ti.In(time.FixedZone("", 3600))}
+		assert.True(t, Diff(due, after).IsEmpty())
+		after.DueAt = &Due{Time: ti, DateOnly: true}
+		changes := Diff(due, after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, TodoChanges{Due: &DueChange{DueAt: after.DueAt}}, changes)
+		after = due
+		after.Timezone = "Asia/Tokyo"
+		assert.Equal(t, &DueChange{DueAt: due.DueAt, Timezone: "Asia/Tokyo"}, Diff(due, after).Due)
+		assert.Equal(t, &DueChange{}, Diff(due, before).Due)
+	})
+
+	t.Run("The recurrence changed", func(t *testing.T) {
+		due := before
+		due.DueAt, due.Timezone = &Due{Time: ti}, "Asia/Tokyo"
+		after := due
+		after.Recurrence = "FREQ=DAILY"
+		assert.Equal(t, TodoChanges{Due: &DueChange{DueAt: due.DueAt, Timezone: "Asia/Tokyo", Recurrence: "FREQ=DAILY"}},
+			Diff(due, after))
+		assert.Equal(t, &DueChange{DueAt: due.DueAt, Timezone: "Asia/Tokyo"}, Diff(after, due).Due)
+	})
+
+	t.Run("The priority changed", func(t *testing.T) {
+		after := before
+		after.Priority = PriorityMedium
+		changes := Diff(before, after)
+		assert.False(t, changes.IsEmpty())
+		assert.Equal(t, TodoChanges{Priority: &after.Priority}, changes)
+		after.Position = "V1"
+		assert.Equal(t, TodoChanges{Priority: &after.Priority}, Diff(before, after))
+	})
+}
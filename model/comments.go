@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// MaxCommentLength is the length of the body column, which is the length of
+// the description of a todo.
+const MaxCommentLength int = 10000
+
+// Comment is a comment that the user of AuthorId wrote on the todo of TodoId.
+// Anyone who reads the todo reads its comments, anyone who writes to it
+// comments on it, and only the author of a comment edits or deletes it. The
+// comments of a todo go with it.
+type Comment struct {
+	Id        string    `json:"id" validate:"required,uuid"`
+	TodoId    string    `json:"todoId" validate:"required,uuid"`
+	AuthorId  string    `json:"authorId" validate:"required,max=40"`
+	Body      string    `json:"body" validate:"required,max=10000"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
+}
+
+// CreateCommentRequest is the body of a POST /todos/:id/comments.
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
+
+// Comment returns the comment with id that the request creates on the todo of
+// todoId for the user of authorId at now.
+func (request CreateCommentRequest) Comment(id string, todoId string, authorId string, now time.Time) Comment {
+	return Comment{Id: id, TodoId: todoId, AuthorId: authorId, Body: request.Body, CreatedAt: now, UpdatedAt: now}
+}
+
+// UpdateCommentRequest is the body of a PUT /todos/:id/comments/:commentId,
+// which replaces the body of a comment.
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
+
+// Comment returns the new state of the comment of id that the request
+// updates at now. CreatedAt is left zero as an update never changes it.
+func (request UpdateCommentRequest) Comment(id string, todoId string, authorId string, now time.Time) Comment {
+	return Comment{Id: id, TodoId: todoId, AuthorId: authorId, Body: request.Body, UpdatedAt: now}
+}
+
+// CommentPage is a page of the comments of a todo, from the oldest to the
+// newest.
+type CommentPage struct {
+	Comments []Comment
+	Next     *Cursor
+	Prev     *Cursor
+}
+
+// CommentCursorOf is CursorOf for a comment, which only carries the time it
+// was created at and its id.
+func CommentCursorOf(comment Comment, backward bool) *Cursor {
+	return &Cursor{CreatedAt: comment.CreatedAt, Id: comment.Id, Backward: backward}
+}
+
+// NewCommentPage is NewPage for comments.
+func NewCommentPage(comments []Comment, pageRequest PageRequest) *CommentPage {
+	comments, next, prev := pageOf(comments, pageRequest, CommentCursorOf)
+	return &CommentPage{Comments: comments, Next: next, Prev: prev}
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentRequests(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	id, todoId := uuid.New().String(), uuid.New().String()
+
+	t.Run("CreateCommentRequest", func(t *testing.T) {
+		comment := CreateCommentRequest{Body: "body"}.Comment(id, todoId, "oewhgwe", ti)
+		assert.Equal(t, Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", Body: "body", CreatedAt: ti, UpdatedAt: ti}, comment)
+		assert.True(t, IsValid(comment))
+	})
+
+	t.Run("UpdateCommentRequest", func(t *testing.T) {
+		comment := UpdateCommentRequest{Body: "body"}.Comment(id, todoId, "oewhgwe", ti)
+		assert.Equal(t, Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", Body: "body", UpdatedAt: ti}, comment)
+		assert.True(t, IsValidExcept(comment, "CreatedAt"))
+	})
+}
+
+func TestCommentIsValid(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	valid := Comment{Id: uuid.New().String(), TodoId: uuid.New().String(), AuthorId: "oewhgwe", Body: "body",
+		CreatedAt: ti, UpdatedAt: ti}
+	assert.True(t, IsValid(valid))
+	long := valid
+	long.Body = strings.Repeat("a", MaxCommentLength)
+	assert.True(t, IsValid(long))
+	for _, change := range []func(comment *Comment){
+		func(comment *Comment) { comment.Id = "wrong" },
+		func(comment *Comment) { comment.TodoId = "" },
+		func(comment *Comment) { comment.AuthorId = "" },
+		func(comment *Comment) { comment.AuthorId = strings.Repeat("a", MaxUserIdLength+1) },
+		func(comment *Comment) { comment.Body = "" },
+		func(comment *Comment) { comment.Body = strings.Repeat("a", MaxCommentLength+1) },
+		func(comment *Comment) { comment.UpdatedAt = time.Time{} },
+	} {
+		invalid := valid
+		change(&invalid)
+		assert.False(t, IsValid(invalid))
+	}
+}
+
+func TestNewCommentPage(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	comments := make([]Comment, 3)
+	for i := range comments {
+		comments[i] = Comment{Id: uuid.New().String(), CreatedAt: ti.Add(time.Duration(i) * time.Minute)}
+	}
+
+	t.Run("Every comment", func(t *testing.T) {
+		page := NewCommentPage(comments, PageRequest{})
+		assert.Equal(t, &CommentPage{Comments: comments}, page)
+	})
+
+	t.Run("The first page", func(t *testing.T) {
+		page := NewCommentPage(append([]Comment{}, comments...), PageRequest{Limit: 2})
+		assert.Equal(t, comments[:2], page.Comments)
+		assert.Equal(t, CommentCursorOf(comments[1], false), page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("The last page", func(t *testing.T) {
+		page := NewCommentPage(comments[2:], PageRequest{Limit: 2, Cursor: CommentCursorOf(comments[1], false)})
+		assert.Equal(t, comments[2:], page.Comments)
+		assert.Nil(t, page.Next)
+		assert.Equal(t, CommentCursorOf(comments[2], true), page.Prev)
+	})
+
+	t.Run("A page backward", func(t *testing.T) {
+		read := []Comment{comments[1], comments[0]}
+		page := NewCommentPage(read, PageRequest{Limit: 2, Cursor: CommentCursorOf(comments[2], true)})
+		assert.Equal(t, comments[:2], page.Comments)
+		assert.Equal(t, CommentCursorOf(comments[1], false), page.Next)
+		assert.Nil(t, page.Prev)
+	})
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	// The time zones of the todos are validated and used wherever the server
+	// runs, even without the tz database of the system.
+	_ "time/tzdata"
+)
+
+// DateLayout is how a day is written: the day a todo is due on, and the days
+// of an agenda.
+const DateLayout string = "2006-01-02"
+
+var ErrInvalidDue error = errors.New("a due date is a day like 2006-01-02 or a time like 2006-01-02T15:04:05Z07:00")
+
+// Due is when a todo is due: at Time, or on the day of Time when DateOnly is
+// set. A day is kept as its midnight in UTC, so that it is the same day
+// whatever time zone it is read in. It reads as a day or as an RFC 3339 time
+// in JSON.
+type Due struct {
+	Time     time.Time
+	DateOnly bool
+}
+
+func (due Due) MarshalText() ([]byte, error) {
+	if due.DateOnly {
+		return []byte(due.Time.Format(DateLayout)), nil
+	}
+	return due.Time.MarshalText()
+}
+
+func (due *Due) UnmarshalText(text []byte) error {
+	if day, err := time.Parse(DateLayout, string(text)); err == nil {
+		*due = Due{Time: day, DateOnly: true}
+		return nil
+	}
+	at, err := time.Parse(time.RFC3339, string(text))
+	if err != nil {
+		return ErrInvalidDue
+	}
+	*due = Due{Time: at}
+	return nil
+}
+
+// Equal tells whether due and other are due at the same time, or on the same
+// day.
+func (due Due) Equal(other Due) bool {
+	return due.DateOnly == other.DateOnly && due.Time.Equal(other.Time)
+}
+
+// OverdueAt returns when a todo that is due becomes overdue: at its time, or
+// once its day is over in timezone. A day ends in UTC when timezone is empty.
+func (due Due) OverdueAt(timezone string) time.Time {
+	if !due.DateOnly {
+		return due.Time
+	}
+	year, month, day := due.Time.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, locationOf(timezone))
+}
+
+// DueBoundOf reads a bound of the due dates that todos are filtered by: a time
+// like 2006-01-02T15:04:05Z07:00, or a day like 2006-01-02 that starts at its
+// midnight in timezone, or in UTC when timezone is empty.
+func DueBoundOf(value string, timezone string) (time.Time, error) {
+	var due Due
+	if err := due.UnmarshalText([]byte(value)); err != nil {
+		return time.Time{}, err
+	}
+	if !due.DateOnly {
+		return due.Time.UTC(), nil
+	}
+	year, month, day := due.Time.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, locationOf(timezone)).UTC(), nil
+}
+
+// Day returns the day that the todo is due on in location.
+func (due Due) Day(location *time.Location) string {
+	if due.DateOnly {
+		return due.Time.Format(DateLayout)
+	}
+	return due.Time.In(location).Format(DateLayout)
+}
+
+// IsOverdue tells whether the todo is due, isn't done and is overdue at now.
+func (todo Todo) IsOverdue(now time.Time) bool {
+	return todo.DueAt != nil && (todo.Done == nil || !*todo.Done) && !todo.DueAt.OverdueAt(todo.Timezone).After(now)
+}
+
+// locationOf returns the time zone of the IANA name timezone, or UTC when it
+// is empty or unknown.
+func locationOf(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func sameDue(a *Due, b *Due) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// IsValidTimezone tells whether timezone can be the Timezone of a todo.
+func IsValidTimezone(timezone string) bool {
+	return validatorr.Var(timezone, "omitempty,timezone") == nil
+}
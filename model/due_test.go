@@ -0,0 +1,84 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueJSON(t *testing.T) {
+	t.Run("A day", func(t *testing.T) {
+		var todo Todo
+		assert.NoError(t, json.Unmarshal([]byte(`{"dueAt": "2024-03-09"}`), &todo))
+		assert.Equal(t, &Due{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), DateOnly: true}, todo.DueAt)
+		content, err := json.Marshal(todo)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"dueAt":"2024-03-09"`)
+	})
+
+	t.Run("A time", func(t *testing.T) {
+		var todo Todo
+		assert.NoError(t, json.Unmarshal([]byte(`{"dueAt": "2024-03-09T17:30:00+01:00"}`), &todo))
+		assert.False(t, todo.DueAt.DateOnly)
+		assert.True(t, time.Date(2024, 3, 9, 16, 30, 0, 0, time.UTC).Equal(todo.DueAt.Time))
+		content, err := json.Marshal(Todo{DueAt: &Due{Time: time.Date(2024, 3, 9, 16, 30, 0, 0, time.UTC)}})
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"dueAt":"2024-03-09T16:30:00Z"`)
+	})
+
+	t.Run("When the todo isn't due", func(t *testing.T) {
+		content, err := json.Marshal(Todo{})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(content), "dueAt")
+		assert.NotContains(t, string(content), "timezone")
+	})
+
+	t.Run("Invalid due date", func(t *testing.T) {
+		for _, text := range []string{"2024-02-30", "09/03/2024", "2024-03-09T17:30:00", "tomorrow", ""} {
+			var due Due
+			assert.Equal(t, ErrInvalidDue, due.UnmarshalText([]byte(text)), text)
+		}
+	})
+}
+
+func TestOverdueAt(t *testing.T) {
+	at := time.Date(2024, 3, 9, 16, 30, 0, 0, time.UTC)
+	assert.Equal(t, at, Due{Time: at}.OverdueAt("Asia/Tokyo"))
+	day := Due{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	assert.True(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC).Equal(day.OverdueAt("")))
+	assert.True(t, time.Date(2024, 3, 9, 15, 0, 0, 0, time.UTC).Equal(day.OverdueAt("Asia/Tokyo")))
+	// The day ends at 04:00 UTC as New York moves to summer time on it.
+	assert.True(t, time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC).Equal(day.OverdueAt("America/New_York")))
+	assert.True(t, time.Date(2024, 3, 11, 4, 0, 0, 0, time.UTC).Equal(
+		Due{Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), DateOnly: true}.OverdueAt("America/New_York")))
+}
+
+func TestDueBoundOf(t *testing.T) {
+	for value, expected := range map[string]time.Time{
+		"2024-03-09T16:30:00+09:00": time.Date(2024, 3, 9, 7, 30, 0, 0, time.UTC),
+		"2024-03-09":                time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC),
+	} {
+		bound, err := DueBoundOf(value, "")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, bound, value)
+	}
+	bound, err := DueBoundOf("2024-03-09", "Asia/Tokyo")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 8, 15, 0, 0, 0, time.UTC), bound, "the day starts at its midnight in Tokyo")
+	_, err = DueBoundOf("tomorrow", "")
+	assert.Equal(t, ErrInvalidDue, err)
+}
+
+func TestIsOverdue(t *testing.T) {
+	notDone, done := false, true
+	now := time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC)
+	day := &Due{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	assert.False(t, Todo{Done: &notDone}.IsOverdue(now))
+	assert.True(t, Todo{Done: &notDone, DueAt: &Due{Time: now}}.IsOverdue(now))
+	assert.False(t, Todo{Done: &notDone, DueAt: &Due{Time: now.Add(time.Second)}}.IsOverdue(now))
+	assert.False(t, Todo{Done: &done, DueAt: &Due{Time: now}}.IsOverdue(now))
+	assert.False(t, Todo{Done: &notDone, DueAt: day}.IsOverdue(now))
+	assert.True(t, Todo{Done: &notDone, DueAt: day, Timezone: "Asia/Tokyo"}.IsOverdue(now))
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	SortByCreatedAt string = "created_at"
+	SortByTitle     string = "title"
+	SortByDone      string = "done"
+	SortByDueAt     string = "due_at"
+	SortByPosition  string = "position"
+	SortByPriority  string = "priority"
+)
+
+const (
+	OrderAsc  string = "asc"
+	OrderDesc string = "desc"
+)
+
+// A todo matches the tags of a filter when it has any of them, or all of
+// them.
+const (
+	TagMatchAny string = "any"
+	TagMatchAll string = "all"
+)
+
+var ErrInvalidCreatedRange error = errors.New("created_after must be before created_before")
+var ErrInvalidDueRange error = errors.New("due_after must be before due_before")
+
+// TodoFilter narrows and orders the todos of a user. The zero value matches
+// every todo, newest first. Tags are normalized as the tags of a todo are.
+// ListId narrows the todos to the ones of a list. Overdue narrows them to the
+// ones that are overdue at Now, or to the others, and the server sets Now.
+// DueAfter and DueBefore are read with DueBoundOf, whose days start in
+// Timezone. A todo that is due on a day is compared with them as its midnight
+// in UTC. Sorting by the due date puts the todos that aren't due
+// after the others. Sorting by the position puts the todos in the order that
+// their user arranged them in, and is ascending unless Order says otherwise.
+type TodoFilter struct {
+	Done          *bool      `form:"done"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Title         string     `form:"title" binding:"max=500"`
+	Sort          string     `form:"sort" binding:"omitempty,oneof=created_at title done due_at position priority"`
+	Order         string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Tags          []string   `form:"tag" binding:"max=20,dive,max=50"`
+	TagMatch      string     `form:"tag_match" binding:"omitempty,oneof=any all"`
+	ListId        string     `form:"list_id" binding:"omitempty,uuid"`
+	DueAfter      *time.Time `form:"-"`
+	DueBefore     *time.Time `form:"-"`
+	Timezone      string     `form:"timezone" binding:"omitempty,timezone"`
+	Overdue       *bool      `form:"overdue"`
+	Now           time.Time  `form:"-"`
+}
+
+// WithDefaults fills in the sort column, the order and how the tags match
+// when they are not set.
+func (filter TodoFilter) WithDefaults() TodoFilter {
+	if filter.Sort == "" {
+		filter.Sort = SortByCreatedAt
+	}
+	if filter.Order == "" && filter.Sort == SortByPosition {
+		filter.Order = OrderAsc
+	} else if filter.Order == "" {
+		filter.Order = OrderDesc
+	}
+	if filter.TagMatch == "" {
+		filter.TagMatch = TagMatchAny
+	}
+	return filter
+}
+
+func (filter TodoFilter) Validate() error {
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil &&
+		!filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return ErrInvalidCreatedRange
+	}
+	if filter.DueAfter != nil && filter.DueBefore != nil && !filter.DueAfter.Before(*filter.DueBefore) {
+		return ErrInvalidDueRange
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoFilter(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		assert.Equal(t, TodoFilter{Sort: SortByCreatedAt, Order: OrderDesc, TagMatch: TagMatchAny}, TodoFilter{}.WithDefaults())
+		assert.Equal(t, TodoFilter{Sort: SortByTitle, Order: OrderAsc, TagMatch: TagMatchAll},
+			TodoFilter{Sort: SortByTitle, Order: OrderAsc, TagMatch: TagMatchAll}.WithDefaults())
+		assert.Equal(t, OrderAsc, TodoFilter{Sort: SortByPosition}.WithDefaults().Order)
+		assert.Equal(t, OrderDesc, TodoFilter{Sort: SortByPosition, Order: OrderDesc}.WithDefaults().Order)
+		assert.Equal(t, OrderDesc, TodoFilter{Sort: SortByPriority}.WithDefaults().Order)
+	})
+
+	t.Run("Valid created range", func(t *testing.T) {
+		after := time.Now()
+		before := after.Add(time.Minute)
+		assert.NoError(t, TodoFilter{CreatedAfter: &after, CreatedBefore: &before}.Validate())
+		assert.NoError(t, TodoFilter{CreatedAfter: &after}.Validate())
+	})
+
+	t.Run("When created_after is not before created_before", func(t *testing.T) {
+		after := time.Now()
+		assert.Equal(t, ErrInvalidCreatedRange, TodoFilter{CreatedAfter: &after, CreatedBefore: &after}.Validate())
+	})
+
+	t.Run("When due_after is not before due_before", func(t *testing.T) {
+		after := time.Now()
+		before := after.Add(-time.Minute)
+		assert.Equal(t, ErrInvalidDueRange, TodoFilter{DueAfter: &after, DueBefore: &before}.Validate())
+		assert.NoError(t, TodoFilter{DueAfter: &before, DueBefore: &after}.Validate())
+	})
+}
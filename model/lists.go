@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// InboxName is the name of the inbox of a user, the list that todos go to
+// when no other list is named.
+const InboxName string = "Inbox"
+
+const MaxListNameLength int = 100
+
+// List is a named list of todos of a user. Every todo belongs to exactly one
+// list. The inbox of a user is created the first time a todo goes to it, and
+// can't be renamed, archived, deleted or shared. ArchivedAt is only set on an
+// archived list, which takes no new todos. OwnerId and Role are only set on a
+// list that another user shares with the user.
+type List struct {
+	Id         string     `json:"id" validate:"required,uuid"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	Inbox      bool       `json:"inbox"`
+	CreatedAt  time.Time  `json:"createdAt" validate:"required"`
+	UpdatedAt  time.Time  `json:"updatedAt" validate:"required"`
+	ArchivedAt *time.Time `json:"archivedAt"`
+	OwnerId    string     `json:"ownerId,omitempty"`
+	Role       Role       `json:"role,omitempty"`
+}
+
+// CreateListRequest is the body of a POST /lists.
+type CreateListRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// List returns the list that the request creates with id at now.
+func (request CreateListRequest) List(id string, now time.Time) List {
+	return List{Id: id, Name: request.Name, CreatedAt: now, UpdatedAt: now}
+}
+
+// ListChanges is the body of a PATCH /lists/:id, which renames a list or
+// archives or unarchives it. Fields that are left out don't change.
+// UpdatedAt is the time of the change.
+type ListChanges struct {
+	Name      *string   `json:"name" binding:"omitempty,min=1,max=100"`
+	Archived  *bool     `json:"archived"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+func (changes ListChanges) IsEmpty() bool {
+	return changes.Name == nil && changes.Archived == nil
+}
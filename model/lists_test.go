@@ -0,0 +1,27 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateListRequestList(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	id := uuid.New().String()
+	list := CreateListRequest{Name: "Groceries"}.List(id, ti)
+	assert.Equal(t, List{Id: id, Name: "Groceries", CreatedAt: ti, UpdatedAt: ti}, list)
+	assert.True(t, IsValid(list))
+	list.Name = strings.Repeat("a", MaxListNameLength+1)
+	assert.False(t, IsValid(list))
+}
+
+func TestListChangesIsEmpty(t *testing.T) {
+	name, archived := "Groceries", true
+	assert.True(t, ListChanges{UpdatedAt: time.Now()}.IsEmpty())
+	assert.False(t, ListChanges{Name: &name}.IsEmpty())
+	assert.False(t, ListChanges{Archived: &archived}.IsEmpty())
+}
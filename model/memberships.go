@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Role is what a user may do with a list and its todos. The user that created
+// a list owns it and shares it with other users as their editor, who reads
+// and changes its todos, or as their viewer, who only reads them.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// MaxUserIdLength is the length of the user_id columns.
+const MaxUserIdLength int = 40
+
+// Membership shares the list of ListId with the user of UserId, who isn't
+// its owner, as an editor or a viewer.
+type Membership struct {
+	ListId    string    `json:"listId" validate:"required,uuid"`
+	UserId    string    `json:"userId" validate:"required,max=40"`
+	Role      Role      `json:"role" validate:"oneof=editor viewer"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	UpdatedAt time.Time `json:"updatedAt" validate:"required"`
+}
+
+// CreateMembershipRequest is the body of a POST /lists/:id/members, which
+// invites a user either by their id or by their email.
+type CreateMembershipRequest struct {
+	UserId string `json:"userId" binding:"required_without=Email,excluded_with=Email,max=40"`
+	Email  string `json:"email" binding:"omitempty,email"`
+	Role   Role   `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// Membership returns the membership that the request creates in the list of
+// listId for the user of userId at now.
+func (request CreateMembershipRequest) Membership(listId string, userId string, now time.Time) Membership {
+	return Membership{ListId: listId, UserId: userId, Role: request.Role, CreatedAt: now, UpdatedAt: now}
+}
+
+// MembershipChanges is the body of a PATCH /lists/:id/members/:userId, which
+// changes the role of a member. UpdatedAt is the time of the change.
+type MembershipChanges struct {
+	Role      Role      `json:"role" binding:"required,oneof=editor viewer"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// Access is the Role of a user in the list of ListId, which belongs to the
+// user of OwnerId. What the user does with the list and its todos is done as
+// its owner once their role allows it.
+type Access struct {
+	OwnerId string
+	ListId  string
+	Role    Role
+}
+
+func (access Access) IsOwner() bool {
+	return access.Role == RoleOwner
+}
+
+// CanWrite tells whether the user may change the todos of the list.
+func (access Access) CanWrite() bool {
+	return access.Role == RoleOwner || access.Role == RoleEditor
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMembershipRequestMembership(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	listId := uuid.New().String()
+	membership := CreateMembershipRequest{Email: "member@example.com", Role: RoleViewer}.Membership(listId, "wehgowe", ti)
+	assert.Equal(t, Membership{ListId: listId, UserId: "wehgowe", Role: RoleViewer, CreatedAt: ti, UpdatedAt: ti}, membership)
+	assert.True(t, IsValid(membership))
+	invalid := membership
+	invalid.Role = RoleOwner
+	assert.False(t, IsValid(invalid), "a list has one owner")
+	invalid = membership
+	invalid.UserId = strings.Repeat("a", MaxUserIdLength+1)
+	assert.False(t, IsValid(invalid))
+	invalid = membership
+	invalid.UserId = ""
+	assert.False(t, IsValid(invalid))
+}
+
+func TestAccess(t *testing.T) {
+	for role, expected := range map[Role][2]bool{
+		RoleOwner:  {true, true},
+		RoleEditor: {false, true},
+		RoleViewer: {false, false},
+	} {
+		access := Access{OwnerId: "wehgowe", ListId: uuid.New().String(), Role: role}
+		assert.Equal(t, expected[0], access.IsOwner(), role)
+		assert.Equal(t, expected[1], access.CanWrite(), role)
+	}
+}
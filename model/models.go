@@ -8,12 +8,48 @@ import (
 
 var validatorr *validator.Validate = validator.New()
 
+// FirstVersion is the version of a todo when it is created. Every write
+// increments it.
+const FirstVersion int64 = 1
+
+// Todo is a todo as it is stored. The server sets the id, the timestamps and
+// the version; clients send a CreateTodoRequest or an UpdateTodoRequest
+// instead.
 type Todo struct {
-	Id          string    `json:"id" bindging:"required,uuid4" validate:"required,uuid4"`
-	Title       string    `json:"title" binding:"required" validate:"required"`
-	Description string    `json:"description" binding:"required" validate:"required"`
-	Done        *bool     `json:"done" binding:"required" validate:"required"`
-	CreatedAt   time.Time `json:"createdAt" binding:"required" validate:"required"`
+	Id          string     `json:"id" validate:"required,uuid"`
+	Title       string     `json:"title" validate:"required"`
+	Description string     `json:"description" validate:"required"`
+	Done        *bool      `json:"done" validate:"required"`
+	CreatedAt   time.Time  `json:"createdAt" validate:"required"`
+	UpdatedAt   time.Time  `json:"updatedAt" validate:"required"`
+	CompletedAt *time.Time `json:"completedAt"`
+	Version     int64      `json:"version"`
+	// DeletedAt is only set on a todo in the trash.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// Tags are normalized and sorted.
+	Tags []string `json:"tags" validate:"max=20,unique,dive,tag"`
+	// ListId is the inbox of the user when a todo is created without one, or
+	// the list of its parent for a subtask.
+	ListId string `json:"listId" validate:"omitempty,uuid"`
+	// ParentId never changes once a todo is created.
+	ParentId string `json:"parentId,omitempty" validate:"omitempty,uuid,nefield=Id"`
+	// Progress is only set on a todo with subtasks.
+	Progress *Progress `json:"progress,omitempty"`
+	// Subtasks are only set when a response expands them.
+	Subtasks []Todo `json:"subtasks,omitempty"`
+	DueAt    *Due   `json:"dueAt,omitempty"`
+	// Timezone is the IANA name of the time zone whose midnight ends the day
+	// that a todo is due on, which is UTC when it is empty.
+	Timezone string   `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	Priority Priority `json:"priority" validate:"min=0,max=3"`
+	// Position is set by the server: at the end of the todos of the user when
+	// a todo is created, and then only when it is moved.
+	Position string `json:"position"`
+	// Recurrence is an RRULE that a todo recurs by from its due date. Once the
+	// todo is done, its next occurrence is created as a new todo.
+	Recurrence string `json:"recurrence,omitempty" validate:"omitempty,recurrence,excluded_without=DueAt"`
+	// CommentCount is counted by the server.
+	CommentCount int64 `json:"commentCount"`
 }
 
 func IsValid(obj interface{}) (ok bool) {
@@ -26,3 +62,26 @@ func IsValid(obj interface{}) (ok bool) {
 	}
 	return true
 }
+
+// IsValidExcept is IsValid without the checks of the named fields.
+func IsValidExcept(obj interface{}, fields ...string) (ok bool) {
+	if obj == nil {
+		return false
+	}
+	err := validatorr.StructExcept(obj, fields...)
+	if err != nil {
+		return false
+	}
+	return true
+}
+
+// Touch stamps a change of the todo made at now. CompletedAt keeps the time
+// the todo was first done until it is undone.
+func (todo *Todo) Touch(now time.Time) {
+	todo.UpdatedAt = now
+	if todo.Done == nil || !*todo.Done {
+		todo.CompletedAt = nil
+	} else if todo.CompletedAt == nil {
+		todo.CompletedAt = &now
+	}
+}
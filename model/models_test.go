@@ -52,7 +52,7 @@ func TestIsValidWhenTodoDoneIsNil(t *testing.T) {
 func TestIsValidWhenTodoIsValid(t *testing.T) {
 	todoDone := false
 	todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title",
-		Done: &todoDone, CreatedAt: time.Now()}
+		Done: &todoDone, CreatedAt: time.Now(), UpdatedAt: time.Now()}
 	ok := IsValid(todo)
 	assert.True(t, ok)
 }
@@ -77,8 +77,107 @@ func TestIsValid(t *testing.T) {
 
 	t.Run("When there is no CreatedAt", func(t *testing.T) {
 		todoDone := false
-		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone}
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			UpdatedAt: time.Now()}
 		ok := IsValid(todo)
 		assert.False(t, ok)
 	})
+
+	t.Run("When there is no UpdatedAt", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			CreatedAt: time.Now()}
+		ok := IsValid(todo)
+		assert.False(t, ok)
+	})
+
+	t.Run("When the id is a version 7 UUID", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.Must(uuid.NewV7()).String(), Description: "description", Title: "title",
+			Done: &todoDone, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		assert.True(t, IsValid(todo))
+	})
+
+	t.Run("When the tags are not normalized or repeated", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), Tags: []string{"home", "work"}}
+		assert.True(t, IsValid(todo))
+		for _, tags := range [][]string{{"Work"}, {""}, {"work", "work"}, {strings.Repeat("a", MaxTagLength+1)},
+			make([]string, MaxTagsPerTodo+1)} {
+			todo.Tags = tags
+			assert.False(t, IsValid(todo), tags)
+		}
+	})
+
+	t.Run("When the todo is a subtask", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), ParentId: uuid.New().String()}
+		assert.True(t, IsValid(todo))
+		todo.ParentId = "abc"
+		assert.False(t, IsValid(todo))
+		todo.ParentId = todo.Id
+		assert.False(t, IsValid(todo))
+	})
+
+	t.Run("When the todo is due", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), DueAt: &Due{Time: time.Now()}, Timezone: "Europe/Berlin"}
+		assert.True(t, IsValid(todo))
+		for _, timezone := range []string{"Mars/Olympus", "Local", "+02:00"} {
+			todo.Timezone = timezone
+			assert.False(t, IsValid(todo), timezone)
+		}
+	})
+
+	t.Run("When the todo has a priority", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), Priority: PriorityHigh}
+		assert.True(t, IsValid(todo))
+		todo.Priority = PriorityHigh + 1
+		assert.False(t, IsValid(todo))
+	})
+}
+
+func TestIsValidExcept(t *testing.T) {
+	todoDone := false
+	todo := Todo{Id: uuid.New().String(), Description: "description", Title: "title", Done: &todoDone,
+		UpdatedAt: time.Now()}
+	assert.True(t, IsValidExcept(todo, "CreatedAt"))
+	assert.False(t, IsValidExcept(todo, "UpdatedAt"))
+	todo.Title = ""
+	assert.False(t, IsValidExcept(todo, "CreatedAt"))
+	assert.False(t, IsValidExcept(nil, "CreatedAt"))
+}
+
+func TestTouch(t *testing.T) {
+	ti1, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	ti2 := ti1.Add(time.Hour)
+
+	t.Run("When the todo is not done", func(t *testing.T) {
+		todoDone := false
+		todo := Todo{Done: &todoDone, CompletedAt: &ti1}
+		todo.Touch(ti2)
+		assert.Equal(t, ti2, todo.UpdatedAt)
+		assert.Nil(t, todo.CompletedAt)
+	})
+
+	t.Run("When the todo becomes done", func(t *testing.T) {
+		todoDone := true
+		todo := Todo{Done: &todoDone}
+		todo.Touch(ti2)
+		assert.Equal(t, ti2, todo.UpdatedAt)
+		assert.Equal(t, &ti2, todo.CompletedAt)
+	})
+
+	t.Run("When the todo was already done", func(t *testing.T) {
+		todoDone := true
+		todo := Todo{Done: &todoDone, CompletedAt: &ti1}
+		todo.Touch(ti2)
+		assert.Equal(t, ti2, todo.UpdatedAt)
+		assert.Equal(t, &ti1, todo.CompletedAt)
+	})
 }
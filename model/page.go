@@ -0,0 +1,116 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultPageLimit int = 50
+const MaxPageLimit int = 100
+
+var ErrInvalidCursor error = errors.New("invalid cursor")
+var ErrInvalidLimit error = errors.New("limit must be a number between 1 and 100")
+
+// Cursor points at the todo a page starts after. It carries the values of
+// every sortable column so that it works whatever the sort order is.
+// Backward cursors walk towards the start of the list, forward ones
+// towards its end.
+type Cursor struct {
+	CreatedAt time.Time  `json:"c"`
+	Title     string     `json:"t,omitempty"`
+	Done      bool       `json:"d,omitempty"`
+	DueAt     *time.Time `json:"u,omitempty"`
+	Priority  Priority   `json:"p,omitempty"`
+	Position  string     `json:"o,omitempty"`
+	Id        string     `json:"i"`
+	Backward  bool       `json:"b,omitempty"`
+}
+
+// PageRequest asks for Limit todos after Cursor. A zero Limit asks for every
+// todo.
+type PageRequest struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+type Page struct {
+	Todos []Todo
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+func CursorOf(todo Todo, backward bool) *Cursor {
+	cursor := &Cursor{CreatedAt: todo.CreatedAt, Title: todo.Title, Priority: todo.Priority, Position: todo.Position,
+		Id: todo.Id, Backward: backward}
+	if todo.Done != nil {
+		cursor.Done = *todo.Done
+	}
+	if todo.DueAt != nil {
+		dueAt := todo.DueAt.Time
+		cursor.DueAt = &dueAt
+	}
+	return cursor
+}
+
+// Encode returns the opaque form of the cursor that is handed to clients.
+func (c Cursor) Encode() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(bytes, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(cursor.Id); err != nil || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// NewPage builds the page for pageRequest out of at most Limit+1 todos, as
+// they were read in the direction of the cursor. The extra todo only tells
+// that there is another page in that direction.
+func NewPage(todos []Todo, pageRequest PageRequest) *Page {
+	todos, next, prev := pageOf(todos, pageRequest, CursorOf)
+	return &Page{Todos: todos, Next: next, Prev: prev}
+}
+
+// pageOf is NewPage for the items of any pager, whose cursors cursorOf makes.
+// It returns the items of the page in order and the cursors of the next and
+// previous pages.
+func pageOf[T any](items []T, pageRequest PageRequest,
+	cursorOf func(item T, backward bool) *Cursor) (_ []T, next *Cursor, prev *Cursor) {
+	if pageRequest.Limit == 0 {
+		return items, nil, nil
+	}
+	hasMore := len(items) > pageRequest.Limit
+	if hasMore {
+		items = items[:pageRequest.Limit]
+	}
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, nil, nil
+	}
+	if (backward && hasMore) || (!backward && pageRequest.Cursor != nil) {
+		prev = cursorOf(items[0], true)
+	}
+	if (!backward && hasMore) || backward {
+		next = cursorOf(items[len(items)-1], false)
+	}
+	return items, next, prev
+}
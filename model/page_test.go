@@ -0,0 +1,119 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("Encode and decode", func(t *testing.T) {
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		cursor := Cursor{CreatedAt: ti, Id: uuid.New().String(), Backward: true}
+		decoded, err := DecodeCursor(cursor.Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+	})
+
+	t.Run("The cursor of a todo that is due", func(t *testing.T) {
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		done := true
+		cursor := CursorOf(Todo{Id: uuid.New().String(), CreatedAt: ti, Done: &done, DueAt: &Due{Time: ti}}, false)
+		decoded, err := DecodeCursor(cursor.Encode())
+		assert.NoError(t, err)
+		assert.True(t, ti.Equal(*decoded.DueAt))
+		assert.Nil(t, CursorOf(Todo{Id: uuid.New().String(), CreatedAt: ti}, false).DueAt)
+	})
+
+	t.Run("The cursor of a todo with a priority and a position", func(t *testing.T) {
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		done := false
+		todo := Todo{Id: uuid.New().String(), CreatedAt: ti, Done: &done, Priority: PriorityHigh, Position: "V0a"}
+		decoded, err := DecodeCursor(CursorOf(todo, false).Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, PriorityHigh, decoded.Priority)
+		assert.Equal(t, "V0a", decoded.Position)
+	})
+
+	t.Run("When the cursor is not base64", func(t *testing.T) {
+		_, err := DecodeCursor("%%%")
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+
+	t.Run("When the cursor is not json", func(t *testing.T) {
+		_, err := DecodeCursor("bm90IGpzb24")
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+
+	t.Run("When the cursor has an invalid id", func(t *testing.T) {
+		cursor := Cursor{CreatedAt: time.Now(), Id: "1"}
+		_, err := DecodeCursor(cursor.Encode())
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+
+	t.Run("When the cursor has no created at", func(t *testing.T) {
+		cursor := Cursor{Id: uuid.New().String()}
+		_, err := DecodeCursor(cursor.Encode())
+		assert.Equal(t, ErrInvalidCursor, err)
+	})
+}
+
+func TestNewPage(t *testing.T) {
+	todos := func(count int) []Todo {
+		result := []Todo{}
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		for i := 0; i < count; i++ {
+			result = append(result, Todo{Id: uuid.New().String(), CreatedAt: ti.Add(-time.Duration(i) * time.Hour)})
+		}
+		return result
+	}
+
+	t.Run("First page with more todos", func(t *testing.T) {
+		read := todos(3)
+		page := NewPage(read, PageRequest{Limit: 2})
+		assert.Equal(t, read[:2], page.Todos)
+		assert.Equal(t, CursorOf(read[1], false), page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("First page without more todos", func(t *testing.T) {
+		read := todos(2)
+		page := NewPage(read, PageRequest{Limit: 2})
+		assert.Equal(t, read, page.Todos)
+		assert.Nil(t, page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("Forward page", func(t *testing.T) {
+		read := todos(2)
+		page := NewPage(read, PageRequest{Limit: 2, Cursor: &Cursor{}})
+		assert.Equal(t, read, page.Todos)
+		assert.Nil(t, page.Next)
+		assert.Equal(t, CursorOf(read[0], true), page.Prev)
+	})
+
+	t.Run("Backward page with more todos", func(t *testing.T) {
+		read := todos(3)
+		page := NewPage([]Todo{read[2], read[1], read[0]}, PageRequest{Limit: 2, Cursor: &Cursor{Backward: true}})
+		assert.Equal(t, []Todo{read[1], read[2]}, page.Todos)
+		assert.Equal(t, CursorOf(read[1], true), page.Prev)
+		assert.Equal(t, CursorOf(read[2], false), page.Next)
+	})
+
+	t.Run("Backward page without more todos", func(t *testing.T) {
+		read := todos(1)
+		page := NewPage(read, PageRequest{Limit: 2, Cursor: &Cursor{Backward: true}})
+		assert.Equal(t, read, page.Todos)
+		assert.Nil(t, page.Prev)
+		assert.Equal(t, CursorOf(read[0], false), page.Next)
+	})
+
+	t.Run("Empty page", func(t *testing.T) {
+		page := NewPage([]Todo{}, PageRequest{Limit: 2, Cursor: &Cursor{}})
+		assert.Empty(t, page.Todos)
+		assert.Nil(t, page.Next)
+		assert.Nil(t, page.Prev)
+	})
+}
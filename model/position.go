@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// positionDigits are the digits of a position in the order of their bytes,
+// so that positions sort as plain byte strings.
+const positionDigits string = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidMove error = errors.New("a todo is moved either before or after another todo")
+
+// MoveRequest is the body of a POST /todos/:id/move, which puts the todo
+// right before the todo of Before, or right after the todo of After.
+type MoveRequest struct {
+	Before string `json:"before" binding:"omitempty,uuid"`
+	After  string `json:"after" binding:"omitempty,uuid"`
+}
+
+// Validate checks that the request moves the todo id next to exactly one
+// other todo.
+func (request MoveRequest) Validate(id string) error {
+	if (request.Before == "") == (request.After == "") || request.Anchor() == id {
+		return ErrInvalidMove
+	}
+	return nil
+}
+
+// Anchor returns the id of the todo that the request moves a todo next to.
+func (request MoveRequest) Anchor() string {
+	if request.Before != "" {
+		return request.Before
+	}
+	return request.After
+}
+
+// PositionBetween returns a position of the todo id between the positions
+// lower and upper, either of which is empty when that side is open. Only the
+// todo that moves gets a new position, which is kept short by being only as
+// long as it takes to fit between its neighbours. Every position ends with
+// the id of its todo, so two todos never get the same position, even when
+// they are moved to the same place at once.
+func PositionBetween(lower string, upper string, id string) string {
+	return positionKey(lower, upper) + strings.ReplaceAll(id, "-", "")
+}
+
+// positionKey returns the shortest digits that sort after lower and before
+// upper, and that aren't the start of upper.
+func positionKey(lower string, upper string) string {
+	last := len(positionDigits) - 1
+	if upper == "" {
+		// Appending to the end bumps the first digit that can be bumped, so
+		// a position only grows by a digit every few dozen appends.
+		for i := 0; i < len(lower); i++ {
+			if digit := digitOf(lower[i]); digit < last {
+				return lower[:i] + string(positionDigits[digit+1])
+			}
+		}
+		return lower + string(positionDigits[last/2])
+	}
+	if lower == "" {
+		for i := 0; i < len(upper); i++ {
+			if digit := digitOf(upper[i]); digit > 0 {
+				return upper[:i] + string(positionDigits[digit-1])
+			}
+		}
+	}
+	key := []byte{}
+	open := false
+	for i := 0; ; i++ {
+		low, high := -1, len(positionDigits)
+		if i < len(lower) {
+			low = digitOf(lower[i])
+		}
+		if !open {
+			if i == len(upper) {
+				// upper is lower followed by zeros, which leaves no room
+				// between them.
+				return string(append(key, positionDigits[last/2]))
+			}
+			high = digitOf(upper[i])
+		}
+		if high-low > 1 {
+			return string(append(key, positionDigits[(low+high)/2]))
+		} else if low == high || low == -1 {
+			key = append(key, upper[i])
+		} else {
+			// Any digits that follow lower[i] sort before upper.
+			key = append(key, lower[i])
+			open = true
+		}
+	}
+}
+
+func digitOf(b byte) int {
+	return strings.IndexByte(positionDigits, b)
+}
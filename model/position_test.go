@@ -0,0 +1,84 @@
+package model
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionBetween(t *testing.T) {
+	t.Run("The first position", func(t *testing.T) {
+		id := uuid.New().String()
+		position := PositionBetween("", "", id)
+		assert.Len(t, position, 33)
+		assert.NotContains(t, position, "-")
+	})
+
+	t.Run("Between two positions", func(t *testing.T) {
+		for _, bounds := range [][2]string{{"V", "W"}, {"V", "V1"}, {"Vz", "W"}, {"V0", "V01"}, {"a", "z"},
+			{"Vzzz1", "W"}, {"", "0001"}, {"y", ""}, {"zzz", ""}, {"", "V"}} {
+			position := PositionBetween(bounds[0], bounds[1], uuid.New().String())
+			assert.Less(t, bounds[0], position, bounds)
+			if bounds[1] != "" {
+				assert.Less(t, position, bounds[1], bounds)
+			}
+		}
+	})
+
+	t.Run("Moving to the same place at once", func(t *testing.T) {
+		lower := PositionBetween("", "", uuid.New().String())
+		upper := PositionBetween(lower, "", uuid.New().String())
+		first := PositionBetween(lower, upper, uuid.New().String())
+		second := PositionBetween(lower, upper, uuid.New().String())
+		assert.NotEqual(t, first, second)
+		for _, position := range []string{first, second} {
+			assert.Less(t, lower, position)
+			assert.Less(t, position, upper)
+		}
+	})
+
+	t.Run("Many moves keep the order and stay short", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		positions := []string{}
+		for i := 0; i < 2000; i++ {
+			at := random.Intn(len(positions) + 1)
+			switch i % 4 {
+			case 0:
+				at = len(positions)
+			case 1:
+				at = 0
+			}
+			lower, upper := "", ""
+			if at > 0 {
+				lower = positions[at-1]
+			}
+			if at < len(positions) {
+				upper = positions[at]
+			}
+			id := uuid.Must(uuid.NewRandomFromReader(random)).String()
+			position := PositionBetween(lower, upper, id)
+			positions = append(positions[:at], append([]string{position}, positions[at:]...)...)
+		}
+		assert.True(t, sort.StringsAreSorted(positions))
+		for i := 1; i < len(positions); i++ {
+			assert.NotEqual(t, positions[i-1], positions[i])
+		}
+		for _, position := range positions {
+			assert.LessOrEqual(t, len(position), 80)
+		}
+	})
+}
+
+func TestMoveRequest(t *testing.T) {
+	id, anchor := uuid.New().String(), uuid.New().String()
+	assert.NoError(t, MoveRequest{Before: anchor}.Validate(id))
+	assert.Equal(t, anchor, MoveRequest{Before: anchor}.Anchor())
+	assert.NoError(t, MoveRequest{After: anchor}.Validate(id))
+	assert.Equal(t, anchor, MoveRequest{After: anchor}.Anchor())
+	for _, request := range []MoveRequest{{}, {Before: anchor, After: anchor}, {Before: id}, {After: id}} {
+		assert.Equal(t, ErrInvalidMove, request.Validate(id), request)
+	}
+}
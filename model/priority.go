@@ -0,0 +1,47 @@
+package model
+
+import "errors"
+
+var ErrInvalidPriority error = errors.New(`a priority is "none", "low", "medium" or "high"`)
+
+// Priority is how urgent a todo is. It reads as its name in JSON, and a todo
+// has no priority until it is given one.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+// IsValidPriority tells whether priority is one of the priorities above.
+func IsValidPriority(priority Priority) bool {
+	return priority >= PriorityNone && priority <= PriorityHigh
+}
+
+func (priority Priority) String() string {
+	if !IsValidPriority(priority) {
+		return ""
+	}
+	return priorityNames[priority]
+}
+
+func (priority Priority) MarshalText() ([]byte, error) {
+	if !IsValidPriority(priority) {
+		return nil, ErrInvalidPriority
+	}
+	return []byte(priority.String()), nil
+}
+
+func (priority *Priority) UnmarshalText(text []byte) error {
+	for i, name := range priorityNames {
+		if name == string(text) {
+			*priority = Priority(i)
+			return nil
+		}
+	}
+	return ErrInvalidPriority
+}
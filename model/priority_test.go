@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityJSON(t *testing.T) {
+	t.Run("Every priority", func(t *testing.T) {
+		for priority, name := range map[Priority]string{PriorityNone: "none", PriorityLow: "low",
+			PriorityMedium: "medium", PriorityHigh: "high"} {
+			content, err := json.Marshal(Todo{Priority: priority})
+			assert.NoError(t, err)
+			assert.Contains(t, string(content), `"priority":"`+name+`"`)
+			var todo Todo
+			assert.NoError(t, json.Unmarshal([]byte(`{"priority": "`+name+`"}`), &todo))
+			assert.Equal(t, priority, todo.Priority)
+		}
+	})
+
+	t.Run("When the priority is left out", func(t *testing.T) {
+		var request CreateTodoRequest
+		assert.NoError(t, json.Unmarshal([]byte(`{"title": "title"}`), &request))
+		assert.Equal(t, PriorityNone, request.Priority)
+	})
+
+	t.Run("Invalid priority", func(t *testing.T) {
+		for _, text := range []string{"urgent", "High", "2", ""} {
+			var priority Priority
+			assert.Equal(t, ErrInvalidPriority, priority.UnmarshalText([]byte(text)), text)
+		}
+		_, err := json.Marshal(Todo{Priority: PriorityHigh + 1})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,355 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	FrequencyDaily   string = "DAILY"
+	FrequencyWeekly  string = "WEEKLY"
+	FrequencyMonthly string = "MONTHLY"
+)
+
+// DefaultOccurrences and MaxOccurrences are how many occurrences a preview
+// lists when it isn't told, and at most.
+const DefaultOccurrences int = 5
+const MaxOccurrences int = 100
+
+// maxRecurrencePeriods bounds how many days, weeks or months a recurrence is
+// followed for, so that one whose BYDAY never matches ends.
+const maxRecurrencePeriods int = 10000
+
+const (
+	untilDateLayout string = "20060102"
+	untilTimeLayout string = "20060102T150405Z"
+)
+
+var ErrInvalidRecurrence error = errors.New("a recurrence is an RRULE with a FREQ of DAILY, WEEKLY or MONTHLY " +
+	"and an INTERVAL, a BYDAY and either a COUNT or an UNTIL")
+var ErrInvalidOccurrenceCount error = errors.New("count must be a number between 1 and 100")
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is the part of an iCalendar RRULE (RFC 5545) that a todo can
+// recur by. Its first occurrence is the due date of the todo, which COUNT
+// counts, and the others keep its time of day in the time zone of the todo.
+// Until is a day or a time in UTC, as in the RRULE.
+type Recurrence struct {
+	Frequency string
+	Interval  int
+	ByDay     []WeekdayNum
+	Count     int
+	Until     *Due
+}
+
+// WeekdayNum is a day of BYDAY: every Weekday of a period when Nth is 0, or
+// the Nth one of a month, counted from its end when Nth is negative.
+type WeekdayNum struct {
+	Nth     int
+	Weekday time.Weekday
+}
+
+func init() {
+	validatorr.RegisterValidation("recurrence", func(field validator.FieldLevel) bool {
+		return IsValidRecurrence(field.Field().String())
+	})
+}
+
+// ParseRecurrence reads an RRULE, with or without its "RRULE:" prefix,
+// ignoring case.
+func ParseRecurrence(text string) (Recurrence, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	text = strings.TrimPrefix(text, "RRULE:")
+	recurrence := Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(text, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[name] {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			if value != FrequencyDaily && value != FrequencyWeekly && value != FrequencyMonthly {
+				return Recurrence{}, ErrInvalidRecurrence
+			}
+			recurrence.Frequency = value
+		case "INTERVAL":
+			recurrence.Interval, err = positiveNumber(value)
+		case "COUNT":
+			recurrence.Count, err = positiveNumber(value)
+		case "UNTIL":
+			recurrence.Until, err = parseUntil(value)
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekdayNum, err := parseWeekdayNum(day)
+				if err != nil {
+					return Recurrence{}, err
+				}
+				recurrence.ByDay = append(recurrence.ByDay, weekdayNum)
+			}
+		default:
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+		if err != nil {
+			return Recurrence{}, err
+		}
+	}
+	if recurrence.Frequency == "" || (recurrence.Count > 0 && recurrence.Until != nil) {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+	for _, day := range recurrence.ByDay {
+		if day.Nth != 0 && recurrence.Frequency != FrequencyMonthly {
+			return Recurrence{}, ErrInvalidRecurrence
+		}
+	}
+	return recurrence, nil
+}
+
+func positiveNumber(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 || strings.HasPrefix(value, "+") {
+		return 0, ErrInvalidRecurrence
+	}
+	return number, nil
+}
+
+func parseUntil(value string) (*Due, error) {
+	if day, err := time.Parse(untilDateLayout, value); err == nil {
+		return &Due{Time: day, DateOnly: true}, nil
+	}
+	at, err := time.Parse(untilTimeLayout, value)
+	if err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+	return &Due{Time: at}, nil
+}
+
+func parseWeekdayNum(text string) (WeekdayNum, error) {
+	if len(text) < 2 {
+		return WeekdayNum{}, ErrInvalidRecurrence
+	}
+	code, nth := text[len(text)-2:], text[:len(text)-2]
+	for weekday, weekdayCode := range weekdayCodes {
+		if code != weekdayCode {
+			continue
+		}
+		weekdayNum := WeekdayNum{Weekday: time.Weekday(weekday)}
+		if nth != "" {
+			number, err := strconv.Atoi(nth)
+			if err != nil || number == 0 || number < -5 || number > 5 {
+				return WeekdayNum{}, ErrInvalidRecurrence
+			}
+			weekdayNum.Nth = number
+		}
+		return weekdayNum, nil
+	}
+	return WeekdayNum{}, ErrInvalidRecurrence
+}
+
+// String returns the RRULE of the recurrence without its prefix, which is
+// how a todo keeps it.
+func (recurrence Recurrence) String() string {
+	parts := []string{"FREQ=" + recurrence.Frequency}
+	if recurrence.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(recurrence.Interval))
+	}
+	if len(recurrence.ByDay) > 0 {
+		days := make([]string, len(recurrence.ByDay))
+		for i, day := range recurrence.ByDay {
+			days[i] = weekdayCodes[day.Weekday]
+			if day.Nth != 0 {
+				days[i] = strconv.Itoa(day.Nth) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if recurrence.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(recurrence.Count))
+	}
+	if until := recurrence.Until; until != nil {
+		if until.DateOnly {
+			parts = append(parts, "UNTIL="+until.Time.Format(untilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+until.Time.UTC().Format(untilTimeLayout))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// IsValidRecurrence tells whether recurrence can be the Recurrence of a
+// todo, which is empty when the todo doesn't recur.
+func IsValidRecurrence(recurrence string) bool {
+	if recurrence == "" {
+		return true
+	}
+	_, err := ParseRecurrence(recurrence)
+	return err == nil
+}
+
+// NormalizeRecurrence returns recurrence the way a todo keeps it, or as it is
+// when it isn't valid.
+func NormalizeRecurrence(recurrence string) string {
+	if recurrence == "" {
+		return ""
+	}
+	parsed, err := ParseRecurrence(recurrence)
+	if err != nil {
+		return recurrence
+	}
+	return parsed.String()
+}
+
+// Occurrences returns the due dates of up to n occurrences that follow the
+// first one, due, in timezone.
+func (recurrence Recurrence) Occurrences(due Due, timezone string, n int) []Due {
+	location := time.UTC
+	if !due.DateOnly {
+		location = locationOf(timezone)
+	}
+	start := due.Time.In(location)
+	year, month, day := start.Date()
+	hour, minute, second := start.Clock()
+	// The days are counted as midnights in UTC, which every day has once.
+	startDay := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	occurrences := []Due{}
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, day := range recurrence.daysOf(startDay, period) {
+			if !day.After(startDay) {
+				continue
+			}
+			if len(occurrences) == n || (recurrence.Count > 0 && len(occurrences)+1 >= recurrence.Count) {
+				return occurrences
+			}
+			occurrence := Due{Time: day, DateOnly: true}
+			if !due.DateOnly {
+				year, month, day := day.Date()
+				occurrence = Due{Time: localTime(year, month, day, hour, minute, second, start.Nanosecond(), location).UTC()}
+			}
+			if recurrence.Until != nil && recurrence.Until.isBefore(occurrence, location) {
+				return occurrences
+			}
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences
+}
+
+// daysOf returns the days of the period-th period of the recurrence from the
+// day it starts, in order.
+func (recurrence Recurrence) daysOf(startDay time.Time, period int) []time.Time {
+	days := []time.Time{}
+	switch recurrence.Frequency {
+	case FrequencyDaily:
+		day := startDay.AddDate(0, 0, period*recurrence.Interval)
+		if len(recurrence.ByDay) == 0 || recurrence.onWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+	case FrequencyWeekly:
+		// The weeks start on Monday, as WKST does by default.
+		monday := startDay.AddDate(0, 0, -(int(startDay.Weekday())+6)%7+7*period*recurrence.Interval)
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if (len(recurrence.ByDay) == 0 && day.Weekday() == startDay.Weekday()) || recurrence.onWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case FrequencyMonthly:
+		first := time.Date(startDay.Year(), startDay.Month()+time.Month(period*recurrence.Interval), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1).Day()
+		if len(recurrence.ByDay) == 0 {
+			// A month without the day of the start is skipped.
+			if startDay.Day() <= last {
+				days = append(days, first.AddDate(0, 0, startDay.Day()-1))
+			}
+			break
+		}
+		for dayOfMonth := 1; dayOfMonth <= last; dayOfMonth++ {
+			day := first.AddDate(0, 0, dayOfMonth-1)
+			for _, weekdayNum := range recurrence.ByDay {
+				if weekdayNum.Weekday == day.Weekday() && (weekdayNum.Nth == 0 ||
+					(weekdayNum.Nth > 0 && (dayOfMonth-1)/7+1 == weekdayNum.Nth) ||
+					(weekdayNum.Nth < 0 && (last-dayOfMonth)/7+1 == -weekdayNum.Nth)) {
+					days = append(days, day)
+					break
+				}
+			}
+		}
+	}
+	return days
+}
+
+func (recurrence Recurrence) onWeekday(weekday time.Weekday) bool {
+	for _, weekdayNum := range recurrence.ByDay {
+		if weekdayNum.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// isBefore tells whether the UNTIL until ends the recurrence before due,
+// comparing days in location when either is a day.
+func (until Due) isBefore(due Due, location *time.Location) bool {
+	if until.DateOnly || due.DateOnly {
+		return until.Day(location) < due.Day(location)
+	}
+	return until.Time.Before(due.Time)
+}
+
+// localTime returns the time that the clocks of location show as the date and
+// the time of day, the way RFC 5545 reads it: a time that a change to summer
+// time skips is read with the offset from before the change, which moves it
+// forward by the change, and a time that the clocks show twice is the first
+// of them.
+func localTime(year int, month time.Month, day int, hour int, minute int, second int, nanosecond int,
+	location *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, minute, second, nanosecond, time.UTC)
+	_, before := wall.Add(-24 * time.Hour).In(location).Zone()
+	_, after := wall.Add(24 * time.Hour).In(location).Zone()
+	for _, offset := range []int{before, after} {
+		at := wall.Add(-time.Duration(offset) * time.Second).In(location)
+		if at.Year() == year && at.Month() == month && at.Day() == day && at.Hour() == hour && at.Minute() == minute {
+			return at
+		}
+	}
+	return wall.Add(-time.Duration(before) * time.Second).In(location)
+}
+
+// Occurrences returns the due dates of up to n occurrences of todo after the
+// one it is due at, which are none when it doesn't recur.
+func (todo Todo) Occurrences(n int) []Due {
+	recurrence, err := ParseRecurrence(todo.Recurrence)
+	if todo.DueAt == nil || todo.Recurrence == "" || err != nil {
+		return []Due{}
+	}
+	return recurrence.Occurrences(*todo.DueAt, todo.Timezone, n)
+}
+
+// NextOccurrence returns the todo that follows todo once it is done at now:
+// a new todo with id like todo, except that it isn't done, is due at the next
+// occurrence and has one occurrence less to go when its recurrence has a
+// COUNT. Its subtasks aren't copied. It returns nil when todo doesn't recur or
+// was the last occurrence.
+func (todo Todo) NextOccurrence(id string, now time.Time) *Todo {
+	occurrences := todo.Occurrences(1)
+	if len(occurrences) == 0 {
+		return nil
+	}
+	recurrence, _ := ParseRecurrence(todo.Recurrence)
+	if recurrence.Count > 0 {
+		recurrence.Count--
+	}
+	done := false
+	next := Todo{Id: id, Title: todo.Title, Description: todo.Description, Done: &done, CreatedAt: now,
+		Tags: append([]string{}, todo.Tags...), ListId: todo.ListId, ParentId: todo.ParentId, DueAt: &occurrences[0],
+		Timezone: todo.Timezone, Priority: todo.Priority, Recurrence: recurrence.String()}
+	next.Touch(now)
+	return &next
+}
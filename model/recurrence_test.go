@@ -0,0 +1,160 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		for text, normalized := range map[string]string{
+			"RRULE:freq=weekly;interval=2;byday=mo,we;count=3": "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=3",
+			"FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20221231":           "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20221231",
+			"FREQ=MONTHLY;BYDAY=+2TU":                          "FREQ=MONTHLY;BYDAY=2TU",
+			"FREQ=DAILY;INTERVAL=1;UNTIL=20221001T120000Z":     "FREQ=DAILY;UNTIL=20221001T120000Z",
+			" FREQ=DAILY;BYDAY=SA,SU ":                         "FREQ=DAILY;BYDAY=SA,SU",
+		} {
+			recurrence, err := ParseRecurrence(text)
+			if assert.NoError(t, err, text) {
+				assert.Equal(t, normalized, recurrence.String())
+			}
+			assert.Equal(t, normalized, NormalizeRecurrence(text))
+			assert.True(t, IsValidRecurrence(text), text)
+		}
+		recurrence, _ := ParseRecurrence("FREQ=MONTHLY;BYDAY=-1FR,MO;UNTIL=20221231")
+		assert.Equal(t, Recurrence{Frequency: FrequencyMonthly, Interval: 1,
+			ByDay: []WeekdayNum{{Nth: -1, Weekday: time.Friday}, {Weekday: time.Monday}},
+			Until: &Due{Time: time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC), DateOnly: true}}, recurrence)
+	})
+
+	t.Run("When the recurrence is invalid", func(t *testing.T) {
+		for _, text := range []string{"FREQ=YEARLY", "INTERVAL=2", "FREQ=DAILY;COUNT=0", "FREQ=DAILY;INTERVAL=-1",
+			"FREQ=DAILY;INTERVAL=+2", "FREQ=DAILY;COUNT=2;UNTIL=20221231", "FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYDAY=6MO", "FREQ=MONTHLY;BYDAY=0MO", "FREQ=DAILY;FREQ=WEEKLY", "FREQ=DAILY;WKST=MO",
+			"FREQ=DAILY;BYDAY=XX", "FREQ=DAILY;BYDAY=", "FREQ=DAILY;UNTIL=tomorrow", "FREQ=DAILY;", "every day"} {
+			_, err := ParseRecurrence(text)
+			assert.Equal(t, ErrInvalidRecurrence, err, text)
+			assert.False(t, IsValidRecurrence(text), text)
+			assert.Equal(t, text, NormalizeRecurrence(text))
+		}
+		assert.True(t, IsValidRecurrence(""))
+		assert.Equal(t, "", NormalizeRecurrence(""))
+	})
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	day := func(year int, month time.Month, dayOfMonth int) Due {
+		return Due{Time: time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	}
+	at := func(text string) Due {
+		at, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Due{Time: at}
+	}
+	for _, testCase := range []struct {
+		name       string
+		recurrence string
+		due        Due
+		timezone   string
+		n          int
+		expected   []Due
+	}{
+		{"Every other day", "FREQ=DAILY;INTERVAL=2", day(2022, 9, 21), "", 3,
+			[]Due{day(2022, 9, 23), day(2022, 9, 25), day(2022, 9, 27)}},
+		{"Every weekend day", "FREQ=DAILY;BYDAY=SA,SU", day(2022, 9, 21), "", 3,
+			[]Due{day(2022, 9, 24), day(2022, 9, 25), day(2022, 10, 1)}},
+		{"Every week on the weekday of the due date", "FREQ=WEEKLY", day(2022, 9, 21), "", 2,
+			[]Due{day(2022, 9, 28), day(2022, 10, 5)}},
+		{"Some days of every week", "FREQ=WEEKLY;BYDAY=MO,WE,FR", day(2022, 9, 21), "", 4,
+			[]Due{day(2022, 9, 23), day(2022, 9, 26), day(2022, 9, 28), day(2022, 9, 30)}},
+		{"Some days of every other week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", day(2022, 9, 21), "", 3,
+			[]Due{day(2022, 10, 3), day(2022, 10, 5), day(2022, 10, 17)}},
+		{"Every month on a day that some months don't have", "FREQ=MONTHLY", day(2022, 1, 31), "", 3,
+			[]Due{day(2022, 3, 31), day(2022, 5, 31), day(2022, 7, 31)}},
+		{"The last Friday of every month", "FREQ=MONTHLY;BYDAY=-1FR", day(2022, 9, 30), "", 3,
+			[]Due{day(2022, 10, 28), day(2022, 11, 25), day(2022, 12, 30)}},
+		{"The second Tuesday of every other month", "FREQ=MONTHLY;INTERVAL=2;BYDAY=2TU", day(2022, 9, 13), "", 2,
+			[]Due{day(2022, 11, 8), day(2023, 1, 10)}},
+		{"COUNT counts the due date", "FREQ=DAILY;COUNT=3", day(2022, 9, 21), "", 5,
+			[]Due{day(2022, 9, 22), day(2022, 9, 23)}},
+		{"The last occurrence", "FREQ=DAILY;COUNT=1", day(2022, 9, 21), "", 5, []Due{}},
+		{"Until a day", "FREQ=DAILY;UNTIL=20220924", day(2022, 9, 21), "", 5,
+			[]Due{day(2022, 9, 22), day(2022, 9, 23), day(2022, 9, 24)}},
+		{"Until a day in the time zone", "FREQ=DAILY;UNTIL=20220923", at("2022-09-21T23:30:00Z"), "Europe/Berlin", 5,
+			[]Due{at("2022-09-22T23:30:00Z")}},
+		{"Until a time", "FREQ=DAILY;UNTIL=20220923T090000Z", at("2022-09-21T09:00:00Z"), "", 5,
+			[]Due{at("2022-09-22T09:00:00Z"), at("2022-09-23T09:00:00Z")}},
+		{"The time of day stays the same across a change to summer time", "FREQ=DAILY",
+			at("2022-03-26T08:00:00Z"), "Europe/Berlin", 2, []Due{at("2022-03-27T07:00:00Z"), at("2022-03-28T07:00:00Z")}},
+		{"A time that the change to summer time skips moves forward", "FREQ=DAILY",
+			at("2022-03-26T01:30:00Z"), "Europe/Berlin", 2, []Due{at("2022-03-27T01:30:00Z"), at("2022-03-28T00:30:00Z")}},
+		{"A time that happens twice is the first one", "FREQ=DAILY",
+			at("2022-10-29T00:30:00Z"), "Europe/Berlin", 2, []Due{at("2022-10-30T00:30:00Z"), at("2022-10-31T01:30:00Z")}},
+		{"A week in New York", "FREQ=WEEKLY", at("2022-11-01T13:00:00Z"), "America/New_York", 1,
+			[]Due{at("2022-11-08T14:00:00Z")}},
+		{"A rule that never matches", "FREQ=DAILY;INTERVAL=7;BYDAY=TU", day(2022, 9, 21), "", 1, []Due{}},
+	} {
+		recurrence, err := ParseRecurrence(testCase.recurrence)
+		if assert.NoError(t, err, testCase.name) {
+			assert.Equal(t, testCase.expected, recurrence.Occurrences(testCase.due, testCase.timezone, testCase.n), testCase.name)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	done := true
+	now := time.Date(2022, 9, 21, 18, 0, 0, 0, time.UTC)
+	todo := Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done,
+		CreatedAt: now.Add(-time.Hour), UpdatedAt: now, CompletedAt: &now, Version: 3, Tags: []string{"home"},
+		ListId: uuid.New().String(), DueAt: &Due{Time: time.Date(2022, 9, 21, 7, 0, 0, 0, time.UTC)},
+		Timezone: "Europe/Berlin", Priority: PriorityHigh, Position: "V", Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3"}
+
+	t.Run("Good case", func(t *testing.T) {
+		id := uuid.New().String()
+		next := todo.NextOccurrence(id, now)
+		notDone := false
+		assert.Equal(t, &Todo{Id: id, Title: "title1", Description: "description1", Done: &notDone, CreatedAt: now,
+			UpdatedAt: now, Tags: []string{"home"}, ListId: todo.ListId,
+			DueAt: &Due{Time: time.Date(2022, 9, 26, 7, 0, 0, 0, time.UTC)}, Timezone: "Europe/Berlin",
+			Priority: PriorityHigh, Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=2"}, next)
+		last := next.NextOccurrence(uuid.New().String(), now)
+		if assert.NotNil(t, last) {
+			assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=1", last.Recurrence)
+			assert.Nil(t, last.NextOccurrence(uuid.New().String(), now))
+		}
+	})
+
+	t.Run("When the todo doesn't recur", func(t *testing.T) {
+		single := todo
+		single.Recurrence = ""
+		assert.Nil(t, single.NextOccurrence(uuid.New().String(), now))
+		assert.Equal(t, []Due{}, single.Occurrences(5))
+		single.Recurrence, single.DueAt = todo.Recurrence, nil
+		assert.Nil(t, single.NextOccurrence(uuid.New().String(), now))
+	})
+}
+
+func TestRecurrenceValidation(t *testing.T) {
+	done := false
+	now := time.Now()
+	todo := Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done, CreatedAt: now,
+		UpdatedAt: now, DueAt: &Due{Time: now}, Recurrence: "FREQ=DAILY"}
+	assert.True(t, IsValid(todo))
+	invalid := todo
+	invalid.Recurrence = "FREQ=HOURLY"
+	assert.False(t, IsValid(invalid))
+	invalid = todo
+	invalid.DueAt = nil
+	assert.False(t, IsValid(invalid), "only a todo that is due can recur")
+
+	assert.True(t, DueChange{DueAt: todo.DueAt, Recurrence: "FREQ=DAILY"}.IsValid())
+	assert.True(t, DueChange{}.IsValid())
+	assert.False(t, DueChange{Recurrence: "FREQ=DAILY"}.IsValid())
+	assert.False(t, DueChange{DueAt: todo.DueAt, Recurrence: "FREQ=HOURLY"}.IsValid())
+	assert.False(t, DueChange{DueAt: todo.DueAt, Timezone: "Mars/Olympus"}.IsValid())
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// CreateTodoRequest is the body of a POST /todos. Any id or timestamp that a
+// client sends is ignored. The todo goes to the inbox when ListId is left
+// out, and is a subtask of the todo of ParentId when it is set. It has no
+// priority when Priority is left out, and recurs when it is due and has a
+// Recurrence.
+type CreateTodoRequest struct {
+	Title       string   `json:"title" binding:"required"`
+	Description string   `json:"description" binding:"required"`
+	Done        *bool    `json:"done" binding:"required"`
+	Tags        []string `json:"tags" binding:"max=20"`
+	ListId      string   `json:"listId" binding:"omitempty,uuid"`
+	ParentId    string   `json:"parentId" binding:"omitempty,uuid"`
+	DueAt       *Due     `json:"dueAt"`
+	Timezone    string   `json:"timezone" binding:"omitempty,timezone"`
+	Priority    Priority `json:"priority"`
+	Recurrence  string   `json:"recurrence"`
+}
+
+// UpdateTodoRequest is the body of a PUT /todos and a PUT /todos/:id. The
+// todo keeps its tags when Tags is left out, and loses them all when Tags is
+// empty. It stays in its list when ListId is left out, and moves to the list
+// of ListId otherwise. Its due date, timezone, priority and recurrence are
+// replaced, so a todo is no longer due when DueAt is left out. Its position
+// never changes, and CommentCount, which the server counts, may only be the
+// count that the todo already has.
+type UpdateTodoRequest struct {
+	Id           string   `json:"id" binding:"required,uuid"`
+	Title        string   `json:"title" binding:"required"`
+	Description  string   `json:"description" binding:"required"`
+	Done         *bool    `json:"done" binding:"required"`
+	Tags         []string `json:"tags" binding:"max=20"`
+	ListId       string   `json:"listId" binding:"omitempty,uuid"`
+	DueAt        *Due     `json:"dueAt"`
+	Timezone     string   `json:"timezone" binding:"omitempty,timezone"`
+	Priority     Priority `json:"priority"`
+	Recurrence   string   `json:"recurrence"`
+	CommentCount *int64   `json:"commentCount"`
+}
+
+// Todo returns the todo that the request creates with id at now.
+func (request CreateTodoRequest) Todo(id string, now time.Time) Todo {
+	todo := Todo{Id: id, Title: request.Title, Description: request.Description, Done: request.Done, CreatedAt: now,
+		Tags: NormalizeTags(request.Tags), ListId: request.ListId, ParentId: request.ParentId, DueAt: request.DueAt,
+		Timezone: request.Timezone, Priority: request.Priority, Recurrence: NormalizeRecurrence(request.Recurrence)}
+	todo.Touch(now)
+	return todo
+}
+
+// Todo returns the new state of the todo that the request updates at now.
+// CreatedAt is left zero as an update never changes it, Tags is nil when the
+// request leaves the tags as they are and ListId is empty when it leaves the
+// list.
+func (request UpdateTodoRequest) Todo(now time.Time) Todo {
+	todo := Todo{Id: request.Id, Title: request.Title, Description: request.Description, Done: request.Done,
+		ListId: request.ListId, DueAt: request.DueAt, Timezone: request.Timezone, Priority: request.Priority,
+		Recurrence: NormalizeRecurrence(request.Recurrence)}
+	if request.Tags != nil {
+		todo.Tags = NormalizeTags(request.Tags)
+	}
+	todo.Touch(now)
+	return todo
+}
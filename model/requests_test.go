@@ -0,0 +1,115 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTodoRequestTodo(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	id := uuid.New().String()
+
+	t.Run("When the todo is not done", func(t *testing.T) {
+		todoDone := false
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone}
+		assert.Equal(t, Todo{Id: id, Title: "title", Description: "description", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti, Tags: []string{}}, request.Todo(id, ti))
+	})
+
+	t.Run("When the todo is done", func(t *testing.T) {
+		todoDone := true
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone}
+		assert.Equal(t, Todo{Id: id, Title: "title", Description: "description", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti, CompletedAt: &ti, Tags: []string{}}, request.Todo(id, ti))
+	})
+
+	t.Run("When the todo has tags", func(t *testing.T) {
+		todoDone := false
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone,
+			Tags: []string{" Work", "urgent", "work"}}
+		assert.Equal(t, []string{"urgent", "work"}, request.Todo(id, ti).Tags)
+	})
+
+	t.Run("When the todo has a list", func(t *testing.T) {
+		todoDone := false
+		listId := uuid.New().String()
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone, ListId: listId}
+		assert.Equal(t, listId, request.Todo(id, ti).ListId)
+	})
+
+	t.Run("When the todo is a subtask", func(t *testing.T) {
+		todoDone := false
+		parentId := uuid.New().String()
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone, ParentId: parentId}
+		assert.Equal(t, parentId, request.Todo(id, ti).ParentId)
+	})
+
+	t.Run("When the todo is due", func(t *testing.T) {
+		todoDone := false
+		due := &Due{Time: ti, DateOnly: true}
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone, DueAt: due,
+			Timezone: "Asia/Tokyo"}
+		todo := request.Todo(id, ti)
+		assert.Equal(t, due, todo.DueAt)
+		assert.Equal(t, "Asia/Tokyo", todo.Timezone)
+	})
+
+	t.Run("When the todo has a priority", func(t *testing.T) {
+		todoDone := false
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone, Priority: PriorityLow}
+		assert.Equal(t, PriorityLow, request.Todo(id, ti).Priority)
+	})
+
+	t.Run("When the todo recurs", func(t *testing.T) {
+		todoDone := false
+		request := CreateTodoRequest{Title: "title", Description: "description", Done: &todoDone,
+			DueAt: &Due{Time: ti}, Recurrence: "rrule:freq=weekly;byday=mo"}
+		todo := request.Todo(id, ti)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", todo.Recurrence)
+		assert.True(t, IsValid(todo))
+	})
+}
+
+func TestUpdateTodoRequestTodo(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	todoDone := true
+	request := UpdateTodoRequest{Id: uuid.New().String(), Title: "title", Description: "description", Done: &todoDone}
+	todo := request.Todo(ti)
+	assert.Equal(t, Todo{Id: request.Id, Title: "title", Description: "description", Done: &todoDone,
+		UpdatedAt: ti, CompletedAt: &ti}, todo)
+	assert.True(t, IsValidExcept(todo, "CreatedAt"))
+
+	t.Run("When the request has tags", func(t *testing.T) {
+		request := request
+		request.Tags = []string{}
+		assert.Equal(t, []string{}, request.Todo(ti).Tags)
+		request.Tags = []string{"Home"}
+		assert.Equal(t, []string{"home"}, request.Todo(ti).Tags)
+	})
+
+	t.Run("When the request has a list", func(t *testing.T) {
+		request := request
+		request.ListId = uuid.New().String()
+		assert.Equal(t, request.ListId, request.Todo(ti).ListId)
+	})
+
+	t.Run("When the request has a due date", func(t *testing.T) {
+		request := request
+		request.DueAt = &Due{Time: ti}
+		request.Timezone = "America/New_York"
+		todo := request.Todo(ti)
+		assert.Equal(t, request.DueAt, todo.DueAt)
+		assert.Equal(t, request.Timezone, todo.Timezone)
+		request.Recurrence = "FREQ=MONTHLY;BYDAY=-1FR"
+		assert.Equal(t, request.Recurrence, request.Todo(ti).Recurrence)
+	})
+
+	t.Run("When the request has a priority", func(t *testing.T) {
+		request := request
+		request.Priority = PriorityHigh
+		assert.Equal(t, PriorityHigh, request.Todo(ti).Priority)
+	})
+}
@@ -0,0 +1,17 @@
+package model
+
+import "errors"
+
+const DefaultSearchLimit int = 20
+const MaxSearchQueryLength int = 500
+
+var ErrInvalidSearchQuery error = errors.New("q must have between 1 and 500 characters")
+
+// SearchResult is a todo that matched a search with how well it matched.
+// The highlighted fields wrap the matched words in <mark></mark>.
+type SearchResult struct {
+	Todo
+	Rank             float64 `json:"rank"`
+	HighlightedTitle string  `json:"highlightedTitle"`
+	Snippet          string  `json:"snippet"`
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+var ErrPastExpiry error = errors.New("a share link must expire after it is created")
+
+// shareTokenBytes is how many random bytes make the token of a share link,
+// which are too many to guess.
+const shareTokenBytes int = 32
+
+// MaxShareTokenLength is the length of the token column.
+const MaxShareTokenLength int = 64
+
+// ShareTarget is what a share link shows: either the todo of TodoId or the
+// list of ListId.
+type ShareTarget struct {
+	TodoId string `json:"todoId,omitempty" validate:"required_without=ListId,excluded_with=ListId,omitempty,uuid"`
+	ListId string `json:"listId,omitempty" validate:"omitempty,uuid"`
+}
+
+// ShareLink shows a todo or a list of the user of OwnerId to anyone who has
+// its Token, without an account, until ExpiresAt when it is set or until it
+// is revoked. A todo or a list has at most one share link. AccessCount counts
+// the times it was opened, the last of which was at LastAccessedAt.
+type ShareLink struct {
+	Token string `json:"token" validate:"required,max=64"`
+	ShareTarget
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	AccessCount    int64      `json:"accessCount"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+	CreatedAt      time.Time  `json:"createdAt" validate:"required"`
+	OwnerId        string     `json:"-"`
+}
+
+func (link ShareLink) IsExpired(now time.Time) bool {
+	return link.ExpiresAt != nil && !link.ExpiresAt.After(now)
+}
+
+// CreateShareLinkRequest is the body of a POST /todos/:id/share or a POST
+// /lists/:id/share, which may be left out for a link that never expires.
+type CreateShareLinkRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// ShareLink returns the share link of target with token that the request
+// creates at now, or ErrPastExpiry when it would already be expired.
+func (request CreateShareLinkRequest) ShareLink(token string, target ShareTarget, now time.Time) (ShareLink, error) {
+	link := ShareLink{Token: token, ShareTarget: target, CreatedAt: now}
+	if request.ExpiresAt != nil {
+		expiresAt := request.ExpiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+	if link.IsExpired(now) {
+		return ShareLink{}, ErrPastExpiry
+	}
+	return link, nil
+}
+
+// NewShareToken returns a new random token of a share link that is safe in
+// a URL.
+func NewShareToken() (string, error) {
+	token := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// SharedView is what GET /shared/:token shows: either the todo of the link
+// with the tree of its subtasks, or its list with a page of the todos in it.
+// Nothing in it tells whose they are.
+type SharedView struct {
+	Todo  *Todo  `json:"todo,omitempty"`
+	List  *List  `json:"list,omitempty"`
+	Todos []Todo `json:"todos,omitempty"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateShareLinkRequestShareLink(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	target := ShareTarget{TodoId: uuid.New().String()}
+
+	t.Run("Without an expiry", func(t *testing.T) {
+		link, err := CreateShareLinkRequest{}.ShareLink("hweogwe", target, ti)
+		assert.NoError(t, err)
+		assert.Equal(t, ShareLink{Token: "hweogwe", ShareTarget: target, CreatedAt: ti}, link)
+		assert.True(t, IsValid(link))
+		assert.False(t, link.IsExpired(ti.AddDate(10, 0, 0)))
+	})
+
+	t.Run("With an expiry", func(t *testing.T) {
+		expiresAt := ti.Add(time.Hour).In(time.FixedZone("UTC+2", 2*60*60))
+		link, err := CreateShareLinkRequest{ExpiresAt: &expiresAt}.ShareLink("hweogwe", target, ti)
+		assert.NoError(t, err)
+		assert.Equal(t, ti.Add(time.Hour), *link.ExpiresAt)
+		assert.Equal(t, time.UTC, link.ExpiresAt.Location())
+		assert.False(t, link.IsExpired(ti.Add(time.Minute)))
+		assert.True(t, link.IsExpired(ti.Add(time.Hour)))
+	})
+
+	t.Run("When the expiry isn't after now", func(t *testing.T) {
+		for _, expiresAt := range []time.Time{ti, ti.Add(-time.Second)} {
+			_, err := CreateShareLinkRequest{ExpiresAt: &expiresAt}.ShareLink("hweogwe", target, ti)
+			assert.Equal(t, ErrPastExpiry, err)
+		}
+	})
+}
+
+func TestShareLinkIsValid(t *testing.T) {
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	todoId, listId := uuid.New().String(), uuid.New().String()
+	assert.True(t, IsValid(ShareLink{Token: "hweogwe", ShareTarget: ShareTarget{ListId: listId}, CreatedAt: ti}))
+	for _, invalid := range []ShareLink{
+		{Token: "hweogwe", CreatedAt: ti},
+		{Token: "hweogwe", ShareTarget: ShareTarget{TodoId: todoId, ListId: listId}, CreatedAt: ti},
+		{Token: "hweogwe", ShareTarget: ShareTarget{TodoId: "wrong"}, CreatedAt: ti},
+		{Token: "hweogwe", ShareTarget: ShareTarget{ListId: "wrong"}, CreatedAt: ti},
+		{ShareTarget: ShareTarget{TodoId: todoId}, CreatedAt: ti},
+		{Token: strings.Repeat("a", MaxShareTokenLength+1), ShareTarget: ShareTarget{TodoId: todoId}, CreatedAt: ti},
+		{Token: "hweogwe", ShareTarget: ShareTarget{TodoId: todoId}},
+	} {
+		assert.False(t, IsValid(invalid), invalid)
+	}
+}
+
+func TestNewShareToken(t *testing.T) {
+	token, err := NewShareToken()
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(token), MaxShareTokenLength)
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, shareTokenBytes)
+	other, err := NewShareToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
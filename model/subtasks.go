@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExpandSubtasks is the value of the expand query parameter that nests the
+// subtasks of the todos of a response in their parents.
+const ExpandSubtasks string = "subtasks"
+
+var ErrInvalidProgress error = errors.New(`the progress of a todo reads as "3/5 done"`)
+
+// Progress counts the subtasks of a todo outside the trash, and how many of
+// them are done. It reads as "3/5 done" in JSON.
+type Progress struct {
+	Done  int
+	Total int
+}
+
+// ProgressOf returns the progress of a todo with total subtasks of which done
+// are done, or nil when it has none.
+func ProgressOf(done int, total int) *Progress {
+	if total == 0 {
+		return nil
+	}
+	return &Progress{Done: done, Total: total}
+}
+
+func (progress Progress) String() string {
+	return fmt.Sprintf("%d/%d done", progress.Done, progress.Total)
+}
+
+func (progress Progress) MarshalText() ([]byte, error) {
+	return []byte(progress.String()), nil
+}
+
+func (progress *Progress) UnmarshalText(text []byte) error {
+	var parsed Progress
+	if _, err := fmt.Sscanf(string(text), "%d/%d done", &parsed.Done, &parsed.Total); err != nil ||
+		parsed.String() != string(text) || parsed.Done < 0 || parsed.Done > parsed.Total {
+		return ErrInvalidProgress
+	}
+	*progress = parsed
+	return nil
+}
+
+// NestSubtasks returns the todos of subtasks whose parent is parentId, each
+// with the todos below it nested in its Subtasks. The todos keep the order
+// they have in subtasks.
+func NestSubtasks(parentId string, subtasks []Todo) []Todo {
+	nested := []Todo{}
+	for _, subtask := range subtasks {
+		if subtask.ParentId == parentId {
+			subtask.Subtasks = NestSubtasks(subtask.Id, subtasks)
+			nested = append(nested, subtask)
+		}
+	}
+	return nested
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressOf(t *testing.T) {
+	assert.Nil(t, ProgressOf(0, 0))
+	assert.Equal(t, &Progress{Done: 3, Total: 5}, ProgressOf(3, 5))
+}
+
+func TestProgressJSON(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		content, err := json.Marshal(Todo{Progress: &Progress{Done: 3, Total: 5}})
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"progress":"3/5 done"`)
+		var todo Todo
+		assert.NoError(t, json.Unmarshal(content, &todo))
+		assert.Equal(t, &Progress{Done: 3, Total: 5}, todo.Progress)
+	})
+
+	t.Run("When the todo has no subtasks", func(t *testing.T) {
+		content, err := json.Marshal(Todo{})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(content), "progress")
+		assert.NotContains(t, string(content), "subtasks")
+		assert.NotContains(t, string(content), "parentId")
+	})
+
+	t.Run("Invalid progress", func(t *testing.T) {
+		for _, text := range []string{"3/5", "3 of 5 done", "6/5 done", "-1/5 done", "3/5 done!"} {
+			var progress Progress
+			assert.Equal(t, ErrInvalidProgress, progress.UnmarshalText([]byte(text)), text)
+		}
+	})
+}
+
+func TestNestSubtasks(t *testing.T) {
+	parentId := uuid.New().String()
+	first := Todo{Id: uuid.New().String(), ParentId: parentId}
+	second := Todo{Id: uuid.New().String(), ParentId: parentId}
+	grandchild := Todo{Id: uuid.New().String(), ParentId: first.Id}
+	nested := NestSubtasks(parentId, []Todo{first, grandchild, second})
+	if assert.Len(t, nested, 2) {
+		assert.Equal(t, first.Id, nested[0].Id)
+		assert.Equal(t, []Todo{{Id: grandchild.Id, ParentId: first.Id, Subtasks: []Todo{}}}, nested[0].Subtasks)
+		assert.Equal(t, second.Id, nested[1].Id)
+		assert.Empty(t, nested[1].Subtasks)
+	}
+	assert.Equal(t, []Todo{}, NestSubtasks(uuid.New().String(), []Todo{first}))
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const MaxTagLength int = 50
+const MaxTagsPerTodo int = 20
+
+var ErrInvalidTag error = errors.New("a tag must have from 1 to 50 characters")
+
+// Tag is a tag of a user with the number of their todos outside the trash
+// that have it.
+type Tag struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// RenameTagRequest is the body of a PATCH /tags/:name.
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MergeTagRequest is the body of a POST /tags/:name/merge, which moves the
+// todos of the tag to the tag Into and deletes the tag.
+type MergeTagRequest struct {
+	Into string `json:"into" binding:"required"`
+}
+
+func init() {
+	validatorr.RegisterValidation("tag", func(field validator.FieldLevel) bool {
+		return IsValidTag(field.Field().String())
+	})
+}
+
+// NormalizeTag trims and lowercases a tag, so that "Work " and "work" are the
+// same tag.
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NormalizeTags normalizes every tag and returns them sorted without
+// duplicates. It never returns nil, so that a todo without tags has [] as
+// its tags in JSON.
+func NormalizeTags(names []string) []string {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag := NormalizeTag(name)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// IsValidTags tells whether tags are the valid tags of a todo.
+func IsValidTags(tags []string) bool {
+	return validatorr.Var(tags, "max=20,unique,dive,tag") == nil
+}
+
+// IsValidTag tells whether name is a normalized tag that isn't empty or too
+// long.
+func IsValidTag(name string) bool {
+	return name != "" && utf8.RuneCountInString(name) <= MaxTagLength && NormalizeTag(name) == name
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{}, NormalizeTags(nil))
+	assert.Equal(t, []string{"home", "work"}, NormalizeTags([]string{" Work ", "home", "WORK"}))
+}
+
+func TestIsValidTag(t *testing.T) {
+	assert.True(t, IsValidTag("work"))
+	assert.True(t, IsValidTag(strings.Repeat("é", MaxTagLength)))
+	assert.False(t, IsValidTag(""))
+	assert.False(t, IsValidTag("Work"))
+	assert.False(t, IsValidTag(" work"))
+	assert.False(t, IsValidTag(strings.Repeat("a", MaxTagLength+1)))
+}
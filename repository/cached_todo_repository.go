@@ -0,0 +1,490 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrTodoRepositoryIsNil = errors.New("TodoRepository is nil")
+var ErrUnitOfWorkIsNil = errors.New("UnitOfWork is nil")
+var ErrTodoCacheIsNil = errors.New("TodoCache is nil")
+
+// The keys of the entries of a user. Every list of todos starts with
+// listKeyPrefix, as any write of the user may change it.
+const (
+	todoKeyPrefix string = "todo/"
+	listKeyPrefix string = "list/"
+	allTodosKey   string = listKeyPrefix + "all"
+	pageKeyPrefix string = listKeyPrefix + "page/"
+	tagsKey       string = listKeyPrefix + "tags"
+)
+
+// CacheStats counts the reads of a TodoCache. A read that waits for the same
+// read of another request counts as a miss.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// TodoCache keeps what GetCachedTodoRepository reads for ttl, in an LRU of
+// users that each hold an LRU of their entries.
+type TodoCache struct {
+	mu             sync.Mutex
+	users          *lru
+	entriesPerUser int
+	ttl            time.Duration
+	now            func() time.Time
+	// calls holds the running reads by user and key. An invalidation of a
+	// user drops their reads, and a read only stores what it read when it is
+	// still there, so that a write of another user never keeps it out.
+	calls  map[string]*cacheCall
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// cacheCall is a read that the concurrent misses of the same key wait for
+// instead of reading too.
+type cacheCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// NewTodoCache returns a TodoCache of at most entriesPerUser entries for each
+// of at most users users.
+func NewTodoCache(users int, entriesPerUser int, ttl time.Duration) *TodoCache {
+	return &TodoCache{users: newLRU(users), entriesPerUser: entriesPerUser, ttl: ttl, now: time.Now,
+		calls: map[string]*cacheCall{}}
+}
+
+func (c *TodoCache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// read returns the entry of key, or what load returns, which it stores.
+func (c *TodoCache) read(ctx context.Context, userId string, key string, load func(ctx context.Context) (any, error)) (any, error) {
+	c.mu.Lock()
+	if entries, ok := c.users.get(userId); ok {
+		if entry, ok := entries.(*lru).get(key); ok {
+			if c.now().Before(entry.(cacheEntry).expiresAt) {
+				c.mu.Unlock()
+				c.hits.Add(1)
+				return entry.(cacheEntry).value, nil
+			}
+			entries.(*lru).remove(key)
+		}
+	}
+	c.misses.Add(1)
+	callKey := userId + "\x00" + key
+	if call, ok := c.calls[callKey]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ErrCanceled
+		}
+		if call.err == ErrCanceled && ctx.Err() == nil {
+			// The request that read was canceled, this one wasn't.
+			return load(ctx)
+		}
+		return call.value, call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.calls[callKey] = call
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.calls[callKey] == call {
+			delete(c.calls, callKey)
+			if call.err == nil {
+				c.store(userId, key, call.value)
+			}
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = load(ctx)
+	return call.value, call.err
+}
+
+// store must be called with the lock held.
+func (c *TodoCache) store(userId string, key string, value any) {
+	entries, ok := c.users.get(userId)
+	if !ok {
+		entries = newLRU(c.entriesPerUser)
+		c.users.add(userId, entries)
+	}
+	entries.(*lru).add(key, cacheEntry{value: value, expiresAt: c.now().Add(c.ttl)})
+}
+
+// invalidateLists drops the lists of a user, after a write that added a todo
+// without changing the others, and keeps the reads of the user that are
+// running from being stored or waited for.
+func (c *TodoCache) invalidateLists(userId string) {
+	c.drop(userId, func(entries *lru) {
+		entries.removeIf(func(key string) bool { return strings.HasPrefix(key, listKeyPrefix) })
+	})
+}
+
+// invalidateUser drops every entry of a user, after a write that may have
+// changed any of their todos. A write on a todo changes the progress of its
+// parent, and may complete its parents or delete or restore its subtasks.
+func (c *TodoCache) invalidateUser(userId string) {
+	c.drop(userId, func(entries *lru) {
+		entries.removeIf(func(key string) bool { return true })
+	})
+}
+
+func (c *TodoCache) drop(userId string, remove func(entries *lru)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entries, ok := c.users.get(userId); ok {
+		remove(entries.(*lru))
+	}
+	for callKey := range c.calls {
+		if strings.HasPrefix(callKey, userId+"\x00") {
+			delete(c.calls, callKey)
+		}
+	}
+}
+
+type cachedTodoRepository struct {
+	common.TodoRepository
+	cache *TodoCache
+}
+
+// GetCachedTodoRepository returns a TodoRepository that reads GetAll, GetPage
+// and GetById of todoRepository through cache. Every write through it
+// invalidates what it may have changed; writes that bypass it are seen
+// once the entries expire.
+func GetCachedTodoRepository(todoRepository common.TodoRepository, cache *TodoCache) (common.TodoRepository, error) {
+	if todoRepository == nil {
+		return nil, ErrTodoRepositoryIsNil
+	}
+	if cache == nil {
+		return nil, ErrTodoCacheIsNil
+	}
+	return cachedTodoRepository{TodoRepository: todoRepository, cache: cache}, nil
+}
+
+func (r cachedTodoRepository) GetAll(ctx context.Context, userId string) ([]model.Todo, error) {
+	value, err := r.cache.read(ctx, userId, allTodosKey, func(ctx context.Context) (any, error) {
+		return r.TodoRepository.GetAll(ctx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyTodos(value.([]model.Todo)), nil
+}
+
+// GetPage reads a page through the cache unless the filter asks for overdue
+// todos, whose filter holds the time of the request and so would never be
+// read again.
+func (r cachedTodoRepository) GetPage(ctx context.Context, userId string, filter model.TodoFilter,
+	pageRequest model.PageRequest) (*model.Page, error) {
+	if filter.Overdue != nil {
+		return r.TodoRepository.GetPage(ctx, userId, filter, pageRequest)
+	}
+	key, err := json.Marshal([]any{filter, pageRequest})
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.cache.read(ctx, userId, pageKeyPrefix+string(key), func(ctx context.Context) (any, error) {
+		return r.TodoRepository.GetPage(ctx, userId, filter, pageRequest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := *value.(*model.Page)
+	page.Todos = copyTodos(page.Todos)
+	if page.Next != nil {
+		next := *page.Next
+		page.Next = &next
+	}
+	if page.Prev != nil {
+		prev := *page.Prev
+		page.Prev = &prev
+	}
+	return &page, nil
+}
+
+func (r cachedTodoRepository) GetById(ctx context.Context, id string, userId string) (*model.Todo, error) {
+	value, err := r.cache.read(ctx, userId, todoKeyPrefix+id, func(ctx context.Context) (any, error) {
+		return r.TodoRepository.GetById(ctx, id, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	todo := copyTodo(*value.(*model.Todo))
+	return &todo, nil
+}
+
+func (r cachedTodoRepository) GetTags(ctx context.Context, userId string) ([]model.Tag, error) {
+	value, err := r.cache.read(ctx, userId, tagsKey, func(ctx context.Context) (any, error) {
+		return r.TodoRepository.GetTags(ctx, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]model.Tag{}, value.([]model.Tag)...), nil
+}
+
+// The writes invalidate even when they fail, as ErrVersionMismatch tells
+// that the entry of the todo is stale.
+
+func (r cachedTodoRepository) Create(ctx context.Context, todo *model.Todo, userId string) error {
+	if todo != nil && todo.ParentId != "" {
+		defer r.cache.invalidateUser(userId)
+	} else {
+		defer r.cache.invalidateLists(userId)
+	}
+	return r.TodoRepository.Create(ctx, todo, userId)
+}
+
+func (r cachedTodoRepository) Update(ctx context.Context, todo *model.Todo, userId string, version int64) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.Update(ctx, todo, userId, version)
+}
+
+func (r cachedTodoRepository) Patch(ctx context.Context, id string, userId string, changes model.TodoChanges, version int64) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.Patch(ctx, id, userId, changes, version)
+}
+
+func (r cachedTodoRepository) Move(ctx context.Context, id string, userId string, move model.MoveRequest, movedAt time.Time) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.Move(ctx, id, userId, move, movedAt)
+}
+
+func (r cachedTodoRepository) Delete(ctx context.Context, id string, userId string, version int64, deletedAt time.Time) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.Delete(ctx, id, userId, version, deletedAt)
+}
+
+func (r cachedTodoRepository) Restore(ctx context.Context, id string, userId string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.Restore(ctx, id, userId)
+}
+
+func (r cachedTodoRepository) RenameTag(ctx context.Context, userId string, name string, newName string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.RenameTag(ctx, userId, name, newName)
+}
+
+func (r cachedTodoRepository) MergeTag(ctx context.Context, userId string, name string, into string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.MergeTag(ctx, userId, name, into)
+}
+
+func (r cachedTodoRepository) DeleteTag(ctx context.Context, userId string, name string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.DeleteTag(ctx, userId, name)
+}
+
+// DeleteList moves or deletes every todo of the list, which the cache can't
+// tell from the rest.
+func (r cachedTodoRepository) DeleteList(ctx context.Context, id string, userId string, cascade bool) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.DeleteList(ctx, id, userId, cascade)
+}
+
+// CreateComment and DeleteComment change the comment count of the todo.
+
+func (r cachedTodoRepository) CreateComment(ctx context.Context, comment *model.Comment, userId string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.CreateComment(ctx, comment, userId)
+}
+
+func (r cachedTodoRepository) DeleteComment(ctx context.Context, todoId string, id string, userId string,
+	authorId string) error {
+	defer r.cache.invalidateUser(userId)
+	return r.TodoRepository.DeleteComment(ctx, todoId, id, userId, authorId)
+}
+
+type cachedUnitOfWork struct {
+	unitOfWork common.UnitOfWork
+	cache      *TodoCache
+}
+
+// GetCachedUnitOfWork returns a UnitOfWork that invalidates in cache what the
+// transactions of unitOfWork wrote once they end.
+func GetCachedUnitOfWork(unitOfWork common.UnitOfWork, cache *TodoCache) (common.UnitOfWork, error) {
+	if unitOfWork == nil {
+		return nil, ErrUnitOfWorkIsNil
+	}
+	if cache == nil {
+		return nil, ErrTodoCacheIsNil
+	}
+	return cachedUnitOfWork{unitOfWork: unitOfWork, cache: cache}, nil
+}
+
+func (uow cachedUnitOfWork) Do(ctx context.Context, work func(tx common.Transaction) error) error {
+	transaction := &cachedTransaction{}
+	defer func() {
+		for _, written := range transaction.written {
+			if written.everyTodo {
+				uow.cache.invalidateUser(written.userId)
+			} else {
+				uow.cache.invalidateLists(written.userId)
+			}
+		}
+	}()
+	return uow.unitOfWork.Do(ctx, func(tx common.Transaction) error {
+		transaction.Transaction = tx
+		return work(transaction)
+	})
+}
+
+// writtenTodos are the todos of a user that a transaction wrote: new todos
+// alone, or any todo of the user when everyTodo is set.
+type writtenTodos struct {
+	userId    string
+	everyTodo bool
+}
+
+// cachedTransaction reads without the cache, as a transaction sees its own
+// writes, and records what it writes.
+type cachedTransaction struct {
+	common.Transaction
+	written []writtenTodos
+}
+
+func (t *cachedTransaction) wrote(userId string, everyTodo bool) {
+	t.written = append(t.written, writtenTodos{userId: userId, everyTodo: everyTodo})
+}
+
+func (t *cachedTransaction) Create(ctx context.Context, todo *model.Todo, userId string) error {
+	t.wrote(userId, todo != nil && todo.ParentId != "")
+	return t.Transaction.Create(ctx, todo, userId)
+}
+
+func (t *cachedTransaction) Update(ctx context.Context, todo *model.Todo, userId string, version int64) error {
+	t.wrote(userId, true)
+	return t.Transaction.Update(ctx, todo, userId, version)
+}
+
+func (t *cachedTransaction) Patch(ctx context.Context, id string, userId string, changes model.TodoChanges, version int64) error {
+	t.wrote(userId, true)
+	return t.Transaction.Patch(ctx, id, userId, changes, version)
+}
+
+func (t *cachedTransaction) Move(ctx context.Context, id string, userId string, move model.MoveRequest, movedAt time.Time) error {
+	t.wrote(userId, true)
+	return t.Transaction.Move(ctx, id, userId, move, movedAt)
+}
+
+func (t *cachedTransaction) Delete(ctx context.Context, id string, userId string, version int64, deletedAt time.Time) error {
+	t.wrote(userId, true)
+	return t.Transaction.Delete(ctx, id, userId, version, deletedAt)
+}
+
+func (t *cachedTransaction) Restore(ctx context.Context, id string, userId string) error {
+	t.wrote(userId, true)
+	return t.Transaction.Restore(ctx, id, userId)
+}
+
+func (t *cachedTransaction) RenameTag(ctx context.Context, userId string, name string, newName string) error {
+	t.wrote(userId, true)
+	return t.Transaction.RenameTag(ctx, userId, name, newName)
+}
+
+func (t *cachedTransaction) MergeTag(ctx context.Context, userId string, name string, into string) error {
+	t.wrote(userId, true)
+	return t.Transaction.MergeTag(ctx, userId, name, into)
+}
+
+func (t *cachedTransaction) DeleteTag(ctx context.Context, userId string, name string) error {
+	t.wrote(userId, true)
+	return t.Transaction.DeleteTag(ctx, userId, name)
+}
+
+func (t *cachedTransaction) DeleteList(ctx context.Context, id string, userId string, cascade bool) error {
+	t.wrote(userId, true)
+	return t.Transaction.DeleteList(ctx, id, userId, cascade)
+}
+
+func (t *cachedTransaction) CreateComment(ctx context.Context, comment *model.Comment, userId string) error {
+	t.wrote(userId, true)
+	return t.Transaction.CreateComment(ctx, comment, userId)
+}
+
+func (t *cachedTransaction) DeleteComment(ctx context.Context, todoId string, id string, userId string,
+	authorId string) error {
+	t.wrote(userId, true)
+	return t.Transaction.DeleteComment(ctx, todoId, id, userId, authorId)
+}
+
+func copyTodos(todos []model.Todo) []model.Todo {
+	copied := make([]model.Todo, len(todos))
+	for i, todo := range todos {
+		copied[i] = copyTodo(todo)
+	}
+	return copied
+}
+
+// lru is a map that evicts its least recently used key when it holds more
+// than capacity keys.
+type lru struct {
+	capacity int
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	value any
+}
+
+func newLRU(capacity int) *lru {
+	return &lru{capacity: capacity, order: list.New(), elements: map[string]*list.Element{}}
+}
+
+func (l *lru) get(key string) (any, bool) {
+	element, ok := l.elements[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem).value, true
+}
+
+func (l *lru) add(key string, value any) {
+	if element, ok := l.elements[key]; ok {
+		element.Value.(*lruItem).value = value
+		l.order.MoveToFront(element)
+		return
+	}
+	l.elements[key] = l.order.PushFront(&lruItem{key: key, value: value})
+	if l.order.Len() > l.capacity {
+		l.remove(l.order.Back().Value.(*lruItem).key)
+	}
+}
+
+func (l *lru) remove(key string) {
+	if element, ok := l.elements[key]; ok {
+		l.order.Remove(element)
+		delete(l.elements, key)
+	}
+}
+
+func (l *lru) removeIf(matches func(key string) bool) {
+	for key := range l.elements {
+		if matches(key) {
+			l.remove(key)
+		}
+	}
+}
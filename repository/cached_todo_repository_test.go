@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCachedTodoRepository(t *testing.T) {
+	t.Run("When TodoRepository is nil", func(t *testing.T) {
+		todoRepository, err := GetCachedTodoRepository(nil, NewTodoCache(1, 1, time.Minute))
+		assert.Nil(t, todoRepository)
+		assert.Equal(t, ErrTodoRepositoryIsNil, err)
+	})
+
+	t.Run("When TodoCache is nil", func(t *testing.T) {
+		todoRepository, err := GetCachedTodoRepository(common.NewMockTodoRepository(gomock.NewController(t)), nil)
+		assert.Nil(t, todoRepository)
+		assert.Equal(t, ErrTodoCacheIsNil, err)
+	})
+}
+
+func TestCachedGetById(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		cache, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(1)
+		first, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		first.Title = "changed"
+		*first.Done = true
+		second, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, todo.Title, second.Title)
+		assert.False(t, *second.Done)
+		assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+	})
+
+	t.Run("When the entry expired", func(t *testing.T) {
+		cache, next, todoRepository := createCached(t, 10, 10)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(2)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		now = now.Add(time.Minute)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.Equal(t, CacheStats{Hits: 0, Misses: 2}, cache.Stats())
+	})
+
+	t.Run("When TodoRepository returns an error", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		next.EXPECT().GetById(gomock.Any(), todoId, userId).Return(nil, ErrNotFound).Times(2)
+		for i := 0; i < 2; i++ {
+			todo, err := todoRepository.GetById(context.Background(), todoId, userId)
+			assert.Nil(t, todo)
+			assert.Equal(t, ErrNotFound, err)
+		}
+	})
+
+	t.Run("When a user has too many entries", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 1)
+		userId := uuid.New().String()
+		todo1, todo2 := newMemoryTodo(time.Now()), newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo1.Id, userId).Return(&todo1, nil).Times(2)
+		next.EXPECT().GetById(gomock.Any(), todo2.Id, userId).Return(&todo2, nil).Times(1)
+		todoRepository.GetById(context.Background(), todo1.Id, userId)
+		todoRepository.GetById(context.Background(), todo2.Id, userId)
+		todoRepository.GetById(context.Background(), todo1.Id, userId)
+	})
+
+	t.Run("When there are too many users", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 1, 10)
+		userId1, userId2 := uuid.New().String(), uuid.New().String()
+		next.EXPECT().GetAll(gomock.Any(), userId1).Return([]model.Todo{}, nil).Times(2)
+		next.EXPECT().GetAll(gomock.Any(), userId2).Return([]model.Todo{}, nil).Times(1)
+		todoRepository.GetAll(context.Background(), userId1)
+		todoRepository.GetAll(context.Background(), userId2)
+		todoRepository.GetAll(context.Background(), userId1)
+	})
+}
+
+func TestCachedGetPage(t *testing.T) {
+	_, next, todoRepository := createCached(t, 10, 10)
+	userId := uuid.New().String()
+	todo := newMemoryTodo(time.Now())
+	done := true
+	page := &model.Page{Todos: []model.Todo{todo}, Next: model.CursorOf(todo, false)}
+	next.EXPECT().GetPage(gomock.Any(), userId, model.TodoFilter{}, model.PageRequest{Limit: 1}).Return(page, nil).Times(1)
+	next.EXPECT().GetPage(gomock.Any(), userId, model.TodoFilter{Done: &done}, model.PageRequest{Limit: 1}).
+		Return(&model.Page{Todos: []model.Todo{}}, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		returned, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, page, returned)
+		returned.Next.Id = "changed"
+		returned, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Done: &done}, model.PageRequest{Limit: 1})
+		assert.NoError(t, err)
+		assert.Empty(t, returned.Todos)
+	}
+}
+
+func TestCachedGetPageOverdue(t *testing.T) {
+	cache, next, todoRepository := createCached(t, 10, 10)
+	userId := uuid.New().String()
+	overdue := true
+	for i := 0; i < 2; i++ {
+		filter := model.TodoFilter{Overdue: &overdue, Now: time.Now().Add(time.Duration(i) * time.Second)}
+		next.EXPECT().GetPage(gomock.Any(), userId, filter, model.PageRequest{}).Return(&model.Page{Todos: []model.Todo{}}, nil)
+		returned, err := todoRepository.GetPage(context.Background(), userId, filter, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, returned.Todos)
+	}
+	assert.Equal(t, CacheStats{}, cache.Stats(), "overdue pages skip the cache")
+}
+
+func TestCachedInvalidation(t *testing.T) {
+	t.Run("Update invalidates every todo and list of its user", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId, otherUserId := uuid.New().String(), uuid.New().String()
+		todo1, todo2 := newMemoryTodo(time.Now()), newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo1.Id, userId).Return(&todo1, nil).Times(2)
+		next.EXPECT().GetById(gomock.Any(), todo2.Id, userId).Return(&todo2, nil).Times(2)
+		next.EXPECT().GetAll(gomock.Any(), userId).Return([]model.Todo{todo1, todo2}, nil).Times(2)
+		next.EXPECT().GetAll(gomock.Any(), otherUserId).Return([]model.Todo{}, nil).Times(1)
+		next.EXPECT().Update(gomock.Any(), &todo1, userId, AnyVersion).Return(nil)
+		read := func() {
+			todoRepository.GetById(context.Background(), todo1.Id, userId)
+			todoRepository.GetById(context.Background(), todo2.Id, userId)
+			todoRepository.GetAll(context.Background(), userId)
+			todoRepository.GetAll(context.Background(), otherUserId)
+		}
+		read()
+		assert.NoError(t, todoRepository.Update(context.Background(), &todo1, userId, AnyVersion))
+		read()
+	})
+
+	t.Run("Create invalidates the lists of its user only", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(1)
+		next.EXPECT().GetAll(gomock.Any(), userId).Return([]model.Todo{todo}, nil).Times(2)
+		next.EXPECT().Create(gomock.Any(), gomock.Any(), userId).Return(nil)
+		read := func() {
+			todoRepository.GetById(context.Background(), todo.Id, userId)
+			todoRepository.GetAll(context.Background(), userId)
+		}
+		read()
+		created := newMemoryTodo(time.Now())
+		assert.NoError(t, todoRepository.Create(context.Background(), &created, userId))
+		read()
+	})
+
+	t.Run("Creating a subtask invalidates every todo and list of its user", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(2)
+		next.EXPECT().Create(gomock.Any(), gomock.Any(), userId).Return(nil)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		subtask := newMemoryTodo(time.Now())
+		subtask.ParentId = todo.Id
+		assert.NoError(t, todoRepository.Create(context.Background(), &subtask, userId))
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+	})
+
+	t.Run("A tag operation invalidates every todo and list of its user", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId, otherUserId := uuid.New().String(), uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(4)
+		next.EXPECT().GetTags(gomock.Any(), userId).Return([]model.Tag{{Name: "work", Count: 1}}, nil).Times(4)
+		next.EXPECT().GetTags(gomock.Any(), otherUserId).Return([]model.Tag{}, nil).Times(1)
+		next.EXPECT().RenameTag(gomock.Any(), userId, "work", "office").Return(nil)
+		next.EXPECT().MergeTag(gomock.Any(), userId, "office", "job").Return(ErrNotFound)
+		next.EXPECT().DeleteTag(gomock.Any(), userId, "office").Return(nil)
+		read := func() {
+			todoRepository.GetById(context.Background(), todo.Id, userId)
+			todoRepository.GetTags(context.Background(), userId)
+			todoRepository.GetTags(context.Background(), otherUserId)
+		}
+		read()
+		assert.NoError(t, todoRepository.RenameTag(context.Background(), userId, "work", "office"))
+		read()
+		assert.Equal(t, ErrNotFound, todoRepository.MergeTag(context.Background(), userId, "office", "job"))
+		read()
+		assert.NoError(t, todoRepository.DeleteTag(context.Background(), userId, "office"))
+		read()
+	})
+
+	t.Run("A new or deleted comment invalidates every todo and list of the owner of its todo", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId, authorId := uuid.New().String(), uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		comment := model.Comment{Id: uuid.New().String(), TodoId: todo.Id, AuthorId: authorId, Body: "body",
+			CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(3)
+		next.EXPECT().GetAll(gomock.Any(), userId).Return([]model.Todo{todo}, nil).Times(3)
+		next.EXPECT().CreateComment(gomock.Any(), &comment, userId).Return(nil)
+		next.EXPECT().UpdateComment(gomock.Any(), &comment, userId).Return(nil)
+		next.EXPECT().DeleteComment(gomock.Any(), todo.Id, comment.Id, userId, authorId).Return(nil)
+		read := func() {
+			todoRepository.GetById(context.Background(), todo.Id, userId)
+			todoRepository.GetAll(context.Background(), userId)
+		}
+		read()
+		assert.NoError(t, todoRepository.CreateComment(context.Background(), &comment, userId))
+		read()
+		assert.NoError(t, todoRepository.UpdateComment(context.Background(), &comment, userId))
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, todoRepository.DeleteComment(context.Background(), todo.Id, comment.Id, userId, authorId))
+		read()
+	})
+
+	t.Run("A failed write invalidates too", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(2)
+		next.EXPECT().Delete(gomock.Any(), todo.Id, userId, model.FirstVersion, gomock.Any()).Return(ErrVersionMismatch)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		err := todoRepository.Delete(context.Background(), todo.Id, userId, model.FirstVersion, time.Now())
+		assert.Equal(t, ErrVersionMismatch, err)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+	})
+
+	t.Run("A read that a write overtook isn't stored", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		title := "title2"
+		reading, written := make(chan struct{}), make(chan struct{})
+		gomock.InOrder(
+			next.EXPECT().GetById(gomock.Any(), todo.Id, userId).DoAndReturn(
+				func(ctx context.Context, id string, userId string) (*model.Todo, error) {
+					close(reading)
+					<-written
+					return &todo, nil
+				}),
+			next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil),
+		)
+		next.EXPECT().Patch(gomock.Any(), todo.Id, userId, gomock.Any(), AnyVersion).Return(nil)
+		go func() {
+			<-reading
+			todoRepository.Patch(context.Background(), todo.Id, userId, model.TodoChanges{Title: &title}, AnyVersion)
+			close(written)
+		}()
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+	})
+
+	t.Run("A write of another user doesn't keep a read from being stored", func(t *testing.T) {
+		_, next, todoRepository := createCached(t, 10, 10)
+		userId, otherUserId := uuid.New().String(), uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		title := "title2"
+		reading, written := make(chan struct{}), make(chan struct{})
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).DoAndReturn(
+			func(ctx context.Context, id string, userId string) (*model.Todo, error) {
+				close(reading)
+				<-written
+				return &todo, nil
+			})
+		next.EXPECT().Patch(gomock.Any(), todo.Id, otherUserId, gomock.Any(), AnyVersion).Return(nil)
+		go func() {
+			<-reading
+			todoRepository.Patch(context.Background(), todo.Id, otherUserId, model.TodoChanges{Title: &title}, AnyVersion)
+			close(written)
+		}()
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		got, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, &todo, got)
+	})
+}
+
+func TestCachedSingleflight(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		cache, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		release := make(chan struct{})
+		next.EXPECT().GetAll(gomock.Any(), userId).DoAndReturn(func(ctx context.Context, userId string) ([]model.Todo, error) {
+			<-release
+			return []model.Todo{newMemoryTodo(time.Now())}, nil
+		}).Times(1)
+		const readers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < readers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				todos, err := todoRepository.GetAll(context.Background(), userId)
+				assert.NoError(t, err)
+				assert.Len(t, todos, 1)
+			}()
+		}
+		for cache.Stats().Misses < readers {
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("When the request that reads is canceled", func(t *testing.T) {
+		cache, next, todoRepository := createCached(t, 10, 10)
+		userId := uuid.New().String()
+		ctx, cancel := context.WithCancel(context.Background())
+		gomock.InOrder(
+			next.EXPECT().GetAll(gomock.Any(), userId).DoAndReturn(func(ctx context.Context, userId string) ([]model.Todo, error) {
+				for cache.Stats().Misses < 2 {
+					time.Sleep(time.Millisecond)
+				}
+				cancel()
+				return nil, ErrCanceled
+			}),
+			next.EXPECT().GetAll(gomock.Any(), userId).Return([]model.Todo{}, nil),
+		)
+		canceled := make(chan error)
+		go func() {
+			_, err := todoRepository.GetAll(ctx, userId)
+			canceled <- err
+		}()
+		for cache.Stats().Misses < 1 {
+			time.Sleep(time.Millisecond)
+		}
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Empty(t, todos)
+		assert.Equal(t, ErrCanceled, <-canceled)
+	})
+}
+
+func TestCachedUnitOfWork(t *testing.T) {
+	t.Run("When UnitOfWork is nil", func(t *testing.T) {
+		unitOfWork, err := GetCachedUnitOfWork(nil, NewTodoCache(1, 1, time.Minute))
+		assert.Nil(t, unitOfWork)
+		assert.Equal(t, ErrUnitOfWorkIsNil, err)
+	})
+
+	t.Run("Good case", func(t *testing.T) {
+		cache, next, todoRepository := createCached(t, 10, 10)
+		mockCtrl := gomock.NewController(t)
+		mockUnitOfWork := common.NewMockUnitOfWork(mockCtrl)
+		mockTransaction := common.NewMockTransaction(mockCtrl)
+		unitOfWork, err := GetCachedUnitOfWork(mockUnitOfWork, cache)
+		assert.NoError(t, err)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		next.EXPECT().GetById(gomock.Any(), todo.Id, userId).Return(&todo, nil).Times(2)
+		mockUnitOfWork.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, work func(tx common.Transaction) error) error {
+				return work(mockTransaction)
+			})
+		mockTransaction.EXPECT().Delete(gomock.Any(), todo.Id, userId, AnyVersion, gomock.Any()).Return(nil)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+		err = unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Delete(context.Background(), todo.Id, userId, AnyVersion, time.Now())
+		})
+		assert.NoError(t, err)
+		todoRepository.GetById(context.Background(), todo.Id, userId)
+	})
+}
+
+func createCached(t *testing.T, users int, entriesPerUser int) (*TodoCache, *common.MockTodoRepository, common.TodoRepository) {
+	t.Helper()
+	cache := NewTodoCache(users, entriesPerUser, time.Minute)
+	next := common.NewMockTodoRepository(gomock.NewController(t))
+	todoRepository, err := GetCachedTodoRepository(next, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache, next, todoRepository
+}
@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository/repositorytest"
+	"github.com/google/uuid"
+)
+
+func TestMemoryTodoRepositoryConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, rules repository.SubtaskRules) common.TodoRepository {
+		store, err := repository.OpenMemoryStore(filepath.Join(t.TempDir(), "todos.json"), uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		todoRepository, err := repository.GetMemoryTodoRepository(store, rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todoRepository
+	})
+}
+
+func TestSQLiteTodoRepositoryConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, rules repository.SubtaskRules) common.TodoRepository {
+		db, err := repository.OpenSQLite(filepath.Join(t.TempDir(), "todo.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		todoRepository, err := repository.GetSQLiteTodoRepository(db, 0, rules, uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todoRepository
+	})
+}
+
+func TestCachedTodoRepositoryConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, rules repository.SubtaskRules) common.TodoRepository {
+		store, err := repository.OpenMemoryStore("", uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memoryTodoRepository, err := repository.GetMemoryTodoRepository(store, rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		todoRepository, err := repository.GetCachedTodoRepository(memoryTodoRepository, repository.NewTodoCache(10, 10, time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todoRepository
+	})
+}
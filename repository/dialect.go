@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+// dialect is the SQL that a todoRepositoryImpl speaks to its database: the
+// fixed queries, and what pageQuery and patchQuery need to build the others.
+type dialect struct {
+	// tags is the column of the tags of a todo as a JSON array, which every
+	// select of todos reads after the todoColumns.
+	tags         string
+	insertTodo   string
+	allTodos     string
+	specificTodo string
+	update       string
+	delete       string
+	version      string
+	trash        string
+	restore      string
+	purge        string
+	purgeTrash   string
+	// The queries of the tags. A list of tags is passed to them as a JSON
+	// array.
+	deleteTodoTags string
+	insertTags     string
+	insertTodoTags string
+	allTags        string
+	tagExists      string
+	touchTagged    string
+	renameTag      string
+	mergeTag       string
+	deleteTag      string
+	// The queries of the lists.
+	inbox         string
+	insertInbox   string
+	listArchived  string
+	listInbox     string
+	allLists      string
+	specificList  string
+	insertList    string
+	patchList     string
+	moveListTodos string
+	deleteList    string
+	// The queries of the subtasks.
+	parent          string
+	parentId        string
+	completeParent  string
+	deleteSubtasks  string
+	parentInTrash   string
+	restoreSubtasks string
+	subtasks        string
+	descendants     string
+	// The queries of the positions.
+	lastPosition   string
+	anchorPosition string
+	positionBefore string
+	positionAfter  string
+	move           string
+	// recurringTodo reads a todo that recurs and was done at a time.
+	recurringTodo string
+	// The queries of the memberships.
+	todoAccess       string
+	listAccess       string
+	sharedLists      string
+	memberships      string
+	insertMembership string
+	updateMembership string
+	deleteMembership string
+	// The queries of the share links.
+	shareLink       string
+	upsertTodoLink  string
+	upsertListLink  string
+	deleteShareLink string
+	openedShareLink string
+	countShareLink  string
+	// The queries of the comments, whose selects commentsQuery builds.
+	commentAuthor string
+	insertComment string
+	updateComment string
+	deleteComment string
+	// placeholder is the placeholder of the nth argument of a query.
+	placeholder func(n int) string
+	// uuid and timestamp follow the placeholders of the values of those
+	// types, to cast them where the database can't tell their type.
+	uuid      string
+	timestamp string
+	// titleLike matches the title against the placeholder of a LIKE pattern
+	// that escapes with a backslash, ignoring case.
+	titleLike string
+	search    func(ctx context.Context, conn dbConn, userId string, query string, limit int) ([]model.SearchResult, error)
+	// conn wraps the transactions of a unitOfWorkImpl.
+	conn func(tx dbConn) dbConn
+}
+
+var postgresDialect = dialect{
+	tags:             tagsColumn,
+	insertTodo:       insertTodoQuery,
+	allTodos:         allTodosQuery,
+	specificTodo:     specificTodoQuery,
+	update:           updateQuery,
+	delete:           deleteQuery,
+	version:          versionQuery,
+	trash:            trashQuery,
+	restore:          restoreQuery,
+	purge:            purgeQuery,
+	purgeTrash:       purgeTrashQuery,
+	deleteTodoTags:   deleteTodoTagsQuery,
+	insertTags:       insertTagsQuery,
+	insertTodoTags:   insertTodoTagsQuery,
+	allTags:          allTagsQuery,
+	tagExists:        tagExistsQuery,
+	touchTagged:      touchTaggedQuery,
+	renameTag:        renameTagQuery,
+	mergeTag:         mergeTagQuery,
+	deleteTag:        deleteTagQuery,
+	inbox:            inboxQuery,
+	insertInbox:      insertInboxQuery,
+	listArchived:     listArchivedQuery,
+	listInbox:        listInboxQuery,
+	allLists:         allListsQuery,
+	specificList:     specificListQuery,
+	insertList:       insertListQuery,
+	patchList:        patchListQuery,
+	moveListTodos:    moveListTodosQuery,
+	deleteList:       deleteListQuery,
+	parent:           parentQuery,
+	parentId:         parentIdQuery,
+	completeParent:   completeParentQuery,
+	deleteSubtasks:   deleteSubtasksQuery,
+	parentInTrash:    parentInTrashQuery,
+	restoreSubtasks:  restoreSubtasksQuery,
+	subtasks:         subtasksQuery,
+	descendants:      descendantsQuery,
+	lastPosition:     lastPositionQuery,
+	anchorPosition:   anchorPositionQuery,
+	positionBefore:   positionBeforeQuery,
+	positionAfter:    positionAfterQuery,
+	move:             moveQuery,
+	recurringTodo:    recurringTodoQuery,
+	todoAccess:       todoAccessQuery,
+	listAccess:       listAccessQuery,
+	sharedLists:      sharedListsQuery,
+	memberships:      membershipsQuery,
+	insertMembership: insertMembershipQuery,
+	updateMembership: updateMembershipQuery,
+	deleteMembership: deleteMembershipQuery,
+	shareLink:        shareLinkQuery,
+	upsertTodoLink:   upsertTodoLinkQuery,
+	upsertListLink:   upsertListLinkQuery,
+	deleteShareLink:  deleteShareLinkQuery,
+	openedShareLink:  openedShareLinkQuery,
+	countShareLink:   countShareLinkQuery,
+	commentAuthor:    commentAuthorQuery,
+	insertComment:    insertCommentQuery,
+	updateComment:    updateCommentQuery,
+	deleteComment:    deleteCommentQuery,
+	placeholder:      func(n int) string { return fmt.Sprintf("$%d", n) },
+	uuid:             "::UUID",
+	timestamp:        "::timestamptz",
+	titleLike:        "title ilike %s",
+	search:           searchPostgres,
+	conn:             func(tx dbConn) dbConn { return tx },
+}
@@ -0,0 +1,1646 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+)
+
+var ErrMemoryStoreIsNil = errors.New("MemoryStore is nil")
+var ErrDuplicateTodo = errors.New("there is already a todo with that id")
+var ErrDuplicateList = errors.New("there is already a list with that id")
+
+// memoryTodo is a todo as a MemoryStore keeps it, with the user it belongs to.
+type memoryTodo struct {
+	UserId string     `json:"userId"`
+	Todo   model.Todo `json:"todo"`
+}
+
+// memoryList is a list as a MemoryStore keeps it, with the user it belongs
+// to.
+type memoryList struct {
+	UserId string     `json:"userId"`
+	List   model.List `json:"list"`
+}
+
+// memoryShareLink is a share link as a MemoryStore keeps it, with the user
+// it belongs to.
+type memoryShareLink struct {
+	UserId string          `json:"userId"`
+	Link   model.ShareLink `json:"link"`
+}
+
+// memorySnapshot is the content of a snapshot file. Snapshots from before
+// lists are a JSON array of the todos alone.
+type memorySnapshot struct {
+	Todos       []memoryTodo       `json:"todos"`
+	Lists       []memoryList       `json:"lists"`
+	Memberships []model.Membership `json:"memberships"`
+	ShareLinks  []memoryShareLink  `json:"shareLinks"`
+	Comments    []model.Comment    `json:"comments"`
+}
+
+// MemoryStore keeps the todos, lists, memberships, share links and comments
+// of every user in memory, for running the server without a database. When
+// it has a snapshot file, it loads them from it when it is opened and
+// rewrites it after every write.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	todos        map[string]memoryTodo
+	lists        map[string]memoryList
+	memberships  map[string]model.Membership
+	shareLinks   map[string]memoryShareLink
+	comments     map[string]model.Comment
+	snapshotFile string
+	inboxes      inboxSource
+}
+
+// OpenMemoryStore opens a MemoryStore that persists to snapshotFile, or that
+// only lives in memory when snapshotFile is empty. A snapshot file that
+// doesn't exist yet is created on the first write. The todos of a snapshot
+// without lists go to the inboxes of their users, which get an id from newId
+// and the time from now when they are created, like every other inbox.
+func OpenMemoryStore(snapshotFile string, newId func() (uuid.UUID, error), now func() time.Time) (*MemoryStore, error) {
+	store := &MemoryStore{todos: map[string]memoryTodo{}, lists: map[string]memoryList{},
+		memberships: map[string]model.Membership{}, shareLinks: map[string]memoryShareLink{}, comments: map[string]model.Comment{},
+		snapshotFile: snapshotFile, inboxes: inboxSource{newId: newId, now: now}}
+	if snapshotFile == "" {
+		return store, nil
+	}
+	content, err := os.ReadFile(snapshotFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var snapshot memorySnapshot
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(content, &snapshot.Todos)
+	} else {
+		err = json.Unmarshal(content, &snapshot)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range snapshot.Lists {
+		store.lists[list.List.Id] = list
+	}
+	for _, membership := range snapshot.Memberships {
+		store.memberships[membershipKey(membership.ListId, membership.UserId)] = membership
+	}
+	for _, link := range snapshot.ShareLinks {
+		store.shareLinks[link.Link.Token] = link
+	}
+	for _, comment := range snapshot.Comments {
+		store.comments[comment.Id] = comment
+	}
+	for _, todo := range snapshot.Todos {
+		if todo.Todo.Tags == nil {
+			todo.Todo.Tags = []string{}
+		}
+		if todo.Todo.ListId == "" {
+			if todo.Todo.ListId, err = store.inbox(todo.UserId); err != nil {
+				return nil, err
+			}
+		}
+		store.todos[todo.Todo.Id] = todo
+	}
+	return store, nil
+}
+
+// save writes the snapshot to a temporary file that then replaces the
+// snapshot file, so that a crash never leaves half a snapshot behind.
+func (store *MemoryStore) save() error {
+	if store.snapshotFile == "" {
+		return nil
+	}
+	snapshot := memorySnapshot{Todos: make([]memoryTodo, 0, len(store.todos)), Lists: make([]memoryList, 0, len(store.lists)),
+		Memberships: make([]model.Membership, 0, len(store.memberships)),
+		ShareLinks:  make([]memoryShareLink, 0, len(store.shareLinks)), Comments: make([]model.Comment, 0, len(store.comments))}
+	for _, todo := range store.todos {
+		snapshot.Todos = append(snapshot.Todos, todo)
+	}
+	sort.Slice(snapshot.Todos, func(i, j int) bool { return snapshot.Todos[i].Todo.Id < snapshot.Todos[j].Todo.Id })
+	for _, list := range store.lists {
+		snapshot.Lists = append(snapshot.Lists, list)
+	}
+	sort.Slice(snapshot.Lists, func(i, j int) bool { return snapshot.Lists[i].List.Id < snapshot.Lists[j].List.Id })
+	keys := make([]string, 0, len(store.memberships))
+	for key := range store.memberships {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		snapshot.Memberships = append(snapshot.Memberships, store.memberships[key])
+	}
+	for _, link := range store.shareLinks {
+		snapshot.ShareLinks = append(snapshot.ShareLinks, link)
+	}
+	sort.Slice(snapshot.ShareLinks, func(i, j int) bool {
+		return snapshot.ShareLinks[i].Link.Token < snapshot.ShareLinks[j].Link.Token
+	})
+	for _, comment := range store.comments {
+		snapshot.Comments = append(snapshot.Comments, comment)
+	}
+	sort.Slice(snapshot.Comments, func(i, j int) bool { return snapshot.Comments[i].Id < snapshot.Comments[j].Id })
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(store.snapshotFile), filepath.Base(store.snapshotFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), store.snapshotFile)
+}
+
+// memoryContents is what copyContents copies of a store, for restore to put
+// back.
+type memoryContents struct {
+	todos       map[string]memoryTodo
+	lists       map[string]memoryList
+	memberships map[string]model.Membership
+	shareLinks  map[string]memoryShareLink
+	comments    map[string]model.Comment
+}
+
+func (store *MemoryStore) copyContents() memoryContents {
+	contents := memoryContents{todos: make(map[string]memoryTodo, len(store.todos)),
+		lists: make(map[string]memoryList, len(store.lists)), memberships: make(map[string]model.Membership, len(store.memberships)),
+		shareLinks: make(map[string]memoryShareLink, len(store.shareLinks)), comments: make(map[string]model.Comment, len(store.comments))}
+	for id, todo := range store.todos {
+		contents.todos[id] = todo
+	}
+	for id, list := range store.lists {
+		contents.lists[id] = list
+	}
+	for key, membership := range store.memberships {
+		contents.memberships[key] = membership
+	}
+	for token, link := range store.shareLinks {
+		contents.shareLinks[token] = link
+	}
+	for id, comment := range store.comments {
+		contents.comments[id] = comment
+	}
+	return contents
+}
+
+func (store *MemoryStore) restore(contents memoryContents) {
+	store.todos, store.lists, store.memberships, store.shareLinks, store.comments = contents.todos, contents.lists,
+		contents.memberships, contents.shareLinks, contents.comments
+}
+
+// inbox returns the id of the inbox of a user, which is created the first
+// time it is asked for. The store must be locked for writing.
+func (store *MemoryStore) inbox(userId string) (string, error) {
+	if id, ok := store.inboxOf(userId); ok {
+		return id, nil
+	}
+	inbox, err := store.inboxes.newInbox()
+	if err != nil {
+		return "", err
+	}
+	store.lists[inbox.Id] = memoryList{UserId: userId, List: inbox}
+	return inbox.Id, nil
+}
+
+func (store *MemoryStore) inboxOf(userId string) (string, bool) {
+	for id, list := range store.lists {
+		if list.UserId == userId && list.List.Inbox {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// listOf is todoRepositoryImpl.listOf for a store that is locked for
+// writing.
+func (store *MemoryStore) listOf(userId string, listId string) (string, error) {
+	if listId == "" {
+		return store.inbox(userId)
+	}
+	list, ok := store.lists[listId]
+	if !ok || list.UserId != userId {
+		return "", ErrListNotFound
+	} else if list.List.ArchivedAt != nil {
+		return "", ErrListArchived
+	}
+	return listId, nil
+}
+
+// memoryTodoRepository is the TodoRepository of a MemoryStore. Inside a
+// transaction of a memoryUnitOfWork, the transaction already holds the lock
+// of the store and saves it when it commits.
+type memoryTodoRepository struct {
+	store         *MemoryStore
+	rules         SubtaskRules
+	inTransaction bool
+}
+
+// GetMemoryTodoRepository returns a TodoRepository on top of store that
+// behaves like the Postgres one with rules, except for Search.
+func GetMemoryTodoRepository(store *MemoryStore, rules SubtaskRules) (common.TodoRepository, error) {
+	if store == nil {
+		return nil, ErrMemoryStoreIsNil
+	}
+	return memoryTodoRepository{store: store, rules: rules}, nil
+}
+
+// read runs read under the read lock of the store.
+func (r memoryTodoRepository) read(ctx context.Context, read func(todos map[string]memoryTodo) error) error {
+	if ctx.Err() != nil {
+		return ErrCanceled
+	}
+	if !r.inTransaction {
+		r.store.mu.RLock()
+		defer r.store.mu.RUnlock()
+	}
+	return read(r.store.todos)
+}
+
+// write runs write under the lock of the store and saves the store after it.
+// write must only change the todos once it can't fail anymore; when the
+// store can't be saved, its todos are put back as they were.
+func (r memoryTodoRepository) write(ctx context.Context, write func(todos map[string]memoryTodo) error) error {
+	if ctx.Err() != nil {
+		return ErrCanceled
+	}
+	if r.inTransaction {
+		return write(r.store.todos)
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var before memoryContents
+	if r.store.snapshotFile != "" {
+		before = r.store.copyContents()
+	}
+	if err := write(r.store.todos); err != nil {
+		return err
+	}
+	if err := r.store.save(); err != nil {
+		r.store.restore(before)
+		return err
+	}
+	return nil
+}
+
+func (r memoryTodoRepository) Create(ctx context.Context, todo *model.Todo, userId string) error {
+	if !model.IsValid(todo) {
+		return ErrInvalidTodo
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if _, ok := todos[todo.Id]; ok {
+			return ErrDuplicateTodo
+		}
+		listId := todo.ListId
+		if todo.ParentId != "" {
+			parent, ok := todos[todo.ParentId]
+			if !ok || parent.UserId != userId || parent.Todo.DeletedAt != nil {
+				return ErrParentNotFound
+			}
+			if r.rules.MaxDepth > 0 && r.store.depth(todo.ParentId) > r.rules.MaxDepth {
+				return ErrSubtaskTooDeep
+			}
+			if listId == "" {
+				listId = parent.Todo.ListId
+			}
+		}
+		listId, err := r.store.listOf(userId, listId)
+		if err != nil {
+			return err
+		}
+		todo.Version, todo.ListId = model.FirstVersion, listId
+		todo.Position = model.PositionBetween(r.store.lastPosition(userId), "", todo.Id)
+		stored := copyTodo(*todo)
+		stored.Tags = model.NormalizeTags(stored.Tags)
+		stored.Progress, stored.Subtasks, stored.CommentCount = nil, nil, 0
+		inUTC(&stored)
+		todos[todo.Id] = memoryTodo{UserId: userId, Todo: stored}
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) GetAll(ctx context.Context, userId string) ([]model.Todo, error) {
+	page, err := r.GetPage(ctx, userId, model.TodoFilter{}, model.PageRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Todos, nil
+}
+
+func (r memoryTodoRepository) GetPage(ctx context.Context, userId string, filter model.TodoFilter,
+	pageRequest model.PageRequest) (*model.Page, error) {
+	filter = filter.WithDefaults()
+	less, ok := memorySortColumns[filter.Sort]
+	if !ok || (filter.Order != model.OrderAsc && filter.Order != model.OrderDesc) ||
+		(filter.TagMatch != model.TagMatchAny && filter.TagMatch != model.TagMatchAll) ||
+		(filter.Overdue != nil && filter.Now.IsZero()) {
+		return nil, ErrInvalidFilter
+	}
+	filter.Tags = model.NormalizeTags(filter.Tags)
+	descending := filter.Order == model.OrderDesc
+	if pageRequest.Cursor != nil && pageRequest.Cursor.Backward {
+		descending = !descending
+	}
+	// before tells whether a comes before b in the direction of the page.
+	before := func(a model.Todo, b model.Todo) bool {
+		if descending {
+			return less(b, a) || (!less(a, b) && a.Id > b.Id)
+		}
+		return less(a, b) || (!less(b, a) && a.Id < b.Id)
+	}
+	var cursorTodo *model.Todo
+	if cursor := pageRequest.Cursor; cursor != nil {
+		cursorTodo = &model.Todo{Id: cursor.Id, Title: cursor.Title, Done: &cursor.Done, CreatedAt: cursor.CreatedAt}
+		if cursor.DueAt != nil {
+			cursorTodo.DueAt = &model.Due{Time: *cursor.DueAt}
+		}
+		cursorTodo.Priority, cursorTodo.Position = cursor.Priority, cursor.Position
+	}
+	todos := []model.Todo{}
+	err := r.read(ctx, func(stored map[string]memoryTodo) error {
+		counts := r.store.countsOf()
+		for _, todo := range stored {
+			if todo.UserId == userId && todo.Todo.DeletedAt == nil && matches(todo.Todo, filter) &&
+				(cursorTodo == nil || before(*cursorTodo, todo.Todo)) {
+				todos = append(todos, withCounts(todo.Todo, counts))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(todos, func(i, j int) bool { return before(todos[i], todos[j]) })
+	if pageRequest.Limit > 0 && len(todos) > pageRequest.Limit+1 {
+		todos = todos[:pageRequest.Limit+1]
+	}
+	return model.NewPage(todos, pageRequest), nil
+}
+
+// memorySortColumns are the sortColumns of the memory repository, as the
+// order of the values of each column.
+var memorySortColumns = map[string]func(a model.Todo, b model.Todo) bool{
+	model.SortByCreatedAt: func(a model.Todo, b model.Todo) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	model.SortByTitle:     func(a model.Todo, b model.Todo) bool { return a.Title < b.Title },
+	model.SortByDone:      func(a model.Todo, b model.Todo) bool { return !*a.Done && *b.Done },
+	model.SortByDueAt: func(a model.Todo, b model.Todo) bool {
+		return a.DueAt != nil && (b.DueAt == nil || a.DueAt.Time.Before(b.DueAt.Time))
+	},
+	model.SortByPosition: func(a model.Todo, b model.Todo) bool { return a.Position < b.Position },
+	model.SortByPriority: func(a model.Todo, b model.Todo) bool { return a.Priority < b.Priority },
+}
+
+func matches(todo model.Todo, filter model.TodoFilter) bool {
+	return (filter.Done == nil || *todo.Done == *filter.Done) &&
+		(filter.ListId == "" || todo.ListId == filter.ListId) &&
+		(filter.CreatedAfter == nil || todo.CreatedAt.After(*filter.CreatedAfter)) &&
+		(filter.CreatedBefore == nil || todo.CreatedAt.Before(*filter.CreatedBefore)) &&
+		(filter.Title == "" || strings.Contains(strings.ToLower(todo.Title), strings.ToLower(filter.Title))) &&
+		(filter.DueAfter == nil || (todo.DueAt != nil && todo.DueAt.Time.After(*filter.DueAfter))) &&
+		(filter.DueBefore == nil || (todo.DueAt != nil && todo.DueAt.Time.Before(*filter.DueBefore))) &&
+		(filter.Overdue == nil || todo.IsOverdue(filter.Now) == *filter.Overdue) &&
+		matchesTags(todo.Tags, filter.Tags, filter.TagMatch)
+}
+
+func matchesTags(tags []string, filterTags []string, tagMatch string) bool {
+	if len(filterTags) == 0 {
+		return true
+	}
+	matched := 0
+	for _, tag := range filterTags {
+		if hasTag(tags, tag) {
+			matched++
+		}
+	}
+	if tagMatch == model.TagMatchAll {
+		return matched == len(filterTags)
+	}
+	return matched > 0
+}
+
+func hasTag(tags []string, name string) bool {
+	for _, tag := range tags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryTodoRepository) GetById(ctx context.Context, id string, userId string) (*model.Todo, error) {
+	var todo model.Todo
+	err := r.read(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		todo = withCounts(stored.Todo, r.store.countsOf())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// Search ranks the todos as searchTodos does.
+func (r memoryTodoRepository) Search(ctx context.Context, userId string, query string, limit int) ([]model.SearchResult, error) {
+	todos := []model.Todo{}
+	err := r.read(ctx, func(stored map[string]memoryTodo) error {
+		counts := r.store.countsOf()
+		for _, todo := range stored {
+			if todo.UserId == userId && todo.Todo.DeletedAt == nil {
+				todos = append(todos, withCounts(todo.Todo, counts))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return searchTodos(todos, query, limit), nil
+}
+
+// searchTodos matches the todos whose title or description hold every word
+// of query and none of the words that start with a -, ignoring case. A word
+// in the title counts more than one in the description, like the weights of
+// the search column of Postgres, but words are matched as they are written
+// instead of by their stems.
+func searchTodos(todos []model.Todo, query string, limit int) []model.SearchResult {
+	results := []model.SearchResult{}
+	words, excludedWords := searchWords(query)
+	if len(words) == 0 {
+		return results
+	}
+	for _, todo := range todos {
+		if result, ok := searchResultOf(todo, words, excludedWords); ok {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.Id > b.Id
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func searchWords(query string) (words []*regexp.Regexp, excludedWords []*regexp.Regexp) {
+	for _, word := range strings.Fields(strings.ReplaceAll(query, `"`, " ")) {
+		excluded := strings.HasPrefix(word, "-")
+		word = strings.TrimLeft(word, "-")
+		if word == "" || strings.EqualFold(word, "or") {
+			continue
+		}
+		pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(word))
+		if excluded {
+			excludedWords = append(excludedWords, pattern)
+		} else {
+			words = append(words, pattern)
+		}
+	}
+	return words, excludedWords
+}
+
+func searchResultOf(todo model.Todo, words []*regexp.Regexp, excludedWords []*regexp.Regexp) (model.SearchResult, bool) {
+	for _, word := range excludedWords {
+		if word.MatchString(todo.Title) || word.MatchString(todo.Description) {
+			return model.SearchResult{}, false
+		}
+	}
+	result := model.SearchResult{Todo: todo}
+	for _, word := range words {
+		inTitle, inDescription := word.MatchString(todo.Title), word.MatchString(todo.Description)
+		if !inTitle && !inDescription {
+			return model.SearchResult{}, false
+		}
+		if inTitle {
+			result.Rank += 1
+		}
+		if inDescription {
+			result.Rank += 0.4
+		}
+	}
+	result.HighlightedTitle = highlight(todo.Title, words)
+	result.Snippet = highlight(todo.Description, words)
+	return result, true
+}
+
+// highlight wraps the words in text in <mark></mark> as ts_headline does.
+func highlight(text string, words []*regexp.Regexp) string {
+	patterns := make([]string, len(words))
+	for i, word := range words {
+		patterns[i] = strings.TrimPrefix(word.String(), "(?i)")
+	}
+	return regexp.MustCompile("(?i)"+strings.Join(patterns, "|")).ReplaceAllString(text, "<mark>$0</mark>")
+}
+
+// Update replaces the title, description and done of a todo at version, its
+// tags unless todo.Tags is nil and its list unless todo.ListId is empty, like
+// updateQuery does, completes its parents as the rules tell and creates the
+// next occurrence of a todo that recurs when it completes it.
+func (r memoryTodoRepository) Update(ctx context.Context, todo *model.Todo, userId string, version int64) error {
+	if todo == nil || !model.IsValidExcept(*todo, "CreatedAt") {
+		return ErrInvalidTodo
+	}
+	title, description, done, tags, listId := todo.Title, todo.Description, *todo.Done, todo.Tags, todo.ListId
+	dueAt, timezone, priority, recurrence := dueInUTC(todo.DueAt), todo.Timezone, todo.Priority, todo.Recurrence
+	return r.versionedWrite(ctx, todo.Id, userId, version, func(stored *model.Todo) error {
+		if listId != "" {
+			if _, err := r.store.listOf(userId, listId); err != nil {
+				return err
+			}
+			stored.ListId = listId
+		}
+		stored.Title, stored.Description, stored.DueAt, stored.Timezone = title, description, dueAt, timezone
+		stored.Priority, stored.Recurrence = priority, recurrence
+		setDone(stored, done, todo.UpdatedAt)
+		if tags != nil {
+			stored.Tags = append([]string{}, tags...)
+		}
+		stored.UpdatedAt = todo.UpdatedAt.UTC()
+		next, err := r.store.nextOccurrence(*stored, userId, todo.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if r.rules.CompleteParents && done {
+			r.store.completeParents(*stored, todo.UpdatedAt)
+		}
+		if next != nil {
+			r.store.todos[next.Id] = memoryTodo{UserId: userId, Todo: *next}
+		}
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) Patch(ctx context.Context, id string, userId string, changes model.TodoChanges, version int64) error {
+	if (changes.Title != nil && *changes.Title == "") || (changes.Description != nil && *changes.Description == "") ||
+		(changes.Tags != nil && !model.IsValidTags(*changes.Tags)) || (changes.ListId != nil && !isListId(*changes.ListId)) ||
+		(changes.Due != nil && !changes.Due.IsValid()) ||
+		(changes.Priority != nil && !model.IsValidPriority(*changes.Priority)) {
+		return ErrInvalidTodo
+	}
+	if changes.IsEmpty() {
+		return nil
+	}
+	if changes.UpdatedAt.IsZero() {
+		return ErrInvalidTodo
+	}
+	return r.versionedWrite(ctx, id, userId, version, func(stored *model.Todo) error {
+		if changes.ListId != nil {
+			listId, err := r.store.listOf(userId, *changes.ListId)
+			if err != nil {
+				return err
+			}
+			stored.ListId = listId
+		}
+		if changes.Title != nil {
+			stored.Title = *changes.Title
+		}
+		if changes.Description != nil {
+			stored.Description = *changes.Description
+		}
+		if changes.Done != nil {
+			setDone(stored, *changes.Done, changes.UpdatedAt)
+		}
+		if changes.Tags != nil {
+			stored.Tags = append([]string{}, *changes.Tags...)
+		}
+		if changes.Due != nil {
+			stored.DueAt, stored.Timezone = dueInUTC(changes.Due.DueAt), changes.Due.Timezone
+			stored.Recurrence = changes.Due.Recurrence
+		}
+		if changes.Priority != nil {
+			stored.Priority = *changes.Priority
+		}
+		stored.UpdatedAt = changes.UpdatedAt.UTC()
+		next, err := r.store.nextOccurrence(*stored, userId, changes.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if r.rules.CompleteParents && changes.Done != nil && *changes.Done {
+			r.store.completeParents(*stored, changes.UpdatedAt)
+		}
+		if next != nil {
+			r.store.todos[next.Id] = memoryTodo{UserId: userId, Todo: *next}
+		}
+		return nil
+	})
+}
+
+// nextOccurrence is recurringTodoQuery for a store that is locked for
+// writing: it returns the next occurrence of stored, with the list and the
+// position that Create gives it, when stored recurs and was done at doneAt.
+func (store *MemoryStore) nextOccurrence(stored model.Todo, userId string, doneAt time.Time) (*model.Todo, error) {
+	if stored.Recurrence == "" || stored.CompletedAt == nil || !stored.CompletedAt.Equal(doneAt) {
+		return nil, nil
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	next := stored.NextOccurrence(id.String(), doneAt.UTC())
+	if next == nil {
+		return nil, nil
+	}
+	listId, err := store.listOf(userId, next.ListId)
+	if err != nil {
+		return nil, err
+	}
+	next.Version, next.ListId = model.FirstVersion, listId
+	next.Position = model.PositionBetween(store.lastPosition(userId), "", next.Id)
+	copied := copyTodo(*next)
+	inUTC(&copied)
+	return &copied, nil
+}
+
+// Move finds the neighbour of the anchor among every todo of the user, the
+// ones in the trash too, as positionBeforeQuery and positionAfterQuery do.
+func (r memoryTodoRepository) Move(ctx context.Context, id string, userId string, move model.MoveRequest,
+	movedAt time.Time) error {
+	if err := move.Validate(id); err != nil {
+		return err
+	}
+	return r.versionedWrite(ctx, id, userId, AnyVersion, func(stored *model.Todo) error {
+		anchor, ok := r.store.todos[move.Anchor()]
+		if !ok || anchor.UserId != userId || anchor.Todo.DeletedAt != nil {
+			return ErrAnchorNotFound
+		}
+		lower, upper := anchor.Todo.Position, ""
+		if move.Before != "" {
+			lower, upper = "", anchor.Todo.Position
+		}
+		for todoId, todo := range r.store.todos {
+			if todo.UserId != userId || todoId == id {
+				continue
+			}
+			position := todo.Todo.Position
+			if move.Before != "" && position < upper && position > lower {
+				lower = position
+			} else if move.Before == "" && position > lower && (upper == "" || position < upper) {
+				upper = position
+			}
+		}
+		stored.Position = model.PositionBetween(lower, upper, id)
+		stored.UpdatedAt = movedAt.UTC()
+		return nil
+	})
+}
+
+// lastPosition is lastPositionQuery for a store that is locked.
+func (store *MemoryStore) lastPosition(userId string) string {
+	last := ""
+	for _, todo := range store.todos {
+		if todo.UserId == userId && todo.Todo.Position > last {
+			last = todo.Todo.Position
+		}
+	}
+	return last
+}
+
+// setDone is completedAtOnChange for a stored todo.
+func setDone(stored *model.Todo, done bool, updatedAt time.Time) {
+	stored.Done = &done
+	if !done {
+		stored.CompletedAt = nil
+	} else if stored.CompletedAt == nil {
+		completedAt := updatedAt.UTC()
+		stored.CompletedAt = &completedAt
+	}
+}
+
+// Delete moves the subtasks of the todo outside the trash to the trash with
+// it, like deleteSubtasksQuery does.
+func (r memoryTodoRepository) Delete(ctx context.Context, id string, userId string, version int64, deletedAt time.Time) error {
+	return r.versionedWrite(ctx, id, userId, version, func(stored *model.Todo) error {
+		deletedAt := deletedAt.UTC()
+		stored.DeletedAt = &deletedAt
+		for _, subtaskId := range r.store.descendants(id) {
+			subtask := r.store.todos[subtaskId]
+			if subtask.Todo.DeletedAt == nil {
+				subtaskDeletedAt := deletedAt
+				subtask.Todo.DeletedAt = &subtaskDeletedAt
+				subtask.Todo.Version++
+				r.store.todos[subtaskId] = subtask
+			}
+		}
+		return nil
+	})
+}
+
+// versionedWrite changes a todo that isn't in the trash when it is at
+// version, and increments its version. When change fails, the todo is left
+// as it was.
+func (r memoryTodoRepository) versionedWrite(ctx context.Context, id string, userId string, version int64,
+	change func(stored *model.Todo) error) error {
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		if version != AnyVersion && stored.Todo.Version != version {
+			return ErrVersionMismatch
+		}
+		if err := change(&stored.Todo); err != nil {
+			return err
+		}
+		stored.Todo.Version++
+		todos[id] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) GetTrash(ctx context.Context, userId string) ([]model.Todo, error) {
+	todos := []model.Todo{}
+	err := r.read(ctx, func(stored map[string]memoryTodo) error {
+		counts := r.store.countsOf()
+		for _, todo := range stored {
+			if todo.UserId == userId && todo.Todo.DeletedAt != nil {
+				todos = append(todos, withCounts(todo.Todo, counts))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DeletedAt.Equal(*todos[j].DeletedAt) {
+			return todos[i].DeletedAt.After(*todos[j].DeletedAt)
+		}
+		return todos[i].Id > todos[j].Id
+	})
+	return todos, nil
+}
+
+// Restore restores the subtasks that went to the trash with the todo, like
+// restoreSubtasksQuery does.
+func (r memoryTodoRepository) Restore(ctx context.Context, id string, userId string) error {
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.UserId != userId || stored.Todo.DeletedAt == nil {
+			return ErrNotFound
+		}
+		if parent, ok := todos[stored.Todo.ParentId]; ok && parent.Todo.DeletedAt != nil {
+			return ErrParentInTrash
+		}
+		for _, subtaskId := range r.store.descendants(id) {
+			subtask := todos[subtaskId]
+			if subtask.Todo.DeletedAt != nil && subtask.Todo.DeletedAt.Equal(*stored.Todo.DeletedAt) {
+				subtask.Todo.DeletedAt = nil
+				subtask.Todo.Version++
+				todos[subtaskId] = subtask
+			}
+		}
+		stored.Todo.DeletedAt = nil
+		stored.Todo.Version++
+		todos[id] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) Purge(ctx context.Context, id string, userId string) error {
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.UserId != userId || stored.Todo.DeletedAt == nil {
+			return ErrNotFound
+		}
+		r.store.deleteTodos([]string{id})
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.write(ctx, func(todos map[string]memoryTodo) error {
+		ids := []string{}
+		for id, stored := range todos {
+			if stored.Todo.DeletedAt != nil && stored.Todo.DeletedAt.Before(deletedBefore) {
+				ids = append(ids, id)
+			}
+		}
+		r.store.deleteTodos(ids)
+		purged = int64(len(ids))
+		return nil
+	})
+	return purged, err
+}
+
+// GetTags counts the tags of the todos of a user as allTagsQuery does. A tag
+// of a user exists as long as one of their todos has it, even in the trash,
+// as the trigger on todo_tag keeps it.
+func (r memoryTodoRepository) GetTags(ctx context.Context, userId string) ([]model.Tag, error) {
+	counts := map[string]int64{}
+	err := r.read(ctx, func(stored map[string]memoryTodo) error {
+		for _, todo := range stored {
+			if todo.UserId != userId {
+				continue
+			}
+			for _, tag := range todo.Todo.Tags {
+				count := counts[tag]
+				if todo.Todo.DeletedAt == nil {
+					count++
+				}
+				counts[tag] = count
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]model.Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, model.Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r memoryTodoRepository) RenameTag(ctx context.Context, userId string, name string, newName string) error {
+	if !model.IsValidTag(name) || !model.IsValidTag(newName) {
+		return model.ErrInvalidTag
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if newName != name && tagExists(todos, userId, newName) {
+			return ErrTagExists
+		}
+		return retag(todos, userId, name, newName)
+	})
+}
+
+func (r memoryTodoRepository) MergeTag(ctx context.Context, userId string, name string, into string) error {
+	if !model.IsValidTag(name) || !model.IsValidTag(into) {
+		return model.ErrInvalidTag
+	}
+	if name == into {
+		return ErrSameTag
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if !tagExists(todos, userId, into) {
+			return ErrNotFound
+		}
+		return retag(todos, userId, name, into)
+	})
+}
+
+func (r memoryTodoRepository) DeleteTag(ctx context.Context, userId string, name string) error {
+	if !model.IsValidTag(name) {
+		return model.ErrInvalidTag
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		return retag(todos, userId, name, "")
+	})
+}
+
+func tagExists(todos map[string]memoryTodo, userId string, name string) bool {
+	for _, todo := range todos {
+		if todo.UserId == userId && hasTag(todo.Todo.Tags, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// retag replaces the tag name by the tag newName, or takes it off when
+// newName is empty, on every todo of a user that has it, and increments
+// their versions.
+func retag(todos map[string]memoryTodo, userId string, name string, newName string) error {
+	if !tagExists(todos, userId, name) {
+		return ErrNotFound
+	}
+	for id, stored := range todos {
+		if stored.UserId != userId || !hasTag(stored.Todo.Tags, name) {
+			continue
+		}
+		tags := []string{}
+		for _, tag := range stored.Todo.Tags {
+			if tag != name {
+				tags = append(tags, tag)
+			}
+		}
+		if newName != "" {
+			tags = model.NormalizeTags(append(tags, newName))
+		}
+		stored.Todo.Tags = tags
+		stored.Todo.Version++
+		todos[id] = stored
+	}
+	return nil
+}
+
+// GetLists orders the lists of a user as allListsQuery does and the lists
+// that are shared with them as sharedListsQuery does.
+func (r memoryTodoRepository) GetLists(ctx context.Context, userId string, includeArchived bool) ([]model.List, error) {
+	if err := r.ensureInbox(ctx, userId); err != nil {
+		return nil, err
+	}
+	lists := []model.List{}
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		for _, list := range r.store.lists {
+			if list.UserId == userId && (includeArchived || list.List.ArchivedAt == nil) {
+				lists = append(lists, copyList(list.List))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	shared := []model.List{}
+	err = r.read(ctx, func(map[string]memoryTodo) error {
+		for _, membership := range r.store.memberships {
+			list := r.store.lists[membership.ListId]
+			if membership.UserId == userId && (includeArchived || list.List.ArchivedAt == nil) {
+				shared = append(shared, copyList(list.List))
+				shared[len(shared)-1].OwnerId, shared[len(shared)-1].Role = list.UserId, membership.Role
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortLists(lists)
+	sortLists(shared)
+	return append(lists, shared...), nil
+}
+
+func sortLists(lists []model.List) {
+	sort.Slice(lists, func(i, j int) bool {
+		a, b := lists[i], lists[j]
+		if a.Inbox != b.Inbox {
+			return a.Inbox
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.Id < b.Id
+	})
+}
+
+// ensureInbox creates the inbox of a user unless they already have one, and
+// only takes the write lock when it has to.
+func (r memoryTodoRepository) ensureInbox(ctx context.Context, userId string) error {
+	found := false
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		_, found = r.store.inboxOf(userId)
+		return nil
+	})
+	if err != nil || found {
+		return err
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		_, err := r.store.inbox(userId)
+		return err
+	})
+}
+
+func (r memoryTodoRepository) GetList(ctx context.Context, id string, userId string) (*model.List, error) {
+	var list model.List
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		stored, ok := r.store.lists[id]
+		if !ok || stored.UserId != userId {
+			return ErrNotFound
+		}
+		list = copyList(stored.List)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r memoryTodoRepository) CreateList(ctx context.Context, list *model.List, userId string) error {
+	if list == nil || !model.IsValid(list) || list.Inbox || list.ArchivedAt != nil {
+		return ErrInvalidList
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		if _, ok := r.store.lists[list.Id]; ok {
+			return ErrDuplicateList
+		}
+		stored := copyList(*list)
+		listInUTC(&stored)
+		r.store.lists[list.Id] = memoryList{UserId: userId, List: stored}
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) PatchList(ctx context.Context, id string, userId string, changes model.ListChanges) error {
+	if changes.Name != nil && !model.IsValid(model.List{Id: id, Name: *changes.Name, CreatedAt: changes.UpdatedAt,
+		UpdatedAt: changes.UpdatedAt}) {
+		return ErrInvalidList
+	}
+	if changes.IsEmpty() {
+		return nil
+	}
+	if changes.UpdatedAt.IsZero() {
+		return ErrInvalidList
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		stored, err := r.store.ownList(id, userId)
+		if err != nil {
+			return err
+		}
+		updatedAt := changes.UpdatedAt.UTC()
+		if changes.Name != nil {
+			stored.List.Name = *changes.Name
+		}
+		if changes.Archived != nil && !*changes.Archived {
+			stored.List.ArchivedAt = nil
+		} else if changes.Archived != nil && stored.List.ArchivedAt == nil {
+			stored.List.ArchivedAt = &updatedAt
+		}
+		stored.List.UpdatedAt = updatedAt
+		r.store.lists[id] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) DeleteList(ctx context.Context, id string, userId string, cascade bool) error {
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if _, err := r.store.ownList(id, userId); err != nil {
+			return err
+		}
+		inbox, err := r.store.inbox(userId)
+		if err != nil {
+			return err
+		}
+		ids := []string{}
+		for todoId, stored := range todos {
+			if stored.Todo.ListId != id {
+				continue
+			}
+			if cascade {
+				ids = append(ids, todoId)
+			} else {
+				stored.Todo.ListId = inbox
+				stored.Todo.Version++
+				todos[todoId] = stored
+			}
+		}
+		r.store.deleteTodos(ids)
+		delete(r.store.lists, id)
+		for key, membership := range r.store.memberships {
+			if membership.ListId == id {
+				delete(r.store.memberships, key)
+			}
+		}
+		r.store.deleteShareLink(model.ShareTarget{ListId: id})
+		return nil
+	})
+}
+
+// GetTodoAccess finds the todos of other users as todoAccessQuery does.
+func (r memoryTodoRepository) GetTodoAccess(ctx context.Context, id string, userId string) (*model.Access, error) {
+	var access *model.Access
+	err := r.read(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		access = r.store.access(stored.UserId, stored.Todo.ListId, userId)
+		if access == nil {
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+func (r memoryTodoRepository) GetListAccess(ctx context.Context, id string, userId string) (*model.Access, error) {
+	var access *model.Access
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		stored, ok := r.store.lists[id]
+		if ok {
+			access = r.store.access(stored.UserId, id, userId)
+		}
+		if access == nil {
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+// access returns the access of a user to the list of listId of ownerId, or
+// nil when the user is neither its owner nor one of its members.
+func (store *MemoryStore) access(ownerId string, listId string, userId string) *model.Access {
+	if ownerId == userId {
+		return &model.Access{OwnerId: ownerId, ListId: listId, Role: model.RoleOwner}
+	}
+	if membership, ok := store.memberships[membershipKey(listId, userId)]; ok {
+		return &model.Access{OwnerId: ownerId, ListId: listId, Role: membership.Role}
+	}
+	return nil
+}
+
+// GetMemberships orders the memberships as membershipsQuery does.
+func (r memoryTodoRepository) GetMemberships(ctx context.Context, listId string, userId string) ([]model.Membership, error) {
+	memberships := []model.Membership{}
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		if stored, ok := r.store.lists[listId]; !ok || stored.UserId != userId {
+			return ErrNotFound
+		}
+		for _, membership := range r.store.memberships {
+			if membership.ListId == listId {
+				memberships = append(memberships, membership)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i], memberships[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.UserId < b.UserId
+	})
+	return memberships, nil
+}
+
+func (r memoryTodoRepository) CreateMembership(ctx context.Context, membership *model.Membership, userId string) error {
+	if membership == nil || !model.IsValid(membership) || membership.UserId == userId {
+		return ErrInvalidMembership
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		if _, err := r.store.ownList(membership.ListId, userId); err != nil {
+			return err
+		}
+		key := membershipKey(membership.ListId, membership.UserId)
+		if _, ok := r.store.memberships[key]; ok {
+			return ErrDuplicateMembership
+		}
+		stored := *membership
+		membershipInUTC(&stored)
+		r.store.memberships[key] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) UpdateMembership(ctx context.Context, listId string, userId string, memberId string,
+	changes model.MembershipChanges) error {
+	if !model.IsValid(model.Membership{ListId: listId, UserId: memberId, Role: changes.Role, CreatedAt: changes.UpdatedAt,
+		UpdatedAt: changes.UpdatedAt}) {
+		return ErrInvalidMembership
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		key := membershipKey(listId, memberId)
+		stored, ok := r.store.memberships[key]
+		if list, found := r.store.lists[listId]; !ok || !found || list.UserId != userId {
+			return ErrNotFound
+		}
+		stored.Role, stored.UpdatedAt = changes.Role, changes.UpdatedAt.UTC()
+		r.store.memberships[key] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) DeleteMembership(ctx context.Context, listId string, userId string, memberId string) error {
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		key := membershipKey(listId, memberId)
+		_, ok := r.store.memberships[key]
+		if list, found := r.store.lists[listId]; !ok || !found || list.UserId != userId {
+			return ErrNotFound
+		}
+		delete(r.store.memberships, key)
+		return nil
+	})
+}
+
+func membershipKey(listId string, userId string) string {
+	return listId + "/" + userId
+}
+
+func (r memoryTodoRepository) GetShareLink(ctx context.Context, target model.ShareTarget, userId string) (*model.ShareLink,
+	error) {
+	var link *model.ShareLink
+	err := r.read(ctx, func(map[string]memoryTodo) error {
+		stored, ok := r.store.shareLinkOf(target)
+		if !ok || stored.UserId != userId {
+			return ErrNotFound
+		}
+		link = &model.ShareLink{}
+		*link = copyShareLink(stored.Link)
+		link.OwnerId = userId
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r memoryTodoRepository) CreateShareLink(ctx context.Context, link *model.ShareLink, userId string) error {
+	if link == nil || !model.IsValid(link) {
+		return ErrInvalidShareLink
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if link.TodoId != "" {
+			if stored, ok := todos[link.TodoId]; !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+				return ErrNotFound
+			}
+		} else if _, err := r.store.ownList(link.ListId, userId); err != nil {
+			return err
+		}
+		r.store.deleteShareLink(link.ShareTarget)
+		stored := copyShareLink(*link)
+		stored.AccessCount, stored.LastAccessedAt, stored.OwnerId = 0, nil, ""
+		shareLinkInUTC(&stored)
+		r.store.shareLinks[stored.Token] = memoryShareLink{UserId: userId, Link: stored}
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) DeleteShareLink(ctx context.Context, target model.ShareTarget, userId string) error {
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		if stored, ok := r.store.shareLinkOf(target); !ok || stored.UserId != userId {
+			return ErrNotFound
+		}
+		r.store.deleteShareLink(target)
+		return nil
+	})
+}
+
+// OpenShareLink doesn't find the link of a todo in the trash, as
+// openedShareLinkQuery doesn't.
+func (r memoryTodoRepository) OpenShareLink(ctx context.Context, token string, openedAt time.Time) (*model.ShareLink, error) {
+	var link *model.ShareLink
+	err := r.write(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := r.store.shareLinks[token]
+		if !ok {
+			return ErrNotFound
+		} else if todo, found := todos[stored.Link.TodoId]; stored.Link.TodoId != "" && (!found || todo.Todo.DeletedAt != nil) {
+			return ErrNotFound
+		} else if stored.Link.IsExpired(openedAt) {
+			return ErrShareLinkExpired
+		}
+		lastAccessedAt := openedAt.UTC()
+		stored.Link.AccessCount++
+		stored.Link.LastAccessedAt = &lastAccessedAt
+		r.store.shareLinks[token] = stored
+		link = &model.ShareLink{}
+		*link = copyShareLink(stored.Link)
+		link.OwnerId = stored.UserId
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// shareLinkOf returns the share link of target, when it has one.
+func (store *MemoryStore) shareLinkOf(target model.ShareTarget) (memoryShareLink, bool) {
+	for _, stored := range store.shareLinks {
+		if stored.Link.ShareTarget == target {
+			return stored, true
+		}
+	}
+	return memoryShareLink{}, false
+}
+
+func (store *MemoryStore) deleteShareLink(target model.ShareTarget) {
+	if stored, ok := store.shareLinkOf(target); ok {
+		delete(store.shareLinks, stored.Link.Token)
+	}
+}
+
+func copyShareLink(link model.ShareLink) model.ShareLink {
+	if link.ExpiresAt != nil {
+		expiresAt := *link.ExpiresAt
+		link.ExpiresAt = &expiresAt
+	}
+	if link.LastAccessedAt != nil {
+		lastAccessedAt := *link.LastAccessedAt
+		link.LastAccessedAt = &lastAccessedAt
+	}
+	return link
+}
+
+// GetComments orders the comments as commentsQuery does.
+func (r memoryTodoRepository) GetComments(ctx context.Context, todoId string, userId string,
+	pageRequest model.PageRequest) (*model.CommentPage, error) {
+	backward := pageRequest.Cursor != nil && pageRequest.Cursor.Backward
+	// before tells whether a comes before b in the direction of the page.
+	before := func(a model.Comment, b model.Comment) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt) != backward
+		}
+		if backward {
+			return a.Id > b.Id
+		}
+		return a.Id < b.Id
+	}
+	comments := []model.Comment{}
+	err := r.read(ctx, func(todos map[string]memoryTodo) error {
+		if stored, ok := todos[todoId]; !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		for _, comment := range r.store.comments {
+			if comment.TodoId == todoId && (pageRequest.Cursor == nil ||
+				before(model.Comment{Id: pageRequest.Cursor.Id, CreatedAt: pageRequest.Cursor.CreatedAt}, comment)) {
+				comments = append(comments, comment)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(comments, func(i, j int) bool { return before(comments[i], comments[j]) })
+	if pageRequest.Limit > 0 && len(comments) > pageRequest.Limit+1 {
+		comments = comments[:pageRequest.Limit+1]
+	}
+	return model.NewCommentPage(comments, pageRequest), nil
+}
+
+func (r memoryTodoRepository) CreateComment(ctx context.Context, comment *model.Comment, userId string) error {
+	if comment == nil || !model.IsValid(comment) {
+		return ErrInvalidComment
+	}
+	return r.write(ctx, func(todos map[string]memoryTodo) error {
+		if stored, ok := todos[comment.TodoId]; !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		stored := *comment
+		commentInUTC(&stored)
+		r.store.comments[stored.Id] = stored
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) UpdateComment(ctx context.Context, comment *model.Comment, userId string) error {
+	if comment == nil || !model.IsValidExcept(comment, "CreatedAt") {
+		return ErrInvalidComment
+	}
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		stored, err := r.store.authoredComment(comment.TodoId, comment.Id, userId, comment.AuthorId)
+		if err != nil {
+			return err
+		}
+		stored.Body, stored.UpdatedAt = comment.Body, comment.UpdatedAt.UTC()
+		r.store.comments[stored.Id] = stored
+		comment.CreatedAt = stored.CreatedAt
+		return nil
+	})
+}
+
+func (r memoryTodoRepository) DeleteComment(ctx context.Context, todoId string, id string, userId string,
+	authorId string) error {
+	return r.write(ctx, func(map[string]memoryTodo) error {
+		if _, err := r.store.authoredComment(todoId, id, userId, authorId); err != nil {
+			return err
+		}
+		delete(r.store.comments, id)
+		return nil
+	})
+}
+
+// authoredComment is commentAuthorQuery for a store that is locked: it
+// returns the comment of id on a todo of a user outside the trash, once
+// authorId wrote it.
+func (store *MemoryStore) authoredComment(todoId string, id string, userId string, authorId string) (model.Comment, error) {
+	comment, ok := store.comments[id]
+	todo, found := store.todos[todoId]
+	if !ok || comment.TodoId != todoId || !found || todo.UserId != userId || todo.Todo.DeletedAt != nil {
+		return model.Comment{}, ErrNotFound
+	} else if comment.AuthorId != authorId {
+		return model.Comment{}, ErrNotCommentAuthor
+	}
+	return comment, nil
+}
+
+// GetSubtasks orders the subtasks as subtasksQuery does.
+func (r memoryTodoRepository) GetSubtasks(ctx context.Context, id string, userId string, all bool) ([]model.Todo, error) {
+	subtasks := []model.Todo{}
+	err := r.read(ctx, func(todos map[string]memoryTodo) error {
+		stored, ok := todos[id]
+		if !ok || stored.UserId != userId || stored.Todo.DeletedAt != nil {
+			return ErrNotFound
+		}
+		counts := r.store.countsOf()
+		for _, subtaskId := range r.store.descendants(id) {
+			subtask := todos[subtaskId]
+			if subtask.Todo.DeletedAt == nil && (all || subtask.Todo.ParentId == id) {
+				subtasks = append(subtasks, withCounts(subtask.Todo, counts))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(subtasks, func(i, j int) bool {
+		a, b := subtasks[i], subtasks[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.Id < b.Id
+	})
+	return subtasks, nil
+}
+
+// progressesOf counts the subtasks outside the trash of every todo that has
+// some, as the progressColumns do.
+func progressesOf(todos map[string]memoryTodo) map[string]model.Progress {
+	progresses := map[string]model.Progress{}
+	for _, todo := range todos {
+		if todo.Todo.ParentId == "" || todo.Todo.DeletedAt != nil {
+			continue
+		}
+		progress := progresses[todo.Todo.ParentId]
+		progress.Total++
+		if *todo.Todo.Done {
+			progress.Done++
+		}
+		progresses[todo.Todo.ParentId] = progress
+	}
+	return progresses
+}
+
+// todoCounts are what the progressColumns and the commentCountColumn count
+// for every todo.
+type todoCounts struct {
+	progresses map[string]model.Progress
+	comments   map[string]int64
+}
+
+func (store *MemoryStore) countsOf() todoCounts {
+	counts := todoCounts{progresses: progressesOf(store.todos), comments: map[string]int64{}}
+	for _, comment := range store.comments {
+		counts.comments[comment.TodoId]++
+	}
+	return counts
+}
+
+func withCounts(todo model.Todo, counts todoCounts) model.Todo {
+	todo = copyTodo(todo)
+	if progress, ok := counts.progresses[todo.Id]; ok {
+		todo.Progress = &progress
+	}
+	todo.CommentCount = counts.comments[todo.Id]
+	return todo
+}
+
+// depth is the depth of the todo id as parentQuery counts it.
+func (store *MemoryStore) depth(id string) int {
+	depth := 0
+	for ; id != ""; id = store.todos[id].Todo.ParentId {
+		depth++
+	}
+	return depth
+}
+
+// descendants returns the ids of the subtasks below the todo id at any
+// depth, in or out of the trash.
+func (store *MemoryStore) descendants(id string) []string {
+	ids := []string{}
+	for parentIds := []string{id}; len(parentIds) > 0; parentIds = parentIds[1:] {
+		for subtaskId, todo := range store.todos {
+			if todo.Todo.ParentId == parentIds[0] {
+				ids = append(ids, subtaskId)
+				parentIds = append(parentIds, subtaskId)
+			}
+		}
+	}
+	return ids
+}
+
+// deleteTodos deletes the todos of ids and their subtasks, with their share
+// links and comments, as the foreign keys of parent_id and todo_id do.
+func (store *MemoryStore) deleteTodos(ids []string) {
+	deleted := map[string]bool{}
+	for _, id := range ids {
+		for _, subtaskId := range store.descendants(id) {
+			delete(store.todos, subtaskId)
+			store.deleteShareLink(model.ShareTarget{TodoId: subtaskId})
+			deleted[subtaskId] = true
+		}
+		delete(store.todos, id)
+		store.deleteShareLink(model.ShareTarget{TodoId: id})
+		deleted[id] = true
+	}
+	for commentId, comment := range store.comments {
+		if deleted[comment.TodoId] {
+			delete(store.comments, commentId)
+		}
+	}
+}
+
+// completeParents is completeParentQuery for the parents of child, which is
+// done but isn't stored yet.
+func (store *MemoryStore) completeParents(child model.Todo, updatedAt time.Time) {
+	for child.ParentId != "" {
+		parent := store.todos[child.ParentId]
+		if *parent.Todo.Done || parent.Todo.DeletedAt != nil {
+			return
+		}
+		for _, todo := range store.todos {
+			if todo.Todo.ParentId == parent.Todo.Id && todo.Todo.Id != child.Id && todo.Todo.DeletedAt == nil && !*todo.Todo.Done {
+				return
+			}
+		}
+		setDone(&parent.Todo, true, updatedAt)
+		parent.Todo.UpdatedAt = updatedAt.UTC()
+		parent.Todo.Version++
+		store.todos[parent.Todo.Id] = parent
+		child = parent.Todo
+	}
+}
+
+// ownList returns a list of a user that isn't their inbox.
+func (store *MemoryStore) ownList(id string, userId string) (memoryList, error) {
+	stored, ok := store.lists[id]
+	if !ok || stored.UserId != userId {
+		return memoryList{}, ErrNotFound
+	} else if stored.List.Inbox {
+		return memoryList{}, ErrInboxList
+	}
+	return stored, nil
+}
+
+func copyList(list model.List) model.List {
+	if list.ArchivedAt != nil {
+		archivedAt := *list.ArchivedAt
+		list.ArchivedAt = &archivedAt
+	}
+	return list
+}
+
+// copyTodo copies the values behind the pointers of a todo too, so that the
+// todos of a store never share them with its callers.
+func copyTodo(todo model.Todo) model.Todo {
+	if todo.Done != nil {
+		done := *todo.Done
+		todo.Done = &done
+	}
+	if todo.CompletedAt != nil {
+		completedAt := *todo.CompletedAt
+		todo.CompletedAt = &completedAt
+	}
+	if todo.DeletedAt != nil {
+		deletedAt := *todo.DeletedAt
+		todo.DeletedAt = &deletedAt
+	}
+	if todo.Tags != nil {
+		todo.Tags = append([]string{}, todo.Tags...)
+	}
+	if todo.Progress != nil {
+		progress := *todo.Progress
+		todo.Progress = &progress
+	}
+	if todo.DueAt != nil {
+		dueAt := *todo.DueAt
+		todo.DueAt = &dueAt
+	}
+	return todo
+}
+
+// dueInUTC copies a due date in UTC, as the store keeps its times.
+func dueInUTC(dueAt *model.Due) *model.Due {
+	if dueAt == nil {
+		return nil
+	}
+	return &model.Due{Time: dueAt.Time.UTC(), DateOnly: dueAt.DateOnly}
+}
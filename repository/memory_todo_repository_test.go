@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemoryTodoRepository(t *testing.T) {
+	t.Run("When MemoryStore is nil", func(t *testing.T) {
+		todoRepository, err := GetMemoryTodoRepository(nil, SubtaskRules{})
+		assert.Nil(t, todoRepository)
+		assert.Equal(t, ErrMemoryStoreIsNil, err)
+	})
+}
+
+func TestMemoryCreate(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, model.FirstVersion, todo.Version)
+		stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, todo.Title, stored.Title)
+		assert.Equal(t, time.UTC, stored.CreatedAt.Location())
+	})
+
+	t.Run("Invalid todo", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		todoDone := false
+		invalidTodo := model.Todo{Title: "title1", Done: &todoDone, CreatedAt: time.Now()}
+		err := todoRepository.Create(context.Background(), &invalidTodo, uuid.New().String())
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+
+	t.Run("When the id is taken", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		todo := newMemoryTodo(time.Now())
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, uuid.New().String()))
+		err := todoRepository.Create(context.Background(), &todo, uuid.New().String())
+		assert.Equal(t, ErrDuplicateTodo, err)
+	})
+
+	t.Run("When the request is canceled", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		todo := newMemoryTodo(time.Now())
+		err := todoRepository.Create(ctx, &todo, uuid.New().String())
+		assert.Equal(t, ErrCanceled, err)
+	})
+}
+
+func TestMemoryGetAll(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	now := time.Now()
+	older, newer, otherUsers := newMemoryTodo(now.Add(-time.Hour)), newMemoryTodo(now), newMemoryTodo(now)
+	assert.NoError(t, todoRepository.Create(context.Background(), &older, userId))
+	assert.NoError(t, todoRepository.Create(context.Background(), &newer, userId))
+	assert.NoError(t, todoRepository.Create(context.Background(), &otherUsers, uuid.New().String()))
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{newer.Id, older.Id}, idsOf(todos))
+	todos[0].Title = "changed"
+	stored, _ := todoRepository.GetById(context.Background(), newer.Id, userId)
+	assert.Equal(t, newer.Title, stored.Title)
+}
+
+func TestMemoryGetPage(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	now := time.Now()
+	todos := make([]model.Todo, 5)
+	for i := range todos {
+		todos[i] = newMemoryTodo(now.Add(time.Duration(i) * time.Minute))
+		assert.NoError(t, todoRepository.Create(context.Background(), &todos[i], userId))
+	}
+
+	t.Run("Pages forward and backward", func(t *testing.T) {
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[4].Id, todos[3].Id}, idsOf(page.Todos))
+		assert.Nil(t, page.Prev)
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+			model.PageRequest{Limit: 2, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[2].Id, todos[1].Id}, idsOf(page.Todos))
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+			model.PageRequest{Limit: 2, Cursor: page.Prev})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[4].Id, todos[3].Id}, idsOf(page.Todos))
+	})
+
+	t.Run("Filters and sorts", func(t *testing.T) {
+		after := now.Add(90 * time.Second)
+		page, err := todoRepository.GetPage(context.Background(), userId,
+			model.TodoFilter{CreatedAfter: &after, Sort: model.SortByCreatedAt, Order: model.OrderAsc}, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[2].Id, todos[3].Id, todos[4].Id}, idsOf(page.Todos))
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: "version"}, model.PageRequest{})
+		assert.Nil(t, page)
+		assert.Equal(t, ErrInvalidFilter, err)
+	})
+}
+
+func TestMemoryGetById(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	todo := newMemoryTodo(time.Now())
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, uuid.New().String()))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, uuid.New().String())
+	assert.Nil(t, stored)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestMemorySearch(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	inTitle, inDescription, excluded := newMemoryTodo(time.Now()), newMemoryTodo(time.Now()), newMemoryTodo(time.Now())
+	inTitle.Title = "Buy milk"
+	inDescription.Description = "and some Milk"
+	excluded.Title, excluded.Description = "milk", "bread"
+	for _, todo := range []*model.Todo{&inTitle, &inDescription, &excluded} {
+		assert.NoError(t, todoRepository.Create(context.Background(), todo, userId))
+	}
+	results, err := todoRepository.Search(context.Background(), userId, "milk -bread", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, inTitle.Id, results[0].Id)
+		assert.Equal(t, "Buy <mark>milk</mark>", results[0].HighlightedTitle)
+		assert.Equal(t, inDescription.Id, results[1].Id)
+		assert.Equal(t, "and some <mark>Milk</mark>", results[1].Snippet)
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+	}
+}
+
+func TestMemoryUpdate(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		done := true
+		updatedAt := time.Now().Add(time.Minute)
+		update := model.Todo{Id: todo.Id, Title: "title2", Description: "description2", Done: &done, UpdatedAt: updatedAt}
+		err := todoRepository.Update(context.Background(), &update, userId, model.FirstVersion)
+		assert.NoError(t, err)
+		stored, _ := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.Equal(t, "title2", stored.Title)
+		assert.True(t, stored.CompletedAt.Equal(updatedAt))
+		assert.Equal(t, model.FirstVersion+1, stored.Version)
+	})
+
+	t.Run("When the version doesn't match", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		err := todoRepository.Update(context.Background(), &todo, userId, model.FirstVersion+1)
+		assert.Equal(t, ErrVersionMismatch, err)
+	})
+
+	t.Run("When the todo doesn't exist", func(t *testing.T) {
+		todoRepository := createMemory(t, "")
+		todo := newMemoryTodo(time.Now())
+		err := todoRepository.Update(context.Background(), &todo, uuid.New().String(), AnyVersion)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestMemoryPatch(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	todo := newMemoryTodo(time.Now())
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+
+	t.Run("Good case", func(t *testing.T) {
+		title := "title2"
+		err := todoRepository.Patch(context.Background(), todo.Id, userId,
+			model.TodoChanges{Title: &title, UpdatedAt: time.Now()}, AnyVersion)
+		assert.NoError(t, err)
+		stored, _ := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.Equal(t, title, stored.Title)
+		assert.Equal(t, todo.Description, stored.Description)
+	})
+
+	t.Run("When the title is empty", func(t *testing.T) {
+		title := ""
+		err := todoRepository.Patch(context.Background(), todo.Id, userId,
+			model.TodoChanges{Title: &title, UpdatedAt: time.Now()}, AnyVersion)
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+}
+
+func TestMemoryTrash(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	now := time.Now()
+	todo, purged := newMemoryTodo(now), newMemoryTodo(now)
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	assert.NoError(t, todoRepository.Create(context.Background(), &purged, userId))
+	assert.NoError(t, todoRepository.Delete(context.Background(), todo.Id, userId, model.FirstVersion, now))
+	assert.NoError(t, todoRepository.Delete(context.Background(), purged.Id, userId, AnyVersion, now.Add(-time.Hour)))
+
+	_, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	assert.Equal(t, ErrNotFound, err)
+	trash, err := todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{todo.Id, purged.Id}, idsOf(trash))
+
+	count, err := todoRepository.PurgeTrash(context.Background(), now.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, todoRepository.Restore(context.Background(), todo.Id, userId))
+	assert.Equal(t, ErrNotFound, todoRepository.Restore(context.Background(), todo.Id, userId))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	assert.NoError(t, err)
+	assert.Equal(t, model.FirstVersion+2, stored.Version)
+
+	assert.Equal(t, ErrNotFound, todoRepository.Purge(context.Background(), todo.Id, userId))
+	assert.NoError(t, todoRepository.Delete(context.Background(), todo.Id, userId, AnyVersion, now))
+	assert.NoError(t, todoRepository.Purge(context.Background(), todo.Id, userId))
+	trash, _ = todoRepository.GetTrash(context.Background(), userId)
+	assert.Empty(t, trash)
+}
+
+func TestMemorySnapshot(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		snapshotFile := filepath.Join(t.TempDir(), "todos.json")
+		todoRepository := createMemory(t, snapshotFile)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		list := model.List{Id: uuid.New().String(), Name: "work", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		assert.NoError(t, todoRepository.CreateList(context.Background(), &list, userId))
+		link := model.ShareLink{Token: uuid.New().String(), ShareTarget: model.ShareTarget{ListId: list.Id},
+			CreatedAt: time.Now()}
+		assert.NoError(t, todoRepository.CreateShareLink(context.Background(), &link, userId))
+		comment := model.CreateCommentRequest{Body: "body1"}.Comment(uuid.New().String(), todo.Id, userId, time.Now())
+		assert.NoError(t, todoRepository.CreateComment(context.Background(), &comment, userId))
+		reopened := createMemory(t, snapshotFile)
+		todos, err := reopened.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todo.Id}, idsOf(todos))
+		assert.Equal(t, int64(1), todos[0].CommentCount)
+		lists, err := reopened.GetLists(context.Background(), userId, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todo.ListId, list.Id}, []string{lists[0].Id, lists[1].Id})
+		opened, err := reopened.OpenShareLink(context.Background(), link.Token, time.Now())
+		if assert.NoError(t, err) {
+			assert.Equal(t, userId, opened.OwnerId)
+		}
+	})
+
+	t.Run("A snapshot from before lists puts the todos in the inboxes", func(t *testing.T) {
+		snapshotFile := filepath.Join(t.TempDir(), "todos.json")
+		userId := uuid.New().String()
+		content := `[{"userId": "` + userId + `", "todo": {"id": "` + uuid.New().String() + `", "title": "title1", ` +
+			`"description": "description1", "done": false, "createdAt": "2022-09-21T14:07:05.768Z", ` +
+			`"updatedAt": "2022-09-21T14:07:05.768Z", "version": 1}}]`
+		assert.NoError(t, os.WriteFile(snapshotFile, []byte(content), 0o600))
+		todoRepository := createMemory(t, snapshotFile)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		lists, err := todoRepository.GetLists(context.Background(), userId, false)
+		assert.NoError(t, err)
+		if assert.Len(t, todos, 1) && assert.Len(t, lists, 1) {
+			assert.True(t, lists[0].Inbox)
+			assert.Equal(t, lists[0].Id, todos[0].ListId)
+		}
+	})
+
+	t.Run("The inbox gets the id and the time that the store is given", func(t *testing.T) {
+		inboxId := uuid.New()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		store, err := OpenMemoryStore("", func() (uuid.UUID, error) { return inboxId, nil },
+			func() time.Time { return ti.Local() })
+		if err != nil {
+			t.Fatal(err)
+		}
+		todoRepository, err := GetMemoryTodoRepository(store, SubtaskRules{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lists, err := todoRepository.GetLists(context.Background(), uuid.New().String(), false)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.List{{Id: inboxId.String(), Name: model.InboxName, Inbox: true, CreatedAt: ti, UpdatedAt: ti}},
+			lists)
+	})
+
+	t.Run("When the snapshot can't be saved", func(t *testing.T) {
+		snapshotFile := filepath.Join(t.TempDir(), "missing", "todos.json")
+		todoRepository := createMemory(t, snapshotFile)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		assert.Error(t, todoRepository.Create(context.Background(), &todo, userId))
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Empty(t, todos)
+	})
+
+	t.Run("When the snapshot is corrupt", func(t *testing.T) {
+		snapshotFile := filepath.Join(t.TempDir(), "todos.json")
+		assert.NoError(t, os.WriteFile(snapshotFile, []byte("{"), 0o600))
+		store, err := OpenMemoryStore(snapshotFile, uuid.NewV7, time.Now)
+		assert.Nil(t, store)
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryConcurrency(t *testing.T) {
+	todoRepository := createMemory(t, "")
+	userId := uuid.New().String()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			todo := newMemoryTodo(time.Now())
+			assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+			_, err := todoRepository.GetAll(context.Background(), userId)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	todos, _ := todoRepository.GetAll(context.Background(), userId)
+	assert.Len(t, todos, 20)
+}
+
+func createMemory(t *testing.T, snapshotFile string) common.TodoRepository {
+	t.Helper()
+	store, err := OpenMemoryStore(snapshotFile, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todoRepository, err := GetMemoryTodoRepository(store, SubtaskRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return todoRepository
+}
+
+func newMemoryTodo(createdAt time.Time) model.Todo {
+	todoDone := false
+	return model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+		CreatedAt: createdAt, UpdatedAt: createdAt}
+}
+
+func idsOf(todos []model.Todo) []string {
+	ids := []string{}
+	for _, todo := range todos {
+		ids = append(ids, todo.Id)
+	}
+	return ids
+}
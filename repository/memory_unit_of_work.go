@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+)
+
+type memoryUnitOfWork struct {
+	store *MemoryStore
+	rules SubtaskRules
+}
+
+// GetMemoryUnitOfWork returns a UnitOfWork on top of store whose subtasks
+// follow rules. Its transactions hold the lock of the store, so they run one
+// at a time and nothing reads the store while they run.
+func GetMemoryUnitOfWork(store *MemoryStore, rules SubtaskRules) (common.UnitOfWork, error) {
+	if store == nil {
+		return nil, ErrMemoryStoreIsNil
+	}
+	return memoryUnitOfWork{store: store, rules: rules}, nil
+}
+
+// Do keeps the changes of work and saves the store when work returns nil,
+// and puts the todos and lists back as they were when work returns an error or panics,
+// or when the store can't be saved.
+func (uow memoryUnitOfWork) Do(ctx context.Context, work func(tx common.Transaction) error) error {
+	if ctx.Err() != nil {
+		return ErrCanceled
+	}
+	uow.store.mu.Lock()
+	defer uow.store.mu.Unlock()
+	before := uow.store.copyContents()
+	committed := false
+	defer func() {
+		if !committed {
+			uow.store.restore(before)
+		}
+	}()
+	transaction := memoryTransaction{memoryTodoRepository: memoryTodoRepository{store: uow.store, rules: uow.rules,
+		inTransaction: true}}
+	if err := work(transaction); err != nil {
+		return contextErr(ctx, ctx, err)
+	}
+	if err := uow.store.save(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+type memoryTransaction struct {
+	memoryTodoRepository
+}
+
+func (t memoryTransaction) Savepoint(ctx context.Context, work func() error) error {
+	before := t.store.copyContents()
+	if err := work(); err != nil {
+		t.store.restore(before)
+		return err
+	}
+	return nil
+}
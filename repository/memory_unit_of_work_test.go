@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemoryUnitOfWork(t *testing.T) {
+	t.Run("When MemoryStore is nil", func(t *testing.T) {
+		unitOfWork, err := GetMemoryUnitOfWork(nil, SubtaskRules{})
+		assert.Nil(t, unitOfWork)
+		assert.Equal(t, ErrMemoryStoreIsNil, err)
+	})
+}
+
+func TestMemoryDo(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		unitOfWork, todoRepository := createMemoryUnitOfWork(t)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Create(context.Background(), &todo, userId)
+		})
+		assert.NoError(t, err)
+		_, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.NoError(t, err)
+	})
+
+	t.Run("When work returns an error", func(t *testing.T) {
+		unitOfWork, todoRepository := createMemoryUnitOfWork(t)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			if err := tx.Create(context.Background(), &todo, userId); err != nil {
+				return err
+			}
+			return common.ErrError
+		})
+		assert.Equal(t, common.ErrError, err)
+		_, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When work panics", func(t *testing.T) {
+		unitOfWork, todoRepository := createMemoryUnitOfWork(t)
+		userId := uuid.New().String()
+		todo := newMemoryTodo(time.Now())
+		assert.Panics(t, func() {
+			unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+				tx.Create(context.Background(), &todo, userId)
+				panic(common.ErrError)
+			})
+		})
+		_, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the request is canceled", func(t *testing.T) {
+		unitOfWork, _ := createMemoryUnitOfWork(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		err := unitOfWork.Do(ctx, func(tx common.Transaction) error {
+			cancel()
+			return tx.Restore(ctx, uuid.New().String(), uuid.New().String())
+		})
+		assert.Equal(t, ErrCanceled, err)
+	})
+}
+
+func TestMemorySavepoint(t *testing.T) {
+	unitOfWork, todoRepository := createMemoryUnitOfWork(t)
+	userId := uuid.New().String()
+	kept, rolledBack := newMemoryTodo(time.Now()), newMemoryTodo(time.Now())
+	err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+		if err := tx.Savepoint(context.Background(), func() error {
+			return tx.Create(context.Background(), &kept, userId)
+		}); err != nil {
+			return err
+		}
+		err := tx.Savepoint(context.Background(), func() error {
+			if err := tx.Create(context.Background(), &rolledBack, userId); err != nil {
+				return err
+			}
+			return tx.Delete(context.Background(), kept.Id, userId, model.FirstVersion+1, time.Now())
+		})
+		assert.Equal(t, ErrVersionMismatch, err)
+		return nil
+	})
+	assert.NoError(t, err)
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{kept.Id}, idsOf(todos))
+}
+
+func createMemoryUnitOfWork(t *testing.T) (common.UnitOfWork, common.TodoRepository) {
+	t.Helper()
+	store, err := OpenMemoryStore("", uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitOfWork, err := GetMemoryUnitOfWork(store, SubtaskRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	todoRepository, err := GetMemoryTodoRepository(store, SubtaskRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unitOfWork, todoRepository
+}
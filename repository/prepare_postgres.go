@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"testing"
+	"time"
 
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/migrations"
 	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/pgxpool"
 	tc "github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -69,6 +71,19 @@ func SetupPostgres(t *testing.T) (tc.Container, TodoRepository) {
 */
 
 func SetupPostgres(t *testing.T) (tc.Container, common.TodoRepository) {
+	postgres, dbpool := SetupPostgresDB(t)
+	todoRepository, err := GetTodoRepository(dbpool, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+		return nil, nil
+	}
+
+	return postgres, todoRepository
+}
+
+// SetupPostgresDB starts a migrated Postgres for the tests that need more
+// than the TodoRepository on top of it.
+func SetupPostgresDB(t *testing.T) (tc.Container, *sql.DB) {
 	dbname, user, password := "testdb", "user", "password"
 	postgresPort := nat.Port("5432/tcp")
 	postgres, err := tc.GenericContainer(context.Background(),
@@ -108,23 +123,17 @@ func SetupPostgres(t *testing.T) (tc.Container, common.TodoRepository) {
 		return nil, nil
 	}
 
-	byteArray, err := os.ReadFile("../schemas/postgres_v1.sql")
+	migrator, err := migrations.GetMigrator(dbpool)
 	if err != nil {
 		t.Fatal(err)
 		return nil, nil
 	}
 
-	_, err = dbpool.Exec(string(byteArray))
+	err = migrator.Up()
 	if err != nil {
 		t.Fatal(err)
 		return nil, nil
 	}
 
-	todoRepository, err := GetTodoRepository(dbpool)
-	if err != nil {
-		t.Fatal(err)
-		return nil, nil
-	}
-
-	return postgres, todoRepository
+	return postgres, dbpool
 }
@@ -0,0 +1,1823 @@
+// Package repositorytest checks that a common.TodoRepository behaves the way
+// the handlers expect of every storage of the todos. A backend proves that it
+// is compatible by calling Run from its tests.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Factory returns the TodoRepository that a case of the suite runs against,
+// whose subtasks follow rules. Every case uses users of its own, so a Factory
+// may return the same repository every time for the same rules.
+type Factory func(t *testing.T, rules repository.SubtaskRules) common.TodoRepository
+
+type testCase struct {
+	name string
+	test func(t *testing.T, todoRepository common.TodoRepository)
+}
+
+// subtaskCase is a case that runs against a repository with rules of its own.
+type subtaskCase struct {
+	name  string
+	rules repository.SubtaskRules
+	test  func(t *testing.T, todoRepository common.TodoRepository)
+}
+
+var testCases = []testCase{
+	{"Create then GetById", testCreate},
+	{"Create an invalid todo", testCreateInvalid},
+	{"GetById of another user", testGetByIdOfAnotherUser},
+	{"GetAll orders by created_at desc", testGetAll},
+	{"GetPage pages forward and backward", testGetPage},
+	{"GetPage filters", testGetPageFilter},
+	{"GetPage with an invalid filter", testGetPageInvalidFilter},
+	{"Search", testSearch},
+	{"Update", testUpdate},
+	{"Update of another user", testUpdateOfAnotherUser},
+	{"Update at another version", testUpdateVersionMismatch},
+	{"Update an invalid todo", testUpdateInvalid},
+	{"Patch", testPatch},
+	{"Patch an invalid todo", testPatchInvalid},
+	{"Tags of a todo", testTags},
+	{"GetPage filters by tags", testGetPageTagFilter},
+	{"GetTags", testGetTags},
+	{"RenameTag", testRenameTag},
+	{"MergeTag", testMergeTag},
+	{"DeleteTag", testDeleteTag},
+	{"Lists and the inbox", testLists},
+	{"GetPage filters by list", testGetPageListFilter},
+	{"Archived lists", testArchivedList},
+	{"The inbox can't be changed", testInboxList},
+	{"Lists of another user", testListsOfAnotherUser},
+	{"DeleteList", testDeleteList},
+	{"Delete, Restore and Purge", testTrash},
+	{"Delete of another user", testDeleteOfAnotherUser},
+	{"Canceled request", testCanceled},
+	{"Concurrent writers", testConcurrentWriters},
+	{"Concurrent updates at the same version", testConcurrentUpdates},
+	{"Due dates", testDueDates},
+	{"GetPage filters by the due date", testGetPageDueFilter},
+	{"GetPage sorts by the due date", testGetPageDueSort},
+	{"Priorities", testPriorities},
+	{"GetPage sorts by the priority", testGetPagePrioritySort},
+	{"Move", testMove},
+	{"GetPage sorts by the position", testGetPagePositionSort},
+	{"Completing a todo that recurs creates its next occurrence", testRecurrence},
+	{"Sharing a list", testMemberships},
+	{"Memberships that can't be created", testInvalidMemberships},
+	{"Memberships of another user", testMembershipsOfAnotherUser},
+	{"DeleteList deletes its memberships", testDeleteSharedList},
+	{"The todos of a shared list stay out of the own todos of a member", testMemberOwnTodos},
+	{"Share links", testShareLinks},
+	{"Share links that can't be created or opened", testInvalidShareLinks},
+	{"Share links go with their todo or list", testDeletedShareLinks},
+	{"Comments", testComments},
+	{"Comments that can't be created or changed", testInvalidComments},
+	{"Comments go with their todo", testDeletedComments},
+}
+
+var subtaskCases = []subtaskCase{
+	{"Subtasks and their progress", repository.SubtaskRules{}, testSubtasks},
+	{"Subtasks deeper than the maximum depth", repository.SubtaskRules{MaxDepth: 2}, testSubtaskDepth},
+	{"Completing the last subtask completes the parents", repository.SubtaskRules{CompleteParents: true}, testCompleteParents},
+	{"Parents stay undone without the rule", repository.SubtaskRules{}, testParentsStayUndone},
+	{"Delete, Restore and Purge a todo with subtasks", repository.SubtaskRules{}, testSubtaskTrash},
+}
+
+// Run runs every case of the suite against the repository of newRepository.
+func Run(t *testing.T, newRepository Factory) {
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.test(t, newRepository(t, repository.SubtaskRules{}))
+		})
+	}
+	for _, subtaskCase := range subtaskCases {
+		subtaskCase := subtaskCase
+		t.Run(subtaskCase.name, func(t *testing.T) {
+			subtaskCase.test(t, newRepository(t, subtaskCase.rules))
+		})
+	}
+}
+
+func testCreate(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	assert.Equal(t, model.FirstVersion, todo.Version)
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, todo, *stored)
+		assert.Equal(t, time.UTC, stored.CreatedAt.Location())
+	}
+}
+
+func testCreateInvalid(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	todo.Title = ""
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Create(context.Background(), &todo, userId))
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Empty(t, todos)
+}
+
+func testGetByIdOfAnotherUser(t *testing.T, todoRepository common.TodoRepository) {
+	todo := create(t, todoRepository, uuid.New().String(), baseTime)
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, uuid.New().String())
+	assert.Nil(t, stored)
+	assert.Equal(t, repository.ErrNotFound, err)
+	stored, err = todoRepository.GetById(context.Background(), uuid.New().String(), uuid.New().String())
+	assert.Nil(t, stored)
+	assert.Equal(t, repository.ErrNotFound, err)
+}
+
+func testGetAll(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	older := create(t, todoRepository, userId, baseTime)
+	newer := create(t, todoRepository, userId, baseTime.Add(time.Hour))
+	create(t, todoRepository, uuid.New().String(), baseTime)
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{newer.Id, older.Id}, idsOf(todos))
+}
+
+func testGetPage(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todos := make([]model.Todo, 5)
+	for i := range todos {
+		todos[i] = create(t, todoRepository, userId, baseTime.Add(time.Duration(i)*time.Minute))
+	}
+	page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{todos[4].Id, todos[3].Id}, idsOf(page.Todos))
+	assert.Nil(t, page.Prev)
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+		model.PageRequest{Limit: 2, Cursor: page.Next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{todos[2].Id, todos[1].Id}, idsOf(page.Todos))
+	next := page.Next
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+		model.PageRequest{Limit: 2, Cursor: page.Prev})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{todos[4].Id, todos[3].Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+		model.PageRequest{Limit: 2, Cursor: next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{todos[0].Id}, idsOf(page.Todos))
+	assert.Nil(t, page.Next)
+}
+
+func testGetPageFilter(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	first := create(t, todoRepository, userId, baseTime)
+	second := newTodo(baseTime.Add(time.Minute))
+	second.Title = "Water the plants"
+	done := true
+	second.Done = &done
+	second.CompletedAt = &second.CreatedAt
+	assert.NoError(t, todoRepository.Create(context.Background(), &second, userId))
+	third := create(t, todoRepository, userId, baseTime.Add(2*time.Minute))
+
+	page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Done: &done}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "PLANTS"}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "%"}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Todos)
+	createdAfter, createdBefore := baseTime, baseTime.Add(2*time.Minute)
+	page, err = todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{CreatedAfter: &createdAfter, CreatedBefore: &createdBefore}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{Sort: model.SortByCreatedAt, Order: model.OrderAsc}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Id, second.Id, third.Id}, idsOf(page.Todos))
+}
+
+func testGetPageInvalidFilter(t *testing.T, todoRepository common.TodoRepository) {
+	page, err := todoRepository.GetPage(context.Background(), uuid.New().String(), model.TodoFilter{Sort: "version"},
+		model.PageRequest{})
+	assert.Nil(t, page)
+	assert.Equal(t, repository.ErrInvalidFilter, err)
+	page, err = todoRepository.GetPage(context.Background(), uuid.New().String(),
+		model.TodoFilter{Tags: []string{"work"}, TagMatch: "some"}, model.PageRequest{})
+	assert.Nil(t, page)
+	assert.Equal(t, repository.ErrInvalidFilter, err)
+}
+
+func testSearch(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	inTitle, inDescription, unrelated := newTodo(baseTime), newTodo(baseTime), newTodo(baseTime)
+	inTitle.Title, inTitle.Description = "Buy milk", "from the shop"
+	inDescription.Title, inDescription.Description = "Call mom", "about the milk"
+	unrelated.Title, unrelated.Description = "Read a book", "any book"
+	for _, todo := range []*model.Todo{&inTitle, &inDescription, &unrelated} {
+		assert.NoError(t, todoRepository.Create(context.Background(), todo, userId))
+	}
+	create(t, todoRepository, uuid.New().String(), baseTime)
+
+	results, err := todoRepository.Search(context.Background(), userId, "milk", model.DefaultSearchLimit)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, inTitle.Id, results[0].Id)
+		assert.Equal(t, "Buy <mark>milk</mark>", results[0].HighlightedTitle)
+		assert.Equal(t, inDescription.Id, results[1].Id)
+	}
+	results, err = todoRepository.Search(context.Background(), userId, "milk", 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = todoRepository.Search(context.Background(), uuid.New().String(), "milk", model.DefaultSearchLimit)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testUpdate(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	done := true
+	updatedAt := baseTime.Add(time.Hour)
+	update := model.Todo{Id: todo.Id, Title: "title2", Description: "description2", Done: &done, UpdatedAt: updatedAt}
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		expected := todo
+		expected.Title, expected.Description, expected.Done = "title2", "description2", &done
+		expected.UpdatedAt, expected.CompletedAt, expected.Version = updatedAt, &updatedAt, model.FirstVersion+1
+		assertSameTodo(t, expected, *stored)
+	}
+	update.UpdatedAt = updatedAt.Add(time.Hour)
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.True(t, updatedAt.Equal(*stored.CompletedAt), "a todo that was done keeps when it was completed")
+	}
+}
+
+func testUpdateOfAnotherUser(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	update := todo
+	update.Title = "title2"
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.Update(context.Background(), &update, uuid.New().String(), repository.AnyVersion))
+	update.Id = uuid.New().String()
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, todo, *stored)
+	}
+}
+
+func testUpdateVersionMismatch(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	assert.Equal(t, repository.ErrVersionMismatch,
+		todoRepository.Update(context.Background(), &todo, userId, model.FirstVersion+1))
+	assert.Equal(t, repository.ErrVersionMismatch,
+		todoRepository.Delete(context.Background(), todo.Id, userId, model.FirstVersion+1, baseTime))
+}
+
+func testUpdateInvalid(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	update := todo
+	update.Title = ""
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Update(context.Background(), nil, userId, repository.AnyVersion))
+}
+
+func testPatch(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	title, done, updatedAt := "title2", true, baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Title: &title, Done: &done, UpdatedAt: updatedAt}, model.FirstVersion))
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId, model.TodoChanges{}, model.FirstVersion))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		expected := todo
+		expected.Title, expected.Done = title, &done
+		expected.UpdatedAt, expected.CompletedAt, expected.Version = updatedAt, &updatedAt, model.FirstVersion+1
+		assertSameTodo(t, expected, *stored)
+	}
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Patch(context.Background(), todo.Id, uuid.New().String(),
+		model.TodoChanges{Title: &title, UpdatedAt: updatedAt}, repository.AnyVersion))
+	assert.Equal(t, repository.ErrVersionMismatch, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Title: &title, UpdatedAt: updatedAt}, model.FirstVersion))
+}
+
+func testPatchInvalid(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	empty := ""
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Description: &empty, UpdatedAt: baseTime}, repository.AnyVersion))
+	title := "title2"
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Title: &title}, repository.AnyVersion))
+}
+
+func testTags(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := createTagged(t, todoRepository, userId, "work", "home")
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"home", "work"}, stored.Tags)
+	}
+
+	update := *stored
+	update.Title, update.Tags = "title2", nil
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"home", "work"}, stored.Tags, "an update without tags keeps the tags")
+	}
+	update.Tags = []string{}
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{}, stored.Tags)
+	}
+
+	tags := []string{"errands"}
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Tags: &tags, UpdatedAt: baseTime}, repository.AnyVersion))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tags, stored.Tags)
+	}
+	invalid := []string{"Errands"}
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Tags: &invalid, UpdatedAt: baseTime}, repository.AnyVersion))
+}
+
+func testGetPageTagFilter(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	both := createTagged(t, todoRepository, userId, "home", "work")
+	work := createTagged(t, todoRepository, userId, "work")
+	createTagged(t, todoRepository, userId, "errands")
+	createTagged(t, todoRepository, uuid.New().String(), "home", "work")
+	for tagMatch, expected := range map[string][]string{
+		model.TagMatchAny: {both.Id, work.Id},
+		model.TagMatchAll: {both.Id},
+	} {
+		page, err := todoRepository.GetPage(context.Background(), userId,
+			model.TodoFilter{Tags: []string{"home", "work"}, TagMatch: tagMatch}, model.PageRequest{})
+		if assert.NoError(t, err) {
+			assert.ElementsMatch(t, expected, idsOf(page.Todos), tagMatch)
+		}
+	}
+}
+
+func testGetTags(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	createTagged(t, todoRepository, userId, "home", "work")
+	createTagged(t, todoRepository, userId, "work")
+	trashed := createTagged(t, todoRepository, userId, "errands")
+	assert.NoError(t, todoRepository.Delete(context.Background(), trashed.Id, userId, repository.AnyVersion, baseTime))
+	createTagged(t, todoRepository, uuid.New().String(), "garden")
+	tags, err := todoRepository.GetTags(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{{Name: "errands", Count: 0}, {Name: "home", Count: 1}, {Name: "work", Count: 2}}, tags)
+	tags, err = todoRepository.GetTags(context.Background(), uuid.New().String())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{}, tags)
+}
+
+func testRenameTag(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	tagged := createTagged(t, todoRepository, userId, "home", "work")
+	untagged := createTagged(t, todoRepository, userId, "home")
+	otherUserId := uuid.New().String()
+	other := createTagged(t, todoRepository, otherUserId, "work")
+	assert.NoError(t, todoRepository.RenameTag(context.Background(), userId, "work", "office"))
+	assertTags(t, todoRepository, userId, tagged.Id, []string{"home", "office"}, model.FirstVersion+1)
+	assertTags(t, todoRepository, userId, untagged.Id, []string{"home"}, model.FirstVersion)
+	assertTags(t, todoRepository, otherUserId, other.Id, []string{"work"}, model.FirstVersion)
+
+	assert.Equal(t, repository.ErrTagExists, todoRepository.RenameTag(context.Background(), userId, "home", "office"))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.RenameTag(context.Background(), userId, "work", "job"))
+	assert.Equal(t, model.ErrInvalidTag, todoRepository.RenameTag(context.Background(), userId, "home", "Home"))
+	assertTags(t, todoRepository, userId, tagged.Id, []string{"home", "office"}, model.FirstVersion+1)
+}
+
+func testMergeTag(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	both := createTagged(t, todoRepository, userId, "job", "work")
+	job := createTagged(t, todoRepository, userId, "job")
+	work := createTagged(t, todoRepository, userId, "work")
+	assert.NoError(t, todoRepository.MergeTag(context.Background(), userId, "job", "work"))
+	assertTags(t, todoRepository, userId, both.Id, []string{"work"}, model.FirstVersion+1)
+	assertTags(t, todoRepository, userId, job.Id, []string{"work"}, model.FirstVersion+1)
+	assertTags(t, todoRepository, userId, work.Id, []string{"work"}, model.FirstVersion)
+	tags, err := todoRepository.GetTags(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{{Name: "work", Count: 3}}, tags)
+
+	assert.Equal(t, repository.ErrSameTag, todoRepository.MergeTag(context.Background(), userId, "work", "work"))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.MergeTag(context.Background(), userId, "job", "work"))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.MergeTag(context.Background(), userId, "work", "job"))
+	assertTags(t, todoRepository, userId, work.Id, []string{"work"}, model.FirstVersion)
+}
+
+func testDeleteTag(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	both := createTagged(t, todoRepository, userId, "home", "work")
+	home := createTagged(t, todoRepository, userId, "home")
+	assert.NoError(t, todoRepository.DeleteTag(context.Background(), userId, "work"))
+	assertTags(t, todoRepository, userId, both.Id, []string{"home"}, model.FirstVersion+1)
+	assertTags(t, todoRepository, userId, home.Id, []string{"home"}, model.FirstVersion)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteTag(context.Background(), userId, "work"))
+	assert.NoError(t, todoRepository.DeleteTag(context.Background(), userId, "home"))
+	assertTags(t, todoRepository, userId, home.Id, []string{}, model.FirstVersion+1)
+	tags, err := todoRepository.GetTags(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{}, tags)
+}
+
+func testLists(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	lists, err := todoRepository.GetLists(context.Background(), userId, false)
+	assert.NoError(t, err)
+	if assert.Len(t, lists, 1) {
+		assert.Equal(t, model.InboxName, lists[0].Name)
+		assert.True(t, lists[0].Inbox)
+	}
+	inboxId := lists[0].Id
+	todo := create(t, todoRepository, userId, baseTime)
+	assert.Equal(t, inboxId, todo.ListId)
+
+	work := createList(t, todoRepository, userId, "work")
+	stored, err := todoRepository.GetList(context.Background(), work.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, work.Name, stored.Name)
+		assert.False(t, stored.Inbox)
+		assert.True(t, work.CreatedAt.Equal(stored.CreatedAt))
+		assert.Nil(t, stored.ArchivedAt)
+	}
+	lists, err = todoRepository.GetLists(context.Background(), userId, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{inboxId, work.Id}, listIdsOf(lists))
+
+	workTodo := newTodo(baseTime)
+	workTodo.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &workTodo, userId))
+	assert.Equal(t, work.Id, workTodo.ListId)
+	update := todo
+	update.Title, update.ListId = "title2", work.Id
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+	assertList(t, todoRepository, userId, todo.Id, work.Id, model.FirstVersion+1)
+	update.Title, update.ListId = "title3", ""
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	assertList(t, todoRepository, userId, todo.Id, work.Id, model.FirstVersion+2)
+	inbox := ""
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{ListId: &inbox, UpdatedAt: baseTime}, repository.AnyVersion))
+	assertList(t, todoRepository, userId, todo.Id, inboxId, model.FirstVersion+3)
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{ListId: &work.Id, UpdatedAt: baseTime}, repository.AnyVersion))
+	assertList(t, todoRepository, userId, todo.Id, work.Id, model.FirstVersion+4)
+
+	missing := uuid.New().String()
+	assert.Equal(t, repository.ErrListNotFound, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{ListId: &missing, UpdatedAt: baseTime}, repository.AnyVersion))
+	assert.Equal(t, repository.ErrVersionMismatch, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{ListId: &missing, UpdatedAt: baseTime}, model.FirstVersion))
+	invalid := "work"
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{ListId: &invalid, UpdatedAt: baseTime}, repository.AnyVersion))
+	assertList(t, todoRepository, userId, todo.Id, work.Id, model.FirstVersion+4)
+}
+
+func testGetPageListFilter(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	work := createList(t, todoRepository, userId, "work")
+	create(t, todoRepository, userId, baseTime)
+	workTodo := newTodo(baseTime)
+	workTodo.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &workTodo, userId))
+	page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{ListId: work.Id}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{workTodo.Id}, idsOf(page.Todos))
+}
+
+func testArchivedList(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	work := createList(t, todoRepository, userId, "work")
+	todo := newTodo(baseTime)
+	todo.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	archived, archivedAt := true, baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.PatchList(context.Background(), work.Id, userId,
+		model.ListChanges{Archived: &archived, UpdatedAt: archivedAt}))
+	lists, err := todoRepository.GetLists(context.Background(), userId, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, listIdsOf(lists), work.Id)
+	lists, err = todoRepository.GetLists(context.Background(), userId, true)
+	assert.NoError(t, err)
+	if assert.Len(t, lists, 2) {
+		assert.Equal(t, work.Id, lists[1].Id)
+		assertSameTime(t, &archivedAt, lists[1].ArchivedAt, "ArchivedAt")
+	}
+
+	rejected := newTodo(baseTime)
+	rejected.ListId = work.Id
+	assert.Equal(t, repository.ErrListArchived, todoRepository.Create(context.Background(), &rejected, userId))
+	_, err = todoRepository.GetById(context.Background(), rejected.Id, userId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	title := "title2"
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Title: &title, UpdatedAt: archivedAt}, repository.AnyVersion),
+		"the todos of an archived list can still change")
+
+	name, unarchived := "office", false
+	assert.NoError(t, todoRepository.PatchList(context.Background(), work.Id, userId,
+		model.ListChanges{Name: &name, Archived: &unarchived, UpdatedAt: archivedAt.Add(time.Hour)}))
+	stored, err := todoRepository.GetList(context.Background(), work.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, name, stored.Name)
+		assert.Nil(t, stored.ArchivedAt)
+		assert.True(t, archivedAt.Add(time.Hour).Equal(stored.UpdatedAt))
+	}
+	assert.NoError(t, todoRepository.Create(context.Background(), &rejected, userId))
+}
+
+func testInboxList(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	name, archived := "todos", true
+	assert.Equal(t, repository.ErrInboxList, todoRepository.PatchList(context.Background(), todo.ListId, userId,
+		model.ListChanges{Name: &name, UpdatedAt: baseTime}))
+	assert.Equal(t, repository.ErrInboxList, todoRepository.PatchList(context.Background(), todo.ListId, userId,
+		model.ListChanges{Archived: &archived, UpdatedAt: baseTime}))
+	assert.Equal(t, repository.ErrInboxList, todoRepository.DeleteList(context.Background(), todo.ListId, userId, true))
+	stored, err := todoRepository.GetList(context.Background(), todo.ListId, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.InboxName, stored.Name)
+		assert.Nil(t, stored.ArchivedAt)
+	}
+	assertList(t, todoRepository, userId, todo.Id, todo.ListId, model.FirstVersion)
+}
+
+func testListsOfAnotherUser(t *testing.T, todoRepository common.TodoRepository) {
+	userId, otherUserId := uuid.New().String(), uuid.New().String()
+	work := createList(t, todoRepository, userId, "work")
+	_, err := todoRepository.GetList(context.Background(), work.Id, otherUserId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	name := "office"
+	assert.Equal(t, repository.ErrNotFound, todoRepository.PatchList(context.Background(), work.Id, otherUserId,
+		model.ListChanges{Name: &name, UpdatedAt: baseTime}))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteList(context.Background(), work.Id, otherUserId, false))
+	todo := newTodo(baseTime)
+	todo.ListId = work.Id
+	assert.Equal(t, repository.ErrListNotFound, todoRepository.Create(context.Background(), &todo, otherUserId))
+	lists, err := todoRepository.GetLists(context.Background(), otherUserId, true)
+	assert.NoError(t, err)
+	assert.NotContains(t, listIdsOf(lists), work.Id)
+}
+
+func testDeleteList(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	inbox := create(t, todoRepository, userId, baseTime)
+	moved, deleted := createList(t, todoRepository, userId, "work"), createList(t, todoRepository, userId, "home")
+	movedTodo, deletedTodo := newTodo(baseTime), newTodo(baseTime)
+	movedTodo.ListId, deletedTodo.ListId = moved.Id, deleted.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &movedTodo, userId))
+	assert.NoError(t, todoRepository.Create(context.Background(), &deletedTodo, userId))
+
+	assert.NoError(t, todoRepository.DeleteList(context.Background(), moved.Id, userId, false))
+	assertList(t, todoRepository, userId, movedTodo.Id, inbox.ListId, model.FirstVersion+1)
+	assert.NoError(t, todoRepository.DeleteList(context.Background(), deleted.Id, userId, true))
+	_, err := todoRepository.GetById(context.Background(), deletedTodo.Id, userId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	assertList(t, todoRepository, userId, inbox.Id, inbox.ListId, model.FirstVersion)
+	lists, err := todoRepository.GetLists(context.Background(), userId, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{inbox.ListId}, listIdsOf(lists))
+	_, err = todoRepository.GetList(context.Background(), moved.Id, userId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteList(context.Background(), moved.Id, userId, false))
+}
+
+func testMemberships(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, editorId, viewerId, strangerId := uuid.New().String(), uuid.New().String(), uuid.New().String(),
+		uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	todo := newTodo(baseTime)
+	todo.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, ownerId))
+	private := create(t, todoRepository, ownerId, baseTime)
+	editor := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, editorId, baseTime)
+	viewer := model.CreateMembershipRequest{Role: model.RoleViewer}.Membership(work.Id, viewerId, baseTime.Add(time.Second))
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &editor, ownerId))
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &viewer, ownerId))
+
+	memberships, err := todoRepository.GetMemberships(context.Background(), work.Id, ownerId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Membership{editor, viewer}, memberships)
+	for userId, role := range map[string]model.Role{ownerId: model.RoleOwner, editorId: model.RoleEditor,
+		viewerId: model.RoleViewer} {
+		access, err := todoRepository.GetTodoAccess(context.Background(), todo.Id, userId)
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.Access{OwnerId: ownerId, ListId: work.Id, Role: role}, *access)
+		}
+		access, err = todoRepository.GetListAccess(context.Background(), work.Id, userId)
+		if assert.NoError(t, err) {
+			assert.Equal(t, model.Access{OwnerId: ownerId, ListId: work.Id, Role: role}, *access)
+		}
+	}
+	_, err = todoRepository.GetTodoAccess(context.Background(), todo.Id, strangerId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	_, err = todoRepository.GetListAccess(context.Background(), work.Id, strangerId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	_, err = todoRepository.GetTodoAccess(context.Background(), private.Id, editorId)
+	assert.Equal(t, repository.ErrNotFound, err, "the inbox of the owner stays private")
+
+	lists, err := todoRepository.GetLists(context.Background(), viewerId, false)
+	if assert.NoError(t, err) && assert.Len(t, lists, 2) {
+		assert.True(t, lists[0].Inbox)
+		assert.Equal(t, work.Id, lists[1].Id)
+		assert.Equal(t, ownerId, lists[1].OwnerId)
+		assert.Equal(t, model.RoleViewer, lists[1].Role)
+	}
+	lists, err = todoRepository.GetLists(context.Background(), ownerId, false)
+	if assert.NoError(t, err) {
+		for _, list := range lists {
+			assert.Empty(t, list.OwnerId)
+			assert.Empty(t, list.Role)
+		}
+	}
+	archived := true
+	assert.NoError(t, todoRepository.PatchList(context.Background(), work.Id, ownerId,
+		model.ListChanges{Archived: &archived, UpdatedAt: baseTime}))
+	lists, err = todoRepository.GetLists(context.Background(), viewerId, false)
+	assert.NoError(t, err)
+	assert.NotContains(t, listIdsOf(lists), work.Id)
+	lists, err = todoRepository.GetLists(context.Background(), viewerId, true)
+	assert.NoError(t, err)
+	assert.Contains(t, listIdsOf(lists), work.Id)
+
+	updatedAt := baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.UpdateMembership(context.Background(), work.Id, ownerId, viewerId,
+		model.MembershipChanges{Role: model.RoleEditor, UpdatedAt: updatedAt}))
+	access, err := todoRepository.GetTodoAccess(context.Background(), todo.Id, viewerId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.RoleEditor, access.Role)
+	}
+	memberships, err = todoRepository.GetMemberships(context.Background(), work.Id, ownerId)
+	if assert.NoError(t, err) && assert.Len(t, memberships, 2) {
+		assertSameTime(t, &updatedAt, &memberships[1].UpdatedAt, "updatedAt")
+	}
+	assert.NoError(t, todoRepository.DeleteMembership(context.Background(), work.Id, ownerId, editorId))
+	_, err = todoRepository.GetTodoAccess(context.Background(), todo.Id, editorId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteMembership(context.Background(), work.Id, ownerId, editorId))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.UpdateMembership(context.Background(), work.Id, ownerId, editorId,
+		model.MembershipChanges{Role: model.RoleViewer, UpdatedAt: updatedAt}))
+}
+
+func testInvalidMemberships(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, memberId := uuid.New().String(), uuid.New().String()
+	inbox := create(t, todoRepository, ownerId, baseTime)
+	work := createList(t, todoRepository, ownerId, "work")
+	membership := model.CreateMembershipRequest{Role: model.RoleViewer}.Membership(work.Id, memberId, baseTime)
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &membership, ownerId))
+	assert.Equal(t, repository.ErrDuplicateMembership, todoRepository.CreateMembership(context.Background(), &membership,
+		ownerId))
+	shared := model.CreateMembershipRequest{Role: model.RoleViewer}.Membership(inbox.ListId, memberId, baseTime)
+	assert.Equal(t, repository.ErrInboxList, todoRepository.CreateMembership(context.Background(), &shared, ownerId))
+	self := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, ownerId, baseTime)
+	assert.Equal(t, repository.ErrInvalidMembership, todoRepository.CreateMembership(context.Background(), &self, ownerId))
+	assert.Equal(t, repository.ErrInvalidMembership, todoRepository.UpdateMembership(context.Background(), work.Id, ownerId,
+		memberId, model.MembershipChanges{Role: model.RoleOwner, UpdatedAt: baseTime}))
+	memberships, err := todoRepository.GetMemberships(context.Background(), work.Id, ownerId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Membership{membership}, memberships)
+}
+
+// testMemberOwnTodos pins that the queries of a user over all of their todos,
+// their tags and their trash only ever cover the todos they own. A member
+// reaches a shared list through its id alone.
+func testMemberOwnTodos(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, memberId := uuid.New().String(), uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	shared := newTodo(baseTime)
+	shared.ListId, shared.Tags = work.Id, []string{"home"}
+	assert.NoError(t, todoRepository.Create(context.Background(), &shared, ownerId))
+	membership := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, memberId, baseTime)
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &membership, ownerId))
+
+	todos, err := todoRepository.GetAll(context.Background(), memberId)
+	assert.NoError(t, err)
+	assert.Empty(t, todos)
+	page, err := todoRepository.GetPage(context.Background(), memberId, model.TodoFilter{}.WithDefaults(),
+		model.PageRequest{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.Todos)
+	}
+	results, err := todoRepository.Search(context.Background(), memberId, shared.Title, model.DefaultSearchLimit)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	tags, err := todoRepository.GetTags(context.Background(), memberId)
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.RenameTag(context.Background(), memberId, "home", "house"))
+	assertTags(t, todoRepository, ownerId, shared.Id, []string{"home"}, model.FirstVersion)
+
+	assert.NoError(t, todoRepository.Delete(context.Background(), shared.Id, ownerId, repository.AnyVersion, baseTime))
+	trash, err := todoRepository.GetTrash(context.Background(), memberId)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Restore(context.Background(), shared.Id, memberId))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Purge(context.Background(), shared.Id, memberId))
+	trash, err = todoRepository.GetTrash(context.Background(), ownerId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{shared.Id}, idsOf(trash))
+}
+
+func testMembershipsOfAnotherUser(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, memberId, otherUserId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	membership := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, memberId, baseTime)
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &membership, ownerId))
+	for _, userId := range []string{memberId, otherUserId} {
+		_, err := todoRepository.GetMemberships(context.Background(), work.Id, userId)
+		assert.Equal(t, repository.ErrNotFound, err)
+		invited := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, uuid.New().String(), baseTime)
+		assert.Equal(t, repository.ErrNotFound, todoRepository.CreateMembership(context.Background(), &invited, userId))
+		assert.Equal(t, repository.ErrNotFound, todoRepository.UpdateMembership(context.Background(), work.Id, userId,
+			memberId, model.MembershipChanges{Role: model.RoleViewer, UpdatedAt: baseTime}))
+		assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteMembership(context.Background(), work.Id, userId,
+			memberId))
+	}
+	access, err := todoRepository.GetListAccess(context.Background(), work.Id, memberId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.RoleEditor, access.Role)
+	}
+}
+
+func testDeleteSharedList(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, memberId := uuid.New().String(), uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	membership := model.CreateMembershipRequest{Role: model.RoleEditor}.Membership(work.Id, memberId, baseTime)
+	assert.NoError(t, todoRepository.CreateMembership(context.Background(), &membership, ownerId))
+	assert.NoError(t, todoRepository.DeleteList(context.Background(), work.Id, ownerId, true))
+	_, err := todoRepository.GetListAccess(context.Background(), work.Id, memberId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	lists, err := todoRepository.GetLists(context.Background(), memberId, true)
+	assert.NoError(t, err)
+	assert.NotContains(t, listIdsOf(lists), work.Id)
+	recreated := model.List{Id: work.Id, Name: "work", CreatedAt: baseTime, UpdatedAt: baseTime}
+	assert.NoError(t, todoRepository.CreateList(context.Background(), &recreated, ownerId))
+	memberships, err := todoRepository.GetMemberships(context.Background(), work.Id, ownerId)
+	assert.NoError(t, err)
+	assert.Empty(t, memberships)
+}
+
+func testShareLinks(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, otherUserId := uuid.New().String(), uuid.New().String()
+	todo := create(t, todoRepository, ownerId, baseTime)
+	work := createList(t, todoRepository, ownerId, "work")
+	todoTarget, listTarget := model.ShareTarget{TodoId: todo.Id}, model.ShareTarget{ListId: work.Id}
+	expiresAt := baseTime.Add(time.Hour)
+	todoLink, err := model.CreateShareLinkRequest{}.ShareLink(uuid.New().String(), todoTarget, baseTime)
+	assert.NoError(t, err)
+	listLink, err := model.CreateShareLinkRequest{ExpiresAt: &expiresAt}.ShareLink(uuid.New().String(), listTarget, baseTime)
+	assert.NoError(t, err)
+	assert.NoError(t, todoRepository.CreateShareLink(context.Background(), &todoLink, ownerId))
+	assert.NoError(t, todoRepository.CreateShareLink(context.Background(), &listLink, ownerId))
+
+	link, err := todoRepository.GetShareLink(context.Background(), todoTarget, ownerId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, todoLink.Token, link.Token)
+		assert.Equal(t, todoTarget, link.ShareTarget)
+		assert.Nil(t, link.ExpiresAt)
+		assert.Zero(t, link.AccessCount)
+		assert.Nil(t, link.LastAccessedAt)
+		assertSameTime(t, &baseTime, &link.CreatedAt, "createdAt")
+	}
+	_, err = todoRepository.GetShareLink(context.Background(), todoTarget, otherUserId)
+	assert.Equal(t, repository.ErrNotFound, err)
+
+	for i := 1; i <= 2; i++ {
+		openedAt := baseTime.Add(time.Duration(i) * time.Minute)
+		link, err = todoRepository.OpenShareLink(context.Background(), listLink.Token, openedAt)
+		if assert.NoError(t, err) {
+			assert.Equal(t, listTarget, link.ShareTarget)
+			assert.Equal(t, ownerId, link.OwnerId)
+			assert.Equal(t, int64(i), link.AccessCount)
+			assertSameTime(t, &openedAt, link.LastAccessedAt, "lastAccessedAt")
+		}
+	}
+	link, err = todoRepository.GetShareLink(context.Background(), listTarget, ownerId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), link.AccessCount)
+		assertSameTime(t, &expiresAt, link.ExpiresAt, "expiresAt")
+	}
+	_, err = todoRepository.OpenShareLink(context.Background(), listLink.Token, expiresAt)
+	assert.Equal(t, repository.ErrShareLinkExpired, err)
+	link, err = todoRepository.GetShareLink(context.Background(), listTarget, ownerId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), link.AccessCount, "an expired link isn't counted")
+	}
+
+	replacement, err := model.CreateShareLinkRequest{}.ShareLink(uuid.New().String(), listTarget, baseTime.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, todoRepository.CreateShareLink(context.Background(), &replacement, ownerId))
+	_, err = todoRepository.OpenShareLink(context.Background(), listLink.Token, baseTime)
+	assert.Equal(t, repository.ErrNotFound, err, "a new link replaces the old one")
+	link, err = todoRepository.OpenShareLink(context.Background(), replacement.Token, expiresAt)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), link.AccessCount)
+	}
+
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteShareLink(context.Background(), todoTarget, otherUserId))
+	assert.NoError(t, todoRepository.DeleteShareLink(context.Background(), todoTarget, ownerId))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.DeleteShareLink(context.Background(), todoTarget, ownerId))
+	_, err = todoRepository.OpenShareLink(context.Background(), todoLink.Token, baseTime)
+	assert.Equal(t, repository.ErrNotFound, err)
+	_, err = todoRepository.GetShareLink(context.Background(), todoTarget, ownerId)
+	assert.Equal(t, repository.ErrNotFound, err)
+}
+
+func testInvalidShareLinks(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, otherUserId := uuid.New().String(), uuid.New().String()
+	todo := create(t, todoRepository, ownerId, baseTime)
+	work := createList(t, todoRepository, ownerId, "work")
+	lists, err := todoRepository.GetLists(context.Background(), ownerId, false)
+	assert.NoError(t, err)
+	for _, target := range []model.ShareTarget{{TodoId: todo.Id}, {ListId: work.Id}, {TodoId: uuid.New().String()}} {
+		link := model.ShareLink{Token: uuid.New().String(), ShareTarget: target, CreatedAt: baseTime}
+		assert.Equal(t, repository.ErrNotFound, todoRepository.CreateShareLink(context.Background(), &link, otherUserId))
+	}
+	inboxLink := model.ShareLink{Token: uuid.New().String(), ShareTarget: model.ShareTarget{ListId: lists[0].Id},
+		CreatedAt: baseTime}
+	assert.Equal(t, repository.ErrInboxList, todoRepository.CreateShareLink(context.Background(), &inboxLink, ownerId))
+	invalid := model.ShareLink{Token: uuid.New().String(), CreatedAt: baseTime}
+	assert.Equal(t, repository.ErrInvalidShareLink, todoRepository.CreateShareLink(context.Background(), &invalid, ownerId))
+	_, err = todoRepository.OpenShareLink(context.Background(), uuid.New().String(), baseTime)
+	assert.Equal(t, repository.ErrNotFound, err)
+}
+
+func testDeletedShareLinks(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId := uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	trashed := create(t, todoRepository, ownerId, baseTime)
+	listed := newTodo(baseTime)
+	listed.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &listed, ownerId))
+	links := map[string]model.ShareLink{}
+	for _, target := range []model.ShareTarget{{TodoId: trashed.Id}, {TodoId: listed.Id}, {ListId: work.Id}} {
+		link := model.ShareLink{Token: uuid.New().String(), ShareTarget: target, CreatedAt: baseTime}
+		assert.NoError(t, todoRepository.CreateShareLink(context.Background(), &link, ownerId))
+		links[target.TodoId+target.ListId] = link
+	}
+
+	assert.NoError(t, todoRepository.Delete(context.Background(), trashed.Id, ownerId, repository.AnyVersion, baseTime))
+	_, err := todoRepository.OpenShareLink(context.Background(), links[trashed.Id].Token, baseTime)
+	assert.Equal(t, repository.ErrNotFound, err, "the link of a todo in the trash shows nothing")
+	assert.NoError(t, todoRepository.Restore(context.Background(), trashed.Id, ownerId))
+	_, err = todoRepository.OpenShareLink(context.Background(), links[trashed.Id].Token, baseTime)
+	assert.NoError(t, err, "the link works again once the todo is restored")
+	assert.NoError(t, todoRepository.Delete(context.Background(), trashed.Id, ownerId, repository.AnyVersion, baseTime))
+	assert.NoError(t, todoRepository.Purge(context.Background(), trashed.Id, ownerId))
+
+	assert.NoError(t, todoRepository.DeleteList(context.Background(), work.Id, ownerId, true))
+	for _, link := range links {
+		_, err = todoRepository.OpenShareLink(context.Background(), link.Token, baseTime)
+		assert.Equal(t, repository.ErrNotFound, err)
+	}
+	recreated := model.List{Id: work.Id, Name: "work", CreatedAt: baseTime, UpdatedAt: baseTime}
+	assert.NoError(t, todoRepository.CreateList(context.Background(), &recreated, ownerId))
+	_, err = todoRepository.GetShareLink(context.Background(), model.ShareTarget{ListId: work.Id}, ownerId)
+	assert.Equal(t, repository.ErrNotFound, err)
+}
+
+func createComment(t *testing.T, todoRepository common.TodoRepository, todoId string, userId string,
+	authorId string, createdAt time.Time) model.Comment {
+	t.Helper()
+	comment := model.CreateCommentRequest{Body: "body1"}.Comment(uuid.New().String(), todoId, authorId, createdAt)
+	if err := todoRepository.CreateComment(context.Background(), &comment, userId); err != nil {
+		t.Fatal(err)
+	}
+	return comment
+}
+
+func assertCommentCount(t *testing.T, todoRepository common.TodoRepository, userId string, id string, count int64) {
+	t.Helper()
+	stored, err := todoRepository.GetById(context.Background(), id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, count, stored.CommentCount)
+	}
+}
+
+func testComments(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, authorId := uuid.New().String(), uuid.New().String()
+	todo := create(t, todoRepository, ownerId, baseTime)
+	other := create(t, todoRepository, ownerId, baseTime.Add(time.Minute))
+	comments := []model.Comment{
+		createComment(t, todoRepository, todo.Id, ownerId, ownerId, baseTime.Add(2*time.Minute)),
+		createComment(t, todoRepository, todo.Id, ownerId, authorId, baseTime),
+		createComment(t, todoRepository, todo.Id, ownerId, ownerId, baseTime.Add(time.Minute)),
+	}
+	oldestFirst := []model.Comment{comments[1], comments[2], comments[0]}
+	assertCommentCount(t, todoRepository, ownerId, todo.Id, 3)
+	assertCommentCount(t, todoRepository, ownerId, other.Id, 0)
+	all, err := todoRepository.GetAll(context.Background(), ownerId)
+	if assert.NoError(t, err) && assert.Len(t, all, 2) {
+		assert.Equal(t, []int64{0, 3}, []int64{all[0].CommentCount, all[1].CommentCount})
+	}
+
+	page, err := todoRepository.GetComments(context.Background(), todo.Id, ownerId, model.PageRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, oldestFirst, page.Comments)
+		assert.Nil(t, page.Next)
+	}
+	page, err = todoRepository.GetComments(context.Background(), todo.Id, ownerId, model.PageRequest{Limit: 2})
+	if assert.NoError(t, err) && assert.NotNil(t, page.Next) {
+		assert.Equal(t, oldestFirst[:2], page.Comments)
+		page, err = todoRepository.GetComments(context.Background(), todo.Id, ownerId,
+			model.PageRequest{Limit: 2, Cursor: page.Next})
+		if assert.NoError(t, err) && assert.NotNil(t, page.Prev) {
+			assert.Equal(t, oldestFirst[2:], page.Comments)
+			assert.Nil(t, page.Next)
+			page, err = todoRepository.GetComments(context.Background(), todo.Id, ownerId,
+				model.PageRequest{Limit: 2, Cursor: page.Prev})
+			if assert.NoError(t, err) {
+				assert.Equal(t, oldestFirst[:2], page.Comments)
+				assert.Nil(t, page.Prev)
+			}
+		}
+	}
+	page, err = todoRepository.GetComments(context.Background(), other.Id, ownerId, model.PageRequest{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.Comments)
+	}
+
+	edited := model.UpdateCommentRequest{Body: "body2"}.Comment(comments[1].Id, todo.Id, authorId,
+		baseTime.Add(time.Hour))
+	assert.NoError(t, todoRepository.UpdateComment(context.Background(), &edited, ownerId))
+	assert.Equal(t, comments[1].CreatedAt, edited.CreatedAt)
+	notAuthored := model.UpdateCommentRequest{Body: "body3"}.Comment(comments[1].Id, todo.Id, ownerId,
+		baseTime.Add(time.Hour))
+	assert.Equal(t, repository.ErrNotCommentAuthor,
+		todoRepository.UpdateComment(context.Background(), &notAuthored, ownerId), "not even the owner of the todo")
+	assert.Equal(t, repository.ErrNotCommentAuthor,
+		todoRepository.DeleteComment(context.Background(), todo.Id, comments[1].Id, ownerId, ownerId))
+	page, err = todoRepository.GetComments(context.Background(), todo.Id, ownerId, model.PageRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.Comment{edited, comments[2], comments[0]}, page.Comments)
+	}
+
+	assert.NoError(t, todoRepository.DeleteComment(context.Background(), todo.Id, comments[1].Id, ownerId, authorId))
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.DeleteComment(context.Background(), todo.Id, comments[1].Id, ownerId, authorId))
+	assertCommentCount(t, todoRepository, ownerId, todo.Id, 2)
+}
+
+func testInvalidComments(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId, otherId := uuid.New().String(), uuid.New().String()
+	todo := create(t, todoRepository, ownerId, baseTime)
+	other := create(t, todoRepository, ownerId, baseTime)
+	comment := createComment(t, todoRepository, todo.Id, ownerId, ownerId, baseTime)
+
+	invalid := model.CreateCommentRequest{}.Comment(uuid.New().String(), todo.Id, ownerId, baseTime)
+	assert.Equal(t, repository.ErrInvalidComment, todoRepository.CreateComment(context.Background(), &invalid, ownerId))
+	assert.Equal(t, repository.ErrInvalidComment, todoRepository.CreateComment(context.Background(), nil, ownerId))
+	invalid = model.UpdateCommentRequest{}.Comment(comment.Id, todo.Id, ownerId, baseTime)
+	assert.Equal(t, repository.ErrInvalidComment, todoRepository.UpdateComment(context.Background(), &invalid, ownerId))
+
+	foreign := model.CreateCommentRequest{Body: "body1"}.Comment(uuid.New().String(), todo.Id, otherId, baseTime)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.CreateComment(context.Background(), &foreign, otherId),
+		"the todo isn't one of the user")
+	_, err := todoRepository.GetComments(context.Background(), todo.Id, otherId, model.PageRequest{})
+	assert.Equal(t, repository.ErrNotFound, err)
+	moved := model.UpdateCommentRequest{Body: "body2"}.Comment(comment.Id, other.Id, ownerId, baseTime)
+	assert.Equal(t, repository.ErrNotFound, todoRepository.UpdateComment(context.Background(), &moved, ownerId),
+		"the comment isn't one of that todo")
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.DeleteComment(context.Background(), other.Id, comment.Id, ownerId, ownerId))
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.DeleteComment(context.Background(), todo.Id, comment.Id, otherId, ownerId))
+	assertCommentCount(t, todoRepository, ownerId, todo.Id, 1)
+}
+
+func testDeletedComments(t *testing.T, todoRepository common.TodoRepository) {
+	ownerId := uuid.New().String()
+	work := createList(t, todoRepository, ownerId, "work")
+	trashed := create(t, todoRepository, ownerId, baseTime)
+	listed := newTodo(baseTime)
+	listed.ListId = work.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &listed, ownerId))
+	comment := createComment(t, todoRepository, trashed.Id, ownerId, ownerId, baseTime)
+	createComment(t, todoRepository, listed.Id, ownerId, ownerId, baseTime)
+
+	assert.NoError(t, todoRepository.Delete(context.Background(), trashed.Id, ownerId, repository.AnyVersion, baseTime))
+	_, err := todoRepository.GetComments(context.Background(), trashed.Id, ownerId, model.PageRequest{})
+	assert.Equal(t, repository.ErrNotFound, err, "the comments of a todo in the trash show nothing")
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.DeleteComment(context.Background(), trashed.Id, comment.Id, ownerId, ownerId))
+	assert.NoError(t, todoRepository.Restore(context.Background(), trashed.Id, ownerId))
+	page, err := todoRepository.GetComments(context.Background(), trashed.Id, ownerId, model.PageRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []model.Comment{comment}, page.Comments, "the comments come back once the todo is restored")
+	}
+	assert.NoError(t, todoRepository.Delete(context.Background(), trashed.Id, ownerId, repository.AnyVersion, baseTime))
+	assert.NoError(t, todoRepository.Purge(context.Background(), trashed.Id, ownerId))
+	assert.NoError(t, todoRepository.DeleteList(context.Background(), work.Id, ownerId, true))
+
+	for _, todo := range []model.Todo{trashed, listed} {
+		recreated := todo
+		recreated.ListId = ""
+		assert.NoError(t, todoRepository.Create(context.Background(), &recreated, ownerId))
+		assertCommentCount(t, todoRepository, ownerId, todo.Id, 0)
+		page, err = todoRepository.GetComments(context.Background(), todo.Id, ownerId, model.PageRequest{})
+		if assert.NoError(t, err) {
+			assert.Empty(t, page.Comments)
+		}
+	}
+}
+
+func testTrash(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	deleted := create(t, todoRepository, userId, baseTime)
+	kept := create(t, todoRepository, userId, baseTime)
+	deletedAt := baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Delete(context.Background(), deleted.Id, userId, model.FirstVersion, deletedAt))
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.Delete(context.Background(), deleted.Id, userId, repository.AnyVersion, deletedAt))
+	_, err := todoRepository.GetById(context.Background(), deleted.Id, userId)
+	assert.Equal(t, repository.ErrNotFound, err)
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{kept.Id}, idsOf(todos))
+	trash, err := todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	if assert.Equal(t, []string{deleted.Id}, idsOf(trash)) {
+		assert.True(t, deletedAt.Equal(*trash[0].DeletedAt))
+		assert.Equal(t, model.FirstVersion+1, trash[0].Version)
+	}
+
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Restore(context.Background(), deleted.Id, uuid.New().String()))
+	assert.NoError(t, todoRepository.Restore(context.Background(), deleted.Id, userId))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Restore(context.Background(), deleted.Id, userId))
+	stored, err := todoRepository.GetById(context.Background(), deleted.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Nil(t, stored.DeletedAt)
+		assert.Equal(t, model.FirstVersion+2, stored.Version)
+	}
+
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Purge(context.Background(), deleted.Id, userId))
+	assert.NoError(t, todoRepository.Delete(context.Background(), deleted.Id, userId, repository.AnyVersion, deletedAt))
+	assert.NoError(t, todoRepository.Purge(context.Background(), deleted.Id, userId))
+	trash, err = todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func testDeleteOfAnotherUser(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.Delete(context.Background(), todo.Id, uuid.New().String(), repository.AnyVersion, baseTime))
+	assert.Equal(t, repository.ErrNotFound,
+		todoRepository.Delete(context.Background(), uuid.New().String(), userId, repository.AnyVersion, baseTime))
+	_, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	assert.NoError(t, err)
+}
+
+func testCanceled(t *testing.T, todoRepository common.TodoRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	assert.Equal(t, repository.ErrCanceled, todoRepository.Create(ctx, &todo, userId))
+	todos, err := todoRepository.GetAll(ctx, userId)
+	assert.Nil(t, todos)
+	assert.Equal(t, repository.ErrCanceled, err)
+}
+
+func testConcurrentWriters(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	const writers, todosPerWriter = 10, 5
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < todosPerWriter; j++ {
+				todo := newTodo(baseTime.Add(time.Duration(i*todosPerWriter+j) * time.Second))
+				if err := todoRepository.Create(context.Background(), &todo, userId); err != nil {
+					t.Error(err)
+					continue
+				}
+				title := fmt.Sprintf("title %d %d", i, j)
+				if err := todoRepository.Patch(context.Background(), todo.Id, userId,
+					model.TodoChanges{Title: &title, UpdatedAt: todo.CreatedAt}, todo.Version); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Len(t, todos, writers*todosPerWriter)
+	for _, todo := range todos {
+		assert.Equal(t, model.FirstVersion+1, todo.Version)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := create(t, todoRepository, userId, baseTime)
+	const writers = 10
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			update := todo
+			update.Title = fmt.Sprintf("title %d", i)
+			errs <- todoRepository.Update(context.Background(), &update, userId, model.FirstVersion)
+		}(i)
+	}
+	succeeded := 0
+	for i := 0; i < writers; i++ {
+		if err := <-errs; err == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, repository.ErrVersionMismatch, err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.FirstVersion+1, stored.Version)
+	}
+}
+
+func testSubtasks(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	list := createList(t, todoRepository, userId, "work")
+	parent := newTodo(baseTime)
+	parent.ListId = list.Id
+	assert.NoError(t, todoRepository.Create(context.Background(), &parent, userId))
+	first := createSubtask(t, todoRepository, userId, parent.Id, baseTime.Add(time.Second))
+	second := createSubtask(t, todoRepository, userId, parent.Id, baseTime.Add(2*time.Second))
+	below := createSubtask(t, todoRepository, userId, first.Id, baseTime.Add(3*time.Second))
+	done := true
+	assert.NoError(t, todoRepository.Patch(context.Background(), first.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: baseTime.Add(time.Minute)}, repository.AnyVersion))
+
+	stored, err := todoRepository.GetById(context.Background(), parent.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &model.Progress{Done: 1, Total: 2}, stored.Progress)
+		assert.Empty(t, stored.ParentId)
+	}
+	stored, err = todoRepository.GetById(context.Background(), first.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, parent.Id, stored.ParentId)
+		assert.Equal(t, list.Id, stored.ListId)
+		assert.Equal(t, &model.Progress{Done: 0, Total: 1}, stored.Progress)
+	}
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	if assert.NoError(t, err) && assert.Len(t, todos, 4) {
+		assert.Equal(t, below.Id, todos[0].Id)
+		assert.Nil(t, todos[0].Progress)
+		assert.Equal(t, parent.Id, todos[3].Id)
+		assert.Equal(t, &model.Progress{Done: 1, Total: 2}, todos[3].Progress)
+	}
+
+	subtasks, err := todoRepository.GetSubtasks(context.Background(), parent.Id, userId, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Id, second.Id}, idsOf(subtasks))
+	subtasks, err = todoRepository.GetSubtasks(context.Background(), parent.Id, userId, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.Id, second.Id, below.Id}, idsOf(subtasks))
+	subtasks, err = todoRepository.GetSubtasks(context.Background(), second.Id, userId, true)
+	assert.NoError(t, err)
+	assert.Empty(t, subtasks)
+	_, err = todoRepository.GetSubtasks(context.Background(), parent.Id, uuid.New().String(), false)
+	assert.Equal(t, repository.ErrNotFound, err)
+
+	orphan := newTodo(baseTime)
+	orphan.ParentId = uuid.New().String()
+	assert.Equal(t, repository.ErrParentNotFound, todoRepository.Create(context.Background(), &orphan, userId))
+	orphan.ParentId = parent.Id
+	assert.Equal(t, repository.ErrParentNotFound, todoRepository.Create(context.Background(), &orphan, uuid.New().String()))
+}
+
+func testSubtaskDepth(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	parent := create(t, todoRepository, userId, baseTime)
+	subtask := createSubtask(t, todoRepository, userId, parent.Id, baseTime)
+	deepest := createSubtask(t, todoRepository, userId, subtask.Id, baseTime)
+	tooDeep := newTodo(baseTime)
+	tooDeep.ParentId = deepest.Id
+	assert.Equal(t, repository.ErrSubtaskTooDeep, todoRepository.Create(context.Background(), &tooDeep, userId))
+	_, err := todoRepository.GetById(context.Background(), tooDeep.Id, userId)
+	assert.Equal(t, repository.ErrNotFound, err)
+}
+
+func testCompleteParents(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	parent := create(t, todoRepository, userId, baseTime)
+	subtask := createSubtask(t, todoRepository, userId, parent.Id, baseTime)
+	first := createSubtask(t, todoRepository, userId, subtask.Id, baseTime)
+	second := createSubtask(t, todoRepository, userId, subtask.Id, baseTime)
+	done := true
+	completedAt := baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Patch(context.Background(), first.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: baseTime.Add(time.Minute)}, model.FirstVersion))
+	assertDone(t, todoRepository, userId, subtask.Id, false, model.FirstVersion)
+
+	second.Done, second.UpdatedAt = &done, completedAt
+	assert.NoError(t, todoRepository.Update(context.Background(), &second, userId, model.FirstVersion))
+	assertDone(t, todoRepository, userId, subtask.Id, true, model.FirstVersion+1)
+	stored := assertDone(t, todoRepository, userId, parent.Id, true, model.FirstVersion+1)
+	if stored != nil {
+		assertSameTime(t, &completedAt, stored.CompletedAt, "CompletedAt")
+		assert.True(t, completedAt.Equal(stored.UpdatedAt))
+		assert.Equal(t, &model.Progress{Done: 1, Total: 1}, stored.Progress)
+	}
+}
+
+func testParentsStayUndone(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	parent := create(t, todoRepository, userId, baseTime)
+	subtask := createSubtask(t, todoRepository, userId, parent.Id, baseTime)
+	done := true
+	assert.NoError(t, todoRepository.Patch(context.Background(), subtask.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: baseTime}, model.FirstVersion))
+	stored := assertDone(t, todoRepository, userId, parent.Id, false, model.FirstVersion)
+	if stored != nil {
+		assert.Equal(t, &model.Progress{Done: 1, Total: 1}, stored.Progress)
+	}
+}
+
+func testSubtaskTrash(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	parent := create(t, todoRepository, userId, baseTime)
+	subtask := createSubtask(t, todoRepository, userId, parent.Id, baseTime)
+	below := createSubtask(t, todoRepository, userId, subtask.Id, baseTime)
+	deletedBefore := createSubtask(t, todoRepository, userId, parent.Id, baseTime)
+	assert.NoError(t, todoRepository.Delete(context.Background(), deletedBefore.Id, userId, repository.AnyVersion,
+		baseTime.Add(time.Minute)))
+	stored, err := todoRepository.GetById(context.Background(), parent.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &model.Progress{Done: 0, Total: 1}, stored.Progress)
+	}
+
+	assert.NoError(t, todoRepository.Delete(context.Background(), parent.Id, userId, model.FirstVersion,
+		baseTime.Add(time.Hour)))
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Empty(t, todos)
+	trash, err := todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{parent.Id, subtask.Id, below.Id, deletedBefore.Id}, idsOf(trash))
+	assert.Equal(t, repository.ErrParentInTrash, todoRepository.Restore(context.Background(), subtask.Id, userId))
+
+	assert.NoError(t, todoRepository.Restore(context.Background(), parent.Id, userId))
+	subtasks, err := todoRepository.GetSubtasks(context.Background(), parent.Id, userId, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{subtask.Id, below.Id}, idsOf(subtasks))
+	assertDone(t, todoRepository, userId, below.Id, false, model.FirstVersion+2)
+	trash, err = todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{deletedBefore.Id}, idsOf(trash))
+
+	assert.NoError(t, todoRepository.Delete(context.Background(), parent.Id, userId, repository.AnyVersion,
+		baseTime.Add(time.Hour)))
+	assert.NoError(t, todoRepository.Purge(context.Background(), parent.Id, userId))
+	trash, err = todoRepository.GetTrash(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+// baseTime is in microseconds, the precision of the timestamps of Postgres.
+var baseTime = time.Date(2022, time.September, 21, 14, 7, 5, 768_000_000, time.UTC)
+
+func testDueDates(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	todo.DueAt, todo.Timezone = &model.Due{Time: baseTime.Add(48 * time.Hour).In(time.FixedZone("", 3600))}, "Europe/Berlin"
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, todo, *stored)
+		assert.Equal(t, time.UTC, stored.DueAt.Time.Location())
+	}
+	day := &model.Due{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	update := todo
+	update.DueAt, update.Timezone, update.UpdatedAt = day, "", baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+	update.Version = model.FirstVersion + 1
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, update, *stored)
+	}
+	updatedAt := baseTime.Add(2 * time.Hour)
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Due: &model.DueChange{DueAt: day, Timezone: "Asia/Tokyo"}, UpdatedAt: updatedAt}, update.Version))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Asia/Tokyo", stored.Timezone)
+		assert.True(t, day.Equal(*stored.DueAt))
+	}
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Due: &model.DueChange{}, UpdatedAt: updatedAt}, repository.AnyVersion))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Nil(t, stored.DueAt)
+		assert.Equal(t, "", stored.Timezone)
+	}
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Due: &model.DueChange{DueAt: day, Timezone: "Mars/Olympus"}, UpdatedAt: updatedAt},
+		repository.AnyVersion))
+	invalid := newTodo(baseTime)
+	invalid.Timezone = "Mars/Olympus"
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Create(context.Background(), &invalid, userId))
+}
+
+func testGetPageDueFilter(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	now := time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC)
+	createDue := func(dueAt *model.Due, timezone string, done bool) model.Todo {
+		t.Helper()
+		todo := newTodo(baseTime)
+		todo.DueAt, todo.Timezone, todo.Done = dueAt, timezone, &done
+		if done {
+			todo.CompletedAt = &todo.CreatedAt
+		}
+		if err := todoRepository.Create(context.Background(), &todo, userId); err != nil {
+			t.Fatal(err)
+		}
+		return todo
+	}
+	past := createDue(&model.Due{Time: now.Add(-time.Hour)}, "", false)
+	pastAndDone := createDue(&model.Due{Time: now.Add(-time.Hour)}, "", true)
+	future := createDue(&model.Due{Time: now.Add(time.Hour)}, "", false)
+	today := &model.Due{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), DateOnly: true}
+	todayInUTC := createDue(today, "", false)
+	// The 9th of March is over in Tokyo at 15:00 UTC.
+	todayInTokyo := createDue(today, "Asia/Tokyo", false)
+	notDue := create(t, todoRepository, userId, baseTime)
+
+	overdue, notOverdue := true, false
+	page, err := todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{Overdue: &overdue, Now: now, Sort: model.SortByDueAt, Order: model.OrderAsc}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{past.Id, todayInTokyo.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{Overdue: &notOverdue, Now: now}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{pastAndDone.Id, future.Id, todayInUTC.Id, notDue.Id}, idsOf(page.Todos))
+	dueAfter, dueBefore := now.Add(-time.Hour), now.Add(2*time.Hour)
+	page, err = todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{DueAfter: &dueAfter}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{future.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId,
+		model.TodoFilter{DueBefore: &dueBefore}, model.PageRequest{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{past.Id, pastAndDone.Id, future.Id, todayInUTC.Id, todayInTokyo.Id}, idsOf(page.Todos))
+	page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Overdue: &overdue}, model.PageRequest{})
+	assert.Nil(t, page)
+	assert.Equal(t, repository.ErrInvalidFilter, err)
+}
+
+func testGetPageDueSort(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todos := make([]model.Todo, 5)
+	for i := range todos {
+		todos[i] = newTodo(baseTime.Add(time.Duration(i) * time.Minute))
+		if i < 3 {
+			todos[i].DueAt = &model.Due{Time: baseTime.Add(time.Duration(3-i) * time.Hour)}
+		}
+		if err := todoRepository.Create(context.Background(), &todos[i], userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	undated := []string{todos[3].Id, todos[4].Id}
+	if undated[0] > undated[1] {
+		undated[0], undated[1] = undated[1], undated[0]
+	}
+	ascending := append([]string{todos[2].Id, todos[1].Id, todos[0].Id}, undated...)
+	for _, order := range []string{model.OrderAsc, model.OrderDesc} {
+		expected := append([]string{}, ascending...)
+		if order == model.OrderDesc {
+			for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+				expected[i], expected[j] = expected[j], expected[i]
+			}
+		}
+		filter := model.TodoFilter{Sort: model.SortByDueAt, Order: order}
+		var ids []string
+		var cursors []*model.Cursor
+		pageRequest := model.PageRequest{Limit: 2}
+		for {
+			page, err := todoRepository.GetPage(context.Background(), userId, filter, pageRequest)
+			if !assert.NoError(t, err) || len(ids) > len(expected) {
+				return
+			}
+			ids = append(ids, idsOf(page.Todos)...)
+			cursors = append(cursors, page.Prev)
+			if page.Next == nil {
+				break
+			}
+			pageRequest.Cursor = page.Next
+		}
+		assert.Equal(t, expected, ids, order)
+		// Walking back from the last page gives the pages before it.
+		page, err := todoRepository.GetPage(context.Background(), userId, filter,
+			model.PageRequest{Limit: 2, Cursor: cursors[len(cursors)-1]})
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected[2:4], idsOf(page.Todos), order)
+		}
+	}
+}
+
+func testPriorities(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	todo.Priority = model.PriorityHigh
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, todo, *stored)
+	}
+	update := todo
+	update.Priority, update.UpdatedAt = model.PriorityLow, baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+	update.Version = model.FirstVersion + 1
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, update, *stored)
+	}
+	priority := model.PriorityNone
+	assert.NoError(t, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Priority: &priority, UpdatedAt: baseTime.Add(2 * time.Hour)}, update.Version))
+	stored, err = todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.PriorityNone, stored.Priority)
+		assert.Equal(t, todo.Position, stored.Position, "a patch keeps the position")
+	}
+	invalidPriority := model.Priority(4)
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), todo.Id, userId,
+		model.TodoChanges{Priority: &invalidPriority, UpdatedAt: baseTime.Add(3 * time.Hour)}, repository.AnyVersion))
+	invalid := newTodo(baseTime)
+	invalid.Priority = invalidPriority
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Create(context.Background(), &invalid, userId))
+}
+
+func testGetPagePrioritySort(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todos := make([]model.Todo, 5)
+	for i := range todos {
+		todos[i] = newTodo(baseTime.Add(time.Duration(i) * time.Minute))
+		todos[i].Priority = model.Priority(i % 4)
+		if err := todoRepository.Create(context.Background(), &todos[i], userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	none := []string{todos[0].Id, todos[4].Id}
+	if none[0] < none[1] {
+		none[0], none[1] = none[1], none[0]
+	}
+	expected := append([]string{todos[3].Id, todos[2].Id, todos[1].Id}, none...)
+	var ids []string
+	pageRequest := model.PageRequest{Limit: 2}
+	for {
+		page, err := todoRepository.GetPage(context.Background(), userId,
+			model.TodoFilter{Sort: model.SortByPriority}, pageRequest)
+		if !assert.NoError(t, err) || len(ids) > len(expected) {
+			return
+		}
+		ids = append(ids, idsOf(page.Todos)...)
+		if page.Next == nil {
+			break
+		}
+		pageRequest.Cursor = page.Next
+	}
+	assert.Equal(t, expected, ids, "the highest priority comes first")
+}
+
+func testMove(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todos := make([]model.Todo, 4)
+	for i := range todos {
+		todos[i] = create(t, todoRepository, userId, baseTime.Add(time.Duration(i)*time.Minute))
+		if i > 0 {
+			assert.Less(t, todos[i-1].Position, todos[i].Position, "a new todo goes last")
+		}
+	}
+	positionIds := func() []string {
+		t.Helper()
+		page, err := todoRepository.GetPage(context.Background(), userId,
+			model.TodoFilter{Sort: model.SortByPosition}, model.PageRequest{})
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		return idsOf(page.Todos)
+	}
+	movedAt := baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Move(context.Background(), todos[3].Id, userId,
+		model.MoveRequest{Before: todos[0].Id}, movedAt))
+	assert.Equal(t, []string{todos[3].Id, todos[0].Id, todos[1].Id, todos[2].Id}, positionIds())
+	assert.NoError(t, todoRepository.Move(context.Background(), todos[0].Id, userId,
+		model.MoveRequest{After: todos[1].Id}, movedAt))
+	assert.Equal(t, []string{todos[3].Id, todos[1].Id, todos[0].Id, todos[2].Id}, positionIds())
+	assert.NoError(t, todoRepository.Move(context.Background(), todos[3].Id, userId,
+		model.MoveRequest{After: todos[2].Id}, movedAt))
+	assert.Equal(t, []string{todos[1].Id, todos[0].Id, todos[2].Id, todos[3].Id}, positionIds())
+	stored, err := todoRepository.GetById(context.Background(), todos[3].Id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, model.FirstVersion+2, stored.Version)
+		assert.True(t, movedAt.Equal(stored.UpdatedAt))
+	}
+	last := create(t, todoRepository, userId, baseTime.Add(time.Hour))
+	assert.Equal(t, []string{todos[1].Id, todos[0].Id, todos[2].Id, todos[3].Id, last.Id}, positionIds())
+
+	assert.Equal(t, repository.ErrAnchorNotFound, todoRepository.Move(context.Background(), todos[0].Id, userId,
+		model.MoveRequest{Before: uuid.New().String()}, movedAt))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Move(context.Background(), uuid.New().String(), userId,
+		model.MoveRequest{Before: todos[0].Id}, movedAt))
+	assert.Equal(t, repository.ErrNotFound, todoRepository.Move(context.Background(), todos[0].Id, uuid.New().String(),
+		model.MoveRequest{Before: todos[1].Id}, movedAt))
+	assert.Equal(t, model.ErrInvalidMove, todoRepository.Move(context.Background(), todos[0].Id, userId,
+		model.MoveRequest{Before: todos[0].Id}, movedAt))
+	other := create(t, todoRepository, uuid.New().String(), baseTime)
+	assert.Equal(t, repository.ErrAnchorNotFound, todoRepository.Move(context.Background(), todos[0].Id, userId,
+		model.MoveRequest{Before: other.Id}, movedAt), "a todo of another user is no anchor")
+}
+
+func testGetPagePositionSort(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todos := make([]model.Todo, 5)
+	for i := range todos {
+		todos[i] = create(t, todoRepository, userId, baseTime.Add(time.Duration(i)*time.Minute))
+	}
+	assert.NoError(t, todoRepository.Move(context.Background(), todos[4].Id, userId,
+		model.MoveRequest{After: todos[1].Id}, baseTime.Add(time.Hour)))
+	expected := []string{todos[0].Id, todos[1].Id, todos[4].Id, todos[2].Id, todos[3].Id}
+	var ids []string
+	var cursors []*model.Cursor
+	pageRequest := model.PageRequest{Limit: 2}
+	for {
+		page, err := todoRepository.GetPage(context.Background(), userId,
+			model.TodoFilter{Sort: model.SortByPosition}, pageRequest)
+		if !assert.NoError(t, err) || len(ids) > len(expected) {
+			return
+		}
+		ids = append(ids, idsOf(page.Todos)...)
+		cursors = append(cursors, page.Prev)
+		if page.Next == nil {
+			break
+		}
+		pageRequest.Cursor = page.Next
+	}
+	assert.Equal(t, expected, ids, "the position sort is ascending by default")
+	page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: model.SortByPosition},
+		model.PageRequest{Limit: 2, Cursor: cursors[len(cursors)-1]})
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected[2:4], idsOf(page.Todos))
+	}
+}
+
+func testRecurrence(t *testing.T, todoRepository common.TodoRepository) {
+	userId := uuid.New().String()
+	todo := newTodo(baseTime)
+	todo.Tags, todo.Priority, todo.Timezone = []string{"home"}, model.PriorityHigh, "Europe/Berlin"
+	todo.DueAt = &model.Due{Time: time.Date(2022, 9, 21, 7, 0, 0, 0, time.UTC)}
+	todo.Recurrence = "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3"
+	assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+	stored, err := todoRepository.GetById(context.Background(), todo.Id, userId)
+	if assert.NoError(t, err) {
+		assertSameTodo(t, todo, *stored)
+	}
+	// nextOf returns the todo of the user that isn't done, which is the next
+	// occurrence once the one before it is done.
+	nextOf := func(previous model.Todo) *model.Todo {
+		t.Helper()
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		var next *model.Todo
+		for i := range todos {
+			if !*todos[i].Done {
+				assert.Nil(t, next, "only one todo is created")
+				next = &todos[i]
+			}
+		}
+		if assert.NotNil(t, next) {
+			assert.NotEqual(t, previous.Id, next.Id)
+			assert.Equal(t, previous.Title, next.Title)
+			assert.Equal(t, []string{"home"}, next.Tags)
+			assert.Equal(t, previous.ListId, next.ListId)
+			assert.Equal(t, model.PriorityHigh, next.Priority)
+			assert.Equal(t, model.FirstVersion, next.Version)
+			assert.Less(t, previous.Position, next.Position, "the next occurrence goes last")
+		}
+		return next
+	}
+
+	done := true
+	update := todo
+	update.Done, update.UpdatedAt = &done, baseTime.Add(time.Hour)
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+	assertDone(t, todoRepository, userId, todo.Id, true, model.FirstVersion+1)
+	next := nextOf(todo)
+	if next == nil {
+		return
+	}
+	assert.True(t, time.Date(2022, 9, 26, 7, 0, 0, 0, time.UTC).Equal(next.DueAt.Time))
+	assert.Equal(t, "Europe/Berlin", next.Timezone)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=2", next.Recurrence)
+	assert.True(t, update.UpdatedAt.Equal(next.CreatedAt))
+	update.UpdatedAt = baseTime.Add(90 * time.Minute)
+	assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, repository.AnyVersion))
+	if again := nextOf(todo); again != nil {
+		assert.Equal(t, next.Id, again.Id, "a todo that was done already doesn't recur again")
+	}
+
+	updatedAt := baseTime.Add(2 * time.Hour)
+	assert.NoError(t, todoRepository.Patch(context.Background(), next.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: updatedAt}, next.Version))
+	last := nextOf(*next)
+	if last == nil {
+		return
+	}
+	assert.True(t, time.Date(2022, 9, 28, 7, 0, 0, 0, time.UTC).Equal(last.DueAt.Time))
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=1", last.Recurrence)
+	assert.NoError(t, todoRepository.Patch(context.Background(), last.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: updatedAt}, last.Version))
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	if assert.NoError(t, err) {
+		assert.Len(t, todos, 3, "the last occurrence doesn't recur")
+	}
+
+	single := newTodo(baseTime)
+	assert.NoError(t, todoRepository.Create(context.Background(), &single, userId))
+	assert.NoError(t, todoRepository.Patch(context.Background(), single.Id, userId,
+		model.TodoChanges{Done: &done, UpdatedAt: updatedAt}, model.FirstVersion))
+	todos, err = todoRepository.GetAll(context.Background(), userId)
+	if assert.NoError(t, err) {
+		assert.Len(t, todos, 4, "a todo that doesn't recur has no next occurrence")
+	}
+
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), single.Id, userId,
+		model.TodoChanges{Due: &model.DueChange{Recurrence: "FREQ=DAILY"}, UpdatedAt: updatedAt}, repository.AnyVersion))
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Patch(context.Background(), single.Id, userId,
+		model.TodoChanges{Due: &model.DueChange{DueAt: todo.DueAt, Recurrence: "FREQ=HOURLY"}, UpdatedAt: updatedAt},
+		repository.AnyVersion))
+	invalid := newTodo(baseTime)
+	invalid.Recurrence = "FREQ=DAILY"
+	assert.Equal(t, repository.ErrInvalidTodo, todoRepository.Create(context.Background(), &invalid, userId))
+}
+
+func newTodo(createdAt time.Time) model.Todo {
+	done := false
+	return model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &done,
+		CreatedAt: createdAt, UpdatedAt: createdAt}
+}
+
+func create(t *testing.T, todoRepository common.TodoRepository, userId string, createdAt time.Time) model.Todo {
+	t.Helper()
+	todo := newTodo(createdAt)
+	if err := todoRepository.Create(context.Background(), &todo, userId); err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+func createSubtask(t *testing.T, todoRepository common.TodoRepository, userId string, parentId string,
+	createdAt time.Time) model.Todo {
+	t.Helper()
+	todo := newTodo(createdAt)
+	todo.ParentId = parentId
+	if err := todoRepository.Create(context.Background(), &todo, userId); err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+// assertDone returns the todo when it could be read.
+func assertDone(t *testing.T, todoRepository common.TodoRepository, userId string, id string, done bool,
+	version int64) *model.Todo {
+	t.Helper()
+	stored, err := todoRepository.GetById(context.Background(), id, userId)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	assert.Equal(t, done, *stored.Done)
+	assert.Equal(t, done, stored.CompletedAt != nil)
+	assert.Equal(t, version, stored.Version)
+	return stored
+}
+
+func createTagged(t *testing.T, todoRepository common.TodoRepository, userId string, tags ...string) model.Todo {
+	t.Helper()
+	todo := newTodo(baseTime)
+	todo.Tags = model.NormalizeTags(tags)
+	if err := todoRepository.Create(context.Background(), &todo, userId); err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+func assertTags(t *testing.T, todoRepository common.TodoRepository, userId string, id string, tags []string,
+	version int64) {
+	t.Helper()
+	stored, err := todoRepository.GetById(context.Background(), id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tags, stored.Tags)
+		assert.Equal(t, version, stored.Version)
+	}
+}
+
+func createList(t *testing.T, todoRepository common.TodoRepository, userId string, name string) model.List {
+	t.Helper()
+	list := model.CreateListRequest{Name: name}.List(uuid.New().String(), baseTime)
+	if err := todoRepository.CreateList(context.Background(), &list, userId); err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func assertList(t *testing.T, todoRepository common.TodoRepository, userId string, id string, listId string,
+	version int64) {
+	t.Helper()
+	stored, err := todoRepository.GetById(context.Background(), id, userId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, listId, stored.ListId)
+		assert.Equal(t, version, stored.Version)
+	}
+}
+
+func listIdsOf(lists []model.List) []string {
+	ids := []string{}
+	for _, list := range lists {
+		ids = append(ids, list.Id)
+	}
+	return ids
+}
+
+func idsOf(todos []model.Todo) []string {
+	ids := []string{}
+	for _, todo := range todos {
+		ids = append(ids, todo.Id)
+	}
+	return ids
+}
+
+// assertSameTodo compares the times of the todos with Equal, as backends
+// don't all return them in the same location.
+func assertSameTodo(t *testing.T, expected model.Todo, actual model.Todo) {
+	t.Helper()
+	assert.Equal(t, expected.Id, actual.Id)
+	assert.Equal(t, expected.Title, actual.Title)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.Done, actual.Done)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "CreatedAt")
+	assert.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "UpdatedAt")
+	assertSameTime(t, expected.CompletedAt, actual.CompletedAt, "CompletedAt")
+	assertSameTime(t, expected.DeletedAt, actual.DeletedAt, "DeletedAt")
+	assert.Equal(t, expected.Version, actual.Version)
+	assert.Equal(t, model.NormalizeTags(expected.Tags), actual.Tags)
+	assert.Equal(t, expected.ListId, actual.ListId)
+	assert.Equal(t, expected.ParentId, actual.ParentId)
+	if expected.DueAt == nil || actual.DueAt == nil {
+		assert.Equal(t, expected.DueAt == nil, actual.DueAt == nil, "DueAt")
+	} else {
+		assert.True(t, expected.DueAt.Equal(*actual.DueAt), "DueAt")
+	}
+	assert.Equal(t, expected.Timezone, actual.Timezone)
+	assert.Equal(t, expected.Priority, actual.Priority)
+	assert.Equal(t, expected.Position, actual.Position)
+	assert.Equal(t, expected.Recurrence, actual.Recurrence)
+}
+
+func assertSameTime(t *testing.T, expected *time.Time, actual *time.Time, name string) {
+	t.Helper()
+	if expected == nil || actual == nil {
+		assert.Equal(t, expected == nil, actual == nil, name)
+	} else {
+		assert.True(t, expected.Equal(*actual), name)
+	}
+}
@@ -0,0 +1,16 @@
+create table if not exists todo (
+    id text primary key,
+    title text not null,
+    description text not null,
+    done boolean not null,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    completed_at timestamp,
+    version integer not null default 1,
+    deleted_at timestamp,
+    user_id text not null
+);
+
+create index if not exists todo_user_id_created_at_id_idx on todo (user_id, created_at, id);
+
+create index if not exists todo_deleted_at_idx on todo (deleted_at) where deleted_at is not null;
@@ -0,0 +1,19 @@
+create table if not exists tag (
+    id integer primary key,
+    user_id text not null,
+    name text not null,
+    unique (user_id, name)
+);
+
+create table if not exists todo_tag (
+    todo_id text not null references todo (id) on delete cascade,
+    tag_id integer not null references tag (id) on delete cascade,
+    primary key (todo_id, tag_id)
+) without rowid;
+
+create index if not exists todo_tag_tag_id_idx on todo_tag (tag_id);
+
+create trigger if not exists todo_tag_delete_unused_tag after delete on todo_tag
+begin
+    delete from tag where id = old.tag_id and not exists (select 1 from todo_tag where tag_id = old.tag_id);
+end;
@@ -0,0 +1,30 @@
+create table if not exists list (
+    id text primary key,
+    user_id text not null,
+    name text not null,
+    inbox boolean not null default false,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    archived_at timestamp
+);
+
+create unique index if not exists list_user_id_inbox_idx on list (user_id) where inbox;
+
+create index if not exists list_user_id_created_at_id_idx on list (user_id, created_at, id);
+
+-- The inboxes of the users that already have todos, with random version 4
+-- UUIDs as ids.
+insert into list (id, user_id, name, inbox, created_at, updated_at)
+select lower(substr(hex, 1, 8) || '-' || substr(hex, 9, 4) || '-4' || substr(hex, 14, 3) || '-8' ||
+    substr(hex, 18, 3) || '-' || substr(hex, 21, 12)), user_id, 'Inbox', true, now, now
+from (select hex(randomblob(16)) as hex, user_id, strftime('%Y-%m-%d %H:%M:%f', 'now') as now from todo group by user_id)
+where true
+on conflict do nothing;
+
+-- SQLite can't add a column that is both not null and a foreign key, so the
+-- repository keeps list_id set.
+alter table todo add column list_id text references list (id) on delete cascade;
+
+update todo set list_id = (select id from list where list.user_id = todo.user_id and list.inbox) where list_id is null;
+
+create index if not exists todo_list_id_idx on todo (list_id);
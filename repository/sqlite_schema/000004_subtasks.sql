@@ -0,0 +1,3 @@
+alter table todo add column parent_id text references todo (id) on delete cascade;
+
+create index if not exists todo_parent_id_idx on todo (parent_id) where parent_id is not null;
@@ -0,0 +1,13 @@
+alter table todo add column priority integer not null default 0;
+
+-- The todos that exist keep the order in which they were created.
+alter table todo add column position text not null default '';
+
+update todo set position = 'V' || substr('0000000000' || (
+    select count(*) from todo as earlier where earlier.user_id = todo.user_id and
+        (earlier.created_at < todo.created_at or (earlier.created_at = todo.created_at and earlier.id <= todo.id))
+), -10) || replace(id, '-', '');
+
+create unique index if not exists todo_user_id_position_idx on todo (user_id, position);
+
+create index if not exists todo_user_id_priority_id_idx on todo (user_id, priority, id) where deleted_at is null;
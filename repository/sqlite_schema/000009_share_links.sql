@@ -0,0 +1,13 @@
+-- The public links that show a todo or a list to anyone who has their token,
+-- without an account. A todo or a list has at most one.
+create table if not exists share_link (
+    token text primary key,
+    user_id text not null,
+    todo_id text unique references todo (id) on delete cascade,
+    list_id text unique references list (id) on delete cascade,
+    expires_at timestamp,
+    access_count integer not null default 0,
+    last_accessed_at timestamp,
+    created_at timestamp not null,
+    check ((todo_id is null) <> (list_id is null))
+);
@@ -0,0 +1,12 @@
+-- The comments that users write on todos, which go with their todo.
+-- todo_comment.user_id is the author of a comment.
+create table if not exists todo_comment (
+    id text primary key,
+    todo_id text not null references todo (id) on delete cascade,
+    user_id text not null,
+    body text not null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+create index if not exists todo_comment_todo_id_created_at_id_idx on todo_comment (todo_id, created_at, id);
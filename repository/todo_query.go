@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+const todoColumns string = "id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, " +
+	"due_at, due_all_day, timezone, priority, position, recurrence, " + progressColumns + ", " + commentCountColumn
+
+// sortColumn is a column that todos can be sorted by. The todos whose
+// nullable column is null come after the others in ascending order, and
+// their cursor value is nil.
+type sortColumn struct {
+	name      string
+	timestamp bool
+	nullable  bool
+	cursor    func(*model.Cursor) any
+}
+
+var sortColumns = map[string]sortColumn{
+	model.SortByCreatedAt: {"created_at", true, false, func(cursor *model.Cursor) any { return cursor.CreatedAt }},
+	model.SortByTitle:     {"title", false, false, func(cursor *model.Cursor) any { return cursor.Title }},
+	model.SortByDone:      {"done", false, false, func(cursor *model.Cursor) any { return cursor.Done }},
+	model.SortByDueAt: {"due_at", true, true, func(cursor *model.Cursor) any {
+		if cursor.DueAt == nil {
+			return nil
+		}
+		return *cursor.DueAt
+	}},
+	model.SortByPosition: {"position", false, false, func(cursor *model.Cursor) any { return cursor.Position }},
+	model.SortByPriority: {"priority", false, false, func(cursor *model.Cursor) any { return int(cursor.Priority) }},
+}
+
+// todoQuery collects the where conditions of a select on the todo table
+// and the arguments their placeholders refer to.
+type todoQuery struct {
+	dialect    dialect
+	conditions []string
+	args       []any
+}
+
+func (q *todoQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return q.dialect.placeholder(len(q.args))
+}
+
+func (q *todoQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// pageQuery builds the select of one page of the todos of userId that match
+// filter. Every value is passed as an argument, never spliced into the SQL.
+func pageQuery(d dialect, userId string, filter model.TodoFilter, pageRequest model.PageRequest) (string, []any, error) {
+	filter = filter.WithDefaults()
+	column, ok := sortColumns[filter.Sort]
+	if !ok || (filter.Order != model.OrderAsc && filter.Order != model.OrderDesc) ||
+		(filter.TagMatch != model.TagMatchAny && filter.TagMatch != model.TagMatchAll) {
+		return "", nil, ErrInvalidFilter
+	}
+	q := &todoQuery{dialect: d}
+	q.where("user_id = " + q.arg(userId))
+	q.where("deleted_at is null")
+	if filter.Done != nil {
+		q.where("done = " + q.arg(*filter.Done))
+	}
+	if filter.CreatedAfter != nil {
+		q.where("created_at > " + q.arg(*filter.CreatedAfter) + d.timestamp)
+	}
+	if filter.CreatedBefore != nil {
+		q.where("created_at < " + q.arg(*filter.CreatedBefore) + d.timestamp)
+	}
+	if filter.Title != "" {
+		q.where(fmt.Sprintf(d.titleLike, q.arg("%"+escapeLike(filter.Title)+"%")))
+	}
+	if filter.ListId != "" {
+		q.where("list_id = " + q.arg(filter.ListId) + d.uuid)
+	}
+	if filter.DueAfter != nil {
+		q.where("due_at > " + q.arg(*filter.DueAfter) + d.timestamp)
+	}
+	if filter.DueBefore != nil {
+		q.where("due_at < " + q.arg(*filter.DueBefore) + d.timestamp)
+	}
+	if filter.Overdue != nil {
+		if filter.Now.IsZero() {
+			return "", nil, ErrInvalidFilter
+		}
+		overdue := "not done and overdue_at is not null and overdue_at <= " + q.arg(filter.Now) + d.timestamp
+		if *filter.Overdue {
+			q.where(overdue)
+		} else {
+			q.where("not (" + overdue + ")")
+		}
+	}
+	if tags := model.NormalizeTags(filter.Tags); len(tags) > 0 {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = q.arg(tag)
+		}
+		tagged := "from todo_tag join tag on tag.id = todo_tag.tag_id where todo_tag.todo_id = todo.id and tag.name in (" +
+			strings.Join(names, ", ") + ")"
+		if filter.TagMatch == model.TagMatchAll {
+			q.where("(select count(*) " + tagged + ") = " + q.arg(len(tags)))
+		} else {
+			q.where("exists (select 1 " + tagged + ")")
+		}
+	}
+	descending := filter.Order == model.OrderDesc
+	if cursor := pageRequest.Cursor; cursor != nil {
+		if cursor.Backward {
+			descending = !descending
+		}
+		comparison := ">"
+		if descending {
+			comparison = "<"
+		}
+		cast := ""
+		if column.timestamp {
+			cast = d.timestamp
+		}
+		value := column.cursor(cursor)
+		if value == nil {
+			// The cursor is among the todos whose column is null, which
+			// come last in ascending order.
+			if descending {
+				q.where(fmt.Sprintf("(%s is not null or id < %s%s)", column.name, q.arg(cursor.Id), d.uuid))
+			} else {
+				q.where(fmt.Sprintf("(%s is null and id > %s%s)", column.name, q.arg(cursor.Id), d.uuid))
+			}
+		} else {
+			after := fmt.Sprintf("(%s, id) %s (%s%s, %s%s)", column.name, comparison, q.arg(value), cast, q.arg(cursor.Id), d.uuid)
+			if column.nullable && !descending {
+				after = fmt.Sprintf("(%s or %s is null)", after, column.name)
+			}
+			q.where(after)
+		}
+	}
+	direction := model.OrderAsc
+	if descending {
+		direction = model.OrderDesc
+	}
+	nulls := ""
+	if column.nullable && descending {
+		nulls = " nulls first"
+	} else if column.nullable {
+		nulls = " nulls last"
+	}
+	query := fmt.Sprintf("select %s, %s from todo where %s order by %s %s%s, id %s", todoColumns, d.tags,
+		strings.Join(q.conditions, " and "), column.name, direction, nulls, direction)
+	if pageRequest.Limit > 0 {
+		query += " limit " + q.arg(pageRequest.Limit+1)
+	}
+	return query, q.args, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// patchQuery builds the update of only the changed columns of a todo at
+// version.
+func patchQuery(d dialect, id string, userId string, changes model.TodoChanges, version int64) (string, []any) {
+	q := &todoQuery{dialect: d}
+	idArg, userIdArg := q.arg(id), q.arg(userId)
+	updatedAtArg := q.arg(changes.UpdatedAt)
+	sets := []string{"updated_at = " + updatedAtArg + d.timestamp}
+	if changes.Title != nil {
+		sets = append(sets, "title = "+q.arg(*changes.Title))
+	}
+	if changes.Description != nil {
+		sets = append(sets, "description = "+q.arg(*changes.Description))
+	}
+	if changes.Done != nil {
+		doneArg := q.arg(*changes.Done)
+		sets = append(sets, "done = "+doneArg, "completed_at = "+completedAtOnChange(d, doneArg, updatedAtArg))
+	}
+	if changes.ListId != nil {
+		sets = append(sets, "list_id = "+q.arg(*changes.ListId)+d.uuid)
+	}
+	if changes.Due != nil {
+		dueAt, allDay, overdueAt := dueValues(changes.Due.DueAt, changes.Due.Timezone)
+		sets = append(sets, "due_at = "+q.arg(dueAt)+d.timestamp, "due_all_day = "+q.arg(allDay),
+			"overdue_at = "+q.arg(overdueAt)+d.timestamp, "timezone = "+q.arg(changes.Due.Timezone),
+			"recurrence = "+q.arg(changes.Due.Recurrence))
+	}
+	if changes.Priority != nil {
+		sets = append(sets, "priority = "+q.arg(int(*changes.Priority)))
+	}
+	sets = append(sets, "version = version + 1")
+	versionArg := q.arg(version)
+	return fmt.Sprintf("update todo set %s where id = %s%s and user_id = %s and deleted_at is null and (%s = 0 or version = %s)",
+		strings.Join(sets, ", "), idArg, d.uuid, userIdArg, versionArg, versionArg), q.args
+}
+
+// completedAtOnChange is the new completed_at of a todo whose done is set to
+// the done placeholder at the updatedAt placeholder, the same as updateQuery
+// does. A todo that was already done keeps the time it was completed.
+func completedAtOnChange(d dialect, done string, updatedAt string) string {
+	return fmt.Sprintf("case when %s then coalesce(completed_at, %s%s) else null end", done, updatedAt, d.timestamp)
+}
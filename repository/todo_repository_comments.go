@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrInvalidComment = errors.New("invalid comment")
+var ErrNotCommentAuthor = errors.New("only the author of the comment may change it")
+
+const (
+	commentColumns string = "id, todo_id, user_id, body, created_at, updated_at"
+	// commentCountColumn counts the comments on a todo. Every select of todos
+	// reads it with the todoColumns.
+	commentCountColumn string = "(select count(*) from todo_comment where todo_comment.todo_id = todo.id)"
+	// commentAuthorQuery returns the author of a comment on a todo of a user
+	// outside the trash, and the time it was created at.
+	commentAuthorQuery string = "select user_id, created_at from todo_comment where id = $1::UUID and todo_id = $2::UUID " +
+		"and todo_id in (select id from todo where user_id = $3 and deleted_at is null)"
+	insertCommentQuery string = "insert into todo_comment (" + commentColumns + ") " +
+		"values ($1::UUID, $2::UUID, $3, $4, $5::timestamptz, $6::timestamptz)"
+	updateCommentQuery string = "update todo_comment set body = $2, updated_at = $3::timestamptz where id = $1::UUID"
+	deleteCommentQuery string = "delete from todo_comment where id = $1::UUID"
+)
+
+func commentFields(comment *model.Comment) []any {
+	return []any{&comment.Id, &comment.TodoId, &comment.AuthorId, &comment.Body, &comment.CreatedAt, &comment.UpdatedAt}
+}
+
+// commentsQuery builds the select of one page of the comments on the todo of
+// todoId, from the oldest to the newest.
+func commentsQuery(d dialect, todoId string, pageRequest model.PageRequest) (string, []any) {
+	q := &todoQuery{dialect: d}
+	q.where("todo_id = " + q.arg(todoId) + d.uuid)
+	direction := model.OrderAsc
+	if cursor := pageRequest.Cursor; cursor != nil {
+		comparison := ">"
+		if cursor.Backward {
+			comparison, direction = "<", model.OrderDesc
+		}
+		q.where(fmt.Sprintf("(created_at, id) %s (%s%s, %s%s)", comparison, q.arg(cursor.CreatedAt), d.timestamp,
+			q.arg(cursor.Id), d.uuid))
+	}
+	query := fmt.Sprintf("select %s from todo_comment where %s order by created_at %s, id %s", commentColumns,
+		strings.Join(q.conditions, " and "), direction, direction)
+	if pageRequest.Limit > 0 {
+		query += " limit " + q.arg(pageRequest.Limit+1)
+	}
+	return query, q.args
+}
+
+// GetComments returns a page of the comments on a todo of a user outside the
+// trash.
+func (tr todoRepositoryImpl) GetComments(ctx context.Context, todoId string, userId string,
+	pageRequest model.PageRequest) (_ *model.CommentPage, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	if err := tr.todoExists(ctx, todoId, userId); err != nil {
+		return nil, err
+	}
+	query, args := commentsQuery(tr.dialect, todoId, pageRequest)
+	rows, err := tr.DBPool.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	comments := []model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		if err := rows.Scan(commentFields(&comment)...); err != nil {
+			return nil, err
+		}
+		commentInUTC(&comment)
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return model.NewCommentPage(comments, pageRequest), nil
+}
+
+// CreateComment adds a comment on a todo of a user outside the trash, whose
+// author may be another user.
+func (tr todoRepositoryImpl) CreateComment(ctx context.Context, comment *model.Comment, userId string) (err error) {
+	if comment == nil || !model.IsValid(comment) {
+		return ErrInvalidComment
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := tx.todoExists(ctx, comment.TodoId, userId); err != nil {
+			return err
+		}
+		_, err := tx.DBPool.ExecContext(ctx, tx.dialect.insertComment, comment.Id, comment.TodoId, comment.AuthorId,
+			comment.Body, comment.CreatedAt, comment.UpdatedAt)
+		return err
+	})
+}
+
+// UpdateComment replaces the body of a comment on a todo of a user once its
+// AuthorId wrote it, and sets the time it was created at.
+func (tr todoRepositoryImpl) UpdateComment(ctx context.Context, comment *model.Comment, userId string) (err error) {
+	if comment == nil || !model.IsValidExcept(comment, "CreatedAt") {
+		return ErrInvalidComment
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		createdAt, err := tx.authoredComment(ctx, comment.TodoId, comment.Id, userId, comment.AuthorId)
+		if err != nil {
+			return err
+		}
+		if err := rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.updateComment, comment.Id, comment.Body,
+			comment.UpdatedAt)); err != nil {
+			return err
+		}
+		comment.CreatedAt = createdAt.UTC()
+		return nil
+	})
+}
+
+// DeleteComment deletes a comment on a todo of a user once authorId wrote it.
+func (tr todoRepositoryImpl) DeleteComment(ctx context.Context, todoId string, id string, userId string,
+	authorId string) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if _, err := tx.authoredComment(ctx, todoId, id, userId, authorId); err != nil {
+			return err
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.deleteComment, id))
+	})
+}
+
+// todoExists tells with ErrNotFound that a user has no todo of id outside the
+// trash.
+func (tr todoRepositoryImpl) todoExists(ctx context.Context, id string, userId string) error {
+	var version int64
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.version, id, userId).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// authoredComment returns the time that the comment of id on a todo of a user
+// was created at, or ErrNotCommentAuthor when authorId didn't write it.
+func (tr todoRepositoryImpl) authoredComment(ctx context.Context, todoId string, id string, userId string,
+	authorId string) (time.Time, error) {
+	var author string
+	var createdAt time.Time
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.commentAuthor, id, todoId, userId).Scan(&author,
+		&createdAt); err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	if author != authorId {
+		return time.Time{}, ErrNotCommentAuthor
+	}
+	return createdAt, nil
+}
+
+func commentInUTC(comment *model.Comment) {
+	comment.CreatedAt = comment.CreatedAt.UTC()
+	comment.UpdatedAt = comment.UpdatedAt.UTC()
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var commentColumnNames = []string{"id", "todo_id", "user_id", "body", "created_at", "updated_at"}
+
+func TestGetComments(t *testing.T) {
+	userId, todoId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	comments := []model.Comment{
+		{Id: uuid.New().String(), TodoId: todoId, AuthorId: userId, Body: "body1", CreatedAt: ti, UpdatedAt: ti},
+		{Id: uuid.New().String(), TodoId: todoId, AuthorId: "oewhgwe", Body: "body2", CreatedAt: ti.Add(time.Minute),
+			UpdatedAt: ti.Add(time.Hour)},
+	}
+	rowsOf := func(comments ...model.Comment) *sqlmock.Rows {
+		rows := sqlmock.NewRows(commentColumnNames)
+		for _, comment := range comments {
+			rows.AddRow(comment.Id, comment.TodoId, comment.AuthorId, comment.Body, comment.CreatedAt.Local(),
+				comment.UpdatedAt.Local())
+		}
+		return rows
+	}
+
+	t.Run("Every comment", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectQuery("select " + commentColumns + " from todo_comment where todo_id = $1::UUID " +
+			"order by created_at asc, id asc").WithArgs(todoId).WillReturnRows(rowsOf(comments...))
+		page, err := todoRepository.GetComments(context.Background(), todoId, userId, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, &model.CommentPage{Comments: comments}, page)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("The first page", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectQuery("select "+commentColumns+" from todo_comment where todo_id = $1::UUID "+
+			"order by created_at asc, id asc limit $2").WithArgs(todoId, 2).WillReturnRows(rowsOf(comments...))
+		page, err := todoRepository.GetComments(context.Background(), todoId, userId, model.PageRequest{Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, comments[:1], page.Comments)
+		assert.Equal(t, model.CommentCursorOf(comments[0], false), page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("The page after a cursor", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		cursor := model.CommentCursorOf(comments[0], false)
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectQuery("select "+commentColumns+" from todo_comment where todo_id = $1::UUID "+
+			"and (created_at, id) > ($2::timestamptz, $3::UUID) order by created_at asc, id asc limit $4").
+			WithArgs(todoId, ti, comments[0].Id, 2).WillReturnRows(rowsOf(comments[1]))
+		page, err := todoRepository.GetComments(context.Background(), todoId, userId, model.PageRequest{Limit: 1, Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, comments[1:], page.Comments)
+		assert.Nil(t, page.Next)
+		assert.Equal(t, model.CommentCursorOf(comments[1], true), page.Prev)
+	})
+
+	t.Run("The page before a cursor", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		cursor := model.CommentCursorOf(comments[1], true)
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectQuery("select "+commentColumns+" from todo_comment where todo_id = $1::UUID "+
+			"and (created_at, id) < ($2::timestamptz, $3::UUID) order by created_at desc, id desc limit $4").
+			WithArgs(todoId, ti.Add(time.Minute), comments[1].Id, 2).WillReturnRows(rowsOf(comments[0]))
+		page, err := todoRepository.GetComments(context.Background(), todoId, userId, model.PageRequest{Limit: 1, Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, comments[:1], page.Comments)
+		assert.Equal(t, model.CommentCursorOf(comments[0], false), page.Next)
+		assert.Nil(t, page.Prev)
+	})
+
+	t.Run("When the todo isn't a todo of the user", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		page, err := todoRepository.GetComments(context.Background(), todoId, userId, model.PageRequest{})
+		assert.Nil(t, page)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestCreateComment(t *testing.T) {
+	userId, todoId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	comment := model.Comment{Id: uuid.New().String(), TodoId: todoId, AuthorId: "oewhgwe", Body: "body", CreatedAt: ti,
+		UpdatedAt: ti}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectExec(insertCommentQuery).WithArgs(comment.Id, todoId, "oewhgwe", "body", ti, ti).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.CreateComment(context.Background(), &comment, userId)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the comment is not valid", func(t *testing.T) {
+		for _, invalid := range []*model.Comment{
+			nil,
+			{Id: comment.Id, TodoId: todoId, AuthorId: "oewhgwe", CreatedAt: ti, UpdatedAt: ti},
+			{Id: comment.Id, TodoId: "wrong", AuthorId: "oewhgwe", Body: "body", CreatedAt: ti, UpdatedAt: ti},
+		} {
+			todoRepository, mock := create(t)
+			err := todoRepository.CreateComment(context.Background(), invalid, userId)
+			assert.Equal(t, ErrInvalidComment, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When the todo isn't a todo of the user", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		err := todoRepository.CreateComment(context.Background(), &comment, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	userId, todoId, id := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	updatedAt := ti.Add(time.Hour)
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		comment := model.Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", Body: "body", UpdatedAt: updatedAt}
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "created_at"}).AddRow("oewhgwe", ti.Local()))
+		mock.ExpectExec(updateCommentQuery).WithArgs(id, "body", updatedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.UpdateComment(context.Background(), &comment, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, ti, comment.CreatedAt)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When another user wrote the comment", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		comment := model.Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", Body: "body", UpdatedAt: updatedAt}
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "created_at"}).AddRow(userId, ti))
+		mock.ExpectRollback()
+		err := todoRepository.UpdateComment(context.Background(), &comment, userId)
+		assert.Equal(t, ErrNotCommentAuthor, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When there is no such comment", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		comment := model.Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", Body: "body", UpdatedAt: updatedAt}
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "created_at"}))
+		mock.ExpectRollback()
+		err := todoRepository.UpdateComment(context.Background(), &comment, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the comment is not valid", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		comment := model.Comment{Id: id, TodoId: todoId, AuthorId: "oewhgwe", UpdatedAt: updatedAt}
+		err := todoRepository.UpdateComment(context.Background(), &comment, userId)
+		assert.Equal(t, ErrInvalidComment, err)
+	})
+}
+
+func TestDeleteComment(t *testing.T) {
+	userId, todoId, id := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "created_at"}).AddRow("oewhgwe", ti))
+		mock.ExpectExec(deleteCommentQuery).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.DeleteComment(context.Background(), todoId, id, userId, "oewhgwe")
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When another user wrote the comment", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "created_at"}).AddRow(userId, ti))
+		mock.ExpectRollback()
+		err := todoRepository.DeleteComment(context.Background(), todoId, id, userId, "oewhgwe")
+		assert.Equal(t, ErrNotCommentAuthor, err)
+	})
+
+	t.Run("When the query fails", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(commentAuthorQuery).WithArgs(id, todoId, userId).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.DeleteComment(context.Background(), todoId, id, userId, "oewhgwe")
+		assert.Equal(t, common.ErrError, err)
+	})
+}
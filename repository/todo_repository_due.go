@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+// dueAtColumn and dueAllDayColumn scan the due_at and due_all_day columns
+// into the DueAt of a todo, which stays nil when it isn't due. The due_at
+// column comes first.
+type dueAtColumn struct{ todo *model.Todo }
+
+func (c dueAtColumn) Scan(value any) error {
+	var dueAt sql.NullTime
+	if err := dueAt.Scan(value); err != nil {
+		return err
+	}
+	c.todo.DueAt = nil
+	if dueAt.Valid {
+		c.todo.DueAt = &model.Due{Time: dueAt.Time}
+	}
+	return nil
+}
+
+type dueAllDayColumn struct{ todo *model.Todo }
+
+func (c dueAllDayColumn) Scan(value any) error {
+	var allDay sql.NullBool
+	if err := allDay.Scan(value); err != nil {
+		return err
+	}
+	if c.todo.DueAt != nil {
+		c.todo.DueAt.DateOnly = allDay.Bool
+	}
+	return nil
+}
+
+// dueValues returns the due_at, due_all_day and overdue_at of a todo that is
+// due at dueAt in timezone.
+func dueValues(dueAt *model.Due, timezone string) (*time.Time, bool, *time.Time) {
+	if dueAt == nil {
+		return nil, false, nil
+	}
+	overdueAt := dueAt.OverdueAt(timezone)
+	return &dueAt.Time, dueAt.DateOnly, &overdueAt
+}
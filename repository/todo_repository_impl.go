@@ -1,58 +1,239 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
 )
 
 var ErrNotFound = errors.New("item is not found")
 var ErrInvalidTodo = errors.New("invalid todo")
 var ErrDBPoolIsNil = errors.New("DBPool is nil")
+var ErrVersionMismatch = errors.New("the todo has been changed since that version")
+var ErrQueryTimeout = errors.New("the database didn't answer within the query timeout")
+var ErrCanceled = errors.New("the request was canceled before the database answered")
+var ErrTagExists = errors.New("there is already a tag with that name")
+var ErrSameTag = errors.New("a tag can't be merged into itself")
+var ErrInvalidList = errors.New("invalid list")
+var ErrListNotFound = errors.New("the list of the todo doesn't exist")
+var ErrListArchived = errors.New("an archived list takes no new todos")
+var ErrInboxList = errors.New("the inbox can't be renamed, archived, deleted or shared")
+
+// AnyVersion as the expected version of a write matches every version of the
+// todo.
+const AnyVersion int64 = 0
 
 const (
-	insertTodoQuery   string = "insert into todo (id, title, description, done, created_at, user_id) values ($1::UUID, $2, $3, $4, $5::timestamptz, $6)"
-	allTodosQuery     string = "select id, title, description, done, created_at from todo where user_id = $1 order by created_at desc"
-	specificTodoQuery string = "select id, title, description, done, created_at from todo where id = $1::UUID and user_id = $2"
-	updateQuery       string = "update todo set title = $2, description = $3, done = $4, created_at = $5 where id = $1::UUID and user_id = $6"
-	deleteQuery       string = "delete from todo where id = $1::UUID and user_id = $2"
+	// tagsColumn is the dialect.tags of Postgres.
+	tagsColumn string = "coalesce((select json_agg(tag.name) from todo_tag join tag on tag.id = todo_tag.tag_id " +
+		"where todo_tag.todo_id = todo.id)::text, '[]')"
+	insertTodoQuery string = "insert into todo (id, title, description, done, created_at, updated_at, completed_at, version, user_id, list_id, parent_id, " +
+		"due_at, due_all_day, overdue_at, timezone, priority, position, recurrence) values ($1::UUID, $2, $3, $4, $5::timestamptz, " +
+		"$6::timestamptz, $7::timestamptz, $8, $9, $10::UUID, $11::UUID, $12::timestamptz, $13, $14::timestamptz, $15, $16, $17, $18)"
+	allTodosQuery     string = "select " + todoColumns + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is null order by created_at desc"
+	specificTodoQuery string = "select " + todoColumns + ", " + tagsColumn + " from todo where id = $1::UUID and user_id = $2 and deleted_at is null"
+	updateQuery       string = "update todo set title = $2, description = $3, done = $4, updated_at = $5::timestamptz, " +
+		"completed_at = case when $4 then coalesce(completed_at, $5::timestamptz) else null end, " +
+		"list_id = coalesce($8::UUID, list_id), due_at = $9::timestamptz, due_all_day = $10, overdue_at = $11::timestamptz, " +
+		"timezone = $12, priority = $13, recurrence = $14, version = version + 1 where id = $1::UUID and user_id = $6 and deleted_at is null and ($7 = 0 or version = $7)"
+	deleteQuery string = "update todo set deleted_at = $4::timestamptz, version = version + 1 " +
+		"where id = $1::UUID and user_id = $2 and deleted_at is null and ($3 = 0 or version = $3)"
+	versionQuery string = "select version from todo where id = $1::UUID and user_id = $2 and deleted_at is null"
+	searchQuery  string = "select " + todoColumns + ", " + tagsColumn + ", " +
+		"ts_rank(search, query) + word_similarity($2, title) as rank, " +
+		"ts_headline('english', title, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'), " +
+		"ts_headline('english', description, query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') " +
+		"from todo, websearch_to_tsquery('english', $2) query " +
+		"where user_id = $1 and deleted_at is null and (search @@ query or $2 <% title) " +
+		"order by rank desc, created_at desc, id desc limit $3"
+	trashQuery string = "select " + todoColumns + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is not null " +
+		"order by deleted_at desc, id desc"
+	restoreQuery    string = "update todo set deleted_at = null, version = version + 1 where id = $1::UUID and user_id = $2 and deleted_at is not null"
+	purgeQuery      string = "delete from todo where id = $1::UUID and user_id = $2 and deleted_at is not null"
+	purgeTrashQuery string = "delete from todo where deleted_at < $1::timestamptz"
+	// The tags that no todo has anymore are deleted by a trigger on todo_tag.
+	deleteTodoTagsQuery string = "delete from todo_tag where todo_id = $1::UUID"
+	insertTagsQuery     string = "insert into tag (user_id, name) select $1, value from json_array_elements_text($2::text::json) " +
+		"on conflict (user_id, name) do nothing"
+	insertTodoTagsQuery string = "insert into todo_tag (todo_id, tag_id) select $1::UUID, id from tag " +
+		"where user_id = $2 and name in (select value from json_array_elements_text($3::text::json))"
+	allTagsQuery string = "select tag.name, count(todo.id) from tag left join todo_tag on todo_tag.tag_id = tag.id " +
+		"left join todo on todo.id = todo_tag.todo_id and todo.deleted_at is null where tag.user_id = $1 " +
+		`group by tag.id, tag.name order by tag.name collate "C"`
+	tagExistsQuery   string = "select count(*) from tag where user_id = $1 and name = $2"
+	touchTaggedQuery string = "update todo set version = version + 1 where id in (select todo_tag.todo_id from todo_tag " +
+		"join tag on tag.id = todo_tag.tag_id where tag.user_id = $1 and tag.name = $2)"
+	renameTagQuery string = "update tag set name = $3 where user_id = $1 and name = $2"
+	mergeTagQuery  string = "insert into todo_tag (todo_id, tag_id) select todo_tag.todo_id, target.id from todo_tag " +
+		"join tag on tag.id = todo_tag.tag_id join tag target on target.user_id = tag.user_id and target.name = $3 " +
+		"where tag.user_id = $1 and tag.name = $2 on conflict do nothing"
+	deleteTagQuery string = "delete from tag where user_id = $1 and name = $2"
+	inboxQuery     string = "select id from list where user_id = $1 and inbox"
+	// insertInboxQuery does nothing when another request created the inbox
+	// first.
+	insertInboxQuery string = "insert into list (id, user_id, name, inbox, created_at, updated_at) " +
+		"values ($1::UUID, $2, $3, true, $4::timestamptz, $4::timestamptz) on conflict (user_id) where inbox do nothing"
+	listArchivedQuery string = "select archived_at is not null from list where id = $1::UUID and user_id = $2"
+	listInboxQuery    string = "select inbox from list where id = $1::UUID and user_id = $2"
+	allListsQuery     string = "select " + listColumns + " from list where user_id = $1 and ($2 or archived_at is null) " +
+		"order by inbox desc, created_at, id"
+	specificListQuery string = "select " + listColumns + " from list where id = $1::UUID and user_id = $2"
+	insertListQuery   string = "insert into list (id, user_id, name, created_at, updated_at) " +
+		"values ($1::UUID, $2, $3, $4::timestamptz, $5::timestamptz)"
+	patchListQuery string = "update list set name = coalesce($3, name), archived_at = case when $4::boolean is null then archived_at " +
+		"when $4 then coalesce(archived_at, $5::timestamptz) else null end, updated_at = $5::timestamptz " +
+		"where id = $1::UUID and user_id = $2 and not inbox"
+	moveListTodosQuery string = "update todo set list_id = $3::UUID, version = version + 1 where list_id = $1::UUID and user_id = $2"
+	deleteListQuery    string = "delete from list where id = $1::UUID and user_id = $2 and not inbox"
 )
 
+// dbConn runs the statements of a todoRepositoryImpl: a pool, or a
+// transaction of a unitOfWorkImpl.
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type todoRepositoryImpl struct {
-	DBPool *sql.DB
+	DBPool       dbConn
+	QueryTimeout time.Duration
+	dialect      dialect
+	rules        SubtaskRules
+	inboxes      inboxSource
+	// transactions runs the writes that take more than one statement in a
+	// transaction. It is nil in a transaction of a unitOfWorkImpl.
+	transactions *unitOfWorkImpl
 }
 
-func GetTodoRepository(dbPool *sql.DB) (common.TodoRepository, error) {
+// GetTodoRepository returns a TodoRepository whose every operation fails
+// with ErrQueryTimeout when it takes longer than queryTimeout. A zero
+// queryTimeout never times out. Its subtasks follow rules, and the inbox of a
+// user gets an id from newId and the time from now when it is created.
+func GetTodoRepository(dbPool *sql.DB, queryTimeout time.Duration, rules SubtaskRules,
+	newId func() (uuid.UUID, error), now func() time.Time) (common.TodoRepository, error) {
 	if dbPool == nil {
 		return nil, ErrDBPoolIsNil
 	}
-	return todoRepositoryImpl{DBPool: dbPool}, nil
+	inboxes := inboxSource{newId: newId, now: now}
+	return todoRepositoryImpl{DBPool: dbPool, QueryTimeout: queryTimeout, dialect: postgresDialect, rules: rules,
+		inboxes:      inboxes,
+		transactions: &unitOfWorkImpl{DBPool: dbPool, dialect: postgresDialect, rules: rules, inboxes: inboxes}}, nil
+}
+
+// operation bounds an operation of the repository by the query timeout. The
+// returned done cancels the context and, when the operation failed as a
+// context was done, replaces *err by ErrCanceled or ErrQueryTimeout.
+func (tr todoRepositoryImpl) operation(ctx context.Context, err *error) (context.Context, func()) {
+	operationCtx, cancel := context.WithCancel(ctx)
+	if tr.QueryTimeout > 0 {
+		operationCtx, cancel = context.WithTimeout(ctx, tr.QueryTimeout)
+	}
+	return operationCtx, func() {
+		*err = contextErr(ctx, operationCtx, *err)
+		cancel()
+	}
 }
 
-func (tr todoRepositoryImpl) Create(todo *model.Todo, userId string) (err error) {
+// contextErr tells ErrCanceled, when ctx of the caller is done, from
+// ErrQueryTimeout, when only operationCtx is.
+func contextErr(ctx context.Context, operationCtx context.Context, err error) error {
+	if err == nil {
+		return nil
+	} else if ctx.Err() != nil {
+		return ErrCanceled
+	} else if operationCtx.Err() != nil {
+		return ErrQueryTimeout
+	}
+	return err
+}
+
+// Create stores todo at the first version in its list, or else in the list
+// of its parent or the inbox, after the last todo of its user, and sets the
+// version, the list and the position on todo.
+func (tr todoRepositoryImpl) Create(ctx context.Context, todo *model.Todo, userId string) (err error) {
 	if !model.IsValid(todo) {
 		return ErrInvalidTodo
 	}
-	_, err = tr.DBPool.Exec(insertTodoQuery, todo.Id, todo.Title,
-		todo.Description, todo.Done, todo.CreatedAt, userId)
-	return err
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	todo.Version = model.FirstVersion
+	var tags *[]string
+	if len(todo.Tags) > 0 {
+		tags = &todo.Tags
+	}
+	dueAt, allDay, overdueAt := dueValues(todo.DueAt, todo.Timezone)
+	create := func(tx todoRepositoryImpl) error {
+		return tx.writeTodo(ctx, todo.Id, userId, nil, &todo.ListId, tags, func(tx todoRepositoryImpl, listId string) error {
+			last, err := tx.lastPosition(ctx, userId)
+			if err != nil {
+				return err
+			}
+			todo.ListId, todo.Position = listId, model.PositionBetween(last, "", todo.Id)
+			_, err = tx.DBPool.ExecContext(ctx, tx.dialect.insertTodo, todo.Id, todo.Title, todo.Description, todo.Done,
+				todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, todo.Version, userId, listId, nullIfEmpty(todo.ParentId),
+				dueAt, allDay, overdueAt, todo.Timezone, int(todo.Priority), todo.Position, todo.Recurrence)
+			return err
+		})
+	}
+	if todo.ParentId == "" {
+		return create(tr)
+	}
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		parentListId, err := tx.parentListOf(ctx, todo.ParentId, userId)
+		if err != nil {
+			return err
+		}
+		if todo.ListId == "" {
+			todo.ListId = parentListId
+		}
+		return create(tx)
+	})
+}
+
+func (tr todoRepositoryImpl) GetAll(ctx context.Context, userId string) (_ []model.Todo, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.allTodos, userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
 }
 
-func (tr todoRepositoryImpl) GetAll(userId string) ([]model.Todo, error) {
-	rows, err := tr.DBPool.Query(allTodosQuery, userId)
+func (tr todoRepositoryImpl) GetPage(ctx context.Context, userId string, filter model.TodoFilter,
+	pageRequest model.PageRequest) (_ *model.Page, err error) {
+	query, args, err := pageQuery(tr.dialect, userId, filter, pageRequest)
+	if err != nil {
+		return nil, err
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	rows, err := tr.DBPool.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewPage(todos, pageRequest), nil
+}
+
+func scanTodos(rows *sql.Rows) ([]model.Todo, error) {
 	defer rows.Close()
 	todos := []model.Todo{}
 	for rows.Next() {
 		var todo model.Todo
-		if err := rows.Scan(&todo.Id, &todo.Title, &todo.Description, &todo.Done, &todo.CreatedAt); err != nil {
+		if err := rows.Scan(todoFields(&todo)...); err != nil {
 			return nil, err
 		}
-		todo.CreatedAt = todo.CreatedAt.UTC()
+		inUTC(&todo)
 		todos = append(todos, todo)
 	}
 	if err := rows.Err(); err != nil {
@@ -61,32 +242,253 @@ func (tr todoRepositoryImpl) GetAll(userId string) ([]model.Todo, error) {
 	return todos, nil
 }
 
-func (tr todoRepositoryImpl) GetById(id string, userId string) (*model.Todo, error) {
-	row := tr.DBPool.QueryRow(specificTodoQuery, id, userId)
+// todoFields returns the destinations of the todoColumns and the tags of a
+// row.
+func todoFields(todo *model.Todo, fields ...any) []any {
+	return append([]any{&todo.Id, &todo.Title, &todo.Description, &todo.Done, &todo.CreatedAt, &todo.UpdatedAt,
+		&todo.CompletedAt, &todo.Version, &todo.DeletedAt, &todo.ListId, (*nullableId)(&todo.ParentId), dueAtColumn{todo},
+		dueAllDayColumn{todo}, &todo.Timezone, &todo.Priority, &todo.Position, &todo.Recurrence, progressTotal{todo},
+		progressDone{todo}, &todo.CommentCount, (*tagList)(&todo.Tags)}, fields...)
+}
+
+func inUTC(todo *model.Todo) {
+	todo.CreatedAt = todo.CreatedAt.UTC()
+	todo.UpdatedAt = todo.UpdatedAt.UTC()
+	if todo.CompletedAt != nil {
+		completedAt := todo.CompletedAt.UTC()
+		todo.CompletedAt = &completedAt
+	}
+	if todo.DeletedAt != nil {
+		deletedAt := todo.DeletedAt.UTC()
+		todo.DeletedAt = &deletedAt
+	}
+	if todo.DueAt != nil {
+		todo.DueAt.Time = todo.DueAt.Time.UTC()
+	}
+}
+
+func (tr todoRepositoryImpl) GetById(ctx context.Context, id string, userId string) (_ *model.Todo, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	row := tr.DBPool.QueryRowContext(ctx, tr.dialect.specificTodo, id, userId)
 	var todo model.Todo
-	if err := row.Scan(&todo.Id, &todo.Title, &todo.Description, &todo.Done, &todo.CreatedAt); err != nil {
+	if err := row.Scan(todoFields(&todo)...); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
 		} else {
 			return nil, err
 		}
 	} else {
-		todo.CreatedAt = todo.CreatedAt.UTC()
+		inUTC(&todo)
 		return &todo, nil
 	}
 
 }
 
-func (tr todoRepositoryImpl) Update(todo *model.Todo, userId string) error {
-	if !model.IsValid(todo) {
+// Update replaces the title, description, done, due date, timezone, priority
+// and recurrence of a todo at version, its tags unless todo.Tags is nil and
+// its list unless todo.ListId is empty. The created_at and the position of the
+// todo are kept and its CreatedAt, Version, ParentId and Position are ignored.
+// Completing a todo that recurs creates its next occurrence.
+func (tr todoRepositoryImpl) Update(ctx context.Context, todo *model.Todo, userId string, version int64) (err error) {
+	if todo == nil || !model.IsValidExcept(*todo, "CreatedAt") {
 		return ErrInvalidTodo
 	}
-	_, err := tr.DBPool.Exec(updateQuery, todo.Id, todo.Title,
-		todo.Description, todo.Done, todo.CreatedAt, userId)
-	return err
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	var listId *string
+	if todo.ListId != "" {
+		listId = &todo.ListId
+	}
+	var tags *[]string
+	if todo.Tags != nil {
+		tags = &todo.Tags
+	}
+	dueAt, allDay, overdueAt := dueValues(todo.DueAt, todo.Timezone)
+	recurs := *todo.Done && todo.Recurrence != ""
+	return tr.recurring(ctx, todo.Id, userId, recurs, todo.UpdatedAt, func(tr todoRepositoryImpl) error {
+		return tr.completingParents(ctx, todo.Id, userId, *todo.Done, todo.UpdatedAt, func(tr todoRepositoryImpl) error {
+			return tr.writeTodo(ctx, todo.Id, userId, &version, listId, tags, func(tx todoRepositoryImpl, listId string) error {
+				return tx.versionedWrite(ctx, todo.Id, userId)(tx.DBPool.ExecContext(ctx, tx.dialect.update, todo.Id, todo.Title,
+					todo.Description, todo.Done, todo.UpdatedAt, userId, version, nullIfEmpty(listId), dueAt, allDay, overdueAt,
+					todo.Timezone, int(todo.Priority), todo.Recurrence))
+			})
+		})
+	})
 }
 
-func (tr todoRepositoryImpl) Delete(id string, userId string) error {
-	_, err := tr.DBPool.Exec(deleteQuery, id, userId)
-	return err
+func (tr todoRepositoryImpl) Patch(ctx context.Context, id string, userId string, changes model.TodoChanges,
+	version int64) (err error) {
+	if (changes.Title != nil && *changes.Title == "") || (changes.Description != nil && *changes.Description == "") ||
+		(changes.Tags != nil && !model.IsValidTags(*changes.Tags)) || (changes.ListId != nil && !isListId(*changes.ListId)) ||
+		(changes.Due != nil && !changes.Due.IsValid()) ||
+		(changes.Priority != nil && !model.IsValidPriority(*changes.Priority)) {
+		return ErrInvalidTodo
+	}
+	if changes.IsEmpty() {
+		return nil
+	}
+	if changes.UpdatedAt.IsZero() {
+		return ErrInvalidTodo
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	completes := changes.Done != nil && *changes.Done
+	recurs := completes && (changes.Due == nil || changes.Due.Recurrence != "")
+	return tr.recurring(ctx, id, userId, recurs, changes.UpdatedAt, func(tr todoRepositoryImpl) error {
+		return tr.completingParents(ctx, id, userId, completes, changes.UpdatedAt, func(tr todoRepositoryImpl) error {
+			return tr.writeTodo(ctx, id, userId, &version, changes.ListId, changes.Tags, func(tx todoRepositoryImpl, listId string) error {
+				if changes.ListId != nil {
+					changes.ListId = &listId
+				}
+				query, args := patchQuery(tx.dialect, id, userId, changes, version)
+				return tx.versionedWrite(ctx, id, userId)(tx.DBPool.ExecContext(ctx, query, args...))
+			})
+		})
+	})
+}
+
+// Delete moves a todo at version to the trash at deletedAt, with its subtasks
+// outside the trash. Every other method but GetTrash, Restore and Purge acts
+// as if the todo doesn't exist anymore.
+func (tr todoRepositoryImpl) Delete(ctx context.Context, id string, userId string, version int64, deletedAt time.Time) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := tx.versionedWrite(ctx, id, userId)(tx.DBPool.ExecContext(ctx, tx.dialect.delete, id, userId, version,
+			deletedAt)); err != nil {
+			return err
+		}
+		_, err := tx.DBPool.ExecContext(ctx, tx.dialect.deleteSubtasks, id, userId, deletedAt)
+		return err
+	})
+}
+
+// GetTrash returns the todos of a user in the trash, the last deleted first.
+func (tr todoRepositoryImpl) GetTrash(ctx context.Context, userId string) (_ []model.Todo, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.trash, userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
+
+// Restore takes a todo out of the trash with the subtasks that went to the
+// trash with it. A todo whose parent is in the trash can't be restored.
+func (tr todoRepositoryImpl) Restore(ctx context.Context, id string, userId string) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		var parentInTrash bool
+		if err := tx.DBPool.QueryRowContext(ctx, tx.dialect.parentInTrash, id, userId).Scan(&parentInTrash); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		} else if parentInTrash {
+			return ErrParentInTrash
+		}
+		if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.restoreSubtasks, id, userId); err != nil {
+			return err
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.restore, id, userId))
+	})
+}
+
+// Purge removes a todo in the trash for good, and its subtasks with it.
+func (tr todoRepositoryImpl) Purge(ctx context.Context, id string, userId string) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return rowAffected(tr.DBPool.ExecContext(ctx, tr.dialect.purge, id, userId))
+}
+
+// PurgeTrash removes for good the todos of every user that were deleted
+// before deletedBefore and returns how many they were.
+func (tr todoRepositoryImpl) PurgeTrash(ctx context.Context, deletedBefore time.Time) (_ int64, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	result, err := tr.DBPool.ExecContext(ctx, tr.dialect.purgeTrash, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// versionedWrite returns the result of a statement that only touches the todo
+// at the version it expects. When no row is touched, it looks the todo up to
+// tell ErrNotFound from ErrVersionMismatch.
+func (tr todoRepositoryImpl) versionedWrite(ctx context.Context, id string, userId string) func(sql.Result, error) error {
+	return func(result sql.Result, err error) error {
+		if err = rowAffected(result, err); err != ErrNotFound {
+			return err
+		}
+		if err := tr.atVersion(ctx, id, userId, AnyVersion); err != nil {
+			return err
+		}
+		return ErrVersionMismatch
+	}
+}
+
+// atVersion returns ErrNotFound when the todo isn't stored outside the trash
+// and ErrVersionMismatch when it isn't at version.
+func (tr todoRepositoryImpl) atVersion(ctx context.Context, id string, userId string, version int64) error {
+	var stored int64
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.version, id, userId).Scan(&stored); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	}
+	if version != AnyVersion && stored != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// rowAffected returns ErrNotFound when a statement that targets a single todo
+// of a user didn't touch any row, as the todo doesn't exist or belongs to
+// another user.
+func rowAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (tr todoRepositoryImpl) Search(ctx context.Context, userId string, query string, limit int) (_ []model.SearchResult, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.dialect.search(ctx, tr.DBPool, userId, query, limit)
+}
+
+// searchPostgres ranks the todos by the full text search of their search
+// column and by the similarity of their title to query.
+func searchPostgres(ctx context.Context, conn dbConn, userId string, query string, limit int) ([]model.SearchResult, error) {
+	rows, err := conn.QueryContext(ctx, searchQuery, userId, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var result model.SearchResult
+		if err := rows.Scan(todoFields(&result.Todo, &result.Rank, &result.HighlightedTitle, &result.Snippet)...); err != nil {
+			return nil, err
+		}
+		inUTC(&result.Todo)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql/driver"
 	"testing"
 	"time"
 
@@ -11,9 +13,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var todoColumnNames = []string{"id", "title", "description", "done", "created_at", "updated_at", "completed_at", "version", "deleted_at", "list_id", "parent_id", "due_at", "due_all_day", "timezone", "priority", "position", "recurrence", "total", "done", "comments", "tags"}
+
 func TestGetTodoRepository(t *testing.T) {
 	t.Run("DBPool is nil", func(t *testing.T) {
-		todoRepository, err := GetTodoRepository(nil)
+		todoRepository, err := GetTodoRepository(nil, 0, SubtaskRules{}, uuid.NewV7, time.Now)
 		assert.Equal(t, ErrDBPoolIsNil, err)
 		assert.Nil(t, todoRepository)
 	})
@@ -22,23 +26,85 @@ func TestGetTodoRepository(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		todoRepository, err := GetTodoRepository(dbPool)
+		todoRepository, err := GetTodoRepository(dbPool, 0, SubtaskRules{}, uuid.NewV7, time.Now)
 		assert.NotNil(t, todoRepository)
 		assert.Nil(t, err)
 	})
 }
 
 func TestCreate(t *testing.T) {
-	t.Run("Good case", func(t *testing.T) {
+	t.Run("Good case: the todo goes to the inbox", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		userId := uuid.New().String()
+		inboxId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
+			Description: "description1", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, inboxId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, model.FirstVersion, todo.Version, todo.DeletedAt)
+		assert.Equal(t, inboxId, todo.ListId)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case: the inbox is created", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		todoDone := false
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
 		userId := uuid.New().String()
+		inboxId := uuid.New().String()
 		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
-			Description: "description1", Done: &todoDone, CreatedAt: ti}
+			Description: "description1", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(insertInboxQuery).WithArgs(sqlmock.AnyArg(), userId, model.InboxName, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, inboxId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, inboxId, todo.ListId)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case with a list and tags", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		userId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti, Tags: []string{"home", "work"}, ListId: uuid.New().String()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(listArchivedQuery).WithArgs(todo.ListId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(false))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
 		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title,
-			todo.Description, todo.Done, todo.CreatedAt, userId).WillReturnResult(sqlmock.NewErrorResult(nil))
-		err := todoRepository.Create(&todo, userId)
+			todo.Description, todo.Done, todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, todo.ListId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteTodoTagsQuery).WithArgs(todo.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertTagsQuery).WithArgs(userId, `["home","work"]`).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(insertTodoTagsQuery).WithArgs(todo.Id, userId, `["home","work"]`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		err := todoRepository.Create(context.Background(), &todo, userId)
 		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
@@ -46,17 +112,70 @@ func TestCreate(t *testing.T) {
 		}
 	})
 
+	t.Run("When the list is archived or doesn't exist", func(t *testing.T) {
+		for wantedErr, rows := range map[error]*sqlmock.Rows{
+			ErrListArchived: sqlmock.NewRows([]string{"archived"}).AddRow(true),
+			ErrListNotFound: sqlmock.NewRows([]string{"archived"}),
+		} {
+			todoRepository, mock := create(t)
+			todoDone := false
+			ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+			userId := uuid.New().String()
+			todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+				CreatedAt: ti, UpdatedAt: ti, ListId: uuid.New().String()}
+			mock.ExpectBegin()
+			mock.ExpectQuery(listArchivedQuery).WithArgs(todo.ListId, userId).WillReturnRows(rows)
+			mock.ExpectRollback()
+			err := todoRepository.Create(context.Background(), &todo, userId)
+			assert.Equal(t, wantedErr, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When writing the tags returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		userId := uuid.New().String()
+		inboxId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
+			Description: "description1", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti, Tags: []string{"work"}}
+		mock.ExpectBegin()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, inboxId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteTodoTagsQuery).WithArgs(todo.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertTagsQuery).WithArgs(userId, `["work"]`).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
 	t.Run("When DBPool returns an error", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		todoDone := false
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
 		userId := uuid.New().String()
+		inboxId := uuid.New().String()
 		todo := model.Todo{Id: uuid.New().String(), Title: "title1",
-			Description: "description1", Done: &todoDone, CreatedAt: ti}
+			Description: "description1", Done: &todoDone, CreatedAt: ti, UpdatedAt: ti, CompletedAt: &ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
 		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id,
-			todo.Title, todo.Description, todo.Done, todo.CreatedAt, userId).
+			todo.Title, todo.Description, todo.Done, todo.CreatedAt, todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, inboxId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
 			WillReturnError(common.ErrError)
-		err := todoRepository.Create(&todo, userId)
+		mock.ExpectRollback()
+		err := todoRepository.Create(context.Background(), &todo, userId)
 		assert.Equal(t, common.ErrError, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
@@ -70,14 +189,23 @@ func TestCreate(t *testing.T) {
 		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
 		userId := uuid.New().String()
 		invalidTodo := model.Todo{Title: "title1", Done: &todoDone, CreatedAt: ti}
-		err := todoRepository.Create(&invalidTodo, userId)
+		err := todoRepository.Create(context.Background(), &invalidTodo, userId)
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+
+	t.Run("When the timestamps are not set", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		todoDone := false
+		userId := uuid.New().String()
+		invalidTodo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone}
+		err := todoRepository.Create(context.Background(), &invalidTodo, userId)
 		assert.Equal(t, ErrInvalidTodo, err)
 	})
 
 	t.Run("Invalid todo 2", func(t *testing.T) {
 		todoRepository, _ := create(t)
 		userId := uuid.New().String()
-		err := todoRepository.Create(nil, userId)
+		err := todoRepository.Create(context.Background(), nil, userId)
 		assert.Equal(t, ErrInvalidTodo, err)
 	})
 }
@@ -90,16 +218,22 @@ func TestGetAll(t *testing.T) {
 		todoDone2 := true
 		todoDone3 := false
 		wantedTodos := []model.Todo{
-			{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone1, CreatedAt: time.Now().UTC()},
-			{Id: uuid.New().String(), Title: "title2", Description: "description2", Done: &todoDone2, CreatedAt: time.Now().UTC()},
-			{Id: uuid.New().String(), Title: "title3", Description: "description3", Done: &todoDone3, CreatedAt: time.Now().UTC()},
-		}
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"}).
-			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, wantedTodos[0].Done, wantedTodos[0].CreatedAt.Local()).
-			AddRow(wantedTodos[1].Id, wantedTodos[1].Title, wantedTodos[1].Description, wantedTodos[1].Done, wantedTodos[1].CreatedAt.Local()).
-			AddRow(wantedTodos[2].Id, wantedTodos[2].Title, wantedTodos[2].Description, wantedTodos[2].Done, wantedTodos[2].CreatedAt.Local())
+			{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone1, CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(), Tags: []string{}},
+			{Id: uuid.New().String(), Title: "title2", Description: "description2", Done: &todoDone2, CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(), Tags: []string{"home", "work"}},
+			{Id: uuid.New().String(), Title: "title3", Description: "description3", Done: &todoDone3, CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(), Tags: []string{}},
+		}
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, wantedTodos[0].Done, wantedTodos[0].CreatedAt.Local(),
+				wantedTodos[0].UpdatedAt.Local(), wantedTodos[0].CompletedAt, wantedTodos[0].Version, wantedTodos[0].DeletedAt, wantedTodos[0].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]").
+			AddRow(wantedTodos[1].Id, wantedTodos[1].Title, wantedTodos[1].Description, wantedTodos[1].Done, wantedTodos[1].CreatedAt.Local(),
+				wantedTodos[1].UpdatedAt.Local(), wantedTodos[1].CompletedAt, wantedTodos[1].Version, wantedTodos[1].DeletedAt, wantedTodos[1].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), `["work","home"]`).
+			AddRow(wantedTodos[2].Id, wantedTodos[2].Title, wantedTodos[2].Description, wantedTodos[2].Done, wantedTodos[2].CreatedAt.Local(),
+				wantedTodos[2].UpdatedAt.Local(), wantedTodos[2].CompletedAt, wantedTodos[2].Version, wantedTodos[2].DeletedAt, wantedTodos[2].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
 		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillReturnRows(rows)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.Equal(t, wantedTodos, todos)
 		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
@@ -111,9 +245,9 @@ func TestGetAll(t *testing.T) {
 	t.Run("Good case 2", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"})
+		rows := sqlmock.NewRows(todoColumnNames)
 		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillReturnRows(rows)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.Equal(t, []model.Todo{}, todos)
 		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
@@ -126,7 +260,7 @@ func TestGetAll(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillReturnError(common.ErrError)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.Nil(t, todos)
 		assert.Equal(t, common.ErrError, err)
 		err = mock.ExpectationsWereMet()
@@ -142,10 +276,11 @@ func TestGetAll(t *testing.T) {
 		wantedTodos := []model.Todo{
 			{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone1, CreatedAt: time.Now()},
 		}
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"}).
-			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, "", wantedTodos[0].CreatedAt)
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, "", wantedTodos[0].CreatedAt,
+				wantedTodos[0].UpdatedAt, wantedTodos[0].CompletedAt, wantedTodos[0].Version, wantedTodos[0].DeletedAt, wantedTodos[0].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
 		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillReturnRows(rows)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.Nil(t, todos)
 		assert.Error(t, err, common.ErrError)
 		err = mock.ExpectationsWereMet()
@@ -165,13 +300,16 @@ func TestGetAll(t *testing.T) {
 			{Id: uuid.New().String(), Title: "title2", Description: "description2", Done: &todoDone2, CreatedAt: time.Now()},
 			{Id: uuid.New().String(), Title: "title3", Description: "description3", Done: &todoDone3, CreatedAt: time.Now()},
 		}
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"}).
-			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, wantedTodos[0].Done, wantedTodos[0].CreatedAt).
-			AddRow(wantedTodos[1].Id, wantedTodos[1].Title, wantedTodos[1].Description, wantedTodos[1].Done, wantedTodos[1].CreatedAt).
-			AddRow(wantedTodos[2].Id, wantedTodos[2].Title, wantedTodos[2].Description, wantedTodos[2].Done, wantedTodos[2].CreatedAt).
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, wantedTodos[0].Done, wantedTodos[0].CreatedAt,
+				wantedTodos[0].UpdatedAt, wantedTodos[0].CompletedAt, wantedTodos[0].Version, wantedTodos[0].DeletedAt, wantedTodos[0].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]").
+			AddRow(wantedTodos[1].Id, wantedTodos[1].Title, wantedTodos[1].Description, wantedTodos[1].Done, wantedTodos[1].CreatedAt,
+				wantedTodos[1].UpdatedAt, wantedTodos[1].CompletedAt, wantedTodos[1].Version, wantedTodos[1].DeletedAt, wantedTodos[1].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]").
+			AddRow(wantedTodos[2].Id, wantedTodos[2].Title, wantedTodos[2].Description, wantedTodos[2].Done, wantedTodos[2].CreatedAt,
+				wantedTodos[2].UpdatedAt, wantedTodos[2].CompletedAt, wantedTodos[2].Version, wantedTodos[2].DeletedAt, wantedTodos[2].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]").
 			RowError(1, common.ErrError)
 		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillReturnRows(rows)
-		todos, err := todoRepository.GetAll(userId)
+		todos, err := todoRepository.GetAll(context.Background(), userId)
 		assert.Nil(t, todos)
 		assert.Error(t, common.ErrError, err)
 		err = mock.ExpectationsWereMet()
@@ -181,18 +319,196 @@ func TestGetAll(t *testing.T) {
 	})
 }
 
+const (
+	firstPageQuery string = "select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, " + progressColumns + ", " + commentCountColumn + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is null order by created_at desc, id desc limit $2"
+	nextPageQuery  string = "select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, " + progressColumns + ", " + commentCountColumn + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is null and (created_at, id) < ($2::timestamptz, $3::UUID) order by created_at desc, id desc limit $4"
+	prevPageQuery  string = "select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, " + progressColumns + ", " + commentCountColumn + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is null and (created_at, id) > ($2::timestamptz, $3::UUID) order by created_at asc, id asc limit $4"
+)
+
+func TestGetPage(t *testing.T) {
+	t.Run("First page", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone := false
+		wantedTodos := []model.Todo{
+			{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone, CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(), Tags: []string{}},
+			{Id: uuid.New().String(), Title: "title2", Description: "description2", Done: &todoDone, CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(), Tags: []string{}},
+		}
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodos[0].Id, wantedTodos[0].Title, wantedTodos[0].Description, wantedTodos[0].Done, wantedTodos[0].CreatedAt.Local(),
+				wantedTodos[0].UpdatedAt.Local(), wantedTodos[0].CompletedAt, wantedTodos[0].Version, wantedTodos[0].DeletedAt, wantedTodos[0].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]").
+			AddRow(wantedTodos[1].Id, wantedTodos[1].Title, wantedTodos[1].Description, wantedTodos[1].Done, wantedTodos[1].CreatedAt.Local(),
+				wantedTodos[1].UpdatedAt.Local(), wantedTodos[1].CompletedAt, wantedTodos[1].Version, wantedTodos[1].DeletedAt, wantedTodos[1].ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
+		mock.ExpectQuery(firstPageQuery).WithArgs(userId, 2).WillReturnRows(rows)
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, wantedTodos[:1], page.Todos)
+		assert.Equal(t, model.CursorOf(wantedTodos[0], false), page.Next)
+		assert.Nil(t, page.Prev)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Next page", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		cursor := model.Cursor{CreatedAt: time.Now().UTC(), Id: uuid.New().String()}
+		rows := sqlmock.NewRows(todoColumnNames)
+		mock.ExpectQuery(nextPageQuery).WithArgs(userId, cursor.CreatedAt, cursor.Id, 11).WillReturnRows(rows)
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 10, Cursor: &cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{}, page.Todos)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Previous page", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		cursor := model.Cursor{CreatedAt: time.Now().UTC(), Id: uuid.New().String(), Backward: true}
+		todoDone := true
+		wantedTodo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC(), Tags: []string{}}
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, wantedTodo.Done, wantedTodo.CreatedAt,
+				wantedTodo.UpdatedAt, wantedTodo.CompletedAt, wantedTodo.Version, wantedTodo.DeletedAt, wantedTodo.ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
+		mock.ExpectQuery(prevPageQuery).WithArgs(userId, cursor.CreatedAt, cursor.Id, 11).WillReturnRows(rows)
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 10, Cursor: &cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{wantedTodo}, page.Todos)
+		assert.Nil(t, page.Prev)
+		assert.Equal(t, model.CursorOf(wantedTodo, false), page.Next)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Filtered and sorted", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		done := true
+		after := time.Now().UTC().Add(-time.Hour)
+		before := time.Now().UTC()
+		filter := model.TodoFilter{Done: &done, CreatedAfter: &after, CreatedBefore: &before,
+			Title: "50%_off", Sort: model.SortByTitle, Order: model.OrderAsc}
+		rows := sqlmock.NewRows(todoColumnNames)
+		mock.ExpectQuery("select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, "+progressColumns+", "+commentCountColumn+", "+tagsColumn+" from todo where user_id = $1 and deleted_at is null and done = $2 and "+
+			"created_at > $3::timestamptz and created_at < $4::timestamptz and title ilike $5 order by title asc, id asc").
+			WithArgs(userId, done, after, before, `%50\%\_off%`).WillReturnRows(rows)
+		page, err := todoRepository.GetPage(context.Background(), userId, filter, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{}, page.Todos)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Filtered by tags", func(t *testing.T) {
+		tagged := "from todo_tag join tag on tag.id = todo_tag.tag_id where todo_tag.todo_id = todo.id and tag.name in ($2, $3)"
+		for tagMatch, condition := range map[string]string{
+			model.TagMatchAny: "exists (select 1 " + tagged + ")",
+			model.TagMatchAll: "(select count(*) " + tagged + ") = $4",
+		} {
+			todoRepository, mock := create(t)
+			userId := uuid.New().String()
+			args := []driver.Value{userId, "home", "work"}
+			if tagMatch == model.TagMatchAll {
+				args = append(args, 2)
+			}
+			rows := sqlmock.NewRows(todoColumnNames)
+			mock.ExpectQuery("select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, " +
+				progressColumns + ", " + commentCountColumn + ", " + tagsColumn + " from todo where user_id = $1 and deleted_at is null and " + condition +
+				" order by created_at desc, id desc").WithArgs(args...).WillReturnRows(rows)
+			_, err := todoRepository.GetPage(context.Background(), userId,
+				model.TodoFilter{Tags: []string{"work", "home", "work"}, TagMatch: tagMatch}, model.PageRequest{})
+			assert.NoError(t, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err, tagMatch)
+			}
+		}
+	})
+
+	t.Run("Next page of a sort by done", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		cursor := model.Cursor{CreatedAt: time.Now().UTC(), Done: true, Id: uuid.New().String()}
+		rows := sqlmock.NewRows(todoColumnNames)
+		mock.ExpectQuery("select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, "+progressColumns+", "+commentCountColumn+", "+tagsColumn+" from todo where user_id = $1 and deleted_at is null and "+
+			"(done, id) < ($2, $3::UUID) order by done desc, id desc limit $4").
+			WithArgs(userId, true, cursor.Id, 6).WillReturnRows(rows)
+		_, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: model.SortByDone},
+			model.PageRequest{Limit: 5, Cursor: &cursor})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Previous page of an ascending sort by title", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		cursor := model.Cursor{CreatedAt: time.Now().UTC(), Title: "title5", Id: uuid.New().String(), Backward: true}
+		rows := sqlmock.NewRows(todoColumnNames)
+		mock.ExpectQuery("select id, title, description, done, created_at, updated_at, completed_at, version, deleted_at, list_id, parent_id, due_at, due_all_day, timezone, priority, position, recurrence, "+progressColumns+", "+commentCountColumn+", "+tagsColumn+" from todo where user_id = $1 and deleted_at is null and "+
+			"(title, id) < ($2, $3::UUID) order by title desc, id desc limit $4").
+			WithArgs(userId, cursor.Title, cursor.Id, 6).WillReturnRows(rows)
+		_, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Sort: model.SortByTitle, Order: model.OrderAsc},
+			model.PageRequest{Limit: 5, Cursor: &cursor})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the filter is invalid", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		for _, filter := range []model.TodoFilter{{Sort: "color"}, {Order: "up"}} {
+			page, err := todoRepository.GetPage(context.Background(), uuid.New().String(), filter, model.PageRequest{})
+			assert.Nil(t, page)
+			assert.Equal(t, ErrInvalidFilter, err)
+		}
+	})
+
+	t.Run("When Query returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(firstPageQuery).WithArgs(userId, 11).WillReturnError(common.ErrError)
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{}, model.PageRequest{Limit: 10})
+		assert.Nil(t, page)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
 func TestGetById(t *testing.T) {
 	t.Run("Good case", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoId := uuid.New().String()
-		todoDone := false
+		todoDone := true
+		completedAt := time.Now().UTC()
 		wantedTodo := model.Todo{Id: todoId, Title: "title1",
-			Description: "description1", Done: &todoDone, CreatedAt: time.Now().UTC()}
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"}).
-			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, wantedTodo.Done, wantedTodo.CreatedAt.Local())
+			Description: "description1", Done: &todoDone, CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(), CompletedAt: &completedAt, Version: 4, Tags: []string{"work"}}
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, wantedTodo.Done, wantedTodo.CreatedAt.Local(),
+				wantedTodo.UpdatedAt.Local(), wantedTodo.CompletedAt.Local(), wantedTodo.Version, wantedTodo.DeletedAt, wantedTodo.ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), `["work"]`)
 		mock.ExpectQuery(specificTodoQuery).WithArgs(todoId, userId).WillReturnRows(rows)
-		todo, err := todoRepository.GetById(todoId, userId)
+		todo, err := todoRepository.GetById(context.Background(), todoId, userId)
 		assert.Equal(t, wantedTodo, *todo)
 		assert.Nil(t, err)
 		err = mock.ExpectationsWereMet()
@@ -205,9 +521,9 @@ func TestGetById(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoId := uuid.New().String()
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"})
+		rows := sqlmock.NewRows(todoColumnNames)
 		mock.ExpectQuery(specificTodoQuery).WithArgs(todoId, userId).WillReturnRows(rows)
-		todo, err := todoRepository.GetById(todoId, userId)
+		todo, err := todoRepository.GetById(context.Background(), todoId, userId)
 		assert.Nil(t, todo)
 		assert.Equal(t, ErrNotFound, err)
 		err = mock.ExpectationsWereMet()
@@ -223,10 +539,11 @@ func TestGetById(t *testing.T) {
 		todoDone := false
 		wantedTodo := model.Todo{Id: todoId, Title: "title1",
 			Description: "description1", Done: &todoDone, CreatedAt: time.Now()}
-		rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "created_at"}).
-			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, "", wantedTodo.CreatedAt)
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, "", wantedTodo.CreatedAt,
+				wantedTodo.UpdatedAt, wantedTodo.CompletedAt, wantedTodo.Version, wantedTodo.DeletedAt, wantedTodo.ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
 		mock.ExpectQuery(specificTodoQuery).WithArgs(todoId, userId).WillReturnRows(rows)
-		todo, err := todoRepository.GetById(todoId, userId)
+		todo, err := todoRepository.GetById(context.Background(), todoId, userId)
 		assert.Nil(t, todo)
 		assert.NotNil(t, err)
 		err = mock.ExpectationsWereMet()
@@ -236,16 +553,90 @@ func TestGetById(t *testing.T) {
 	})
 }
 
+func TestSearch(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone := false
+		wantedResult := model.SearchResult{Todo: model.Todo{Id: uuid.New().String(), Title: "buy milk",
+			Description: "from the shop", Done: &todoDone, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC(), Tags: []string{}},
+			Rank:             0.75,
+			HighlightedTitle: "buy <mark>milk</mark>", Snippet: "from the shop"}
+		rows := sqlmock.NewRows(append(todoColumnNames, "rank", "title", "snippet")).
+			AddRow(wantedResult.Id, wantedResult.Title, wantedResult.Description, wantedResult.Done,
+				wantedResult.CreatedAt.Local(), wantedResult.UpdatedAt.Local(), wantedResult.CompletedAt, wantedResult.Version, wantedResult.DeletedAt, wantedResult.ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]",
+				wantedResult.Rank, wantedResult.HighlightedTitle, wantedResult.Snippet)
+		mock.ExpectQuery(searchQuery).WithArgs(userId, "milk", 20).WillReturnRows(rows)
+		results, err := todoRepository.Search(context.Background(), userId, "milk", 20)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.SearchResult{wantedResult}, results)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When Query returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(searchQuery).WithArgs(userId, "milk", 20).WillReturnError(common.ErrError)
+		results, err := todoRepository.Search(context.Background(), userId, "milk", 20)
+		assert.Nil(t, results)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When a rows.Scan() call returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		rows := sqlmock.NewRows(append(todoColumnNames, "rank", "title", "snippet")).
+			AddRow(uuid.New().String(), "title", "description", "", time.Now(), time.Now(), nil, 1, nil, uuid.New().String(), nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]", 0.5, "title", "description")
+		mock.ExpectQuery(searchQuery).WithArgs(userId, "milk", 20).WillReturnRows(rows)
+		results, err := todoRepository.Search(context.Background(), userId, "milk", 20)
+		assert.Nil(t, results)
+		assert.Error(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
 func TestUpdate(t *testing.T) {
 	t.Run("Good case", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoDone1 := false
 		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
-			Done: &todoDone1, CreatedAt: time.Now()}
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		version := int64(3)
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case: the todo moves to another list", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1, UpdatedAt: time.Now(), ListId: uuid.New().String()}
+		version := int64(3)
+		mock.ExpectBegin()
+		mock.ExpectQuery(listArchivedQuery).WithArgs(todo.ListId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(false))
 		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
-			todo.Description, todo.Done, todo.CreatedAt, userId).WillReturnResult(sqlmock.NewErrorResult(nil))
-		err := todoRepository.Update(&todo, userId)
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, todo.ListId, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
 		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
@@ -253,15 +644,107 @@ func TestUpdate(t *testing.T) {
 		}
 	})
 
+	t.Run("When the list is archived", func(t *testing.T) {
+		for stored, wantedErr := range map[int64]error{3: ErrListArchived, 4: ErrVersionMismatch} {
+			todoRepository, mock := create(t)
+			userId := uuid.New().String()
+			todoDone1 := false
+			todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+				Done: &todoDone1, UpdatedAt: time.Now(), ListId: uuid.New().String()}
+			mock.ExpectBegin()
+			mock.ExpectQuery(listArchivedQuery).WithArgs(todo.ListId, userId).
+				WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(true))
+			mock.ExpectQuery(versionQuery).WithArgs(todo.Id, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(stored))
+			mock.ExpectRollback()
+			err := todoRepository.Update(context.Background(), &todo, userId, 3)
+			assert.Equal(t, wantedErr, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When that todo is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		version := int64(3)
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todo.Id, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When that todo has another version", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		version := int64(3)
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todo.Id, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
+		assert.Equal(t, ErrVersionMismatch, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the version lookup returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.UpdatedAt, userId, AnyVersion, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todo.Id, userId).WillReturnError(common.ErrError)
+		err := todoRepository.Update(context.Background(), &todo, userId, AnyVersion)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When RowsAffected returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		version := int64(3)
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewErrorResult(common.ErrError))
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
 	t.Run("When DBPool returns an error", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoDone1 := false
 		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
-			Done: &todoDone1, CreatedAt: time.Now()}
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		version := int64(3)
 		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title,
-			todo.Description, todo.Done, todo.CreatedAt, userId).WillReturnError(common.ErrError)
-		err := todoRepository.Update(&todo, userId)
+			todo.Description, todo.Done, todo.UpdatedAt, userId, version, nil, nil, false, nil, "", 0, "").WillReturnError(common.ErrError)
+		err := todoRepository.Update(context.Background(), &todo, userId, version)
 		assert.Equal(t, common.ErrError, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
@@ -274,17 +757,149 @@ func TestUpdate(t *testing.T) {
 		userId := uuid.New().String()
 		todoDone1 := false
 		invalidTodo := model.Todo{Id: uuid.New().String(), Title: "", Description: "description1",
-			Done: &todoDone1, CreatedAt: time.Now()}
-		err := todoRepository.Update(&invalidTodo, userId)
+			Done: &todoDone1, UpdatedAt: time.Now()}
+		err := todoRepository.Update(context.Background(), &invalidTodo, userId, AnyVersion)
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+
+	t.Run("When UpdatedAt is not set", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		userId := uuid.New().String()
+		todoDone1 := false
+		invalidTodo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+			Done: &todoDone1}
+		err := todoRepository.Update(context.Background(), &invalidTodo, userId, AnyVersion)
 		assert.Equal(t, ErrInvalidTodo, err)
 	})
 
 	t.Run("When todo is invalid 2", func(t *testing.T) {
 		todoRepository, _ := create(t)
 		userId := uuid.New().String()
-		err := todoRepository.Update(nil, userId)
+		err := todoRepository.Update(context.Background(), nil, userId, AnyVersion)
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+}
+
+func TestPatch(t *testing.T) {
+	t.Run("Only the changed columns are updated", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		title := "title2"
+		updatedAt := time.Now()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, title = $4, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)").
+			WithArgs(todoId, userId, updatedAt, title, int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Title: &title, UpdatedAt: updatedAt}, 2)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Every column is changed", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		title, description, done, updatedAt := "title2", "description2", true, time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, title = $4, description = $5, done = $6, "+
+			"completed_at = case when $6 then coalesce(completed_at, $3::timestamptz) else null end, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($7 = 0 or version = $7)").
+			WithArgs(todoId, userId, updatedAt, title, description, done, AnyVersion).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(recurringTodoQuery).WithArgs(todoId, userId, updatedAt).WillReturnRows(sqlmock.NewRows(todoColumnNames))
+		mock.ExpectCommit()
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Title: &title, Description: &description,
+			Done: &done, UpdatedAt: updatedAt}, AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When that todo is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		title := "title2"
+		updatedAt := time.Now()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, title = $4, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)").
+			WithArgs(todoId, userId, updatedAt, title, int64(2)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Title: &title, UpdatedAt: updatedAt}, 2)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When that todo has another version", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		title := "title2"
+		updatedAt := time.Now()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, title = $4, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)").
+			WithArgs(todoId, userId, updatedAt, title, int64(2)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Title: &title, UpdatedAt: updatedAt}, 2)
+		assert.Equal(t, ErrVersionMismatch, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When nothing changed", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		err := todoRepository.Patch(context.Background(), uuid.New().String(), uuid.New().String(), model.TodoChanges{UpdatedAt: time.Now()}, AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When a change is invalid", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		empty := ""
+		err := todoRepository.Patch(context.Background(), uuid.New().String(), uuid.New().String(),
+			model.TodoChanges{Title: &empty, UpdatedAt: time.Now()}, AnyVersion)
+		assert.Equal(t, ErrInvalidTodo, err)
+		err = todoRepository.Patch(context.Background(), uuid.New().String(), uuid.New().String(),
+			model.TodoChanges{Description: &empty, UpdatedAt: time.Now()}, AnyVersion)
 		assert.Equal(t, ErrInvalidTodo, err)
 	})
+
+	t.Run("When UpdatedAt is not set", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		done := true
+		err := todoRepository.Patch(context.Background(), uuid.New().String(), uuid.New().String(), model.TodoChanges{Done: &done}, AnyVersion)
+		assert.Equal(t, ErrInvalidTodo, err)
+	})
+
+	t.Run("When DBPool returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		done := false
+		updatedAt := time.Now()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, done = $4, "+
+			"completed_at = case when $4 then coalesce(completed_at, $3::timestamptz) else null end, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)").
+			WithArgs(todoId, userId, updatedAt, done, AnyVersion).WillReturnError(common.ErrError)
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Done: &done, UpdatedAt: updatedAt}, AnyVersion)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
 }
 
 func TestDelete(t *testing.T) {
@@ -292,8 +907,113 @@ func TestDelete(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoId := uuid.New().String()
-		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewErrorResult(nil))
-		err := todoRepository.Delete(todoId, userId)
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, int64(5), deletedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteSubtasksQuery).WithArgs(todoId, userId, deletedAt).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		err := todoRepository.Delete(context.Background(), todoId, userId, 5, deletedAt)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When that todo is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, int64(5), deletedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		err := todoRepository.Delete(context.Background(), todoId, userId, 5, deletedAt)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When that todo has another version", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, int64(5), deletedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(6))
+		mock.ExpectRollback()
+		err := todoRepository.Delete(context.Background(), todoId, userId, 5, deletedAt)
+		assert.Equal(t, ErrVersionMismatch, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When DBPool.Exec returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, int64(5), deletedAt).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Delete(context.Background(), todoId, userId, 5, deletedAt)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetTrash(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoDone := false
+		deletedAt := time.Now().UTC()
+		wantedTodo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC(), Version: 2, DeletedAt: &deletedAt, Tags: []string{}}
+		rows := sqlmock.NewRows(todoColumnNames).
+			AddRow(wantedTodo.Id, wantedTodo.Title, wantedTodo.Description, wantedTodo.Done, wantedTodo.CreatedAt.Local(),
+				wantedTodo.UpdatedAt.Local(), wantedTodo.CompletedAt, wantedTodo.Version, wantedTodo.DeletedAt.Local(), wantedTodo.ListId, nil, nil, false, "", 0, "", "", int64(0), int64(0), int64(0), "[]")
+		mock.ExpectQuery(trashQuery).WithArgs(userId).WillReturnRows(rows)
+		todos, err := todoRepository.GetTrash(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Todo{wantedTodo}, todos)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When Query returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(trashQuery).WithArgs(userId).WillReturnError(common.ErrError)
+		todos, err := todoRepository.GetTrash(context.Background(), userId)
+		assert.Equal(t, common.ErrError, err)
+		assert.Nil(t, todos)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentInTrashQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_in_trash"}).AddRow(false))
+		mock.ExpectExec(restoreSubtasksQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(restoreQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Restore(context.Background(), todoId, userId)
 		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
@@ -301,27 +1021,159 @@ func TestDelete(t *testing.T) {
 		}
 	})
 
+	t.Run("When that todo is not in the trash", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentInTrashQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_in_trash"}))
+		mock.ExpectRollback()
+		err := todoRepository.Restore(context.Background(), todoId, userId)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the parent of that todo is in the trash", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentInTrashQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_in_trash"}).AddRow(true))
+		mock.ExpectRollback()
+		err := todoRepository.Restore(context.Background(), todoId, userId)
+		assert.Equal(t, ErrParentInTrash, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
 	t.Run("When DBPool.Exec returns an error", func(t *testing.T) {
 		todoRepository, mock := create(t)
 		userId := uuid.New().String()
 		todoId := uuid.New().String()
-		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId).WillReturnError(common.ErrError)
-		err := todoRepository.Delete(todoId, userId)
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentInTrashQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_in_trash"}).AddRow(false))
+		mock.ExpectExec(restoreSubtasksQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(restoreQuery).WithArgs(todoId, userId).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Restore(context.Background(), todoId, userId)
 		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectExec(purgeQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.Purge(context.Background(), todoId, userId)
+		assert.NoError(t, err)
 		err = mock.ExpectationsWereMet()
 		if err != nil {
 			t.Error(err)
 		}
 	})
+
+	t.Run("When that todo is not in the trash", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectExec(purgeQuery).WithArgs(todoId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+		err := todoRepository.Purge(context.Background(), todoId, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		deletedBefore := time.Now()
+		mock.ExpectExec(purgeTrashQuery).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 3))
+		purged, err := todoRepository.PurgeTrash(context.Background(), deletedBefore)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When DBPool.Exec returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		deletedBefore := time.Now()
+		mock.ExpectExec(purgeTrashQuery).WithArgs(deletedBefore).WillReturnError(common.ErrError)
+		_, err := todoRepository.PurgeTrash(context.Background(), deletedBefore)
+		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	t.Run("When the query takes longer than the query timeout", func(t *testing.T) {
+		dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatal()
+		}
+		todoRepository, err := GetTodoRepository(dbPool, 10*time.Millisecond, SubtaskRules{}, uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal()
+		}
+		userId := uuid.New().String()
+		mock.ExpectQuery(allTodosQuery).WithArgs(userId).WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows(todoColumnNames))
+		todos, err := todoRepository.GetAll(context.Background(), userId)
+		assert.Equal(t, ErrQueryTimeout, err)
+		assert.Nil(t, todos)
+	})
+
+	t.Run("When the request is canceled", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		ctx, cancel := context.WithCancel(context.Background())
+		mock.ExpectExec(purgeQuery).WithArgs(todoId, userId).WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		time.AfterFunc(10*time.Millisecond, cancel)
+		err := todoRepository.Purge(ctx, todoId, userId)
+		assert.Equal(t, ErrCanceled, err)
+	})
+
+	t.Run("When the query fails before the query timeout", func(t *testing.T) {
+		dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatal()
+		}
+		todoRepository, err := GetTodoRepository(dbPool, time.Second, SubtaskRules{}, uuid.NewV7, time.Now)
+		if err != nil {
+			t.Fatal()
+		}
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectExec(purgeQuery).WithArgs(todoId, userId).WillReturnError(common.ErrError)
+		err = todoRepository.Purge(context.Background(), todoId, userId)
+		assert.Equal(t, common.ErrError, err)
+	})
 }
 
 func create(t *testing.T) (common.TodoRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	return createWithRules(t, SubtaskRules{})
+}
+
+func createWithRules(t *testing.T, rules SubtaskRules) (common.TodoRepository, sqlmock.Sqlmock) {
 	t.Helper()
 	dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
 		t.Fatal()
 	}
-	todoRepository, err := GetTodoRepository(dbPool)
+	todoRepository, err := GetTodoRepository(dbPool, 0, rules, uuid.NewV7, time.Now)
 	if err != nil {
 		t.Fatal()
 	}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+)
+
+const listColumns string = "id, name, inbox, created_at, updated_at, archived_at"
+
+func listFields(list *model.List) []any {
+	return []any{&list.Id, &list.Name, &list.Inbox, &list.CreatedAt, &list.UpdatedAt, &list.ArchivedAt}
+}
+
+func listInUTC(list *model.List) {
+	list.CreatedAt = list.CreatedAt.UTC()
+	list.UpdatedAt = list.UpdatedAt.UTC()
+	if list.ArchivedAt != nil {
+		archivedAt := list.ArchivedAt.UTC()
+		list.ArchivedAt = &archivedAt
+	}
+}
+
+// isListId tells whether id names a list of a todo: the inbox when it is
+// empty, or another list.
+func isListId(id string) bool {
+	if id == "" {
+		return true
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+func nullIfEmpty(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// inboxSource gives the id and the creation time of the inbox that a
+// repository creates the first time a user needs one.
+type inboxSource struct {
+	newId func() (uuid.UUID, error)
+	now   func() time.Time
+}
+
+// newInbox returns the inbox to create for a user who has none yet.
+func (source inboxSource) newInbox() (model.List, error) {
+	id, err := source.newId()
+	if err != nil {
+		return model.List{}, err
+	}
+	now := source.now().UTC()
+	return model.List{Id: id.String(), Name: model.InboxName, Inbox: true, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// inbox returns the id of the inbox of a user, which is created the first
+// time it is asked for.
+func (tr todoRepositoryImpl) inbox(ctx context.Context, userId string) (string, error) {
+	var id string
+	err := tr.DBPool.QueryRowContext(ctx, tr.dialect.inbox, userId).Scan(&id)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+	inbox, err := tr.inboxes.newInbox()
+	if err != nil {
+		return "", err
+	}
+	if _, err := tr.DBPool.ExecContext(ctx, tr.dialect.insertInbox, inbox.Id, userId, inbox.Name,
+		inbox.CreatedAt); err != nil {
+		return "", err
+	}
+	err = tr.DBPool.QueryRowContext(ctx, tr.dialect.inbox, userId).Scan(&id)
+	return id, err
+}
+
+// listOf returns the list that a todo of a user goes to when it names
+// listId: the inbox when listId is empty, or else listId itself, which must
+// be a list of the user that isn't archived.
+func (tr todoRepositoryImpl) listOf(ctx context.Context, userId string, listId string) (string, error) {
+	if listId == "" {
+		return tr.inbox(ctx, userId)
+	}
+	var archived bool
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.listArchived, listId, userId).Scan(&archived); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrListNotFound
+		}
+		return "", err
+	} else if archived {
+		return "", ErrListArchived
+	}
+	return listId, nil
+}
+
+// GetLists returns the lists of a user with the inbox first and then the
+// rest by creation, followed by the lists that other users share with them.
+// Archived lists are left out unless includeArchived.
+func (tr todoRepositoryImpl) GetLists(ctx context.Context, userId string, includeArchived bool) (_ []model.List, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	if _, err := tr.inbox(ctx, userId); err != nil {
+		return nil, err
+	}
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.allLists, userId, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lists := []model.List{}
+	for rows.Next() {
+		var list model.List
+		if err := rows.Scan(listFields(&list)...); err != nil {
+			return nil, err
+		}
+		listInUTC(&list)
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	shared, err := tr.sharedLists(ctx, userId, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	return append(lists, shared...), nil
+}
+
+func (tr todoRepositoryImpl) GetList(ctx context.Context, id string, userId string) (_ *model.List, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	var list model.List
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.specificList, id, userId).Scan(listFields(&list)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	listInUTC(&list)
+	return &list, nil
+}
+
+// CreateList stores a list that isn't the inbox nor archived.
+func (tr todoRepositoryImpl) CreateList(ctx context.Context, list *model.List, userId string) (err error) {
+	if list == nil || !model.IsValid(list) || list.Inbox || list.ArchivedAt != nil {
+		return ErrInvalidList
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	_, err = tr.DBPool.ExecContext(ctx, tr.dialect.insertList, list.Id, userId, list.Name, list.CreatedAt, list.UpdatedAt)
+	return err
+}
+
+// PatchList renames, archives or unarchives a list of a user. The inbox
+// can't be patched.
+func (tr todoRepositoryImpl) PatchList(ctx context.Context, id string, userId string, changes model.ListChanges) (err error) {
+	if changes.Name != nil && !model.IsValid(model.List{Id: id, Name: *changes.Name, CreatedAt: changes.UpdatedAt,
+		UpdatedAt: changes.UpdatedAt}) {
+		return ErrInvalidList
+	}
+	if changes.IsEmpty() {
+		return nil
+	}
+	if changes.UpdatedAt.IsZero() {
+		return ErrInvalidList
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	err = rowAffected(tr.DBPool.ExecContext(ctx, tr.dialect.patchList, id, userId, changes.Name, changes.Archived,
+		changes.UpdatedAt))
+	if err != ErrNotFound {
+		return err
+	}
+	if inbox, err := tr.isInbox(ctx, id, userId); err != nil {
+		return err
+	} else if inbox {
+		return ErrInboxList
+	}
+	return ErrNotFound
+}
+
+// DeleteList deletes a list of a user that isn't the inbox. Its todos are
+// deleted with it when cascade, or else moved to the inbox.
+func (tr todoRepositoryImpl) DeleteList(ctx context.Context, id string, userId string, cascade bool) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if inbox, err := tx.isInbox(ctx, id, userId); err != nil {
+			return err
+		} else if inbox {
+			return ErrInboxList
+		}
+		if !cascade {
+			inbox, err := tx.inbox(ctx, userId)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.moveListTodos, id, userId, inbox); err != nil {
+				return err
+			}
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.deleteList, id, userId))
+	})
+}
+
+// isInbox tells whether the list id of a user is their inbox. It returns
+// ErrNotFound when the list doesn't exist.
+func (tr todoRepositoryImpl) isInbox(ctx context.Context, id string, userId string) (bool, error) {
+	var inbox bool
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.listInbox, id, userId).Scan(&inbox); err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrNotFound
+		}
+		return false, err
+	}
+	return inbox, nil
+}
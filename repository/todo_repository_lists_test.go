@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var listColumnNames = []string{"id", "name", "inbox", "created_at", "updated_at", "archived_at"}
+
+func TestGetLists(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		wantedLists := []model.List{
+			{Id: uuid.New().String(), Name: model.InboxName, Inbox: true, CreatedAt: ti, UpdatedAt: ti},
+			{Id: uuid.New().String(), Name: "work", CreatedAt: ti, UpdatedAt: ti, ArchivedAt: &ti},
+			{Id: uuid.New().String(), Name: "family", CreatedAt: ti, UpdatedAt: ti, OwnerId: "wehgowe", Role: model.RoleViewer},
+		}
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(wantedLists[0].Id))
+		mock.ExpectQuery(allListsQuery).WithArgs(userId, true).WillReturnRows(sqlmock.NewRows(listColumnNames).
+			AddRow(wantedLists[0].Id, wantedLists[0].Name, true, ti.Local(), ti.Local(), nil).
+			AddRow(wantedLists[1].Id, wantedLists[1].Name, false, ti.Local(), ti.Local(), ti.Local()))
+		mock.ExpectQuery(sharedListsQuery).WithArgs(userId, true).
+			WillReturnRows(sqlmock.NewRows(append(listColumnNames, "user_id", "role")).
+				AddRow(wantedLists[2].Id, wantedLists[2].Name, false, ti.Local(), ti.Local(), nil, "wehgowe", "viewer"))
+		lists, err := todoRepository.GetLists(context.Background(), userId, true)
+		assert.NoError(t, err)
+		assert.Equal(t, wantedLists, lists)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the inbox can't be created", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(insertInboxQuery).WithArgs(sqlmock.AnyArg(), userId, model.InboxName, sqlmock.AnyArg()).
+			WillReturnError(common.ErrError)
+		lists, err := todoRepository.GetLists(context.Background(), userId, false)
+		assert.Nil(t, lists)
+		assert.Equal(t, common.ErrError, err)
+	})
+
+	t.Run("The inbox gets the id and the time that the repository is given", func(t *testing.T) {
+		dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inboxId := uuid.New()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		todoRepository, err := GetTodoRepository(dbPool, 0, SubtaskRules{},
+			func() (uuid.UUID, error) { return inboxId, nil }, func() time.Time { return ti.Local() })
+		if err != nil {
+			t.Fatal(err)
+		}
+		userId := uuid.New().String()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(insertInboxQuery).WithArgs(inboxId.String(), userId, model.InboxName, ti).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId.String()))
+		mock.ExpectQuery(allListsQuery).WithArgs(userId, false).WillReturnRows(sqlmock.NewRows(listColumnNames).
+			AddRow(inboxId.String(), model.InboxName, true, ti, ti, nil))
+		mock.ExpectQuery(sharedListsQuery).WithArgs(userId, false).
+			WillReturnRows(sqlmock.NewRows(append(listColumnNames, "user_id", "role")))
+		lists, err := todoRepository.GetLists(context.Background(), userId, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.List{{Id: inboxId.String(), Name: model.InboxName, Inbox: true, CreatedAt: ti, UpdatedAt: ti}},
+			lists)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the id of the inbox can't be made", func(t *testing.T) {
+		dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatal(err)
+		}
+		todoRepository, err := GetTodoRepository(dbPool, 0, SubtaskRules{},
+			func() (uuid.UUID, error) { return uuid.Nil, common.ErrError }, time.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		userId := uuid.New().String()
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		lists, err := todoRepository.GetLists(context.Background(), userId, false)
+		assert.Nil(t, lists)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetList(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		wantedList := model.List{Id: uuid.New().String(), Name: "work", CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectQuery(specificListQuery).WithArgs(wantedList.Id, userId).WillReturnRows(sqlmock.NewRows(listColumnNames).
+			AddRow(wantedList.Id, wantedList.Name, false, ti, ti, nil))
+		list, err := todoRepository.GetList(context.Background(), wantedList.Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, &wantedList, list)
+	})
+
+	t.Run("When the list doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, listId := uuid.New().String(), uuid.New().String()
+		mock.ExpectQuery(specificListQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows(listColumnNames))
+		list, err := todoRepository.GetList(context.Background(), listId, userId)
+		assert.Nil(t, list)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestCreateList(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		list := model.List{Id: uuid.New().String(), Name: "work", CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectExec(insertListQuery).WithArgs(list.Id, userId, list.Name, ti, ti).WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, todoRepository.CreateList(context.Background(), &list, userId))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Invalid list", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		ti := time.Now()
+		for _, list := range []*model.List{
+			nil,
+			{Id: uuid.New().String(), CreatedAt: ti, UpdatedAt: ti},
+			{Id: uuid.New().String(), Name: "inbox", Inbox: true, CreatedAt: ti, UpdatedAt: ti},
+			{Id: uuid.New().String(), Name: "work", CreatedAt: ti, UpdatedAt: ti, ArchivedAt: &ti},
+		} {
+			assert.Equal(t, ErrInvalidList, todoRepository.CreateList(context.Background(), list, uuid.New().String()))
+		}
+	})
+}
+
+func TestPatchList(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, listId := uuid.New().String(), uuid.New().String()
+		name, archived, updatedAt := "office", true, time.Now()
+		mock.ExpectExec(patchListQuery).WithArgs(listId, userId, &name, &archived, updatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.PatchList(context.Background(), listId, userId,
+			model.ListChanges{Name: &name, Archived: &archived, UpdatedAt: updatedAt})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When no list is patched", func(t *testing.T) {
+		for wantedErr, rows := range map[error]*sqlmock.Rows{
+			ErrInboxList: sqlmock.NewRows([]string{"inbox"}).AddRow(true),
+			ErrNotFound:  sqlmock.NewRows([]string{"inbox"}),
+		} {
+			todoRepository, mock := create(t)
+			userId, listId := uuid.New().String(), uuid.New().String()
+			name, updatedAt := "office", time.Now()
+			mock.ExpectExec(patchListQuery).WithArgs(listId, userId, &name, nil, updatedAt).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(rows)
+			err := todoRepository.PatchList(context.Background(), listId, userId, model.ListChanges{Name: &name, UpdatedAt: updatedAt})
+			assert.Equal(t, wantedErr, err)
+		}
+	})
+
+	t.Run("When there are no changes", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		err := todoRepository.PatchList(context.Background(), uuid.New().String(), uuid.New().String(), model.ListChanges{})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Invalid changes", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		empty, archived := "", true
+		for _, changes := range []model.ListChanges{
+			{Name: &empty, UpdatedAt: time.Now()},
+			{Archived: &archived},
+		} {
+			err := todoRepository.PatchList(context.Background(), uuid.New().String(), uuid.New().String(), changes)
+			assert.Equal(t, ErrInvalidList, err)
+		}
+	})
+}
+
+func TestDeleteList(t *testing.T) {
+	t.Run("Good case: the todos move to the inbox", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, listId, inboxId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectExec(moveListTodosQuery).WithArgs(listId, userId, inboxId).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(deleteListQuery).WithArgs(listId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		assert.NoError(t, todoRepository.DeleteList(context.Background(), listId, userId, false))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case: the todos are deleted with the list", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, listId := uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectExec(deleteListQuery).WithArgs(listId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		assert.NoError(t, todoRepository.DeleteList(context.Background(), listId, userId, true))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the list is the inbox or doesn't exist", func(t *testing.T) {
+		for wantedErr, rows := range map[error]*sqlmock.Rows{
+			ErrInboxList: sqlmock.NewRows([]string{"inbox"}).AddRow(true),
+			ErrNotFound:  sqlmock.NewRows([]string{"inbox"}),
+		} {
+			todoRepository, mock := create(t)
+			userId, listId := uuid.New().String(), uuid.New().String()
+			mock.ExpectBegin()
+			mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(rows)
+			mock.ExpectRollback()
+			assert.Equal(t, wantedErr, todoRepository.DeleteList(context.Background(), listId, userId, false))
+			err := mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrInvalidMembership = errors.New("invalid membership")
+var ErrDuplicateMembership = errors.New("the list is already shared with that user")
+
+const (
+	membershipColumns string = "list_id, user_id, role, created_at, updated_at"
+	// todoAccessQuery and listAccessQuery only find a todo or a list of
+	// another user through a membership of the user, so that nothing else of
+	// the owner ever shows.
+	todoAccessQuery string = "select todo.user_id, todo.list_id, case when todo.user_id = $2 then 'owner' else list_member.role end " +
+		"from todo left join list_member on list_member.list_id = todo.list_id and list_member.user_id = $2 " +
+		"where todo.id = $1::UUID and todo.deleted_at is null and (todo.user_id = $2 or list_member.user_id is not null)"
+	listAccessQuery string = "select list.user_id, case when list.user_id = $2 then 'owner' else list_member.role end " +
+		"from list left join list_member on list_member.list_id = list.id and list_member.user_id = $2 " +
+		"where list.id = $1::UUID and (list.user_id = $2 or list_member.user_id is not null)"
+	sharedListsQuery string = "select " + listColumns + ", user_id, " +
+		"(select role from list_member where list_member.list_id = list.id and list_member.user_id = $1) from list " +
+		"where id in (select list_id from list_member where user_id = $1) and ($2 or archived_at is null) order by created_at, id"
+	membershipsQuery      string = "select " + membershipColumns + " from list_member where list_id = $1::UUID order by created_at, user_id"
+	insertMembershipQuery string = "insert into list_member (" + membershipColumns + ") " +
+		"values ($1::UUID, $2, $3, $4::timestamptz, $5::timestamptz) on conflict (list_id, user_id) do nothing"
+	updateMembershipQuery string = "update list_member set role = $4, updated_at = $5::timestamptz " +
+		"where list_id = $1::UUID and user_id = $3 and list_id in (select id from list where user_id = $2)"
+	deleteMembershipQuery string = "delete from list_member " +
+		"where list_id = $1::UUID and user_id = $3 and list_id in (select id from list where user_id = $2)"
+)
+
+func membershipFields(membership *model.Membership) []any {
+	return []any{&membership.ListId, &membership.UserId, &membership.Role, &membership.CreatedAt, &membership.UpdatedAt}
+}
+
+// GetTodoAccess returns the access of a user to a todo outside the trash,
+// which is in their own list or in a list that is shared with them.
+func (tr todoRepositoryImpl) GetTodoAccess(ctx context.Context, id string, userId string) (_ *model.Access, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	var access model.Access
+	var listId nullableId
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.todoAccess, id, userId).Scan(&access.OwnerId, &listId,
+		&access.Role); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	access.ListId = string(listId)
+	return &access, nil
+}
+
+// GetListAccess returns the access of a user to a list of their own or that
+// is shared with them.
+func (tr todoRepositoryImpl) GetListAccess(ctx context.Context, id string, userId string) (_ *model.Access, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	access := model.Access{ListId: id}
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.listAccess, id, userId).Scan(&access.OwnerId,
+		&access.Role); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &access, nil
+}
+
+// sharedLists returns the lists that other users share with a user by
+// creation.
+func (tr todoRepositoryImpl) sharedLists(ctx context.Context, userId string, includeArchived bool) ([]model.List, error) {
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.sharedLists, userId, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lists := []model.List{}
+	for rows.Next() {
+		var list model.List
+		if err := rows.Scan(append(listFields(&list), &list.OwnerId, &list.Role)...); err != nil {
+			return nil, err
+		}
+		listInUTC(&list)
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetMemberships returns the memberships of a list of a user by creation.
+func (tr todoRepositoryImpl) GetMemberships(ctx context.Context, listId string, userId string) (_ []model.Membership, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	if _, err := tr.isInbox(ctx, listId, userId); err != nil {
+		return nil, err
+	}
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.memberships, listId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	memberships := []model.Membership{}
+	for rows.Next() {
+		var membership model.Membership
+		if err := rows.Scan(membershipFields(&membership)...); err != nil {
+			return nil, err
+		}
+		membershipInUTC(&membership)
+		memberships = append(memberships, membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// CreateMembership shares a list of a user that isn't their inbox with
+// another user.
+func (tr todoRepositoryImpl) CreateMembership(ctx context.Context, membership *model.Membership, userId string) (err error) {
+	if membership == nil || !model.IsValid(membership) || membership.UserId == userId {
+		return ErrInvalidMembership
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if inbox, err := tx.isInbox(ctx, membership.ListId, userId); err != nil {
+			return err
+		} else if inbox {
+			return ErrInboxList
+		}
+		err := rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.insertMembership, membership.ListId, membership.UserId,
+			string(membership.Role), membership.CreatedAt, membership.UpdatedAt))
+		if err == ErrNotFound {
+			return ErrDuplicateMembership
+		}
+		return err
+	})
+}
+
+// UpdateMembership changes the role of a member of a list of a user.
+func (tr todoRepositoryImpl) UpdateMembership(ctx context.Context, listId string, userId string, memberId string,
+	changes model.MembershipChanges) (err error) {
+	if !model.IsValid(model.Membership{ListId: listId, UserId: memberId, Role: changes.Role, CreatedAt: changes.UpdatedAt,
+		UpdatedAt: changes.UpdatedAt}) {
+		return ErrInvalidMembership
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return rowAffected(tr.DBPool.ExecContext(ctx, tr.dialect.updateMembership, listId, userId, memberId,
+		string(changes.Role), changes.UpdatedAt))
+}
+
+// DeleteMembership stops sharing a list of a user with a member.
+func (tr todoRepositoryImpl) DeleteMembership(ctx context.Context, listId string, userId string, memberId string) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return rowAffected(tr.DBPool.ExecContext(ctx, tr.dialect.deleteMembership, listId, userId, memberId))
+}
+
+func membershipInUTC(membership *model.Membership) {
+	membership.CreatedAt = membership.CreatedAt.UTC()
+	membership.UpdatedAt = membership.UpdatedAt.UTC()
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var membershipColumnNames = []string{"list_id", "user_id", "role", "created_at", "updated_at"}
+
+func TestGetTodoAccess(t *testing.T) {
+	userId, todoId, listId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(todoAccessQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "list_id", "role"}).AddRow("wehgowe", listId, "editor"))
+		access, err := todoRepository.GetTodoAccess(context.Background(), todoId, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Access{OwnerId: "wehgowe", ListId: listId, Role: model.RoleEditor}, access)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the user has no access to the todo", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(todoAccessQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "list_id", "role"}))
+		access, err := todoRepository.GetTodoAccess(context.Background(), todoId, userId)
+		assert.Nil(t, access)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the query fails", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(todoAccessQuery).WithArgs(todoId, userId).WillReturnError(common.ErrError)
+		access, err := todoRepository.GetTodoAccess(context.Background(), todoId, userId)
+		assert.Nil(t, access)
+		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func TestGetListAccess(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(listAccessQuery).WithArgs(listId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).AddRow(userId, "owner"))
+		access, err := todoRepository.GetListAccess(context.Background(), listId, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Access{OwnerId: userId, ListId: listId, Role: model.RoleOwner}, access)
+	})
+
+	t.Run("When the user has no access to the list", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(listAccessQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}))
+		access, err := todoRepository.GetListAccess(context.Background(), listId, userId)
+		assert.Nil(t, access)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestGetMemberships(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		wantedMemberships := []model.Membership{
+			{ListId: listId, UserId: "wehgowe", Role: model.RoleEditor, CreatedAt: ti, UpdatedAt: ti},
+			{ListId: listId, UserId: "hwoegwe", Role: model.RoleViewer, CreatedAt: ti, UpdatedAt: ti},
+		}
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectQuery(membershipsQuery).WithArgs(listId).WillReturnRows(sqlmock.NewRows(membershipColumnNames).
+			AddRow(listId, "wehgowe", "editor", ti.Local(), ti.Local()).
+			AddRow(listId, "hwoegwe", "viewer", ti.Local(), ti.Local()))
+		memberships, err := todoRepository.GetMemberships(context.Background(), listId, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, wantedMemberships, memberships)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the list isn't a list of the user", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}))
+		memberships, err := todoRepository.GetMemberships(context.Background(), listId, userId)
+		assert.Nil(t, memberships)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestCreateMembership(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	membership := model.Membership{ListId: listId, UserId: "wehgowe", Role: model.RoleEditor, CreatedAt: ti, UpdatedAt: ti}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectExec(insertMembershipQuery).WithArgs(listId, "wehgowe", "editor", ti, ti).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.CreateMembership(context.Background(), &membership, userId)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the membership is not valid", func(t *testing.T) {
+		for _, invalid := range []*model.Membership{
+			nil,
+			{ListId: listId, UserId: "wehgowe", Role: model.RoleOwner, CreatedAt: ti, UpdatedAt: ti},
+			{ListId: listId, UserId: userId, Role: model.RoleViewer, CreatedAt: ti, UpdatedAt: ti},
+		} {
+			todoRepository, mock := create(t)
+			err := todoRepository.CreateMembership(context.Background(), invalid, userId)
+			assert.Equal(t, ErrInvalidMembership, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When the list is the inbox", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(true))
+		mock.ExpectRollback()
+		err := todoRepository.CreateMembership(context.Background(), &membership, userId)
+		assert.Equal(t, ErrInboxList, err)
+	})
+
+	t.Run("When the list is already shared with the user", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectExec(insertMembershipQuery).WithArgs(listId, "wehgowe", "editor", ti, ti).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		err := todoRepository.CreateMembership(context.Background(), &membership, userId)
+		assert.Equal(t, ErrDuplicateMembership, err)
+	})
+}
+
+func TestUpdateMembership(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	changes := model.MembershipChanges{Role: model.RoleViewer, UpdatedAt: ti}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(updateMembershipQuery).WithArgs(listId, userId, "wehgowe", "viewer", ti).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.UpdateMembership(context.Background(), listId, userId, "wehgowe", changes)
+		assert.NoError(t, err)
+	})
+
+	t.Run("When the membership doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(updateMembershipQuery).WithArgs(listId, userId, "wehgowe", "viewer", ti).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		err := todoRepository.UpdateMembership(context.Background(), listId, userId, "wehgowe", changes)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the role is not valid", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		err := todoRepository.UpdateMembership(context.Background(), listId, userId, "wehgowe",
+			model.MembershipChanges{Role: model.RoleOwner, UpdatedAt: ti})
+		assert.Equal(t, ErrInvalidMembership, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestDeleteMembership(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(deleteMembershipQuery).WithArgs(listId, userId, "wehgowe").WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.DeleteMembership(context.Background(), listId, userId, "wehgowe")
+		assert.NoError(t, err)
+	})
+
+	t.Run("When the membership doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(deleteMembershipQuery).WithArgs(listId, userId, "wehgowe").WillReturnResult(sqlmock.NewResult(0, 0))
+		err := todoRepository.DeleteMembership(context.Background(), listId, userId, "wehgowe")
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrAnchorNotFound = errors.New("the todo to move next to doesn't exist")
+
+const (
+	// The position column compares byte by byte, as model.PositionBetween
+	// expects. The positions of the todos in the trash are kept so that a
+	// restored todo goes back to where it was.
+	lastPositionQuery   string = "select coalesce(max(position), '') from todo where user_id = $1"
+	anchorPositionQuery string = "select position from todo where id = $1::UUID and user_id = $2 and deleted_at is null"
+	positionBeforeQuery string = "select coalesce(max(position), '') from todo where user_id = $1 and position < $2 and id <> $3::UUID"
+	positionAfterQuery  string = "select coalesce(min(position), '') from todo where user_id = $1 and position > $2 and id <> $3::UUID"
+	moveQuery           string = "update todo set position = $3, updated_at = $4::timestamptz, version = version + 1 " +
+		"where id = $1::UUID and user_id = $2 and deleted_at is null"
+)
+
+// lastPosition returns the position after which a new todo of userId goes,
+// which is empty when they have none.
+func (tr todoRepositoryImpl) lastPosition(ctx context.Context, userId string) (string, error) {
+	var last string
+	err := tr.DBPool.QueryRowContext(ctx, tr.dialect.lastPosition, userId).Scan(&last)
+	return last, err
+}
+
+// Move puts the todo right before or right after the todo that move names,
+// at movedAt. Only the position of the todo is written, between the one of
+// the anchor and the one of its neighbour, so moves never renumber the other
+// todos.
+func (tr todoRepositoryImpl) Move(ctx context.Context, id string, userId string, move model.MoveRequest,
+	movedAt time.Time) (err error) {
+	if err := move.Validate(id); err != nil {
+		return err
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := tx.atVersion(ctx, id, userId, AnyVersion); err != nil {
+			return err
+		}
+		var anchor string
+		if err := tx.DBPool.QueryRowContext(ctx, tx.dialect.anchorPosition, move.Anchor(), userId).Scan(&anchor); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrAnchorNotFound
+			}
+			return err
+		}
+		neighbourQuery := tx.dialect.positionAfter
+		if move.Before != "" {
+			neighbourQuery = tx.dialect.positionBefore
+		}
+		var neighbour string
+		if err := tx.DBPool.QueryRowContext(ctx, neighbourQuery, userId, anchor, id).Scan(&neighbour); err != nil {
+			return err
+		}
+		lower, upper := anchor, neighbour
+		if move.Before != "" {
+			lower, upper = neighbour, anchor
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.move, id, userId,
+			model.PositionBetween(lower, upper, id), movedAt))
+	})
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMove(t *testing.T) {
+	movedAt := time.Date(2022, 9, 21, 14, 7, 5, 0, time.UTC)
+
+	t.Run("Good case: before a todo", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, todoId, anchorId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectQuery(anchorPositionQuery).WithArgs(anchorId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("W"))
+		mock.ExpectQuery(positionBeforeQuery).WithArgs(userId, "W", todoId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("V"))
+		mock.ExpectExec(moveQuery).WithArgs(todoId, userId, model.PositionBetween("V", "W", todoId), movedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Move(context.Background(), todoId, userId, model.MoveRequest{Before: anchorId}, movedAt)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case: after the last todo", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, todoId, anchorId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectQuery(anchorPositionQuery).WithArgs(anchorId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("W"))
+		mock.ExpectQuery(positionAfterQuery).WithArgs(userId, "W", todoId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(moveQuery).WithArgs(todoId, userId, model.PositionBetween("W", "", todoId), movedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Move(context.Background(), todoId, userId, model.MoveRequest{After: anchorId}, movedAt)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the request is invalid", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		todoId := uuid.New().String()
+		err := todoRepository.Move(context.Background(), todoId, uuid.New().String(), model.MoveRequest{After: todoId}, movedAt)
+		assert.Equal(t, model.ErrInvalidMove, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the todo doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, todoId, anchorId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		err := todoRepository.Move(context.Background(), todoId, userId, model.MoveRequest{Before: anchorId}, movedAt)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the anchor doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, todoId, anchorId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectQuery(anchorPositionQuery).WithArgs(anchorId, userId).WillReturnRows(sqlmock.NewRows([]string{"position"}))
+		mock.ExpectRollback()
+		err := todoRepository.Move(context.Background(), todoId, userId, model.MoveRequest{Before: anchorId}, movedAt)
+		assert.Equal(t, ErrAnchorNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When DBPool.Exec returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId, todoId, anchorId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectQuery(anchorPositionQuery).WithArgs(anchorId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("W"))
+		mock.ExpectQuery(positionAfterQuery).WithArgs(userId, "W", todoId).
+			WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("X"))
+		mock.ExpectExec(moveQuery).WithArgs(todoId, userId, model.PositionBetween("W", "X", todoId), movedAt).
+			WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Move(context.Background(), todoId, userId, model.MoveRequest{After: anchorId}, movedAt)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
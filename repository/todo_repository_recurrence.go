@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+)
+
+// recurringTodoQuery only finds the todo when it recurs and was done at the
+// time, which tells a todo that a write has just done from one that was
+// done before, as the write keeps the completed_at of a todo that was.
+const recurringTodoQuery string = "select " + todoColumns + ", " + tagsColumn + " from todo " +
+	"where id = $1::UUID and user_id = $2 and deleted_at is null and completed_at = $3::timestamptz and recurrence <> ''"
+
+// recurring runs write, which may make the todo id done at doneAt when done
+// is set, and then creates the next occurrence of the todo when it recurs,
+// in the same transaction.
+func (tr todoRepositoryImpl) recurring(ctx context.Context, id string, userId string, done bool, doneAt time.Time,
+	write func(tx todoRepositoryImpl) error) error {
+	if !done {
+		return write(tr)
+	}
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		var todo model.Todo
+		if err := tx.DBPool.QueryRowContext(ctx, tx.dialect.recurringTodo, id, userId, doneAt).Scan(todoFields(&todo)...); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		inUTC(&todo)
+		nextId, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		next := todo.NextOccurrence(nextId.String(), doneAt)
+		if next == nil {
+			return nil
+		}
+		return tx.Create(ctx, next, userId)
+	})
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurring(t *testing.T) {
+	userId, todoId, listId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	done, updatedAt := true, time.Date(2022, 9, 21, 18, 0, 0, 0, time.UTC)
+	dueAt, nextDueAt := time.Date(2022, 9, 21, 7, 0, 0, 0, time.UTC), time.Date(2022, 9, 26, 7, 0, 0, 0, time.UTC)
+	patchDoneQuery := "update todo set updated_at = $3::timestamptz, done = $4, " +
+		"completed_at = case when $4 then coalesce(completed_at, $3::timestamptz) else null end, version = version + 1 " +
+		"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)"
+	recurringRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(todoColumnNames).AddRow(todoId, "title1", "description1", true, updatedAt.Add(-time.Hour),
+			updatedAt, updatedAt, int64(4), nil, listId, nil, dueAt, false, "Europe/Berlin", 3, "V",
+			"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3", int64(0), int64(0), int64(0), `["home"]`)
+	}
+
+	t.Run("Good case: completing a todo that recurs creates its next occurrence", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(patchDoneQuery).WithArgs(todoId, userId, updatedAt, done, AnyVersion).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(recurringTodoQuery).WithArgs(todoId, userId, updatedAt).WillReturnRows(recurringRows())
+		mock.ExpectQuery(listArchivedQuery).WithArgs(listId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(false))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("V"))
+		mock.ExpectExec(insertTodoQuery).WithArgs(sqlmock.AnyArg(), "title1", "description1", false, updatedAt, updatedAt,
+			nil, model.FirstVersion, userId, listId, nil, nextDueAt, false, nextDueAt, "Europe/Berlin", 3, sqlmock.AnyArg(),
+			"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=2").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteTodoTagsQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertTagsQuery).WithArgs(userId, `["home"]`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertTodoTagsQuery).WithArgs(sqlmock.AnyArg(), userId, `["home"]`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Done: &done, UpdatedAt: updatedAt},
+			AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the todo doesn't recur or was done before", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(patchDoneQuery).WithArgs(todoId, userId, updatedAt, done, AnyVersion).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(recurringTodoQuery).WithArgs(todoId, userId, updatedAt).WillReturnRows(sqlmock.NewRows(todoColumnNames))
+		mock.ExpectCommit()
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Done: &done, UpdatedAt: updatedAt},
+			AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When a patch removes the recurrence", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, done = $4, "+
+			"completed_at = case when $4 then coalesce(completed_at, $3::timestamptz) else null end, "+
+			"due_at = $5::timestamptz, due_all_day = $6, overdue_at = $7::timestamptz, timezone = $8, recurrence = $9, "+
+			"version = version + 1 where id = $1::UUID and user_id = $2 and deleted_at is null and ($10 = 0 or version = $10)").
+			WithArgs(todoId, userId, updatedAt, done, nil, false, nil, "", "", AnyVersion).WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.Patch(context.Background(), todoId, userId,
+			model.TodoChanges{Done: &done, Due: &model.DueChange{}, UpdatedAt: updatedAt}, AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the next occurrence can't be created", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(patchDoneQuery).WithArgs(todoId, userId, updatedAt, done, AnyVersion).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(recurringTodoQuery).WithArgs(todoId, userId, updatedAt).WillReturnRows(recurringRows())
+		mock.ExpectQuery(listArchivedQuery).WithArgs(listId, userId).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Done: &done, UpdatedAt: updatedAt},
+			AnyVersion)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrInvalidShareLink = errors.New("invalid share link")
+var ErrShareLinkExpired = errors.New("the share link has expired")
+
+const (
+	shareLinkColumns string = "token, todo_id, list_id, expires_at, access_count, last_accessed_at, created_at"
+	// A target of a share link is passed as the todo id and the list id, one
+	// of which is null.
+	shareLinkQuery       string = "select " + shareLinkColumns + " from share_link where (todo_id = $1::UUID or list_id = $2::UUID) and user_id = $3"
+	insertShareLinkQuery string = "insert into share_link (" + shareLinkColumns + ", user_id) " +
+		"values ($1, $2::UUID, $3::UUID, $4::timestamptz, 0, null, $5::timestamptz, $6)"
+	// A new link takes the place of the one its todo or its list had in the
+	// same statement, so two links that are created at once can't race.
+	replaceShareLink string = " do update set token = excluded.token, expires_at = excluded.expires_at, " +
+		"access_count = 0, last_accessed_at = null, created_at = excluded.created_at"
+	upsertTodoLinkQuery  string = insertShareLinkQuery + " on conflict (todo_id)" + replaceShareLink
+	upsertListLinkQuery  string = insertShareLinkQuery + " on conflict (list_id)" + replaceShareLink
+	deleteShareLinkQuery string = "delete from share_link where (todo_id = $1::UUID or list_id = $2::UUID) and user_id = $3"
+	// openedShareLinkQuery doesn't find the link of a todo in the trash.
+	openedShareLinkQuery string = "select " + shareLinkColumns + ", user_id from share_link " +
+		"where token = $1 and (todo_id is null or todo_id in (select id from todo where deleted_at is null))"
+	countShareLinkQuery string = "update share_link set access_count = access_count + 1, last_accessed_at = $2::timestamptz " +
+		"where token = $1"
+)
+
+func shareLinkFields(link *model.ShareLink) []any {
+	return []any{&link.Token, (*nullableId)(&link.TodoId), (*nullableId)(&link.ListId), &link.ExpiresAt, &link.AccessCount,
+		&link.LastAccessedAt, &link.CreatedAt}
+}
+
+// GetShareLink returns the share link of a todo or a list of a user.
+func (tr todoRepositoryImpl) GetShareLink(ctx context.Context, target model.ShareTarget, userId string) (_ *model.ShareLink,
+	err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	var link model.ShareLink
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.shareLink, nullIfEmpty(target.TodoId), nullIfEmpty(target.ListId),
+		userId).Scan(shareLinkFields(&link)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	link.OwnerId = userId
+	shareLinkInUTC(&link)
+	return &link, nil
+}
+
+// CreateShareLink shares a todo of a user outside the trash, or a list of
+// theirs that isn't their inbox, through a new share link, which replaces the
+// one it had.
+func (tr todoRepositoryImpl) CreateShareLink(ctx context.Context, link *model.ShareLink, userId string) (err error) {
+	if link == nil || !model.IsValid(link) {
+		return ErrInvalidShareLink
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if link.TodoId != "" {
+			if err := tx.todoExists(ctx, link.TodoId, userId); err != nil {
+				return err
+			}
+		} else if inbox, err := tx.isInbox(ctx, link.ListId, userId); err != nil {
+			return err
+		} else if inbox {
+			return ErrInboxList
+		}
+		query := tx.dialect.upsertListLink
+		if link.TodoId != "" {
+			query = tx.dialect.upsertTodoLink
+		}
+		_, err := tx.DBPool.ExecContext(ctx, query, link.Token, nullIfEmpty(link.TodoId), nullIfEmpty(link.ListId),
+			link.ExpiresAt, link.CreatedAt, userId)
+		return err
+	})
+}
+
+// DeleteShareLink revokes the share link of a todo or a list of a user.
+func (tr todoRepositoryImpl) DeleteShareLink(ctx context.Context, target model.ShareTarget, userId string) (err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return rowAffected(tr.DBPool.ExecContext(ctx, tr.dialect.deleteShareLink, nullIfEmpty(target.TodoId),
+		nullIfEmpty(target.ListId), userId))
+}
+
+// OpenShareLink returns the share link of token, with its owner, once it
+// isn't expired at openedAt, and counts that it was opened then.
+func (tr todoRepositoryImpl) OpenShareLink(ctx context.Context, token string, openedAt time.Time) (_ *model.ShareLink,
+	err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	var link model.ShareLink
+	err = tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := tx.DBPool.QueryRowContext(ctx, tx.dialect.openedShareLink, token).Scan(append(shareLinkFields(&link),
+			&link.OwnerId)...); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		} else if link.IsExpired(openedAt) {
+			return ErrShareLinkExpired
+		}
+		if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.countShareLink, token, openedAt); err != nil {
+			return err
+		}
+		link.AccessCount++
+		link.LastAccessedAt = &openedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	shareLinkInUTC(&link)
+	return &link, nil
+}
+
+func shareLinkInUTC(link *model.ShareLink) {
+	link.CreatedAt = link.CreatedAt.UTC()
+	if link.ExpiresAt != nil {
+		expiresAt := link.ExpiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+	if link.LastAccessedAt != nil {
+		lastAccessedAt := link.LastAccessedAt.UTC()
+		link.LastAccessedAt = &lastAccessedAt
+	}
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var shareLinkColumnNames = []string{"token", "todo_id", "list_id", "expires_at", "access_count", "last_accessed_at",
+	"created_at"}
+
+func TestGetShareLink(t *testing.T) {
+	userId, todoId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	target := model.ShareTarget{TodoId: todoId}
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		expiresAt := ti.Add(time.Hour)
+		wantedLink := &model.ShareLink{Token: "hweogwe", ShareTarget: target, ExpiresAt: &expiresAt, AccessCount: 3,
+			LastAccessedAt: &ti, CreatedAt: ti, OwnerId: userId}
+		mock.ExpectQuery(shareLinkQuery).WithArgs(todoId, nil, userId).WillReturnRows(sqlmock.NewRows(shareLinkColumnNames).
+			AddRow("hweogwe", todoId, nil, expiresAt.Local(), 3, ti.Local(), ti.Local()))
+		link, err := todoRepository.GetShareLink(context.Background(), target, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, wantedLink, link)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the todo has no share link", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectQuery(shareLinkQuery).WithArgs(todoId, nil, userId).WillReturnRows(sqlmock.NewRows(shareLinkColumnNames))
+		link, err := todoRepository.GetShareLink(context.Background(), target, userId)
+		assert.Nil(t, link)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestCreateShareLink(t *testing.T) {
+	userId, todoId, listId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+
+	t.Run("Good case: a todo", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{TodoId: todoId}, CreatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectExec(upsertTodoLinkQuery).WithArgs("hweogwe", todoId, nil, (*time.Time)(nil), ti, userId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.CreateShareLink(context.Background(), &link, userId)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Good case: a list", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		expiresAt := ti.Add(time.Hour)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{ListId: listId}, ExpiresAt: &expiresAt,
+			CreatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(false))
+		mock.ExpectExec(upsertListLinkQuery).WithArgs("hweogwe", nil, listId, &expiresAt, ti, userId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.CreateShareLink(context.Background(), &link, userId)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the share link is not valid", func(t *testing.T) {
+		for _, invalid := range []*model.ShareLink{
+			nil,
+			{Token: "hweogwe", CreatedAt: ti},
+			{ShareTarget: model.ShareTarget{TodoId: todoId}, CreatedAt: ti},
+		} {
+			todoRepository, mock := create(t)
+			err := todoRepository.CreateShareLink(context.Background(), invalid, userId)
+			assert.Equal(t, ErrInvalidShareLink, err)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When the todo isn't a todo of the user", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{TodoId: todoId}, CreatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		err := todoRepository.CreateShareLink(context.Background(), &link, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the list is the inbox", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		link := model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{ListId: listId}, CreatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectQuery(listInboxQuery).WithArgs(listId, userId).WillReturnRows(sqlmock.NewRows([]string{"inbox"}).AddRow(true))
+		mock.ExpectRollback()
+		err := todoRepository.CreateShareLink(context.Background(), &link, userId)
+		assert.Equal(t, ErrInboxList, err)
+	})
+}
+
+func TestDeleteShareLink(t *testing.T) {
+	userId, listId := uuid.New().String(), uuid.New().String()
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(deleteShareLinkQuery).WithArgs(nil, listId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		err := todoRepository.DeleteShareLink(context.Background(), model.ShareTarget{ListId: listId}, userId)
+		assert.NoError(t, err)
+	})
+
+	t.Run("When the list has no share link", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectExec(deleteShareLinkQuery).WithArgs(nil, listId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+		err := todoRepository.DeleteShareLink(context.Background(), model.ShareTarget{ListId: listId}, userId)
+		assert.Equal(t, ErrNotFound, err)
+	})
+}
+
+func TestOpenShareLink(t *testing.T) {
+	userId, todoId := uuid.New().String(), uuid.New().String()
+	ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+	openedAt := ti.Add(time.Minute)
+	columnNames := append(shareLinkColumnNames, "user_id")
+
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		expiresAt := ti.Add(time.Hour)
+		wantedLink := &model.ShareLink{Token: "hweogwe", ShareTarget: model.ShareTarget{TodoId: todoId},
+			ExpiresAt: &expiresAt, AccessCount: 4, LastAccessedAt: &openedAt, CreatedAt: ti, OwnerId: userId}
+		mock.ExpectBegin()
+		mock.ExpectQuery(openedShareLinkQuery).WithArgs("hweogwe").WillReturnRows(sqlmock.NewRows(columnNames).
+			AddRow("hweogwe", todoId, nil, expiresAt.Local(), 3, ti.Local(), ti.Local(), userId))
+		mock.ExpectExec(countShareLinkQuery).WithArgs("hweogwe", openedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		link, err := todoRepository.OpenShareLink(context.Background(), "hweogwe", openedAt)
+		assert.NoError(t, err)
+		assert.Equal(t, wantedLink, link)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the share link has expired", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(openedShareLinkQuery).WithArgs("hweogwe").WillReturnRows(sqlmock.NewRows(columnNames).
+			AddRow("hweogwe", todoId, nil, openedAt, 3, ti, ti, userId))
+		mock.ExpectRollback()
+		link, err := todoRepository.OpenShareLink(context.Background(), "hweogwe", openedAt)
+		assert.Nil(t, link)
+		assert.Equal(t, ErrShareLinkExpired, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When there is no share link with the token", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(openedShareLinkQuery).WithArgs("hweogwe").WillReturnRows(sqlmock.NewRows(columnNames))
+		mock.ExpectRollback()
+		link, err := todoRepository.OpenShareLink(context.Background(), "hweogwe", openedAt)
+		assert.Nil(t, link)
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("When the query fails", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(openedShareLinkQuery).WithArgs("hweogwe").WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		link, err := todoRepository.OpenShareLink(context.Background(), "hweogwe", openedAt)
+		assert.Nil(t, link)
+		assert.Equal(t, common.ErrError, err)
+	})
+}
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed sqlite_schema/*.sql
+var sqliteSchemaFiles embed.FS
+
+// sqliteOptions open the database in WAL mode, so that reads don't wait for
+// a write, begin every transaction as a write, and wait for the write lock
+// that another process holds instead of failing at once.
+const sqliteOptions string = "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate&_foreign_keys=on"
+
+const (
+	sqliteInsertTodoQuery string = "insert into todo (id, title, description, done, created_at, updated_at, completed_at, version, user_id, list_id, parent_id, " +
+		"due_at, due_all_day, overdue_at, timezone, priority, position, recurrence) " +
+		"values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15, ?16, ?17, ?18)"
+	// sqliteTagsColumn is the tagsColumn of SQLite, which can't order what it
+	// aggregates.
+	sqliteTagsColumn string = "(select json_group_array(tag.name) from todo_tag join tag on tag.id = todo_tag.tag_id " +
+		"where todo_tag.todo_id = todo.id)"
+	sqliteAllTodosQuery     string = "select " + todoColumns + ", " + sqliteTagsColumn + " from todo where user_id = ?1 and deleted_at is null order by created_at desc"
+	sqliteSpecificTodoQuery string = "select " + todoColumns + ", " + sqliteTagsColumn + " from todo where id = ?1 and user_id = ?2 and deleted_at is null"
+	sqliteUpdateQuery       string = "update todo set title = ?2, description = ?3, done = ?4, updated_at = ?5, " +
+		"completed_at = case when ?4 then coalesce(completed_at, ?5) else null end, " +
+		"list_id = coalesce(?8, list_id), due_at = ?9, due_all_day = ?10, overdue_at = ?11, timezone = ?12, priority = ?13, " +
+		"recurrence = ?14, version = version + 1 " +
+		"where id = ?1 and user_id = ?6 and deleted_at is null and (?7 = 0 or version = ?7)"
+	sqliteDeleteQuery string = "update todo set deleted_at = ?4, version = version + 1 " +
+		"where id = ?1 and user_id = ?2 and deleted_at is null and (?3 = 0 or version = ?3)"
+	sqliteVersionQuery string = "select version from todo where id = ?1 and user_id = ?2 and deleted_at is null"
+	sqliteTrashQuery   string = "select " + todoColumns + ", " + sqliteTagsColumn + " from todo where user_id = ?1 and deleted_at is not null " +
+		"order by deleted_at desc, id desc"
+	sqliteRestoreQuery        string = "update todo set deleted_at = null, version = version + 1 where id = ?1 and user_id = ?2 and deleted_at is not null"
+	sqlitePurgeQuery          string = "delete from todo where id = ?1 and user_id = ?2 and deleted_at is not null"
+	sqlitePurgeTrashQuery     string = "delete from todo where deleted_at < ?1"
+	sqliteDeleteTodoTagsQuery string = "delete from todo_tag where todo_id = ?1"
+	sqliteInsertTagsQuery     string = "insert into tag (user_id, name) select ?1, value from json_each(?2) where true " +
+		"on conflict (user_id, name) do nothing"
+	sqliteInsertTodoTagsQuery string = "insert into todo_tag (todo_id, tag_id) select ?1, id from tag " +
+		"where user_id = ?2 and name in (select value from json_each(?3))"
+	sqliteAllTagsQuery string = "select tag.name, count(todo.id) from tag left join todo_tag on todo_tag.tag_id = tag.id " +
+		"left join todo on todo.id = todo_tag.todo_id and todo.deleted_at is null where tag.user_id = ?1 " +
+		"group by tag.id, tag.name order by tag.name"
+	sqliteTagExistsQuery   string = "select count(*) from tag where user_id = ?1 and name = ?2"
+	sqliteTouchTaggedQuery string = "update todo set version = version + 1 where id in (select todo_tag.todo_id from todo_tag " +
+		"join tag on tag.id = todo_tag.tag_id where tag.user_id = ?1 and tag.name = ?2)"
+	sqliteRenameTagQuery string = "update tag set name = ?3 where user_id = ?1 and name = ?2"
+	sqliteMergeTagQuery  string = "insert into todo_tag (todo_id, tag_id) select todo_tag.todo_id, target.id from todo_tag " +
+		"join tag on tag.id = todo_tag.tag_id join tag target on target.user_id = tag.user_id and target.name = ?3 " +
+		"where tag.user_id = ?1 and tag.name = ?2 on conflict do nothing"
+	sqliteDeleteTagQuery   string = "delete from tag where user_id = ?1 and name = ?2"
+	sqliteInboxQuery       string = "select id from list where user_id = ?1 and inbox"
+	sqliteInsertInboxQuery string = "insert into list (id, user_id, name, inbox, created_at, updated_at) " +
+		"values (?1, ?2, ?3, true, ?4, ?4) on conflict (user_id) where inbox do nothing"
+	sqliteListArchivedQuery string = "select archived_at is not null from list where id = ?1 and user_id = ?2"
+	sqliteListInboxQuery    string = "select inbox from list where id = ?1 and user_id = ?2"
+	sqliteAllListsQuery     string = "select " + listColumns + " from list where user_id = ?1 and (?2 or archived_at is null) " +
+		"order by inbox desc, created_at, id"
+	sqliteSpecificListQuery string = "select " + listColumns + " from list where id = ?1 and user_id = ?2"
+	sqliteInsertListQuery   string = "insert into list (id, user_id, name, created_at, updated_at) values (?1, ?2, ?3, ?4, ?5)"
+	sqlitePatchListQuery    string = "update list set name = coalesce(?3, name), archived_at = case when ?4 is null then archived_at " +
+		"when ?4 then coalesce(archived_at, ?5) else null end, updated_at = ?5 where id = ?1 and user_id = ?2 and not inbox"
+	sqliteMoveListTodosQuery string = "update todo set list_id = ?3, version = version + 1 where list_id = ?1 and user_id = ?2"
+	sqliteDeleteListQuery    string = "delete from list where id = ?1 and user_id = ?2 and not inbox"
+	sqliteParentQuery        string = "with recursive ancestor (id, parent_id) as (select id, parent_id from todo where id = ?1 " +
+		"union all select todo.id, todo.parent_id from todo join ancestor on todo.id = ancestor.parent_id) " +
+		"select list_id, (select count(*) from ancestor) from todo where id = ?1 and user_id = ?2 and deleted_at is null"
+	sqliteParentIdQuery       string = "select parent_id from todo where id = ?1 and user_id = ?2"
+	sqliteCompleteParentQuery string = "update todo set done = true, completed_at = coalesce(completed_at, ?3), " +
+		"updated_at = ?3, version = version + 1 where id = ?1 and user_id = ?2 and not done and deleted_at is null " +
+		"and not exists (select 1 from todo child where child.parent_id = todo.id and child.deleted_at is null and not child.done)"
+	sqliteDescendantsCTE string = "with recursive descendant (id) as (select id from todo where parent_id = ?1 " +
+		"union all select todo.id from todo join descendant on todo.parent_id = descendant.id) "
+	sqliteDeleteSubtasksQuery string = sqliteDescendantsCTE + "update todo set deleted_at = ?3, version = version + 1 " +
+		"where id in (select id from descendant) and user_id = ?2 and deleted_at is null"
+	sqliteParentInTrashQuery string = "select parent.deleted_at is not null from todo left join todo parent on parent.id = todo.parent_id " +
+		"where todo.id = ?1 and todo.user_id = ?2 and todo.deleted_at is not null"
+	sqliteRestoreSubtasksQuery string = sqliteDescendantsCTE + "update todo set deleted_at = null, version = version + 1 " +
+		"where id in (select id from descendant) and user_id = ?2 and deleted_at = (select deleted_at from todo where id = ?1)"
+	sqliteSubtasksQuery string = "select " + todoColumns + ", " + sqliteTagsColumn + " from todo " +
+		"where parent_id = ?1 and user_id = ?2 and deleted_at is null order by created_at, id"
+	sqliteDescendantsQuery string = sqliteDescendantsCTE + "select " + todoColumns + ", " + sqliteTagsColumn + " from todo " +
+		"where id in (select id from descendant) and user_id = ?2 and deleted_at is null order by created_at, id"
+	sqliteLastPositionQuery   string = "select coalesce(max(position), '') from todo where user_id = ?1"
+	sqliteAnchorPositionQuery string = "select position from todo where id = ?1 and user_id = ?2 and deleted_at is null"
+	sqlitePositionBeforeQuery string = "select coalesce(max(position), '') from todo where user_id = ?1 and position < ?2 and id <> ?3"
+	sqlitePositionAfterQuery  string = "select coalesce(min(position), '') from todo where user_id = ?1 and position > ?2 and id <> ?3"
+	sqliteMoveQuery           string = "update todo set position = ?3, updated_at = ?4, version = version + 1 " +
+		"where id = ?1 and user_id = ?2 and deleted_at is null"
+	sqliteRecurringTodoQuery string = "select " + todoColumns + ", " + sqliteTagsColumn + " from todo " +
+		"where id = ?1 and user_id = ?2 and deleted_at is null and completed_at = ?3 and recurrence <> ''"
+	sqliteTodoAccessQuery string = "select todo.user_id, todo.list_id, case when todo.user_id = ?2 then 'owner' else list_member.role end " +
+		"from todo left join list_member on list_member.list_id = todo.list_id and list_member.user_id = ?2 " +
+		"where todo.id = ?1 and todo.deleted_at is null and (todo.user_id = ?2 or list_member.user_id is not null)"
+	sqliteListAccessQuery string = "select list.user_id, case when list.user_id = ?2 then 'owner' else list_member.role end " +
+		"from list left join list_member on list_member.list_id = list.id and list_member.user_id = ?2 " +
+		"where list.id = ?1 and (list.user_id = ?2 or list_member.user_id is not null)"
+	sqliteSharedListsQuery string = "select " + listColumns + ", user_id, " +
+		"(select role from list_member where list_member.list_id = list.id and list_member.user_id = ?1) from list " +
+		"where id in (select list_id from list_member where user_id = ?1) and (?2 or archived_at is null) order by created_at, id"
+	sqliteMembershipsQuery      string = "select " + membershipColumns + " from list_member where list_id = ?1 order by created_at, user_id"
+	sqliteInsertMembershipQuery string = "insert into list_member (" + membershipColumns + ") " +
+		"values (?1, ?2, ?3, ?4, ?5) on conflict (list_id, user_id) do nothing"
+	sqliteUpdateMembershipQuery string = "update list_member set role = ?4, updated_at = ?5 " +
+		"where list_id = ?1 and user_id = ?3 and list_id in (select id from list where user_id = ?2)"
+	sqliteDeleteMembershipQuery string = "delete from list_member " +
+		"where list_id = ?1 and user_id = ?3 and list_id in (select id from list where user_id = ?2)"
+	sqliteShareLinkQuery       string = "select " + shareLinkColumns + " from share_link where (todo_id = ?1 or list_id = ?2) and user_id = ?3"
+	sqliteInsertShareLinkQuery string = "insert into share_link (" + shareLinkColumns + ", user_id) " +
+		"values (?1, ?2, ?3, ?4, 0, null, ?5, ?6)"
+	sqliteUpsertTodoLinkQuery  string = sqliteInsertShareLinkQuery + " on conflict (todo_id)" + replaceShareLink
+	sqliteUpsertListLinkQuery  string = sqliteInsertShareLinkQuery + " on conflict (list_id)" + replaceShareLink
+	sqliteDeleteShareLinkQuery string = "delete from share_link where (todo_id = ?1 or list_id = ?2) and user_id = ?3"
+	sqliteOpenedShareLinkQuery string = "select " + shareLinkColumns + ", user_id from share_link " +
+		"where token = ?1 and (todo_id is null or todo_id in (select id from todo where deleted_at is null))"
+	sqliteCountShareLinkQuery string = "update share_link set access_count = access_count + 1, last_accessed_at = ?2 " +
+		"where token = ?1"
+	sqliteCommentAuthorQuery string = "select user_id, created_at from todo_comment where id = ?1 and todo_id = ?2 " +
+		"and todo_id in (select id from todo where user_id = ?3 and deleted_at is null)"
+	sqliteInsertCommentQuery string = "insert into todo_comment (" + commentColumns + ") values (?1, ?2, ?3, ?4, ?5, ?6)"
+	sqliteUpdateCommentQuery string = "update todo_comment set body = ?2, updated_at = ?3 where id = ?1"
+	sqliteDeleteCommentQuery string = "delete from todo_comment where id = ?1"
+	userVersionQuery         string = "pragma user_version"
+)
+
+// sqliteDialect keeps the timestamps as text, which sorts as the times do
+// because sqliteConn passes them all in UTC.
+var sqliteDialect = dialect{
+	tags:             sqliteTagsColumn,
+	insertTodo:       sqliteInsertTodoQuery,
+	allTodos:         sqliteAllTodosQuery,
+	specificTodo:     sqliteSpecificTodoQuery,
+	update:           sqliteUpdateQuery,
+	delete:           sqliteDeleteQuery,
+	version:          sqliteVersionQuery,
+	trash:            sqliteTrashQuery,
+	restore:          sqliteRestoreQuery,
+	purge:            sqlitePurgeQuery,
+	purgeTrash:       sqlitePurgeTrashQuery,
+	deleteTodoTags:   sqliteDeleteTodoTagsQuery,
+	insertTags:       sqliteInsertTagsQuery,
+	insertTodoTags:   sqliteInsertTodoTagsQuery,
+	allTags:          sqliteAllTagsQuery,
+	tagExists:        sqliteTagExistsQuery,
+	touchTagged:      sqliteTouchTaggedQuery,
+	renameTag:        sqliteRenameTagQuery,
+	mergeTag:         sqliteMergeTagQuery,
+	deleteTag:        sqliteDeleteTagQuery,
+	inbox:            sqliteInboxQuery,
+	insertInbox:      sqliteInsertInboxQuery,
+	listArchived:     sqliteListArchivedQuery,
+	listInbox:        sqliteListInboxQuery,
+	allLists:         sqliteAllListsQuery,
+	specificList:     sqliteSpecificListQuery,
+	insertList:       sqliteInsertListQuery,
+	patchList:        sqlitePatchListQuery,
+	moveListTodos:    sqliteMoveListTodosQuery,
+	deleteList:       sqliteDeleteListQuery,
+	parent:           sqliteParentQuery,
+	parentId:         sqliteParentIdQuery,
+	completeParent:   sqliteCompleteParentQuery,
+	deleteSubtasks:   sqliteDeleteSubtasksQuery,
+	parentInTrash:    sqliteParentInTrashQuery,
+	restoreSubtasks:  sqliteRestoreSubtasksQuery,
+	subtasks:         sqliteSubtasksQuery,
+	descendants:      sqliteDescendantsQuery,
+	lastPosition:     sqliteLastPositionQuery,
+	anchorPosition:   sqliteAnchorPositionQuery,
+	positionBefore:   sqlitePositionBeforeQuery,
+	positionAfter:    sqlitePositionAfterQuery,
+	move:             sqliteMoveQuery,
+	recurringTodo:    sqliteRecurringTodoQuery,
+	todoAccess:       sqliteTodoAccessQuery,
+	listAccess:       sqliteListAccessQuery,
+	sharedLists:      sqliteSharedListsQuery,
+	memberships:      sqliteMembershipsQuery,
+	insertMembership: sqliteInsertMembershipQuery,
+	updateMembership: sqliteUpdateMembershipQuery,
+	deleteMembership: sqliteDeleteMembershipQuery,
+	shareLink:        sqliteShareLinkQuery,
+	upsertTodoLink:   sqliteUpsertTodoLinkQuery,
+	upsertListLink:   sqliteUpsertListLinkQuery,
+	deleteShareLink:  sqliteDeleteShareLinkQuery,
+	openedShareLink:  sqliteOpenedShareLinkQuery,
+	countShareLink:   sqliteCountShareLinkQuery,
+	commentAuthor:    sqliteCommentAuthorQuery,
+	insertComment:    sqliteInsertCommentQuery,
+	updateComment:    sqliteUpdateCommentQuery,
+	deleteComment:    sqliteDeleteCommentQuery,
+	placeholder:      func(n int) string { return fmt.Sprintf("?%d", n) },
+	titleLike:        `title like %s escape '\'`,
+	search:           searchSQLite,
+	conn:             func(tx dbConn) dbConn { return sqliteConn{conn: tx} },
+}
+
+// SQLiteDB is a SQLite database whose writes run one at a time, as SQLite
+// only lets one connection write at a time.
+type SQLiteDB struct {
+	*sql.DB
+	writes chan struct{}
+}
+
+// OpenSQLite opens the SQLite database in the file at path, creating it when
+// it doesn't exist, and brings its schema up to date.
+func OpenSQLite(path string) (*SQLiteDB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?"+sqliteOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySQLiteSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteDB{DB: db, writes: make(chan struct{}, 1)}, nil
+}
+
+// applySQLiteSchema runs the schema files that are newer than the
+// user_version of the database, which keeps the version of the last one.
+func applySQLiteSchema(db *sql.DB) error {
+	var userVersion int64
+	if err := db.QueryRow(userVersionQuery).Scan(&userVersion); err != nil {
+		return err
+	}
+	fileNames, err := fs.Glob(sqliteSchemaFiles, "sqlite_schema/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
+		version, err := strconv.ParseInt(strings.SplitN(path.Base(fileName), "_", 2)[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		if version <= userVersion {
+			continue
+		}
+		content, err := fs.ReadFile(sqliteSchemaFiles, fileName)
+		if err != nil {
+			return err
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(content)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("%s = %d", userVersionQuery, version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSQLiteTodoRepository returns a TodoRepository on top of db that times
+// out and creates inboxes like the one of GetTodoRepository.
+func GetSQLiteTodoRepository(db *SQLiteDB, queryTimeout time.Duration, rules SubtaskRules,
+	newId func() (uuid.UUID, error), now func() time.Time) (common.TodoRepository, error) {
+	if db == nil {
+		return nil, ErrDBPoolIsNil
+	}
+	inboxes := inboxSource{newId: newId, now: now}
+	return todoRepositoryImpl{DBPool: sqliteConn{conn: db.DB, writes: db.writes}, QueryTimeout: queryTimeout,
+		dialect: sqliteDialect, rules: rules, inboxes: inboxes,
+		transactions: &unitOfWorkImpl{DBPool: db.DB, dialect: sqliteDialect, rules: rules, inboxes: inboxes,
+			writes: db.writes}}, nil
+}
+
+// GetSQLiteUnitOfWork returns a UnitOfWork on top of db whose transactions
+// hold the write lock of db until they end.
+func GetSQLiteUnitOfWork(db *SQLiteDB, queryTimeout time.Duration, rules SubtaskRules, newId func() (uuid.UUID, error),
+	now func() time.Time) (common.UnitOfWork, error) {
+	if db == nil {
+		return nil, ErrDBPoolIsNil
+	}
+	return unitOfWorkImpl{DBPool: db.DB, QueryTimeout: queryTimeout, dialect: sqliteDialect, rules: rules,
+		inboxes: inboxSource{newId: newId, now: now}, writes: db.writes}, nil
+}
+
+// sqliteConn passes the times to SQLite in UTC, and takes the write lock of
+// its SQLiteDB for every statement that it executes. A nil writes means that
+// the transaction that conn runs already holds it.
+type sqliteConn struct {
+	conn   dbConn
+	writes chan struct{}
+}
+
+func (c sqliteConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if c.writes != nil {
+		if err := lockWrites(ctx, c.writes); err != nil {
+			return nil, err
+		}
+		defer func() { <-c.writes }()
+	}
+	return c.conn.ExecContext(ctx, query, argsInUTC(args)...)
+}
+
+func (c sqliteConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.conn.QueryContext(ctx, query, argsInUTC(args)...)
+}
+
+func (c sqliteConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.conn.QueryRowContext(ctx, query, argsInUTC(args)...)
+}
+
+// lockWrites waits for the write lock until ctx is done.
+func lockWrites(ctx context.Context, writes chan struct{}) error {
+	select {
+	case writes <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func argsInUTC(args []any) []any {
+	converted := make([]any, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			converted[i] = value.UTC()
+		case *time.Time:
+			if value != nil {
+				converted[i] = value.UTC()
+			} else {
+				converted[i] = value
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// searchSQLite ranks the todos of userId with searchTodos, as SQLite has no
+// full text search without an extension.
+func searchSQLite(ctx context.Context, conn dbConn, userId string, query string, limit int) ([]model.SearchResult, error) {
+	rows, err := conn.QueryContext(ctx, sqliteAllTodosQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+	return searchTodos(todos, query, limit), nil
+}
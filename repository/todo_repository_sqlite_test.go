@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenSQLite(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		db := openSQLite(t)
+		var journalMode string
+		assert.NoError(t, db.QueryRow("pragma journal_mode").Scan(&journalMode))
+		assert.Equal(t, "wal", journalMode)
+		var userVersion int64
+		assert.NoError(t, db.QueryRow(userVersionQuery).Scan(&userVersion))
+		assert.Equal(t, int64(10), userVersion)
+	})
+
+	t.Run("When it is opened again", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "todo.db")
+		db, err := OpenSQLite(path)
+		assert.NoError(t, err)
+		db.Close()
+		db, err = OpenSQLite(path)
+		assert.NoError(t, err)
+		db.Close()
+	})
+
+	t.Run("When the file can't be created", func(t *testing.T) {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "missing", "todo.db"))
+		assert.Nil(t, db)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetSQLiteTodoRepository(t *testing.T) {
+	t.Run("When SQLiteDB is nil", func(t *testing.T) {
+		todoRepository, err := GetSQLiteTodoRepository(nil, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+		assert.Nil(t, todoRepository)
+		assert.Equal(t, ErrDBPoolIsNil, err)
+	})
+}
+
+func TestSQLiteTodoRepository(t *testing.T) {
+	db := openSQLite(t)
+	todoRepository, err := GetSQLiteTodoRepository(db, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userId := uuid.New().String()
+	now := time.Now().Truncate(time.Microsecond)
+	todos := make([]model.Todo, 3)
+	for i := range todos {
+		todos[i] = newMemoryTodo(now.Add(time.Duration(i) * time.Second).In(time.FixedZone("UTC+2", 2*60*60)))
+		assert.NoError(t, todoRepository.Create(context.Background(), &todos[i], userId))
+	}
+
+	t.Run("GetAll and GetById", func(t *testing.T) {
+		all, err := todoRepository.GetAll(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[2].Id, todos[1].Id, todos[0].Id}, idsOf(all))
+		todo, err := todoRepository.GetById(context.Background(), todos[0].Id, userId)
+		assert.NoError(t, err)
+		assert.True(t, todos[0].CreatedAt.Equal(todo.CreatedAt))
+		assert.Equal(t, time.UTC, todo.CreatedAt.Location())
+		assert.False(t, *todo.Done)
+		_, err = todoRepository.GetById(context.Background(), todos[0].Id, uuid.New().String())
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("GetPage", func(t *testing.T) {
+		page, err := todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "TITLE"},
+			model.PageRequest{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[2].Id, todos[1].Id}, idsOf(page.Todos))
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{},
+			model.PageRequest{Limit: 2, Cursor: page.Next})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[0].Id}, idsOf(page.Todos))
+		page, err = todoRepository.GetPage(context.Background(), userId, model.TodoFilter{Title: "%"}, model.PageRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Todos)
+	})
+
+	t.Run("Update and Patch", func(t *testing.T) {
+		done := true
+		updatedAt := now.Add(time.Minute)
+		update := model.Todo{Id: todos[0].Id, Title: "title2", Description: "description2", Done: &done, UpdatedAt: updatedAt}
+		assert.NoError(t, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+		assert.Equal(t, ErrVersionMismatch, todoRepository.Update(context.Background(), &update, userId, model.FirstVersion))
+		description := "description3"
+		assert.NoError(t, todoRepository.Patch(context.Background(), todos[0].Id, userId,
+			model.TodoChanges{Description: &description, UpdatedAt: updatedAt.Add(time.Minute)}, AnyVersion))
+		todo, err := todoRepository.GetById(context.Background(), todos[0].Id, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, "title2", todo.Title)
+		assert.Equal(t, description, todo.Description)
+		assert.True(t, updatedAt.Equal(*todo.CompletedAt))
+		assert.Equal(t, model.FirstVersion+2, todo.Version)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		results, err := todoRepository.Search(context.Background(), userId, "title2", 10)
+		assert.NoError(t, err)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "<mark>title2</mark>", results[0].HighlightedTitle)
+		}
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		assert.NoError(t, todoRepository.Delete(context.Background(), todos[1].Id, userId, AnyVersion, now))
+		trash, err := todoRepository.GetTrash(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{todos[1].Id}, idsOf(trash))
+		assert.NoError(t, todoRepository.Restore(context.Background(), todos[1].Id, userId))
+		assert.NoError(t, todoRepository.Delete(context.Background(), todos[1].Id, userId, AnyVersion, now))
+		purged, err := todoRepository.PurgeTrash(context.Background(), now.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.Equal(t, ErrNotFound, todoRepository.Purge(context.Background(), todos[1].Id, userId))
+	})
+}
+
+func TestSQLiteConcurrentWrites(t *testing.T) {
+	db := openSQLite(t)
+	todoRepository, err := GetSQLiteTodoRepository(db, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitOfWork, err := GetSQLiteUnitOfWork(db, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userId := uuid.New().String()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			todo := newMemoryTodo(time.Now())
+			assert.NoError(t, todoRepository.Create(context.Background(), &todo, userId))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+				todo := newMemoryTodo(time.Now())
+				if err := tx.Create(context.Background(), &todo, userId); err != nil {
+					return err
+				}
+				_, err := tx.GetAll(context.Background(), userId)
+				return err
+			}))
+		}()
+	}
+	wg.Wait()
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 40)
+}
+
+func TestSQLiteSavepoint(t *testing.T) {
+	db := openSQLite(t)
+	unitOfWork, err := GetSQLiteUnitOfWork(db, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todoRepository, _ := GetSQLiteTodoRepository(db, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	userId := uuid.New().String()
+	kept, rolledBack := newMemoryTodo(time.Now()), newMemoryTodo(time.Now())
+	err = unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+		if err := tx.Create(context.Background(), &kept, userId); err != nil {
+			return err
+		}
+		return tx.Savepoint(context.Background(), func() error {
+			if err := tx.Create(context.Background(), &rolledBack, userId); err != nil {
+				return err
+			}
+			return ErrNotFound
+		})
+	})
+	assert.Equal(t, ErrNotFound, err)
+	// The inbox that Create put the todos in was rolled back with them.
+	kept.ListId, rolledBack.ListId = "", ""
+	err = unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+		if err := tx.Create(context.Background(), &kept, userId); err != nil {
+			return err
+		}
+		tx.Savepoint(context.Background(), func() error {
+			if err := tx.Create(context.Background(), &rolledBack, userId); err != nil {
+				return err
+			}
+			return ErrNotFound
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	todos, err := todoRepository.GetAll(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{kept.Id}, idsOf(todos))
+}
+
+func openSQLite(t *testing.T) *SQLiteDB {
+	t.Helper()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "todo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
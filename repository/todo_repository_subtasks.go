@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+var ErrParentNotFound = errors.New("the parent of the todo doesn't exist")
+var ErrSubtaskTooDeep = errors.New("the subtask would nest deeper than the subtasks may")
+var ErrParentInTrash = errors.New("the parent of the todo is in the trash")
+
+// SubtaskRules are the rules of the subtasks of a TodoRepository. MaxDepth is
+// how many levels of subtasks a todo without a parent may have below it, and
+// a zero MaxDepth doesn't limit them. When CompleteParents is set, a todo is
+// done once a write makes the last of its subtasks done.
+type SubtaskRules struct {
+	MaxDepth        int
+	CompleteParents bool
+}
+
+const (
+	// progressColumns count the subtasks of a todo outside the trash, and
+	// the ones of them that are done. Every select of todos reads them with
+	// the todoColumns.
+	progressColumns string = "(select count(*) from todo child where child.parent_id = todo.id and child.deleted_at is null), " +
+		"(select count(*) from todo child where child.parent_id = todo.id and child.deleted_at is null and child.done)"
+	// parentQuery returns the list of a parent and its depth, which is 1 for
+	// a todo without a parent.
+	parentQuery string = "with recursive ancestor (id, parent_id) as (select id, parent_id from todo where id = $1::UUID " +
+		"union all select todo.id, todo.parent_id from todo join ancestor on todo.id = ancestor.parent_id) " +
+		"select list_id, (select count(*) from ancestor) from todo where id = $1::UUID and user_id = $2 and deleted_at is null"
+	parentIdQuery       string = "select parent_id from todo where id = $1::UUID and user_id = $2"
+	completeParentQuery string = "update todo set done = true, completed_at = coalesce(completed_at, $3::timestamptz), " +
+		"updated_at = $3::timestamptz, version = version + 1 where id = $1::UUID and user_id = $2 and not done and deleted_at is null " +
+		"and not exists (select 1 from todo child where child.parent_id = todo.id and child.deleted_at is null and not child.done)"
+	// descendantsCTE is the ids of the subtasks below the todo of $1 at any
+	// depth.
+	descendantsCTE string = "with recursive descendant (id) as (select id from todo where parent_id = $1::UUID " +
+		"union all select todo.id from todo join descendant on todo.parent_id = descendant.id) "
+	deleteSubtasksQuery string = descendantsCTE + "update todo set deleted_at = $3::timestamptz, version = version + 1 " +
+		"where id in (select id from descendant) and user_id = $2 and deleted_at is null"
+	// parentInTrashQuery tells whether the parent of a todo in the trash is
+	// in the trash too. A todo without a parent has none in the trash.
+	parentInTrashQuery string = "select parent.deleted_at is not null from todo left join todo parent on parent.id = todo.parent_id " +
+		"where todo.id = $1::UUID and todo.user_id = $2 and todo.deleted_at is not null"
+	// restoreSubtasksQuery only restores the subtasks that went to the trash
+	// with the todo, and not the ones that were deleted before it.
+	restoreSubtasksQuery string = descendantsCTE + "update todo set deleted_at = null, version = version + 1 " +
+		"where id in (select id from descendant) and user_id = $2 and deleted_at = (select deleted_at from todo where id = $1::UUID)"
+	subtasksQuery string = "select " + todoColumns + ", " + tagsColumn + " from todo " +
+		"where parent_id = $1::UUID and user_id = $2 and deleted_at is null order by created_at, id"
+	descendantsQuery string = descendantsCTE + "select " + todoColumns + ", " + tagsColumn + " from todo " +
+		"where id in (select id from descendant) and user_id = $2 and deleted_at is null order by created_at, id"
+)
+
+// nullableId scans an id column that is null when there is no id.
+type nullableId string
+
+func (id *nullableId) Scan(value any) error {
+	var scanned sql.NullString
+	if err := scanned.Scan(value); err != nil {
+		return err
+	}
+	*id = nullableId(scanned.String)
+	return nil
+}
+
+// progressTotal and progressDone scan the progressColumns into the Progress
+// of a todo, which stays nil when it has no subtasks. The total comes first.
+type progressTotal struct{ todo *model.Todo }
+
+func (p progressTotal) Scan(value any) error {
+	var total sql.NullInt64
+	if err := total.Scan(value); err != nil {
+		return err
+	}
+	p.todo.Progress = model.ProgressOf(0, int(total.Int64))
+	return nil
+}
+
+type progressDone struct{ todo *model.Todo }
+
+func (p progressDone) Scan(value any) error {
+	var done sql.NullInt64
+	if err := done.Scan(value); err != nil {
+		return err
+	}
+	if p.todo.Progress != nil {
+		p.todo.Progress.Done = int(done.Int64)
+	}
+	return nil
+}
+
+// parentListOf returns the list of the parent of a new subtask, after it
+// checks that the subtask doesn't nest deeper than the rules let it.
+func (tr todoRepositoryImpl) parentListOf(ctx context.Context, parentId string, userId string) (string, error) {
+	var listId string
+	var depth int
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.parent, parentId, userId).Scan(&listId, &depth); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrParentNotFound
+		}
+		return "", err
+	}
+	if tr.rules.MaxDepth > 0 && depth > tr.rules.MaxDepth {
+		return "", ErrSubtaskTooDeep
+	}
+	return listId, nil
+}
+
+// completingParents runs write, which may make the todo id done, and then
+// completes its parents as the rules tell, in the same transaction.
+func (tr todoRepositoryImpl) completingParents(ctx context.Context, id string, userId string, done bool,
+	updatedAt time.Time, write func(tx todoRepositoryImpl) error) error {
+	if !tr.rules.CompleteParents || !done {
+		return write(tr)
+	}
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		for {
+			var parentId nullableId
+			if err := tx.DBPool.QueryRowContext(ctx, tx.dialect.parentId, id, userId).Scan(&parentId); err != nil {
+				return err
+			}
+			if parentId == "" {
+				return nil
+			}
+			result, err := tx.DBPool.ExecContext(ctx, tx.dialect.completeParent, string(parentId), userId, updatedAt)
+			if err = rowAffected(result, err); err == ErrNotFound {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			id = string(parentId)
+		}
+	})
+}
+
+// GetSubtasks returns the subtasks of a todo outside the trash by creation:
+// the ones right below it, or the ones at every depth when all is set.
+func (tr todoRepositoryImpl) GetSubtasks(ctx context.Context, id string, userId string, all bool) (_ []model.Todo, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	if err := tr.atVersion(ctx, id, userId, AnyVersion); err != nil {
+		return nil, err
+	}
+	query := tr.dialect.subtasks
+	if all {
+		query = tr.dialect.descendants
+	}
+	rows, err := tr.DBPool.QueryContext(ctx, query, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanTodos(rows)
+}
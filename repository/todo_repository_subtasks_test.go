@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSubtask(t *testing.T) {
+	t.Run("Good case: the subtask goes to the list of its parent", func(t *testing.T) {
+		todoRepository, mock := createWithRules(t, SubtaskRules{MaxDepth: 2})
+		todoDone := false
+		ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+		userId := uuid.New().String()
+		listId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti, ParentId: uuid.New().String()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentQuery).WithArgs(todo.ParentId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"list_id", "depth"}).AddRow(listId, 2))
+		mock.ExpectQuery(listArchivedQuery).WithArgs(listId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"archived"}).AddRow(false))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title, todo.Description, todo.Done, todo.CreatedAt,
+			todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, listId, todo.ParentId, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, listId, todo.ListId)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the parent doesn't exist", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		todoDone := false
+		userId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), ParentId: uuid.New().String()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentQuery).WithArgs(todo.ParentId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"list_id", "depth"}))
+		mock.ExpectRollback()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.Equal(t, ErrParentNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the subtask would nest too deep", func(t *testing.T) {
+		todoRepository, mock := createWithRules(t, SubtaskRules{MaxDepth: 2})
+		todoDone := false
+		userId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), ParentId: uuid.New().String()}
+		mock.ExpectBegin()
+		mock.ExpectQuery(parentQuery).WithArgs(todo.ParentId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"list_id", "depth"}).AddRow(uuid.New().String(), 3))
+		mock.ExpectRollback()
+		err := todoRepository.Create(context.Background(), &todo, userId)
+		assert.Equal(t, ErrSubtaskTooDeep, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestCompleteParents(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := createWithRules(t, SubtaskRules{CompleteParents: true})
+		userId := uuid.New().String()
+		todoId, parentId, grandparentId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+		done, updatedAt := true, time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec("update todo set updated_at = $3::timestamptz, done = $4, "+
+			"completed_at = case when $4 then coalesce(completed_at, $3::timestamptz) else null end, version = version + 1 "+
+			"where id = $1::UUID and user_id = $2 and deleted_at is null and ($5 = 0 or version = $5)").
+			WithArgs(todoId, userId, updatedAt, done, AnyVersion).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(parentIdQuery).WithArgs(todoId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_id"}).AddRow(parentId))
+		mock.ExpectExec(completeParentQuery).WithArgs(parentId, userId, updatedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(parentIdQuery).WithArgs(parentId, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_id"}).AddRow(grandparentId))
+		mock.ExpectExec(completeParentQuery).WithArgs(grandparentId, userId, updatedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(recurringTodoQuery).WithArgs(todoId, userId, updatedAt).WillReturnRows(sqlmock.NewRows(todoColumnNames))
+		mock.ExpectCommit()
+		err := todoRepository.Patch(context.Background(), todoId, userId, model.TodoChanges{Done: &done, UpdatedAt: updatedAt}, AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the todo has no parent", func(t *testing.T) {
+		todoRepository, mock := createWithRules(t, SubtaskRules{CompleteParents: true})
+		todoDone := true
+		ti := time.Now()
+		userId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title, todo.Description, todo.Done, todo.UpdatedAt, userId,
+			AnyVersion, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(parentIdQuery).WithArgs(todo.Id, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_id"}).AddRow(nil))
+		mock.ExpectCommit()
+		err := todoRepository.Update(context.Background(), &todo, userId, AnyVersion)
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When completing a parent returns an error", func(t *testing.T) {
+		todoRepository, mock := createWithRules(t, SubtaskRules{CompleteParents: true})
+		todoDone := true
+		ti := time.Now()
+		userId := uuid.New().String()
+		parentId := uuid.New().String()
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: ti, UpdatedAt: ti}
+		mock.ExpectBegin()
+		mock.ExpectExec(updateQuery).WithArgs(todo.Id, todo.Title, todo.Description, todo.Done, todo.UpdatedAt, userId,
+			AnyVersion, nil, nil, false, nil, "", 0, "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(parentIdQuery).WithArgs(todo.Id, userId).
+			WillReturnRows(sqlmock.NewRows([]string{"parent_id"}).AddRow(parentId))
+		mock.ExpectExec(completeParentQuery).WithArgs(parentId, userId, todo.UpdatedAt).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := todoRepository.Update(context.Background(), &todo, userId, AnyVersion)
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetSubtasks(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		for all, query := range map[bool]string{false: subtasksQuery, true: descendantsQuery} {
+			todoRepository, mock := create(t)
+			todoDone := true
+			ti, _ := time.Parse(time.RFC3339, "2022-09-21T14:07:05.768Z")
+			userId := uuid.New().String()
+			todoId := uuid.New().String()
+			wantedSubtask := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1",
+				Done: &todoDone, CreatedAt: ti, UpdatedAt: ti, CompletedAt: &ti, Version: 2, Tags: []string{},
+				ListId: uuid.New().String(), ParentId: todoId, Progress: &model.Progress{Done: 1, Total: 3}}
+			mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+			rows := sqlmock.NewRows(todoColumnNames).
+				AddRow(wantedSubtask.Id, wantedSubtask.Title, wantedSubtask.Description, wantedSubtask.Done,
+					wantedSubtask.CreatedAt.Local(), wantedSubtask.UpdatedAt.Local(), wantedSubtask.CompletedAt.Local(),
+					wantedSubtask.Version, nil, wantedSubtask.ListId, todoId, nil, false, "", 0, "", "", int64(3), int64(1), int64(0), "[]")
+			mock.ExpectQuery(query).WithArgs(todoId, userId).WillReturnRows(rows)
+			subtasks, err := todoRepository.GetSubtasks(context.Background(), todoId, userId, all)
+			assert.NoError(t, err)
+			assert.Equal(t, []model.Todo{wantedSubtask}, subtasks)
+			err = mock.ExpectationsWereMet()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("When that todo is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		subtasks, err := todoRepository.GetSubtasks(context.Background(), todoId, userId, false)
+		assert.Nil(t, subtasks)
+		assert.Equal(t, ErrNotFound, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When DBPool.Query returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectQuery(versionQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectQuery(subtasksQuery).WithArgs(todoId, userId).WillReturnError(common.ErrError)
+		subtasks, err := todoRepository.GetSubtasks(context.Background(), todoId, userId, false)
+		assert.Nil(t, subtasks)
+		assert.Equal(t, common.ErrError, err)
+	})
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+)
+
+// tagList scans the tags column of a todo. The tags are sorted here, as
+// SQLite can't sort what it aggregates.
+type tagList []string
+
+func (tags *tagList) Scan(value any) error {
+	var content []byte
+	switch value := value.(type) {
+	case string:
+		content = []byte(value)
+	case []byte:
+		content = value
+	default:
+		return fmt.Errorf("the tags of a todo can't be scanned from %T", value)
+	}
+	if err := json.Unmarshal(content, (*[]string)(tags)); err != nil {
+		return err
+	}
+	sort.Strings(*tags)
+	return nil
+}
+
+// inTransaction runs work with the repository of a transaction: tr itself
+// when it is already in one, or a new one that is committed when work
+// returns nil.
+func (tr todoRepositoryImpl) inTransaction(ctx context.Context, work func(tx todoRepositoryImpl) error) error {
+	if tr.transactions == nil {
+		return work(tr)
+	}
+	return tr.transactions.run(ctx, func(tx *sql.Tx) error {
+		return work(todoRepositoryImpl{DBPool: tr.dialect.conn(tx), QueryTimeout: tr.QueryTimeout, dialect: tr.dialect,
+			rules: tr.rules, inboxes: tr.inboxes})
+	})
+}
+
+// writeTodo runs write on the todo id in one transaction with the rest of
+// the write: when listId isn't nil, write gets the list that listOf finds for
+// *listId, and when tags isn't nil, the tags of the todo are replaced by
+// *tags after write. When both are nil, write runs alone and gets no list.
+// version is nil for a new todo; for a stored one, a list that can't take
+// the todo only fails the write once the todo is known to be at version, so
+// that ErrNotFound and ErrVersionMismatch come first as they do without a
+// list.
+func (tr todoRepositoryImpl) writeTodo(ctx context.Context, id string, userId string, version *int64, listId *string,
+	tags *[]string, write func(tx todoRepositoryImpl, listId string) error) error {
+	if listId == nil && tags == nil {
+		return write(tr, "")
+	}
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		var list string
+		if listId != nil {
+			var err error
+			if list, err = tx.listOf(ctx, userId, *listId); err != nil {
+				if version != nil && (err == ErrListNotFound || err == ErrListArchived) {
+					if versionErr := tx.atVersion(ctx, id, userId, *version); versionErr != nil {
+						return versionErr
+					}
+				}
+				return err
+			}
+		}
+		if err := write(tx, list); err != nil {
+			return err
+		}
+		if tags == nil {
+			return nil
+		}
+		return tx.replaceTags(ctx, id, userId, *tags)
+	})
+}
+
+func (tr todoRepositoryImpl) replaceTags(ctx context.Context, id string, userId string, tags []string) error {
+	if _, err := tr.DBPool.ExecContext(ctx, tr.dialect.deleteTodoTags, id); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	names, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	if _, err := tr.DBPool.ExecContext(ctx, tr.dialect.insertTags, userId, string(names)); err != nil {
+		return err
+	}
+	_, err = tr.DBPool.ExecContext(ctx, tr.dialect.insertTodoTags, id, userId, string(names))
+	return err
+}
+
+// GetTags returns the tags of a user by name, with how many of their todos
+// outside the trash have each.
+func (tr todoRepositoryImpl) GetTags(ctx context.Context, userId string) (_ []model.Tag, err error) {
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	rows, err := tr.DBPool.QueryContext(ctx, tr.dialect.allTags, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := []model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// RenameTag renames a tag of a user on every todo that has it. As the todos
+// change, their versions are incremented.
+func (tr todoRepositoryImpl) RenameTag(ctx context.Context, userId string, name string, newName string) (err error) {
+	if !model.IsValidTag(name) || !model.IsValidTag(newName) {
+		return model.ErrInvalidTag
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if newName != name {
+			if exists, err := tx.tagExists(ctx, userId, newName); err != nil {
+				return err
+			} else if exists {
+				return ErrTagExists
+			}
+		}
+		if err := rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.renameTag, userId, name, newName)); err != nil {
+			return err
+		}
+		_, err := tx.DBPool.ExecContext(ctx, tx.dialect.touchTagged, userId, newName)
+		return err
+	})
+}
+
+// MergeTag gives the tag into to every todo of a user that has the tag name,
+// which is then deleted. Both tags must exist.
+func (tr todoRepositoryImpl) MergeTag(ctx context.Context, userId string, name string, into string) (err error) {
+	if !model.IsValidTag(name) || !model.IsValidTag(into) {
+		return model.ErrInvalidTag
+	}
+	if name == into {
+		return ErrSameTag
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if exists, err := tx.tagExists(ctx, userId, into); err != nil {
+			return err
+		} else if !exists {
+			return ErrNotFound
+		}
+		if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.touchTagged, userId, name); err != nil {
+			return err
+		}
+		if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.mergeTag, userId, name, into); err != nil {
+			return err
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.deleteTag, userId, name))
+	})
+}
+
+// DeleteTag takes a tag of a user off every todo that has it.
+func (tr todoRepositoryImpl) DeleteTag(ctx context.Context, userId string, name string) (err error) {
+	if !model.IsValidTag(name) {
+		return model.ErrInvalidTag
+	}
+	ctx, done := tr.operation(ctx, &err)
+	defer done()
+	return tr.inTransaction(ctx, func(tx todoRepositoryImpl) error {
+		if _, err := tx.DBPool.ExecContext(ctx, tx.dialect.touchTagged, userId, name); err != nil {
+			return err
+		}
+		return rowAffected(tx.DBPool.ExecContext(ctx, tx.dialect.deleteTag, userId, name))
+	})
+}
+
+func (tr todoRepositoryImpl) tagExists(ctx context.Context, userId string, name string) (bool, error) {
+	var count int64
+	if err := tr.DBPool.QueryRowContext(ctx, tr.dialect.tagExists, userId, name).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
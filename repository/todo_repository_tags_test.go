@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagList(t *testing.T) {
+	t.Run("The tags are sorted", func(t *testing.T) {
+		var tags tagList
+		assert.NoError(t, tags.Scan(`["work","home"]`))
+		assert.Equal(t, tagList{"home", "work"}, tags)
+		assert.NoError(t, tags.Scan([]byte(`[]`)))
+		assert.Equal(t, tagList{}, tags)
+	})
+
+	t.Run("When the column is not JSON text", func(t *testing.T) {
+		var tags tagList
+		assert.Error(t, tags.Scan(int64(1)))
+		assert.Error(t, tags.Scan("work"))
+	})
+}
+
+func TestGetTags(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(allTagsQuery).WithArgs(userId).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("home", 0).AddRow("work", 2))
+		tags, err := todoRepository.GetTags(context.Background(), userId)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Tag{{Name: "home", Count: 0}, {Name: "work", Count: 2}}, tags)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When Query returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectQuery(allTagsQuery).WithArgs(userId).WillReturnError(common.ErrError)
+		tags, err := todoRepository.GetTags(context.Background(), userId)
+		assert.Nil(t, tags)
+		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func TestRenameTag(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(tagExistsQuery).WithArgs(userId, "office").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(renameTagQuery).WithArgs(userId, "work", "office").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(touchTaggedQuery).WithArgs(userId, "office").WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+		assert.NoError(t, todoRepository.RenameTag(context.Background(), userId, "work", "office"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the new name is taken", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(tagExistsQuery).WithArgs(userId, "office").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+		assert.Equal(t, ErrTagExists, todoRepository.RenameTag(context.Background(), userId, "work", "office"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the tag is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(tagExistsQuery).WithArgs(userId, "office").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(renameTagQuery).WithArgs(userId, "work", "office").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		assert.Equal(t, ErrNotFound, todoRepository.RenameTag(context.Background(), userId, "work", "office"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When a name is not a normalized tag", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		assert.Equal(t, model.ErrInvalidTag, todoRepository.RenameTag(context.Background(), uuid.New().String(), "work", "Office"))
+		assert.Equal(t, model.ErrInvalidTag, todoRepository.RenameTag(context.Background(), uuid.New().String(), "", "office"))
+	})
+}
+
+func TestMergeTag(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(tagExistsQuery).WithArgs(userId, "work").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(touchTaggedQuery).WithArgs(userId, "job").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(mergeTagQuery).WithArgs(userId, "job", "work").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteTagQuery).WithArgs(userId, "job").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		assert.NoError(t, todoRepository.MergeTag(context.Background(), userId, "job", "work"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the tag to merge into is not found", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectQuery(tagExistsQuery).WithArgs(userId, "work").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+		assert.Equal(t, ErrNotFound, todoRepository.MergeTag(context.Background(), userId, "job", "work"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the tag is merged into itself", func(t *testing.T) {
+		todoRepository, _ := create(t)
+		assert.Equal(t, ErrSameTag, todoRepository.MergeTag(context.Background(), uuid.New().String(), "work", "work"))
+	})
+}
+
+func TestDeleteTag(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectExec(touchTaggedQuery).WithArgs(userId, "work").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(deleteTagQuery).WithArgs(userId, "work").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		assert.NoError(t, todoRepository.DeleteTag(context.Background(), userId, "work"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When DBPool.Exec returns an error", func(t *testing.T) {
+		todoRepository, mock := create(t)
+		userId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectExec(touchTaggedQuery).WithArgs(userId, "work").WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		assert.Equal(t, common.ErrError, todoRepository.DeleteTag(context.Background(), userId, "work"))
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
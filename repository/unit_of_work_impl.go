@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/google/uuid"
+)
+
+const (
+	savepointQuery         string = "savepoint todo_operation"
+	rollbackSavepointQuery string = "rollback to savepoint todo_operation"
+	releaseSavepointQuery  string = "release savepoint todo_operation"
+)
+
+type unitOfWorkImpl struct {
+	DBPool       *sql.DB
+	QueryTimeout time.Duration
+	dialect      dialect
+	rules        SubtaskRules
+	inboxes      inboxSource
+	// writes is the write lock of a SQLiteDB, when there is one.
+	writes chan struct{}
+}
+
+// GetUnitOfWork returns a UnitOfWork whose transactions bound every operation
+// on the todos by queryTimeout, follow rules and create inboxes with newId
+// and now, like GetTodoRepository does.
+func GetUnitOfWork(dbPool *sql.DB, queryTimeout time.Duration, rules SubtaskRules, newId func() (uuid.UUID, error),
+	now func() time.Time) (common.UnitOfWork, error) {
+	if dbPool == nil {
+		return nil, ErrDBPoolIsNil
+	}
+	return unitOfWorkImpl{DBPool: dbPool, QueryTimeout: queryTimeout, dialect: postgresDialect, rules: rules,
+		inboxes: inboxSource{newId: newId, now: now}}, nil
+}
+
+// Do commits the transaction when work returns nil and rolls it back when
+// work returns an error or panics, or when ctx is done.
+func (uow unitOfWorkImpl) Do(ctx context.Context, work func(tx common.Transaction) error) error {
+	return uow.run(ctx, func(tx *sql.Tx) error {
+		return work(transactionImpl{todoRepositoryImpl: todoRepositoryImpl{DBPool: uow.dialect.conn(tx),
+			QueryTimeout: uow.QueryTimeout, dialect: uow.dialect, rules: uow.rules, inboxes: uow.inboxes}, tx: tx})
+	})
+}
+
+// run runs work in a transaction as Do does.
+func (uow unitOfWorkImpl) run(ctx context.Context, work func(tx *sql.Tx) error) (err error) {
+	defer func() {
+		err = contextErr(ctx, ctx, err)
+	}()
+	if uow.writes != nil {
+		if err := lockWrites(ctx, uow.writes); err != nil {
+			return err
+		}
+		defer func() { <-uow.writes }()
+	}
+	tx, err := uow.DBPool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+	if err := work(tx); err != nil {
+		return err
+	}
+	committed = true
+	return tx.Commit()
+}
+
+type transactionImpl struct {
+	todoRepositoryImpl
+	tx *sql.Tx
+}
+
+// Savepoint reuses the name of its savepoint, as a nested savepoint hides
+// the outer one with the same name until it is released.
+func (t transactionImpl) Savepoint(ctx context.Context, work func() error) error {
+	if _, err := t.tx.ExecContext(ctx, savepointQuery); err != nil {
+		return contextErr(ctx, ctx, err)
+	}
+	if err := work(); err != nil {
+		if _, rollbackErr := t.tx.ExecContext(ctx, rollbackSavepointQuery); rollbackErr != nil {
+			return contextErr(ctx, ctx, rollbackErr)
+		}
+		if _, releaseErr := t.tx.ExecContext(ctx, releaseSavepointQuery); releaseErr != nil {
+			return contextErr(ctx, ctx, releaseErr)
+		}
+		return err
+	}
+	_, err := t.tx.ExecContext(ctx, releaseSavepointQuery)
+	return contextErr(ctx, ctx, err)
+}
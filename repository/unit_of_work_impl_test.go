@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUnitOfWork(t *testing.T) {
+	t.Run("When DBPool is nil", func(t *testing.T) {
+		unitOfWork, err := GetUnitOfWork(nil, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+		assert.Nil(t, unitOfWork)
+		assert.Equal(t, ErrDBPoolIsNil, err)
+	})
+}
+
+func TestDo(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, AnyVersion, deletedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteSubtasksQuery).WithArgs(todoId, userId, deletedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Delete(context.Background(), todoId, userId, AnyVersion, deletedAt)
+		})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When work returns an error", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		deletedAt := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(todoId, userId, AnyVersion, deletedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteSubtasksQuery).WithArgs(todoId, userId, deletedAt).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			if err := tx.Delete(context.Background(), todoId, userId, AnyVersion, deletedAt); err != nil {
+				return err
+			}
+			return common.ErrError
+		})
+		assert.Equal(t, common.ErrError, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When work panics", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		assert.Panics(t, func() {
+			unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+				panic(common.ErrError)
+			})
+		})
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When Begin returns an error", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		mock.ExpectBegin().WillReturnError(common.ErrError)
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			t.Error("work must not run")
+			return nil
+		})
+		assert.Equal(t, common.ErrError, err)
+	})
+
+	t.Run("When the request is canceled", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err := unitOfWork.Do(ctx, func(tx common.Transaction) error {
+			cancel()
+			return ctx.Err()
+		})
+		assert.Equal(t, ErrCanceled, err)
+	})
+
+	t.Run("When Commit returns an error", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(common.ErrError)
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return nil
+		})
+		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func TestSavepoint(t *testing.T) {
+	t.Run("Good case", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		userId := uuid.New().String()
+		inboxId := uuid.New().String()
+		todoDone := false
+		todo := model.Todo{Id: uuid.New().String(), Title: "title1", Description: "description1", Done: &todoDone,
+			CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		mock.ExpectBegin()
+		mock.ExpectExec(savepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(inboxQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(inboxId))
+		mock.ExpectQuery(lastPositionQuery).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(""))
+		mock.ExpectExec(insertTodoQuery).WithArgs(todo.Id, todo.Title, todo.Description, todo.Done, todo.CreatedAt,
+			todo.UpdatedAt, todo.CompletedAt, model.FirstVersion, userId, inboxId, nil, nil, false, nil, "", 0, model.PositionBetween("", "", todo.Id), "").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(releaseSavepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Savepoint(context.Background(), func() error {
+				return tx.Create(context.Background(), &todo, userId)
+			})
+		})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When work returns an error", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		userId := uuid.New().String()
+		todoId := uuid.New().String()
+		mock.ExpectBegin()
+		mock.ExpectExec(savepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(parentInTrashQuery).WithArgs(todoId, userId).WillReturnRows(sqlmock.NewRows([]string{"parent_in_trash"}))
+		mock.ExpectExec(rollbackSavepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(releaseSavepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			err := tx.Savepoint(context.Background(), func() error {
+				return tx.Restore(context.Background(), todoId, userId)
+			})
+			assert.Equal(t, ErrNotFound, err)
+			return nil
+		})
+		assert.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("When the savepoint can't be set", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(savepointQuery).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Savepoint(context.Background(), func() error {
+				t.Error("work must not run")
+				return nil
+			})
+		})
+		assert.Equal(t, common.ErrError, err)
+	})
+
+	t.Run("When the savepoint can't be rolled back to", func(t *testing.T) {
+		unitOfWork, mock := createUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(savepointQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(rollbackSavepointQuery).WillReturnError(common.ErrError)
+		mock.ExpectRollback()
+		err := unitOfWork.Do(context.Background(), func(tx common.Transaction) error {
+			return tx.Savepoint(context.Background(), func() error {
+				return ErrNotFound
+			})
+		})
+		assert.Equal(t, common.ErrError, err)
+	})
+}
+
+func createUnitOfWork(t *testing.T) (common.UnitOfWork, sqlmock.Sqlmock) {
+	t.Helper()
+	dbPool, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal()
+	}
+	unitOfWork, err := GetUnitOfWork(dbPool, 0, SubtaskRules{}, uuid.NewV7, time.Now)
+	if err != nil {
+		t.Fatal()
+	}
+	return unitOfWork, mock
+}
@@ -1,19 +1,66 @@
 package router
 
 import (
+	"time"
+
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/handler"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
 	"github.com/google/uuid"
 )
 
-func SetTodoRoutes(router common.Router, todoRepository common.TodoRepository,
+// SetTodoRoutes sets the routes of the todos on router. Only GET
+// /shared/:token goes without an auth token; every other route is in a group
+// behind the auth middleware.
+func SetTodoRoutes(router common.Router, todoRepository common.TodoRepository, unitOfWork common.UnitOfWork,
 	errorHandler common.ErrorHandler, authClient common.AuthClient) common.Router {
-	router.Use(middleware.GetAuthMiddleware(authClient, errorHandler))
-	router.POST("/todos", handler.Create(todoRepository, errorHandler))
-	router.GET("/todos", handler.GetAll(todoRepository, errorHandler))
-	router.GET("/todos/:id", handler.GetById(todoRepository, errorHandler, uuid.Parse))
-	router.PUT("/todos", handler.Update(todoRepository, errorHandler))
-	router.DELETE("/todos/:id", handler.Delete(todoRepository, errorHandler, uuid.Parse))
+	router.GET("/shared/:token", handler.GetShared(todoRepository, errorHandler, time.Now))
+	authorized := router.Group("/", middleware.GetAuthMiddleware(authClient, errorHandler))
+	authorized.POST("/todos", handler.Create(todoRepository, errorHandler, uuid.NewV7, time.Now))
+	authorized.POST("/todos:batch", handler.Batch(unitOfWork, errorHandler, uuid.Parse, uuid.NewV7, time.Now))
+	authorized.GET("/todos", handler.GetAll(todoRepository, errorHandler, time.Now))
+	authorized.GET("/todos/search", handler.Search(todoRepository, errorHandler))
+	authorized.GET("/todos/:id", handler.GetById(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/todos/:id/subtasks", handler.GetSubtasks(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/todos/:id/occurrences", handler.GetOccurrences(todoRepository, errorHandler, uuid.Parse))
+	authorized.PUT("/todos", handler.Update(todoRepository, errorHandler, time.Now))
+	authorized.PUT("/todos/:id", handler.UpdateById(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.PATCH("/todos/:id", handler.Patch(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.DELETE("/todos/:id", handler.Delete(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.POST("/todos/:id/restore", handler.Restore(todoRepository, errorHandler, uuid.Parse))
+	authorized.POST("/todos/:id/move", handler.Move(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.GET("/agenda", handler.GetAgenda(todoRepository, errorHandler, time.Now))
+	authorized.GET("/trash", handler.GetTrash(todoRepository, errorHandler))
+	authorized.DELETE("/trash/:id", handler.Purge(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/tags", handler.GetTags(todoRepository, errorHandler))
+	authorized.PATCH("/tags/:name", handler.RenameTag(todoRepository, errorHandler))
+	authorized.POST("/tags/:name/merge", handler.MergeTag(todoRepository, errorHandler))
+	authorized.DELETE("/tags/:name", handler.DeleteTag(todoRepository, errorHandler))
+	authorized.GET("/lists", handler.GetLists(todoRepository, errorHandler))
+	authorized.POST("/lists", handler.CreateList(todoRepository, errorHandler, uuid.NewV7, time.Now))
+	authorized.GET("/lists/:id", handler.GetList(todoRepository, errorHandler, uuid.Parse))
+	authorized.PATCH("/lists/:id", handler.PatchList(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.DELETE("/lists/:id", handler.DeleteList(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/lists/:id/todos", handler.GetListTodos(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.GET("/lists/:id/members", handler.GetMemberships(todoRepository, errorHandler, uuid.Parse))
+	authorized.POST("/lists/:id/members", handler.CreateMembership(todoRepository, authClient, errorHandler, uuid.Parse,
+		time.Now))
+	authorized.PATCH("/lists/:id/members/:userId", handler.PatchMembership(todoRepository, errorHandler, uuid.Parse, time.Now))
+	authorized.DELETE("/lists/:id/members/:userId", handler.DeleteMembership(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/todos/:id/share", handler.GetTodoShareLink(todoRepository, errorHandler, uuid.Parse))
+	authorized.POST("/todos/:id/share", handler.CreateTodoShareLink(todoRepository, errorHandler, uuid.Parse,
+		model.NewShareToken, time.Now))
+	authorized.DELETE("/todos/:id/share", handler.DeleteTodoShareLink(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/lists/:id/share", handler.GetListShareLink(todoRepository, errorHandler, uuid.Parse))
+	authorized.POST("/lists/:id/share", handler.CreateListShareLink(todoRepository, errorHandler, uuid.Parse,
+		model.NewShareToken, time.Now))
+	authorized.DELETE("/lists/:id/share", handler.DeleteListShareLink(todoRepository, errorHandler, uuid.Parse))
+	authorized.GET("/todos/:id/comments", handler.GetComments(todoRepository, errorHandler, uuid.Parse))
+	authorized.POST("/todos/:id/comments", handler.CreateComment(todoRepository, errorHandler, uuid.Parse, uuid.NewV7,
+		time.Now))
+	authorized.PUT("/todos/:id/comments/:commentId", handler.UpdateComment(todoRepository, errorHandler, uuid.Parse,
+		time.Now))
+	authorized.DELETE("/todos/:id/comments/:commentId", handler.DeleteComment(todoRepository, errorHandler, uuid.Parse))
 	return router
 }
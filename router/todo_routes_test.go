@@ -1,12 +1,20 @@
 package router
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"firebase.google.com/go/v4/auth"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/common"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/handler"
 	"github.com/ahmedsameha1/todo_backend_go_to_practice/middleware"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/model"
+	"github.com/ahmedsameha1/todo_backend_go_to_practice/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -17,31 +25,138 @@ func TestSetTodoRoutes(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	routerMock := common.NewMockRouter(mockCtrl)
 	todoRepositoryMock := common.NewMockTodoRepository(mockCtrl)
+	unitOfWorkMock := common.NewMockUnitOfWork(mockCtrl)
 	errorHandlerMock := common.NewMockErrorHandler(mockCtrl)
 	firebaseAuthClientMock := common.NewMockAuthClient(mockCtrl)
 	authMiddleware := middleware.GetAuthMiddleware(firebaseAuthClientMock, errorHandlerMock)
-	routerMock.EXPECT().Use(gomock.Any()).Do(func(handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(authMiddleware).Pointer(), reflect.ValueOf(handler).Pointer())
+	getShared := handler.GetShared(todoRepositoryMock, errorHandlerMock, time.Now)
+	routerMock.EXPECT().GET("/shared/:token", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
+		assertSameHandler(t, getShared, handler)
 	})
-	create := handler.Create(todoRepositoryMock, errorHandlerMock)
-	routerMock.EXPECT().POST("/todos", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(create).Pointer(), reflect.ValueOf(handler).Pointer())
+	engine := gin.New()
+	routerMock.EXPECT().Group("/", gomock.Any()).DoAndReturn(func(path string,
+		handlers ...gin.HandlerFunc) *gin.RouterGroup {
+		if assert.Len(t, handlers, 1) {
+			assertSameHandler(t, authMiddleware, handlers[0])
+		}
+		return engine.Group(path, handlers...)
 	})
-	getAll := handler.GetAll(todoRepositoryMock, errorHandlerMock)
-	routerMock.EXPECT().GET("/todos", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(getAll).Pointer(), reflect.ValueOf(handler).Pointer())
+	SetTodoRoutes(routerMock, todoRepositoryMock, unitOfWorkMock, errorHandlerMock, firebaseAuthClientMock)
+	authorizedRoutes := map[string]gin.HandlerFunc{
+		"POST /todos":                handler.Create(todoRepositoryMock, errorHandlerMock, uuid.NewV7, time.Now),
+		"POST /todos:batch":          handler.Batch(unitOfWorkMock, errorHandlerMock, uuid.Parse, uuid.NewV7, time.Now),
+		"GET /todos":                 handler.GetAll(todoRepositoryMock, errorHandlerMock, time.Now),
+		"GET /todos/search":          handler.Search(todoRepositoryMock, errorHandlerMock),
+		"GET /todos/:id":             handler.GetById(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /todos/:id/subtasks":    handler.GetSubtasks(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /todos/:id/occurrences": handler.GetOccurrences(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"PUT /todos":                 handler.Update(todoRepositoryMock, errorHandlerMock, time.Now),
+		"PUT /todos/:id":             handler.UpdateById(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"PATCH /todos/:id":           handler.Patch(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"DELETE /todos/:id":          handler.Delete(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"POST /todos/:id/restore":    handler.Restore(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"POST /todos/:id/move":       handler.Move(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"GET /agenda":                handler.GetAgenda(todoRepositoryMock, errorHandlerMock, time.Now),
+		"GET /trash":                 handler.GetTrash(todoRepositoryMock, errorHandlerMock),
+		"DELETE /trash/:id":          handler.Purge(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /tags":                  handler.GetTags(todoRepositoryMock, errorHandlerMock),
+		"PATCH /tags/:name":          handler.RenameTag(todoRepositoryMock, errorHandlerMock),
+		"POST /tags/:name/merge":     handler.MergeTag(todoRepositoryMock, errorHandlerMock),
+		"DELETE /tags/:name":         handler.DeleteTag(todoRepositoryMock, errorHandlerMock),
+		"GET /lists":                 handler.GetLists(todoRepositoryMock, errorHandlerMock),
+		"POST /lists":                handler.CreateList(todoRepositoryMock, errorHandlerMock, uuid.NewV7, time.Now),
+		"GET /lists/:id":             handler.GetList(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"PATCH /lists/:id":           handler.PatchList(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"DELETE /lists/:id":          handler.DeleteList(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /lists/:id/todos":       handler.GetListTodos(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"GET /lists/:id/members":     handler.GetMemberships(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"POST /lists/:id/members": handler.CreateMembership(todoRepositoryMock, firebaseAuthClientMock, errorHandlerMock,
+			uuid.Parse, time.Now),
+		"PATCH /lists/:id/members/:userId":  handler.PatchMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse, time.Now),
+		"DELETE /lists/:id/members/:userId": handler.DeleteMembership(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /todos/:id/share":              handler.GetTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"POST /todos/:id/share": handler.CreateTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse,
+			model.NewShareToken, time.Now),
+		"DELETE /todos/:id/share": handler.DeleteTodoShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /lists/:id/share":    handler.GetListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"POST /lists/:id/share": handler.CreateListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse,
+			model.NewShareToken, time.Now),
+		"DELETE /lists/:id/share": handler.DeleteListShareLink(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"GET /todos/:id/comments": handler.GetComments(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+		"POST /todos/:id/comments": handler.CreateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse, uuid.NewV7,
+			time.Now),
+		"PUT /todos/:id/comments/:commentId": handler.UpdateComment(todoRepositoryMock, errorHandlerMock, uuid.Parse,
+			time.Now),
+		"DELETE /todos/:id/comments/:commentId": handler.DeleteComment(todoRepositoryMock, errorHandlerMock, uuid.Parse),
+	}
+	routes := engine.Routes()
+	assert.Len(t, routes, len(authorizedRoutes))
+	for _, route := range routes {
+		expected, ok := authorizedRoutes[route.Method+" "+route.Path]
+		if assert.True(t, ok, route.Method+" "+route.Path) {
+			assertSameHandler(t, expected, route.HandlerFunc)
+		}
+	}
+}
+
+func TestSetTodoRoutesAuthentication(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	todoRepositoryMock := common.NewMockTodoRepository(mockCtrl)
+	errorHandlerMock := common.NewMockErrorHandler(mockCtrl)
+	engine := gin.New()
+	SetTodoRoutes(engine, todoRepositoryMock, common.NewMockUnitOfWork(mockCtrl), errorHandlerMock,
+		common.NewMockAuthClient(mockCtrl))
+
+	t.Run("GET /shared/:token goes without an auth token", func(t *testing.T) {
+		todoRepositoryMock.EXPECT().OpenShareLink(gomock.Any(), "hweogwe", gomock.Any()).Return(nil, repository.ErrNotFound)
+		errorHandlerMock.EXPECT().HandleAppError(gomock.Any(), repository.ErrNotFound, http.StatusNotFound)
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/shared/hweogwe", nil))
 	})
-	getById := handler.GetById(todoRepositoryMock, errorHandlerMock, uuid.Parse)
-	routerMock.EXPECT().GET("/todos/:id", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(getById).Pointer(), reflect.ValueOf(handler).Pointer())
+
+	t.Run("Every other route needs one", func(t *testing.T) {
+		errorHandlerMock.EXPECT().HandleAppError(gomock.Any(), middleware.ErrNoAuthorizationHeader, http.StatusUnauthorized).
+			Do(func(ctx *gin.Context, err error, code int) { ctx.AbortWithStatus(code) })
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/todos", nil))
 	})
-	update := handler.Update(todoRepositoryMock, errorHandlerMock)
-	routerMock.EXPECT().PUT("/todos", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(update).Pointer(), reflect.ValueOf(handler).Pointer())
+}
+
+func TestSetTodoRoutesBatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	errorHandlerMock := common.NewMockErrorHandler(mockCtrl)
+	authClientMock := common.NewMockAuthClient(mockCtrl)
+	engine := gin.New()
+	SetTodoRoutes(engine, common.NewMockTodoRepository(mockCtrl), common.NewMockUnitOfWork(mockCtrl), errorHandlerMock,
+		authClientMock)
+	authClientMock.EXPECT().VerifyIDToken(gomock.Any(), "oiwhegwe").Return(&auth.Token{UID: "uid"}, nil).AnyTimes()
+	newRequest := func(path string) *http.Request {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		request.Header.Set(middleware.AUTHORIZATION, middleware.BEARER+"oiwhegwe")
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	t.Run("POST /todos:batch reaches Batch", func(t *testing.T) {
+		errorHandlerMock.EXPECT().HandleAppError(gomock.Any(), gomock.Any(), http.StatusBadRequest).
+			Do(func(ctx *gin.Context, err error, code int) { ctx.AbortWithStatus(code) })
+		http_recorder := httptest.NewRecorder()
+		engine.ServeHTTP(http_recorder, newRequest("/todos:batch"))
+		assert.Equal(t, http.StatusBadRequest, http_recorder.Code)
 	})
-	delete := handler.Delete(todoRepositoryMock, errorHandlerMock, uuid.Parse)
-	routerMock.EXPECT().DELETE("/todos/:id", gomock.Any()).Do(func(path string, handler gin.HandlerFunc) {
-		assert.Equal(t, reflect.ValueOf(delete).Pointer(), reflect.ValueOf(handler).Pointer())
+
+	t.Run("Any other path after /todos is not found", func(t *testing.T) {
+		for _, path := range []string{"/todosfoo", "/todos:batches", "/todos:other"} {
+			http_recorder := httptest.NewRecorder()
+			engine.ServeHTTP(http_recorder, newRequest(path))
+			assert.Equal(t, http.StatusNotFound, http_recorder.Code, path)
+		}
 	})
-	SetTodoRoutes(routerMock, todoRepositoryMock, errorHandlerMock, firebaseAuthClientMock)
+}
+
+// assertSameHandler compares the functions behind two handlers by name, as
+// the compiler may give the same closure more than one address when it
+// inlines the function that returns it.
+func assertSameHandler(t *testing.T, expected gin.HandlerFunc, actual gin.HandlerFunc) {
+	t.Helper()
+	assert.Equal(t, runtime.FuncForPC(reflect.ValueOf(expected).Pointer()).Name(),
+		runtime.FuncForPC(reflect.ValueOf(actual).Pointer()).Name())
 }